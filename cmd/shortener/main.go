@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"log"
+	"os"
 
 	"github.com/KartoonYoko/go-url-shortener/internal/app"
 )
@@ -14,5 +16,20 @@ func main() {
 	fmt.Printf("Build version: %s\n", buildVersion)
 	fmt.Printf("Build date: %s\n", buildDate)
 	fmt.Printf("Build commit: %s\n", buildCommit)
+
+	if len(os.Args) > 1 && os.Args[1] == app.CommandMigrateStorage {
+		if err := app.RunMigrateStorage(os.Args[2:]); err != nil {
+			log.Fatal(fmt.Errorf("%s: %w", app.CommandMigrateStorage, err))
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == app.CommandDB {
+		if err := app.RunDB(os.Args[2:]); err != nil {
+			log.Fatal(fmt.Errorf("%s: %w", app.CommandDB, err))
+		}
+		return
+	}
+
 	app.Run()
 }
@@ -5,11 +5,14 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config конфигурация приложения
@@ -30,6 +33,73 @@ type Config struct {
 	TrustedSubnets string
 	// Адрес запуска gRPC сервера; флаг ga
 	BootstrapAddressgRPC string
+	// строка подключения к Redis; флаг r
+	RedisURL string
+	// Максимальное количество записей в кеше URL'ов, 0 - кеш выключен; флаг cs
+	URLCacheSize int
+	// Время жизни найденного URL'а в кеше; флаг ct
+	URLCacheTTL time.Duration
+	// Время жизни записи об отсутствующем или удалённом URL'е в кеше; флаг cnt
+	URLCacheNegativeTTL time.Duration
+	// Максимальное количество соединений с БД, 0 - по умолчанию pgx; флаг dmx
+	DatabaseMaxConns int
+	// Минимальное количество открытых соединений с БД; флаг dmn
+	DatabaseMinConns int
+	// Время жизни соединения с БД; флаг dml
+	DatabaseMaxConnLifetime time.Duration
+	// Таймаут установки соединения с БД; флаг dct
+	DatabaseConnectTimeout time.Duration
+	// Таймаут выполнения запроса к БД, 0 - без ограничения; флаг dst
+	DatabaseStatementTimeout time.Duration
+	// Размер кеша подготовленных запросов на соединение, 0 - не подготавливать запросы; флаг dsc
+	DatabaseStatementCacheCapacity int
+	// Строки подключения к репликам БД для чтения; флаг dr, значения через запятую
+	DatabaseReplicaDSNs []string
+	// Время после записи пользователя, в течение которого его чтения идут в основную БД; флаг drs
+	DatabaseReplicaStickiness time.Duration
+	// Не накатывать миграции БД при запуске сервера; флаг no-auto-migrate
+	NoAutoMigrate bool
+	// Стратегия генерации ID URL'ов: hash, random, counter, time или hashids; флаг ig
+	IDGenerator string
+	// Длина ID для random, минимальная длина для hashids; флаг il
+	IDLength int
+	// Соль для hashids; флаг is
+	IDSalt string
+	// Количество значений счётчика ID, арендуемых у БД за раз; флаг irs
+	IDRangeSize int
+	// Отсекать поиск несуществующих ID фильтром Блума; флаг bf
+	URLBloomFilter bool
+	// Допустимая вероятность ложного срабатывания фильтра Блума; флаг bfp
+	URLBloomFalsePositiveRate float64
+	// Интервал перестроения фильтра Блума, 0 - не перестраивать; флаг bfr
+	URLBloomRebuildInterval time.Duration
+	// Путь к файлу с набором ключей подписи токенов в JSON; флаг jk
+	JWTKeysFile string
+	// Набор ключей подписи токенов в JSON; флаг jks
+	JWTKeys string
+	// Время жизни токена доступа; флаг att
+	AccessTokenTTL time.Duration
+	// Время жизни токена обновления; флаг rtt
+	RefreshTokenTTL time.Duration
+	// ID пользователей, которым при запуске выдаётся роль администратора; флаг admins, значения через запятую
+	AdminUsers []string
+	// Адрес издателя OpenID Connect, пусто - вход через поставщика удостоверений выключен; флаг oidc-issuer
+	OIDCIssuer string
+	// ID клиента у издателя OpenID Connect; флаг oidc-client-id
+	OIDCClientID string
+	// Секрет клиента у издателя OpenID Connect, пусто - публичный клиент; флаг oidc-client-secret
+	OIDCClientSecret string
+	// Адрес возврата от издателя, пусто - базовый адрес и /api/auth/oidc/callback; флаг oidc-redirect-url
+	OIDCRedirectURL string
+	// Сертификат сервера в формате PEM, пусто - самоподписанный сертификат; флаг tls-cert
+	TLSCertFile string
+	// Закрытый ключ сертификата сервера в формате PEM; флаг tls-key
+	TLSKeyFile string
+	// Сертификаты удостоверяющих центров клиентов в формате PEM, пусто - mTLS выключен; флаг tls-client-ca
+	TLSClientCAFile string
+	// CN субъекта или SAN клиентских сертификатов, которым доступны внутренние ручки;
+	// пусто - любой сертификат от удостоверяющего центра клиентов; флаг trusted-clients, значения через запятую
+	TrustedClients []string
 
 	wasSetBootstrapNetAddress  bool
 	wasSetBaseURLAddress       bool
@@ -38,16 +108,88 @@ type Config struct {
 	wasSetEnableHTTPS          bool
 	wasSetTrustedSubnets       bool
 	wasSetBootstrapAddressgRPC bool
+	wasSetRedisURL             bool
+	wasSetURLCacheSize         bool
+	wasSetURLCacheTTL          bool
+	wasSetURLCacheNegativeTTL  bool
+
+	wasSetDatabaseMaxConns               bool
+	wasSetDatabaseMinConns               bool
+	wasSetDatabaseMaxConnLifetime        bool
+	wasSetDatabaseConnectTimeout         bool
+	wasSetDatabaseStatementTimeout       bool
+	wasSetDatabaseStatementCacheCapacity bool
+	wasSetDatabaseReplicaDSNs            bool
+	wasSetDatabaseReplicaStickiness      bool
+	wasSetNoAutoMigrate                  bool
+	wasSetIDGenerator                    bool
+	wasSetIDLength                       bool
+	wasSetIDSalt                         bool
+	wasSetIDRangeSize                    bool
+	wasSetURLBloomFilter                 bool
+	wasSetURLBloomFalsePositiveRate      bool
+	wasSetURLBloomRebuildInterval        bool
+	wasSetJWTKeysFile                    bool
+	wasSetJWTKeys                        bool
+	wasSetAccessTokenTTL                 bool
+	wasSetRefreshTokenTTL                bool
+	wasSetAdminUsers                     bool
+	wasSetOIDCIssuer                     bool
+	wasSetOIDCClientID                   bool
+	wasSetOIDCClientSecret               bool
+	wasSetOIDCRedirectURL                bool
+	wasSetTLSCertFile                    bool
+	wasSetTLSKeyFile                     bool
+	wasSetTLSClientCAFile                bool
+	wasSetTrustedClients                 bool
 }
 
 type configFileJSON struct {
-	ServerAddress     *string `json:"server_address"`      // аналог переменной окружения SERVER_ADDRESS или флага -a
-	ServerAddressgRPC *string `json:"server_address_grpc"` // аналог переменной окружения SERVER_ADDRESS_GRPC или флага -ga
-	BaseURL           *string `json:"base_url"`            // аналог переменной окружения BASE_URL или флага -b
-	FileStoragePath   *string `json:"file_storage_path"`   // аналог переменной окружения FILE_STORAGE_PATH или флага -f
-	DatabaseDSN       *string `json:"database_dsn"`        // аналог переменной окружения DATABASE_DSN или флага -d
-	EnableHTTPS       *bool   `json:"enable_https"`        // аналог переменной окружения ENABLE_HTTPS или флага -s
-	TrustedSubnets    *string `json:"trusted_subnet"`      // аналог переменной окружения TRUSTED_SUBNETS или флага -t
+	ServerAddress     *string `json:"server_address"`         // аналог переменной окружения SERVER_ADDRESS или флага -a
+	ServerAddressgRPC *string `json:"server_address_grpc"`    // аналог переменной окружения SERVER_ADDRESS_GRPC или флага -ga
+	BaseURL           *string `json:"base_url"`               // аналог переменной окружения BASE_URL или флага -b
+	FileStoragePath   *string `json:"file_storage_path"`      // аналог переменной окружения FILE_STORAGE_PATH или флага -f
+	DatabaseDSN       *string `json:"database_dsn"`           // аналог переменной окружения DATABASE_DSN или флага -d
+	EnableHTTPS       *bool   `json:"enable_https"`           // аналог переменной окружения ENABLE_HTTPS или флага -s
+	TrustedSubnets    *string `json:"trusted_subnet"`         // аналог переменной окружения TRUSTED_SUBNETS или флага -t
+	RedisURL          *string `json:"redis_url"`              // аналог переменной окружения REDIS_URL или флага -r
+	URLCacheSize      *int    `json:"url_cache_size"`         // аналог переменной окружения URL_CACHE_SIZE или флага -cs
+	URLCacheTTL       *string `json:"url_cache_ttl"`          // аналог переменной окружения URL_CACHE_TTL или флага -ct
+	URLCacheNegTTL    *string `json:"url_cache_negative_ttl"` // аналог переменной окружения URL_CACHE_NEGATIVE_TTL или флага -cnt
+
+	DatabaseMaxConns               *int     `json:"database_max_conns"`                // аналог переменной окружения DATABASE_MAX_CONNS или флага -dmx
+	DatabaseMinConns               *int     `json:"database_min_conns"`                // аналог переменной окружения DATABASE_MIN_CONNS или флага -dmn
+	DatabaseMaxConnLifetime        *string  `json:"database_max_conn_lifetime"`        // аналог переменной окружения DATABASE_MAX_CONN_LIFETIME или флага -dml
+	DatabaseConnectTimeout         *string  `json:"database_connect_timeout"`          // аналог переменной окружения DATABASE_CONNECT_TIMEOUT или флага -dct
+	DatabaseStatementTimeout       *string  `json:"database_statement_timeout"`        // аналог переменной окружения DATABASE_STATEMENT_TIMEOUT или флага -dst
+	DatabaseStatementCacheCapacity *int     `json:"database_statement_cache_capacity"` // аналог переменной окружения DATABASE_STATEMENT_CACHE_CAPACITY или флага -dsc
+	DatabaseReplicaDSNs            []string `json:"database_replica_dsns"`             // аналог переменной окружения DATABASE_REPLICA_DSNS или флага -dr
+	DatabaseReplicaStickiness      *string  `json:"database_replica_stickiness"`       // аналог переменной окружения DATABASE_REPLICA_STICKINESS или флага -drs
+	NoAutoMigrate                  *bool    `json:"no_auto_migrate"`                   // аналог переменной окружения NO_AUTO_MIGRATE или флага -no-auto-migrate
+	IDGenerator                    *string  `json:"id_generator"`                      // аналог переменной окружения ID_GENERATOR или флага -ig
+	IDLength                       *int     `json:"id_length"`                         // аналог переменной окружения ID_LENGTH или флага -il
+	IDSalt                         *string  `json:"id_salt"`                           // аналог переменной окружения ID_SALT или флага -is
+	IDRangeSize                    *int     `json:"id_range_size"`                     // аналог переменной окружения ID_RANGE_SIZE или флага -irs
+	URLBloomFilter                 *bool    `json:"url_bloom_filter"`                  // аналог переменной окружения URL_BLOOM_FILTER или флага -bf
+	URLBloomFalsePositiveRate      *float64 `json:"url_bloom_false_positive_rate"`     // аналог переменной окружения URL_BLOOM_FALSE_POSITIVE_RATE или флага -bfp
+	URLBloomRebuildInterval        *string  `json:"url_bloom_rebuild_interval"`        // аналог переменной окружения URL_BLOOM_REBUILD_INTERVAL или флага -bfr
+	JWTKeysFile                    *string  `json:"jwt_keys_file"`                     // аналог переменной окружения JWT_KEYS_FILE или флага -jk
+	// JWTKeys аналог переменной окружения JWT_KEYS или флага -jks; задаётся объектом, а не строкой
+	JWTKeys json.RawMessage `json:"jwt_keys"`
+
+	AccessTokenTTL  *string  `json:"access_token_ttl"`  // аналог переменной окружения ACCESS_TOKEN_TTL или флага -att
+	RefreshTokenTTL *string  `json:"refresh_token_ttl"` // аналог переменной окружения REFRESH_TOKEN_TTL или флага -rtt
+	AdminUsers      []string `json:"admin_users"`       // аналог переменной окружения ADMIN_USERS или флага -admins
+
+	OIDCIssuer       *string `json:"oidc_issuer"`        // аналог переменной окружения OIDC_ISSUER или флага -oidc-issuer
+	OIDCClientID     *string `json:"oidc_client_id"`     // аналог переменной окружения OIDC_CLIENT_ID или флага -oidc-client-id
+	OIDCClientSecret *string `json:"oidc_client_secret"` // аналог переменной окружения OIDC_CLIENT_SECRET или флага -oidc-client-secret
+	OIDCRedirectURL  *string `json:"oidc_redirect_url"`  // аналог переменной окружения OIDC_REDIRECT_URL или флага -oidc-redirect-url
+
+	TLSCertFile     *string  `json:"tls_cert_file"`      // аналог переменной окружения TLS_CERT_FILE или флага -tls-cert
+	TLSKeyFile      *string  `json:"tls_key_file"`       // аналог переменной окружения TLS_KEY_FILE или флага -tls-key
+	TLSClientCAFile *string  `json:"tls_client_ca_file"` // аналог переменной окружения TLS_CLIENT_CA_FILE или флага -tls-client-ca
+	TrustedClients  []string `json:"trusted_clients"`    // аналог переменной окружения TRUSTED_CLIENTS или флага -trusted-clients
 }
 
 // New собирает конфигурацию из флагов командной строки, переменных среды
@@ -69,9 +211,24 @@ func New() (*Config, error) {
 		return nil, err
 	}
 
+	err = c.validate()
+	if err != nil {
+		return nil, err
+	}
+
 	return c, nil
 }
 
+// validate проверит совместимость настроек
+func (c *Config) validate() error {
+	// Redis не рассылает изменения, и фильтр отсекал бы URL'ы, сохранённые другими экземплярами
+	if c.URLBloomFilter && c.DatabaseDsn == "" && c.RedisURL != "" {
+		return errors.New("url bloom filter is not supported with redis storage")
+	}
+
+	return nil
+}
+
 // setFromEnv устанавливает данные из переменных окружения, если они не были заданы ранее
 func (c *Config) setFromEnv() error {
 	if !c.wasSetBootstrapNetAddress {
@@ -114,6 +271,342 @@ func (c *Config) setFromEnv() error {
 		}
 	}
 
+	if !c.wasSetRedisURL {
+		envValue, ok := os.LookupEnv("REDIS_URL")
+		c.wasSetRedisURL = ok
+		if ok {
+			c.RedisURL = envValue
+		}
+	}
+
+	if !c.wasSetURLCacheSize {
+		envValue, ok := os.LookupEnv("URL_CACHE_SIZE")
+		c.wasSetURLCacheSize = ok
+		if ok {
+			value, err := strconv.Atoi(envValue)
+			if err != nil {
+				return err
+			}
+			c.URLCacheSize = value
+		}
+	}
+
+	if !c.wasSetURLCacheTTL {
+		envValue, ok := os.LookupEnv("URL_CACHE_TTL")
+		c.wasSetURLCacheTTL = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.URLCacheTTL = value
+		}
+	}
+
+	if !c.wasSetURLCacheNegativeTTL {
+		envValue, ok := os.LookupEnv("URL_CACHE_NEGATIVE_TTL")
+		c.wasSetURLCacheNegativeTTL = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.URLCacheNegativeTTL = value
+		}
+	}
+
+	if !c.wasSetDatabaseMaxConns {
+		envValue, ok := os.LookupEnv("DATABASE_MAX_CONNS")
+		c.wasSetDatabaseMaxConns = ok
+		if ok {
+			value, err := strconv.Atoi(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseMaxConns = value
+		}
+	}
+
+	if !c.wasSetDatabaseMinConns {
+		envValue, ok := os.LookupEnv("DATABASE_MIN_CONNS")
+		c.wasSetDatabaseMinConns = ok
+		if ok {
+			value, err := strconv.Atoi(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseMinConns = value
+		}
+	}
+
+	if !c.wasSetDatabaseMaxConnLifetime {
+		envValue, ok := os.LookupEnv("DATABASE_MAX_CONN_LIFETIME")
+		c.wasSetDatabaseMaxConnLifetime = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseMaxConnLifetime = value
+		}
+	}
+
+	if !c.wasSetDatabaseConnectTimeout {
+		envValue, ok := os.LookupEnv("DATABASE_CONNECT_TIMEOUT")
+		c.wasSetDatabaseConnectTimeout = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseConnectTimeout = value
+		}
+	}
+
+	if !c.wasSetDatabaseStatementTimeout {
+		envValue, ok := os.LookupEnv("DATABASE_STATEMENT_TIMEOUT")
+		c.wasSetDatabaseStatementTimeout = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseStatementTimeout = value
+		}
+	}
+
+	if !c.wasSetDatabaseStatementCacheCapacity {
+		envValue, ok := os.LookupEnv("DATABASE_STATEMENT_CACHE_CAPACITY")
+		c.wasSetDatabaseStatementCacheCapacity = ok
+		if ok {
+			value, err := strconv.Atoi(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseStatementCacheCapacity = value
+		}
+	}
+
+	if !c.wasSetDatabaseReplicaDSNs {
+		envValue, ok := os.LookupEnv("DATABASE_REPLICA_DSNS")
+		c.wasSetDatabaseReplicaDSNs = ok
+		if ok {
+			c.DatabaseReplicaDSNs = splitList(envValue)
+		}
+	}
+
+	if !c.wasSetDatabaseReplicaStickiness {
+		envValue, ok := os.LookupEnv("DATABASE_REPLICA_STICKINESS")
+		c.wasSetDatabaseReplicaStickiness = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.DatabaseReplicaStickiness = value
+		}
+	}
+
+	if !c.wasSetNoAutoMigrate {
+		envValue, ok := os.LookupEnv("NO_AUTO_MIGRATE")
+		c.wasSetNoAutoMigrate = ok
+		if ok {
+			value, err := strconv.ParseBool(envValue)
+			if err != nil {
+				return err
+			}
+			c.NoAutoMigrate = value
+		}
+	}
+
+	if !c.wasSetIDGenerator {
+		envValue, ok := os.LookupEnv("ID_GENERATOR")
+		c.wasSetIDGenerator = ok
+		if ok {
+			c.IDGenerator = envValue
+		}
+	}
+
+	if !c.wasSetIDLength {
+		envValue, ok := os.LookupEnv("ID_LENGTH")
+		c.wasSetIDLength = ok
+		if ok {
+			value, err := strconv.Atoi(envValue)
+			if err != nil {
+				return err
+			}
+			c.IDLength = value
+		}
+	}
+
+	if !c.wasSetIDSalt {
+		envValue, ok := os.LookupEnv("ID_SALT")
+		c.wasSetIDSalt = ok
+		if ok {
+			c.IDSalt = envValue
+		}
+	}
+
+	if !c.wasSetIDRangeSize {
+		envValue, ok := os.LookupEnv("ID_RANGE_SIZE")
+		c.wasSetIDRangeSize = ok
+		if ok {
+			value, err := strconv.Atoi(envValue)
+			if err != nil {
+				return err
+			}
+			c.IDRangeSize = value
+		}
+	}
+
+	if !c.wasSetURLBloomFilter {
+		envValue, ok := os.LookupEnv("URL_BLOOM_FILTER")
+		c.wasSetURLBloomFilter = ok
+		if ok {
+			value, err := strconv.ParseBool(envValue)
+			if err != nil {
+				return err
+			}
+			c.URLBloomFilter = value
+		}
+	}
+
+	if !c.wasSetURLBloomFalsePositiveRate {
+		envValue, ok := os.LookupEnv("URL_BLOOM_FALSE_POSITIVE_RATE")
+		c.wasSetURLBloomFalsePositiveRate = ok
+		if ok {
+			value, err := strconv.ParseFloat(envValue, 64)
+			if err != nil {
+				return err
+			}
+			c.URLBloomFalsePositiveRate = value
+		}
+	}
+
+	if !c.wasSetURLBloomRebuildInterval {
+		envValue, ok := os.LookupEnv("URL_BLOOM_REBUILD_INTERVAL")
+		c.wasSetURLBloomRebuildInterval = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.URLBloomRebuildInterval = value
+		}
+	}
+
+	if !c.wasSetJWTKeysFile {
+		envValue, ok := os.LookupEnv("JWT_KEYS_FILE")
+		c.wasSetJWTKeysFile = ok
+		if ok {
+			c.JWTKeysFile = envValue
+		}
+	}
+
+	if !c.wasSetJWTKeys {
+		envValue, ok := os.LookupEnv("JWT_KEYS")
+		c.wasSetJWTKeys = ok
+		if ok {
+			c.JWTKeys = envValue
+		}
+	}
+
+	if !c.wasSetAccessTokenTTL {
+		envValue, ok := os.LookupEnv("ACCESS_TOKEN_TTL")
+		c.wasSetAccessTokenTTL = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.AccessTokenTTL = value
+		}
+	}
+
+	if !c.wasSetRefreshTokenTTL {
+		envValue, ok := os.LookupEnv("REFRESH_TOKEN_TTL")
+		c.wasSetRefreshTokenTTL = ok
+		if ok {
+			value, err := time.ParseDuration(envValue)
+			if err != nil {
+				return err
+			}
+			c.RefreshTokenTTL = value
+		}
+	}
+
+	if !c.wasSetAdminUsers {
+		envValue, ok := os.LookupEnv("ADMIN_USERS")
+		c.wasSetAdminUsers = ok
+		if ok {
+			c.AdminUsers = splitList(envValue)
+		}
+	}
+
+	if !c.wasSetOIDCIssuer {
+		envValue, ok := os.LookupEnv("OIDC_ISSUER")
+		c.wasSetOIDCIssuer = ok
+		if ok {
+			c.OIDCIssuer = envValue
+		}
+	}
+
+	if !c.wasSetOIDCClientID {
+		envValue, ok := os.LookupEnv("OIDC_CLIENT_ID")
+		c.wasSetOIDCClientID = ok
+		if ok {
+			c.OIDCClientID = envValue
+		}
+	}
+
+	if !c.wasSetOIDCClientSecret {
+		envValue, ok := os.LookupEnv("OIDC_CLIENT_SECRET")
+		c.wasSetOIDCClientSecret = ok
+		if ok {
+			c.OIDCClientSecret = envValue
+		}
+	}
+
+	if !c.wasSetOIDCRedirectURL {
+		envValue, ok := os.LookupEnv("OIDC_REDIRECT_URL")
+		c.wasSetOIDCRedirectURL = ok
+		if ok {
+			c.OIDCRedirectURL = envValue
+		}
+	}
+
+	if !c.wasSetTLSCertFile {
+		envValue, ok := os.LookupEnv("TLS_CERT_FILE")
+		c.wasSetTLSCertFile = ok
+		if ok {
+			c.TLSCertFile = envValue
+		}
+	}
+
+	if !c.wasSetTLSKeyFile {
+		envValue, ok := os.LookupEnv("TLS_KEY_FILE")
+		c.wasSetTLSKeyFile = ok
+		if ok {
+			c.TLSKeyFile = envValue
+		}
+	}
+
+	if !c.wasSetTLSClientCAFile {
+		envValue, ok := os.LookupEnv("TLS_CLIENT_CA_FILE")
+		c.wasSetTLSClientCAFile = ok
+		if ok {
+			c.TLSClientCAFile = envValue
+		}
+	}
+
+	if !c.wasSetTrustedClients {
+		envValue, ok := os.LookupEnv("TRUSTED_CLIENTS")
+		c.wasSetTrustedClients = ok
+		if ok {
+			c.TrustedClients = splitList(envValue)
+		}
+	}
+
 	if !c.wasSetEnableHTTPS {
 		envValue, ok := os.LookupEnv("ENABLE_HTTPS")
 		c.wasSetEnableHTTPS = ok
@@ -145,9 +638,42 @@ func (c *Config) setFromFlags() error {
 	b := flag.String("b", "http://localhost:8080", "Flag responsible for base addres of shorted url")
 	f := flag.String("f", "", "Path of short url's file")
 	d := flag.String("d", "", "Database connection string")
+	r := flag.String("r", "", "Redis connection string")
 	cf := flag.String("c", "", "Config file path")
 	t := flag.String("t", "", "Trusted subnets. Used to authorize access to several endpoints.")
 	s := flag.Bool("s", false, "Enable TLS")
+	cs := flag.Int("cs", 0, "Max count of cached URLs; 0 disables cache")
+	ct := flag.Duration("ct", time.Minute, "TTL of cached URL")
+	cnt := flag.Duration("cnt", 5*time.Second, "TTL of cached unknown or deleted URL")
+	dmx := flag.Int("dmx", 0, "Max count of database connections; 0 uses pgx default")
+	dmn := flag.Int("dmn", 0, "Min count of open database connections")
+	dml := flag.Duration("dml", time.Hour, "Max lifetime of database connection")
+	dct := flag.Duration("dct", 10*time.Second, "Database connect timeout")
+	dst := flag.Duration("dst", 0, "Database statement timeout; 0 means no timeout")
+	dsc := flag.Int("dsc", 512, "Capacity of prepared statements cache per database connection; 0 disables prepared statements")
+	dr := flag.String("dr", "", "Comma separated database replica connection strings")
+	drs := flag.Duration("drs", 5*time.Second, "Time after user write while user reads go to primary database")
+	nam := flag.Bool("no-auto-migrate", false, "Do not apply database migrations on start; use db migrate command instead")
+	ig := flag.String("ig", "hash", "Short URL ID generator: hash, random, counter, time or hashids")
+	il := flag.Int("il", 8, "Length of random IDs and min length of hashids IDs")
+	is := flag.String("is", "", "Salt of hashids IDs")
+	irs := flag.Int("irs", 100, "Count of ID counter values leased from database at once")
+	bf := flag.Bool("bf", false, "Reject unknown short URL IDs with bloom filter")
+	bfp := flag.Float64("bfp", 0.01, "False positive rate of bloom filter")
+	bfr := flag.Duration("bfr", 10*time.Minute, "Bloom filter rebuild interval; 0 disables rebuilds")
+	jk := flag.String("jk", "", "Path of JSON file with JWT signing keys")
+	jks := flag.String("jks", "", "JSON with JWT signing keys")
+	att := flag.Duration("att", 15*time.Minute, "Access token TTL")
+	rtt := flag.Duration("rtt", 30*24*time.Hour, "Refresh token TTL")
+	admins := flag.String("admins", "", "Comma separated IDs of users granted admin role on start")
+	oidcIssuer := flag.String("oidc-issuer", "", "OpenID Connect issuer URL; empty disables identity provider login")
+	oidcClientID := flag.String("oidc-client-id", "", "OpenID Connect client ID")
+	oidcClientSecret := flag.String("oidc-client-secret", "", "OpenID Connect client secret; empty for public client")
+	oidcRedirectURL := flag.String("oidc-redirect-url", "", "OpenID Connect redirect URL; defaults to base URL with /api/auth/oidc/callback")
+	tlsCert := flag.String("tls-cert", "", "Path of PEM server certificate; empty for self-signed certificate")
+	tlsKey := flag.String("tls-key", "", "Path of PEM server certificate private key")
+	tlsClientCA := flag.String("tls-client-ca", "", "Path of PEM client CA bundle; empty disables mTLS")
+	trustedClients := flag.String("trusted-clients", "", "Comma separated client certificate CNs or SANs allowed to internal endpoints")
 	flag.Parse()
 
 	c.BootstrapNetAddress = *a
@@ -155,17 +681,83 @@ func (c *Config) setFromFlags() error {
 	c.BaseURLAddress = *b
 	c.FileStoragePath = *f
 	c.DatabaseDsn = *d
+	c.RedisURL = *r
 	c.EnableHTTPS = *s
 	c.ConfigFileName = *cf
 	c.TrustedSubnets = *t
+	c.URLCacheSize = *cs
+	c.URLCacheTTL = *ct
+	c.URLCacheNegativeTTL = *cnt
+	c.DatabaseMaxConns = *dmx
+	c.DatabaseMinConns = *dmn
+	c.DatabaseMaxConnLifetime = *dml
+	c.DatabaseConnectTimeout = *dct
+	c.DatabaseStatementTimeout = *dst
+	c.DatabaseStatementCacheCapacity = *dsc
+	c.DatabaseReplicaDSNs = splitList(*dr)
+	c.DatabaseReplicaStickiness = *drs
+	c.NoAutoMigrate = *nam
+	c.IDGenerator = *ig
+	c.IDLength = *il
+	c.IDSalt = *is
+	c.IDRangeSize = *irs
+	c.URLBloomFilter = *bf
+	c.URLBloomFalsePositiveRate = *bfp
+	c.URLBloomRebuildInterval = *bfr
+	c.JWTKeysFile = *jk
+	c.JWTKeys = *jks
+	c.AccessTokenTTL = *att
+	c.RefreshTokenTTL = *rtt
+	c.AdminUsers = splitList(*admins)
+	c.OIDCIssuer = *oidcIssuer
+	c.OIDCClientID = *oidcClientID
+	c.OIDCClientSecret = *oidcClientSecret
+	c.OIDCRedirectURL = *oidcRedirectURL
+	c.TLSCertFile = *tlsCert
+	c.TLSKeyFile = *tlsKey
+	c.TLSClientCAFile = *tlsClientCA
+	c.TrustedClients = splitList(*trustedClients)
 
 	c.wasSetBaseURLAddress = isFlagPassed("b")
 	c.wasSetBootstrapNetAddress = isFlagPassed("a")
 	c.wasSetBootstrapAddressgRPC = isFlagPassed("ga")
 	c.wasSetDatabaseDsn = isFlagPassed("d")
+	c.wasSetRedisURL = isFlagPassed("r")
 	c.wasSetEnableHTTPS = isFlagPassed("s")
 	c.wasSetFileStoragePath = isFlagPassed("f")
 	c.wasSetTrustedSubnets = isFlagPassed("t")
+	c.wasSetURLCacheSize = isFlagPassed("cs")
+	c.wasSetURLCacheTTL = isFlagPassed("ct")
+	c.wasSetURLCacheNegativeTTL = isFlagPassed("cnt")
+	c.wasSetDatabaseMaxConns = isFlagPassed("dmx")
+	c.wasSetDatabaseMinConns = isFlagPassed("dmn")
+	c.wasSetDatabaseMaxConnLifetime = isFlagPassed("dml")
+	c.wasSetDatabaseConnectTimeout = isFlagPassed("dct")
+	c.wasSetDatabaseStatementTimeout = isFlagPassed("dst")
+	c.wasSetDatabaseStatementCacheCapacity = isFlagPassed("dsc")
+	c.wasSetDatabaseReplicaDSNs = isFlagPassed("dr")
+	c.wasSetDatabaseReplicaStickiness = isFlagPassed("drs")
+	c.wasSetNoAutoMigrate = isFlagPassed("no-auto-migrate")
+	c.wasSetIDGenerator = isFlagPassed("ig")
+	c.wasSetIDLength = isFlagPassed("il")
+	c.wasSetIDSalt = isFlagPassed("is")
+	c.wasSetIDRangeSize = isFlagPassed("irs")
+	c.wasSetURLBloomFilter = isFlagPassed("bf")
+	c.wasSetURLBloomFalsePositiveRate = isFlagPassed("bfp")
+	c.wasSetURLBloomRebuildInterval = isFlagPassed("bfr")
+	c.wasSetJWTKeysFile = isFlagPassed("jk")
+	c.wasSetJWTKeys = isFlagPassed("jks")
+	c.wasSetAccessTokenTTL = isFlagPassed("att")
+	c.wasSetRefreshTokenTTL = isFlagPassed("rtt")
+	c.wasSetAdminUsers = isFlagPassed("admins")
+	c.wasSetOIDCIssuer = isFlagPassed("oidc-issuer")
+	c.wasSetOIDCClientID = isFlagPassed("oidc-client-id")
+	c.wasSetOIDCClientSecret = isFlagPassed("oidc-client-secret")
+	c.wasSetOIDCRedirectURL = isFlagPassed("oidc-redirect-url")
+	c.wasSetTLSCertFile = isFlagPassed("tls-cert")
+	c.wasSetTLSKeyFile = isFlagPassed("tls-key")
+	c.wasSetTLSClientCAFile = isFlagPassed("tls-client-ca")
+	c.wasSetTrustedClients = isFlagPassed("trusted-clients")
 
 	return nil
 }
@@ -213,6 +805,165 @@ func (c *Config) setFromConfigFile() error {
 		c.DatabaseDsn = *j.DatabaseDSN
 		c.wasSetDatabaseDsn = true
 	}
+	if !c.wasSetRedisURL && j.RedisURL != nil {
+		c.RedisURL = *j.RedisURL
+		c.wasSetRedisURL = true
+	}
+	if !c.wasSetURLCacheSize && j.URLCacheSize != nil {
+		c.URLCacheSize = *j.URLCacheSize
+		c.wasSetURLCacheSize = true
+	}
+	if !c.wasSetURLCacheTTL && j.URLCacheTTL != nil {
+		c.URLCacheTTL, err = time.ParseDuration(*j.URLCacheTTL)
+		if err != nil {
+			return fmt.Errorf("can not parse url_cache_ttl: %w", err)
+		}
+		c.wasSetURLCacheTTL = true
+	}
+	if !c.wasSetURLCacheNegativeTTL && j.URLCacheNegTTL != nil {
+		c.URLCacheNegativeTTL, err = time.ParseDuration(*j.URLCacheNegTTL)
+		if err != nil {
+			return fmt.Errorf("can not parse url_cache_negative_ttl: %w", err)
+		}
+		c.wasSetURLCacheNegativeTTL = true
+	}
+	if !c.wasSetDatabaseMaxConns && j.DatabaseMaxConns != nil {
+		c.DatabaseMaxConns = *j.DatabaseMaxConns
+		c.wasSetDatabaseMaxConns = true
+	}
+	if !c.wasSetDatabaseMinConns && j.DatabaseMinConns != nil {
+		c.DatabaseMinConns = *j.DatabaseMinConns
+		c.wasSetDatabaseMinConns = true
+	}
+	if !c.wasSetDatabaseMaxConnLifetime && j.DatabaseMaxConnLifetime != nil {
+		c.DatabaseMaxConnLifetime, err = time.ParseDuration(*j.DatabaseMaxConnLifetime)
+		if err != nil {
+			return fmt.Errorf("can not parse database_max_conn_lifetime: %w", err)
+		}
+		c.wasSetDatabaseMaxConnLifetime = true
+	}
+	if !c.wasSetDatabaseConnectTimeout && j.DatabaseConnectTimeout != nil {
+		c.DatabaseConnectTimeout, err = time.ParseDuration(*j.DatabaseConnectTimeout)
+		if err != nil {
+			return fmt.Errorf("can not parse database_connect_timeout: %w", err)
+		}
+		c.wasSetDatabaseConnectTimeout = true
+	}
+	if !c.wasSetDatabaseStatementTimeout && j.DatabaseStatementTimeout != nil {
+		c.DatabaseStatementTimeout, err = time.ParseDuration(*j.DatabaseStatementTimeout)
+		if err != nil {
+			return fmt.Errorf("can not parse database_statement_timeout: %w", err)
+		}
+		c.wasSetDatabaseStatementTimeout = true
+	}
+	if !c.wasSetDatabaseStatementCacheCapacity && j.DatabaseStatementCacheCapacity != nil {
+		c.DatabaseStatementCacheCapacity = *j.DatabaseStatementCacheCapacity
+		c.wasSetDatabaseStatementCacheCapacity = true
+	}
+	if !c.wasSetDatabaseReplicaDSNs && j.DatabaseReplicaDSNs != nil {
+		c.DatabaseReplicaDSNs = j.DatabaseReplicaDSNs
+		c.wasSetDatabaseReplicaDSNs = true
+	}
+	if !c.wasSetDatabaseReplicaStickiness && j.DatabaseReplicaStickiness != nil {
+		c.DatabaseReplicaStickiness, err = time.ParseDuration(*j.DatabaseReplicaStickiness)
+		if err != nil {
+			return fmt.Errorf("can not parse database_replica_stickiness: %w", err)
+		}
+		c.wasSetDatabaseReplicaStickiness = true
+	}
+	if !c.wasSetNoAutoMigrate && j.NoAutoMigrate != nil {
+		c.NoAutoMigrate = *j.NoAutoMigrate
+		c.wasSetNoAutoMigrate = true
+	}
+	if !c.wasSetIDGenerator && j.IDGenerator != nil {
+		c.IDGenerator = *j.IDGenerator
+		c.wasSetIDGenerator = true
+	}
+	if !c.wasSetIDLength && j.IDLength != nil {
+		c.IDLength = *j.IDLength
+		c.wasSetIDLength = true
+	}
+	if !c.wasSetIDSalt && j.IDSalt != nil {
+		c.IDSalt = *j.IDSalt
+		c.wasSetIDSalt = true
+	}
+	if !c.wasSetIDRangeSize && j.IDRangeSize != nil {
+		c.IDRangeSize = *j.IDRangeSize
+		c.wasSetIDRangeSize = true
+	}
+	if !c.wasSetURLBloomFilter && j.URLBloomFilter != nil {
+		c.URLBloomFilter = *j.URLBloomFilter
+		c.wasSetURLBloomFilter = true
+	}
+	if !c.wasSetURLBloomFalsePositiveRate && j.URLBloomFalsePositiveRate != nil {
+		c.URLBloomFalsePositiveRate = *j.URLBloomFalsePositiveRate
+		c.wasSetURLBloomFalsePositiveRate = true
+	}
+	if !c.wasSetURLBloomRebuildInterval && j.URLBloomRebuildInterval != nil {
+		c.URLBloomRebuildInterval, err = time.ParseDuration(*j.URLBloomRebuildInterval)
+		if err != nil {
+			return fmt.Errorf("can not parse url_bloom_rebuild_interval: %w", err)
+		}
+		c.wasSetURLBloomRebuildInterval = true
+	}
+	if !c.wasSetJWTKeysFile && j.JWTKeysFile != nil {
+		c.JWTKeysFile = *j.JWTKeysFile
+		c.wasSetJWTKeysFile = true
+	}
+	if !c.wasSetJWTKeys && j.JWTKeys != nil {
+		c.JWTKeys = string(j.JWTKeys)
+		c.wasSetJWTKeys = true
+	}
+	if !c.wasSetAccessTokenTTL && j.AccessTokenTTL != nil {
+		c.AccessTokenTTL, err = time.ParseDuration(*j.AccessTokenTTL)
+		if err != nil {
+			return fmt.Errorf("can not parse access_token_ttl: %w", err)
+		}
+		c.wasSetAccessTokenTTL = true
+	}
+	if !c.wasSetRefreshTokenTTL && j.RefreshTokenTTL != nil {
+		c.RefreshTokenTTL, err = time.ParseDuration(*j.RefreshTokenTTL)
+		if err != nil {
+			return fmt.Errorf("can not parse refresh_token_ttl: %w", err)
+		}
+		c.wasSetRefreshTokenTTL = true
+	}
+	if !c.wasSetAdminUsers && j.AdminUsers != nil {
+		c.AdminUsers = j.AdminUsers
+		c.wasSetAdminUsers = true
+	}
+	if !c.wasSetOIDCIssuer && j.OIDCIssuer != nil {
+		c.OIDCIssuer = *j.OIDCIssuer
+		c.wasSetOIDCIssuer = true
+	}
+	if !c.wasSetOIDCClientID && j.OIDCClientID != nil {
+		c.OIDCClientID = *j.OIDCClientID
+		c.wasSetOIDCClientID = true
+	}
+	if !c.wasSetOIDCClientSecret && j.OIDCClientSecret != nil {
+		c.OIDCClientSecret = *j.OIDCClientSecret
+		c.wasSetOIDCClientSecret = true
+	}
+	if !c.wasSetOIDCRedirectURL && j.OIDCRedirectURL != nil {
+		c.OIDCRedirectURL = *j.OIDCRedirectURL
+		c.wasSetOIDCRedirectURL = true
+	}
+	if !c.wasSetTLSCertFile && j.TLSCertFile != nil {
+		c.TLSCertFile = *j.TLSCertFile
+		c.wasSetTLSCertFile = true
+	}
+	if !c.wasSetTLSKeyFile && j.TLSKeyFile != nil {
+		c.TLSKeyFile = *j.TLSKeyFile
+		c.wasSetTLSKeyFile = true
+	}
+	if !c.wasSetTLSClientCAFile && j.TLSClientCAFile != nil {
+		c.TLSClientCAFile = *j.TLSClientCAFile
+		c.wasSetTLSClientCAFile = true
+	}
+	if !c.wasSetTrustedClients && j.TrustedClients != nil {
+		c.TrustedClients = j.TrustedClients
+		c.wasSetTrustedClients = true
+	}
 	if !c.wasSetEnableHTTPS && j.EnableHTTPS != nil {
 		c.EnableHTTPS = *j.EnableHTTPS
 		c.wasSetEnableHTTPS = true
@@ -224,6 +975,18 @@ func (c *Config) setFromConfigFile() error {
 	return nil
 }
 
+// splitList разбивает список значений, перечисленных через запятую
+func splitList(value string) []string {
+	result := make([]string, 0)
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
 // isFlagPassed определяет был ли передан флаг
 func isFlagPassed(name string) bool {
 	found := false
@@ -5,22 +5,36 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"strings"
 	"sync"
 
 	"github.com/KartoonYoko/go-url-shortener/config"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
 	"github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver"
 	"github.com/KartoonYoko/go-url-shortener/internal/controller/http"
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	"github.com/KartoonYoko/go-url-shortener/internal/oidc"
+	bloomRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/bloomrepo"
+	cacheRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/cacherepo"
 	fileRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/filerepo"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
 	inmrRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
 	pgsqlRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/psgsqlrepo"
+	redisRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/redisrepo"
 	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	usecaseBackup "github.com/KartoonYoko/go-url-shortener/internal/usecase/backup"
 	usecasePinger "github.com/KartoonYoko/go-url-shortener/internal/usecase/ping"
 	usecaseShortener "github.com/KartoonYoko/go-url-shortener/internal/usecase/shortener"
 	usecaseStats "github.com/KartoonYoko/go-url-shortener/internal/usecase/stats"
+	usecaseWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/zap"
 )
 
@@ -30,7 +44,77 @@ type shortenerRepoCloser interface {
 	usecasePinger.PingRepo
 	usecaseAuth.AuthRepo
 	usecaseStats.StatsRepo
+	usecaseBackup.BackupRepo
+	usecaseWorkspace.WorkspaceRepo
 	io.Closer
+	SetIDGenerator(g idgen.Generator)
+	GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error)
+}
+
+// changesListener хранилище, рассылающее изменения URL'ов между экземплярами приложения
+type changesListener interface {
+	ListenChanges(h pgsqlRepo.ChangeHandler) error
+}
+
+// changeHandlers рассылает изменения URL'ов всем обработчикам
+type changeHandlers []pgsqlRepo.ChangeHandler
+
+// Invalidate передаст изменённые ID всем обработчикам
+func (hs changeHandlers) Invalidate(ids ...string) {
+	for _, h := range hs {
+		h.Invalidate(ids...)
+	}
+}
+
+// InvalidateAll сбросит состояние всех обработчиков
+func (hs changeHandlers) InvalidateAll() {
+	for _, h := range hs {
+		h.InvalidateAll()
+	}
+}
+
+// filteredBackupRepo хранилище снимков, добавляющее восстановленные URL'ы в фильтр Блума
+type filteredBackupRepo struct {
+	usecaseBackup.BackupRepo
+	filtered *bloomRepo.FilteredRepo
+}
+
+// ImportURLs загрузит URL'ы в хранилище и добавит неудалённые в фильтр
+func (r filteredBackupRepo) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	if err := r.BackupRepo.ImportURLs(ctx, urls); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if !u.Deleted {
+			ids = append(ids, u.ID)
+		}
+	}
+	r.filtered.Invalidate(ids...)
+
+	return nil
+}
+
+// cachedBackupRepo хранилище снимков, сбрасывающее закешированные записи о восстановленных URL'ах:
+// иначе отсутствовавшие ID отвечали бы 404, а удалённые в снимке URL'ы перенаправляли бы до истечения TTL
+type cachedBackupRepo struct {
+	usecaseBackup.BackupRepo
+	cached *cacheRepo.CachedRepo
+}
+
+// ImportURLs загрузит URL'ы в хранилище и сбросит их записи в кеше
+func (r cachedBackupRepo) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	err := r.BackupRepo.ImportURLs(ctx, urls)
+
+	// сбрасываем и при ошибке: часть URL'ов могла успеть загрузиться
+	ids := make([]string, 0, len(urls))
+	for _, u := range urls {
+		ids = append(ids, u.ID)
+	}
+	r.cached.Invalidate(ids...)
+
+	return err
 }
 
 type serverHandler interface {
@@ -54,6 +138,27 @@ func Run() {
 		return
 	}
 
+	// ключи подписи токенов
+	tokens, err := initTokens(*conf)
+	if err != nil {
+		logger.Log.Error("jwt keys init error: ", zap.Error(err))
+		return
+	}
+
+	// вход через поставщика удостоверений
+	oidcProvider, err := initOIDC(*conf)
+	if err != nil {
+		logger.Log.Error("oidc init error: ", zap.Error(err))
+		return
+	}
+
+	// взаимная аутентификация TLS
+	tlsConfig, err := initTLS(*conf)
+	if err != nil {
+		logger.Log.Error("tls init error: ", zap.Error(err))
+		return
+	}
+
 	// репозитории
 	repo, err := initRepo(ctx, *conf)
 	if err != nil {
@@ -62,11 +167,78 @@ func Run() {
 	}
 	defer repo.Close()
 
+	// генератор ID URL'ов
+	idGen, idRanges, err := initIDGenerator(ctx, *conf, repo)
+	if err != nil {
+		logger.Log.Error("id generator init error: ", zap.Error(err))
+		return
+	}
+	repo.SetIDGenerator(idGen)
+	if idRanges != nil {
+		// неиспользованные значения счётчика достанутся другим экземплярам
+		defer func() {
+			if err := idRanges.Close(context.Background()); err != nil {
+				logger.Log.Error("release id range error: ", zap.Error(err))
+			}
+		}()
+	}
+
+	var shortenerRepo cacheRepo.ShortenerRepo = repo
+	var backupRepo usecaseBackup.BackupRepo = repo
+	statsProviders := make([]usecaseStats.StatsProvider, 0)
+	handlers := make(changeHandlers, 0)
+
+	// фильтр Блума несуществующих ID; строится после подписки на изменения, чтобы не пропустить их
+	var filteredRepo *bloomRepo.FilteredRepo
+	if conf.URLBloomFilter {
+		filteredRepo, err = bloomRepo.New(repo, conf.URLBloomFalsePositiveRate, conf.URLBloomRebuildInterval)
+		if err != nil {
+			logger.Log.Error("bloom filter init error: ", zap.Error(err))
+			return
+		}
+		defer filteredRepo.Close()
+		shortenerRepo = filteredRepo
+		backupRepo = filteredBackupRepo{BackupRepo: repo, filtered: filteredRepo}
+		statsProviders = append(statsProviders, filteredRepo)
+		handlers = append(handlers, filteredRepo)
+	}
+
+	// кеш URL'ов для перенаправлений
+	if conf.URLCacheSize > 0 {
+		cachedRepo := cacheRepo.New(shortenerRepo, conf.URLCacheSize, conf.URLCacheTTL, conf.URLCacheNegativeTTL)
+		shortenerRepo = cachedRepo
+		backupRepo = cachedBackupRepo{BackupRepo: backupRepo, cached: cachedRepo}
+		statsProviders = append(statsProviders, cachedRepo)
+		handlers = append(handlers, cachedRepo)
+	}
+
+	// изменения, сделанные другими экземплярами, сбрасывают кеш и пополняют фильтр,
+	// а при репликах направляют чтения изменённых данных в основную БД
+	if l, ok := repo.(changesListener); ok && (len(handlers) > 0 || len(conf.DatabaseReplicaDSNs) > 0) {
+		if err = l.ListenChanges(handlers); err != nil {
+			logger.Log.Error("listen changes error: ", zap.Error(err))
+			return
+		}
+	}
+	if filteredRepo != nil {
+		if err = filteredRepo.Rebuild(ctx); err != nil {
+			logger.Log.Error("bloom filter build error: ", zap.Error(err))
+			return
+		}
+	}
+
 	// usecase'ы
-	serviceShortener := usecaseShortener.New(repo, conf.BaseURLAddress)
+	serviceShortener := usecaseShortener.New(shortenerRepo, conf.BaseURLAddress)
 	servicePinger := usecasePinger.NewPingUseCase(repo)
 	serviceAuth := usecaseAuth.NewAuthUseCase(repo)
-	serviceStats := usecaseStats.New(repo)
+	serviceAuth.SetRefreshTokenTTL(conf.RefreshTokenTTL)
+	if err = serviceAuth.GrantAdmin(ctx, conf.AdminUsers); err != nil {
+		logger.Log.Error("grant admin role error: ", zap.Error(err))
+		return
+	}
+	serviceStats := usecaseStats.New(repo, statsProviders...)
+	serviceBackup := usecaseBackup.New(backupRepo)
+	serviceWorkspace := usecaseWorkspace.New(repo)
 
 	// контроллеры
 	httpController := http.NewShortenerController(
@@ -74,14 +246,27 @@ func Run() {
 		servicePinger,
 		serviceAuth,
 		serviceStats,
+		serviceBackup,
+		serviceWorkspace,
+		tokens,
 		conf)
+	if oidcProvider != nil {
+		httpController.SetOIDCProvider(oidcProvider)
+	}
 	grpcController := grpcserver.NewGRPCController(
 		conf,
 		serviceShortener,
 		servicePinger,
 		serviceAuth,
 		serviceStats,
+		serviceBackup,
+		serviceWorkspace,
+		tokens,
 	)
+	if tlsConfig != nil {
+		httpController.SetTLSConfig(tlsConfig)
+		grpcController.SetTLSConfig(tlsConfig)
+	}
 
 	startServer(ctx, httpController, grpcController)
 }
@@ -90,12 +275,50 @@ func initRepo(ctx context.Context, conf config.Config) (shortenerRepoCloser, err
 	if conf.DatabaseDsn != "" {
 		logger.Log.Info("starting postgresql repo")
 
-		db, err := pgsqlRepo.NewSQLxConnection(ctx, conf.DatabaseDsn)
+		poolConfig := pgsqlRepo.PoolConfig{
+			MaxConns:               conf.DatabaseMaxConns,
+			MinConns:               conf.DatabaseMinConns,
+			MaxConnLifetime:        conf.DatabaseMaxConnLifetime,
+			ConnectTimeout:         conf.DatabaseConnectTimeout,
+			StatementTimeout:       conf.DatabaseStatementTimeout,
+			StatementCacheCapacity: conf.DatabaseStatementCacheCapacity,
+			SkipMigrations:         conf.NoAutoMigrate,
+		}
+		pool, err := pgsqlRepo.NewPool(ctx, conf.DatabaseDsn, poolConfig)
 		if err != nil {
 			return nil, err
 		}
 
-		repo, err := pgsqlRepo.NewPsgsqlRepo(ctx, db)
+		replicas := make([]*pgxpool.Pool, 0, len(conf.DatabaseReplicaDSNs))
+		for i, dsn := range conf.DatabaseReplicaDSNs {
+			replica, err := pgsqlRepo.NewReplicaPool(ctx, dsn, poolConfig)
+			if err != nil {
+				for _, r := range replicas {
+					r.Close()
+				}
+				pool.Close()
+				return nil, fmt.Errorf("can not init database replica %d: %w", i, err)
+			}
+			replicas = append(replicas, replica)
+		}
+		if len(replicas) > 0 {
+			logger.Log.Info("postgresql read replicas enabled", zap.Int("count", len(replicas)))
+		}
+
+		repo, err := pgsqlRepo.NewPsgsqlRepoWithReplicas(ctx, pool, replicas, pgsqlRepo.ReplicaConfig{
+			Stickiness: conf.DatabaseReplicaStickiness,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return repo, nil
+	}
+
+	if conf.RedisURL != "" {
+		logger.Log.Info("starting redis repo")
+
+		repo, err := redisRepo.NewRedisRepo(ctx, conf.RedisURL)
 		if err != nil {
 			return nil, err
 		}
@@ -118,6 +341,111 @@ func initRepo(ctx context.Context, conf config.Config) (shortenerRepoCloser, err
 	return inmrRepo.NewInMemoryRepo(), nil
 }
 
+// initTokens загрузит ключи подписи токенов из конфигурации; если ключи не заданы,
+// токены подписываются случайным ключом и перестают действовать после перезапуска
+func initTokens(conf config.Config) (*common.JWTManager, error) {
+	if conf.AccessTokenTTL <= 0 || conf.RefreshTokenTTL <= 0 {
+		return nil, errors.New("access and refresh token ttl must be positive")
+	}
+	if conf.AccessTokenTTL > conf.RefreshTokenTTL {
+		return nil, errors.New("access token ttl must not exceed refresh token ttl")
+	}
+
+	tokens, err := loadTokens(conf)
+	if err != nil {
+		return nil, err
+	}
+	tokens.SetAccessTokenTTL(conf.AccessTokenTTL)
+
+	return tokens, nil
+}
+
+// loadTokens создаст менеджер токенов из ключей, заданных файлом или строкой
+func loadTokens(conf config.Config) (*common.JWTManager, error) {
+	if conf.JWTKeys != "" && conf.JWTKeysFile != "" {
+		return nil, errors.New("jwt keys and jwt keys file are both set")
+	}
+	if conf.JWTKeys != "" {
+		return common.ParseJWTKeySet([]byte(conf.JWTKeys))
+	}
+	if conf.JWTKeysFile != "" {
+		data, err := os.ReadFile(conf.JWTKeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("can not read jwt keys file: %w", err)
+		}
+		return common.ParseJWTKeySet(data)
+	}
+
+	logger.Log.Warn("jwt keys are not set; tokens are signed with random key and will be invalid after restart")
+	return common.NewRandomJWTManager()
+}
+
+// initOIDC создаст клиент издателя OpenID Connect; nil, если издатель не задан
+func initOIDC(conf config.Config) (*oidc.Provider, error) {
+	if conf.OIDCIssuer == "" {
+		return nil, nil
+	}
+
+	redirectURL := conf.OIDCRedirectURL
+	if redirectURL == "" {
+		redirectURL = strings.TrimSuffix(conf.BaseURLAddress, "/") + "/api/auth/oidc/callback"
+	}
+
+	return oidc.New(oidc.Config{
+		Issuer:       conf.OIDCIssuer,
+		ClientID:     conf.OIDCClientID,
+		ClientSecret: conf.OIDCClientSecret,
+		RedirectURL:  redirectURL,
+	})
+}
+
+// initTLS соберёт настройки mTLS для HTTP и gRPC серверов; nil, если удостоверяющий центр клиентов не задан
+func initTLS(conf config.Config) (*tls.Config, error) {
+	if conf.TLSClientCAFile == "" {
+		return nil, nil
+	}
+	if conf.TLSCertFile == "" || conf.TLSKeyFile == "" {
+		return nil, errors.New("server certificate and key are required for mtls")
+	}
+
+	return common.ServerTLSConfig(conf.TLSCertFile, conf.TLSKeyFile, conf.TLSClientCAFile)
+}
+
+// initIDGenerator создаст генератор ID URL'ов. Если хранилище общее для нескольких экземпляров,
+// счётчик арендует у него диапазоны значений; иначе счётчик продолжается после количества
+// сохранённых URL'ов. Значения, занятые прежними ID, пропускаются при коллизии
+func initIDGenerator(ctx context.Context,
+	conf config.Config, repo shortenerRepoCloser) (idgen.Generator, *idgen.RangeSequence, error) {
+	idConfig := idgen.Config{
+		Strategy: conf.IDGenerator,
+		Length:   conf.IDLength,
+		Salt:     conf.IDSalt,
+	}
+	var ranges *idgen.RangeSequence
+	if idgen.NeedsSequence(conf.IDGenerator) {
+		if conf.IDRangeSize < 0 {
+			return nil, nil, errors.New("id range size must not be negative")
+		}
+		if store, ok := repo.(idgen.RangeStore); ok {
+			ranges = idgen.NewRangeSequence(store, uint64(conf.IDRangeSize))
+			idConfig.Sequence = ranges
+		} else {
+			stats, err := repo.GetStats(ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+			idConfig.Sequence = idgen.NewLocalSequence(uint64(stats.URLs) + 1)
+		}
+	}
+
+	logger.Log.Info("short url id generator", zap.String("strategy", conf.IDGenerator))
+	g, err := idgen.New(idConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return g, ranges, nil
+}
+
 func startServer(ctx context.Context, httpController serverHandler, grpcController serverHandler) {
 	wg := sync.WaitGroup{}
 
@@ -0,0 +1,172 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+)
+
+// Ошибки аутентификации
+var (
+	ErrNoCredentials = errors.New("token is required") // токен не передан, а новый пользователь не заводится
+	ErrInvalidToken  = errors.New("token is invalid")  // токен не прошёл проверку подлинности
+)
+
+// bearerScheme схема токена доступа в заголовке Authorization
+const bearerScheme = "Bearer"
+
+// AnonymousPolicy что делать с запросом без токена доступа; задаётся там, где регистрируется
+// маршрут HTTP или метод gRPC
+type AnonymousPolicy int
+
+const (
+	AnonymousAllow   AnonymousPolicy = iota // маршруту пользователь не нужен: токен не проверяется, запрос идёт без пользователя
+	AnonymousNewUser                        // завести нового пользователя и выдать ему токены
+	AnonymousReject                         // отклонить запрос: у нового пользователя нечего запрашивать
+)
+
+// AccessTokenManager выпускает и проверяет токены доступа
+type AccessTokenManager interface {
+	BuildSessionAccessToken(userID string, sessionID string) (string, time.Time, error)
+	ValidateAccessToken(tokenString string) (*Claims, error)
+}
+
+// SessionIssuer заводит пользователей, начинает их сеансы и проверяет, что сеанс не отозван
+type SessionIssuer interface {
+	GetNewUserID(ctx context.Context) (string, error)
+	StartSession(ctx context.Context, userID string, userAgent string) (*modelAuth.IssuedRefreshToken, error)
+	SessionActive(ctx context.Context, userID string, sessionID string) (bool, error)
+}
+
+// Authenticator проверяет и выдаёт токены одинаково для HTTP и gRPC,
+// поэтому токен, полученный по одному протоколу, принимается и другим
+type Authenticator struct {
+	tokens   AccessTokenManager
+	sessions SessionIssuer
+}
+
+// NewAuthenticator создаст аутентификатор
+func NewAuthenticator(tokens AccessTokenManager, sessions SessionIssuer) *Authenticator {
+	return &Authenticator{tokens: tokens, sessions: sessions}
+}
+
+// Authentication результат аутентификации
+type Authentication struct {
+	UserID string
+	// SessionID сеанс запроса; пусто для токенов, выданных вне сеанса
+	SessionID string
+	// Tokens токены, выданные новому пользователю; nil, если запрос пришёл с токеном доступа
+	Tokens *modelAuth.TokensResponse
+}
+
+// BearerToken достанет токен из значения "Bearer <токен>"; схема не зависит от регистра.
+// Значение без схемы считается самим токеном
+func BearerToken(value string) string {
+	value = strings.TrimSpace(value)
+	scheme, token, ok := strings.Cut(value, " ")
+	if ok && strings.EqualFold(scheme, bearerScheme) {
+		return strings.TrimSpace(token)
+	}
+	return value
+}
+
+// FormatBearer вернёт значение заголовка Authorization для токена доступа
+func FormatBearer(token string) string {
+	return fmt.Sprintf("%s %s", bearerScheme, token)
+}
+
+// Authenticate проверит токен доступа из credentials - заголовка Authorization, куки или метаданных.
+// Если токена нет, поступит по политике policy; новому пользователю сеанс начнётся на клиенте userAgent.
+// По политике AnonymousAllow токен не проверяется и запрос идёт без пользователя.
+// Для подлинного токена с истёкшим сроком вернёт ErrTokenExpired, для остальных неверных токенов
+// и токенов отозванного сеанса - ErrInvalidToken
+func (a *Authenticator) Authenticate(ctx context.Context,
+	credentials string, userAgent string, policy AnonymousPolicy) (*Authentication, error) {
+	// публичные маршруты не должны писать в хранилище и даже читать из него сеансы
+	if policy == AnonymousAllow {
+		return &Authentication{}, nil
+	}
+
+	token := BearerToken(credentials)
+	if token == "" {
+		if policy == AnonymousReject {
+			return nil, ErrNoCredentials
+		}
+		return a.newUser(ctx, userAgent)
+	}
+
+	claims, err := a.tokens.ValidateAccessToken(token)
+	if errors.Is(err, ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err)
+	}
+
+	// токены без сеанса выданы до появления сеансов и действуют до истечения
+	if claims.SessionID != "" {
+		active, err := a.sessions.SessionActive(ctx, claims.UserID, claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("check session: %w", err)
+		}
+		if !active {
+			return nil, fmt.Errorf("%w: session is revoked", ErrInvalidToken)
+		}
+	}
+
+	return &Authentication{UserID: claims.UserID, SessionID: claims.SessionID}, nil
+}
+
+// newUser заведёт нового пользователя и выдаст ему токены
+func (a *Authenticator) newUser(ctx context.Context, userAgent string) (*Authentication, error) {
+	userID, err := a.sessions.GetNewUserID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get new user ID: %w", err)
+	}
+	tokens, sessionID, err := a.issueTokens(ctx, userID, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Authentication{UserID: userID, SessionID: sessionID, Tokens: tokens}, nil
+}
+
+// IssueTokens начнёт сеанс пользователя на клиенте userAgent и выдаст в нём токен доступа и токен обновления
+func (a *Authenticator) IssueTokens(ctx context.Context,
+	userID string, userAgent string) (*modelAuth.TokensResponse, error) {
+	tokens, _, err := a.issueTokens(ctx, userID, userAgent)
+	return tokens, err
+}
+
+func (a *Authenticator) issueTokens(ctx context.Context,
+	userID string, userAgent string) (*modelAuth.TokensResponse, string, error) {
+	refreshToken, err := a.sessions.StartSession(ctx, userID, userAgent)
+	if err != nil {
+		return nil, "", fmt.Errorf("start session: %w", err)
+	}
+	tokens, err := a.BuildTokens(refreshToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return tokens, refreshToken.SessionID, nil
+}
+
+// BuildTokens выпустит токен доступа к уже выданному токену обновления в том же сеансе
+func (a *Authenticator) BuildTokens(refreshToken *modelAuth.IssuedRefreshToken) (*modelAuth.TokensResponse, error) {
+	accessToken, accessExpiresAt, err := a.tokens.BuildSessionAccessToken(refreshToken.UserID, refreshToken.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("build access token: %w", err)
+	}
+
+	return &modelAuth.TokensResponse{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          refreshToken.Token,
+		RefreshTokenExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubSessions struct {
+	started []string
+	revoked map[string]bool
+}
+
+func (u *stubSessions) GetNewUserID(ctx context.Context) (string, error) {
+	return "new-user", nil
+}
+
+func (u *stubSessions) StartSession(ctx context.Context,
+	userID string, userAgent string) (*modelAuth.IssuedRefreshToken, error) {
+	u.started = append(u.started, userAgent)
+	return &modelAuth.IssuedRefreshToken{
+		Token:     "refresh-" + userID,
+		UserID:    userID,
+		SessionID: "session-" + userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}, nil
+}
+
+func (u *stubSessions) SessionActive(ctx context.Context, userID string, sessionID string) (bool, error) {
+	return !u.revoked[sessionID], nil
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := map[string]string{
+		"Bearer token":   "token",
+		"bearer token":   "token",
+		"BEARER  token ": "token",
+		"token":          "token",
+		" token ":        "token",
+		"":               "",
+		"Basic token":    "Basic token",
+	}
+	for value, want := range tests {
+		assert.Equal(t, want, BearerToken(value), value)
+	}
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	tokens, err := NewRandomJWTManager()
+	require.NoError(t, err)
+	sessions := &stubSessions{revoked: make(map[string]bool)}
+	a := NewAuthenticator(tokens, sessions)
+
+	// новый пользователь получает токены, которые затем принимаются в любом виде
+	auth, err := a.Authenticate(ctx, "", "curl/8.0", AnonymousNewUser)
+	require.NoError(t, err)
+	assert.Equal(t, "new-user", auth.UserID)
+	assert.Equal(t, "session-new-user", auth.SessionID)
+	require.NotNil(t, auth.Tokens)
+	assert.Equal(t, "refresh-new-user", auth.Tokens.RefreshToken)
+	assert.Equal(t, []string{"curl/8.0"}, sessions.started)
+
+	for _, credentials := range []string{auth.Tokens.AccessToken, FormatBearer(auth.Tokens.AccessToken)} {
+		got, err := a.Authenticate(ctx, credentials, "", AnonymousReject)
+		require.NoError(t, err)
+		assert.Equal(t, "new-user", got.UserID)
+		assert.Equal(t, "session-new-user", got.SessionID)
+		assert.Nil(t, got.Tokens)
+	}
+
+	_, err = a.Authenticate(ctx, "", "", AnonymousReject)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	_, err = a.Authenticate(ctx, "Bearer wrong", "", AnonymousNewUser)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// токен отозванного сеанса не принимается, а токен вне сеанса принимается
+	sessions.revoked["session-new-user"] = true
+	_, err = a.Authenticate(ctx, auth.Tokens.AccessToken, "", AnonymousReject)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	legacy, _, err := tokens.BuildAccessToken("user")
+	require.NoError(t, err)
+	got, err := a.Authenticate(ctx, legacy, "", AnonymousReject)
+	require.NoError(t, err)
+	assert.Equal(t, "user", got.UserID)
+	assert.Empty(t, got.SessionID)
+
+	tokens.SetAccessTokenTTL(-time.Minute)
+	expired, _, err := tokens.BuildAccessToken("user")
+	require.NoError(t, err)
+	_, err = a.Authenticate(ctx, expired, "", AnonymousNewUser)
+	assert.True(t, errors.Is(err, ErrTokenExpired))
+	assert.False(t, errors.Is(err, ErrInvalidToken))
+}
+
+func TestAuthenticator_Authenticate_Allow(t *testing.T) {
+	ctx := context.Background()
+	tokens, err := NewRandomJWTManager()
+	require.NoError(t, err)
+	sessions := &stubSessions{revoked: make(map[string]bool)}
+	a := NewAuthenticator(tokens, sessions)
+
+	// публичному маршруту ни новый пользователь, ни проверка токена не нужны
+	for _, credentials := range []string{"", "Bearer wrong"} {
+		auth, err := a.Authenticate(ctx, credentials, "curl/8.0", AnonymousAllow)
+		require.NoError(t, err)
+		assert.Empty(t, auth.UserID)
+		assert.Nil(t, auth.Tokens)
+	}
+	assert.Empty(t, sessions.started)
+}
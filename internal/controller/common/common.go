@@ -1,54 +1,207 @@
 package common
 
 import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-const SecretKey = "supersecretkey"
+// DefaultAccessTokenTTL время жизни токена доступа по умолчанию
+const DefaultAccessTokenTTL = 15 * time.Minute
+
+// ErrTokenExpired подпись токена верна, но срок его действия истёк; токен можно обновить
+var ErrTokenExpired = errors.New("token is expired")
 
 // Claims — структура утверждений, которая включает стандартные утверждения
-// и одно пользовательское — UserID
+// и пользовательские — UserID и ID сеанса
 type Claims struct {
 	jwt.RegisteredClaims
 	UserID string
+	// SessionID сеанс, в котором выдан токен; пусто у токенов, выданных вне сеанса
+	SessionID string `json:"sid,omitempty"`
+}
+
+// JWTKey ключ подписи токенов; токен ссылается на ключ заголовком kid
+type JWTKey struct {
+	ID     string            // идентификатор ключа, заголовок kid
+	Method jwt.SigningMethod // алгоритм подписи: HS256, HS384, HS512 или EdDSA
+	// SignKey ключ подписи: []byte для HMAC, ed25519.PrivateKey для EdDSA;
+	// nil, если ключ только проверяет подпись
+	SignKey interface{}
+	// VerifyKey ключ проверки подписи: []byte для HMAC, ed25519.PublicKey для EdDSA
+	VerifyKey interface{}
+}
+
+// JWTManager выпускает токены активным ключом и проверяет их всеми принятыми ключами,
+// поэтому ключи можно менять, не разлогинивая пользователей
+type JWTManager struct {
+	active    *JWTKey
+	keys      map[string]*JWTKey
+	accessTTL time.Duration
+	now       func() time.Time
+}
+
+// NewJWTManager создаст менеджер токенов; токены подписываются ключом activeKID,
+// а проверяются любым из keys
+func NewJWTManager(activeKID string, keys ...JWTKey) (*JWTManager, error) {
+	m := &JWTManager{
+		keys:      make(map[string]*JWTKey, len(keys)),
+		accessTTL: DefaultAccessTokenTTL,
+		now:       time.Now,
+	}
+	for i := range keys {
+		key := &keys[i]
+		if key.ID == "" {
+			return nil, errors.New("jwt key id is empty")
+		}
+		if _, ok := m.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate jwt key id %q", key.ID)
+		}
+		if err := validateJWTKey(key); err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", key.ID, err)
+		}
+		m.keys[key.ID] = key
+	}
+
+	active, ok := m.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active jwt key %q not found", activeKID)
+	}
+	if active.SignKey == nil {
+		return nil, fmt.Errorf("active jwt key %q has no signing key", activeKID)
+	}
+	m.active = active
+
+	return m, nil
+}
+
+// NewRandomJWTManager создаст менеджер со случайным HMAC ключом; токены перестанут
+// проходить проверку после перезапуска и не принимаются другими экземплярами приложения
+func NewRandomJWTManager() (*JWTManager, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return NewJWTManager("random", JWTKey{
+		ID:        "random",
+		Method:    jwt.SigningMethodHS256,
+		SignKey:   secret,
+		VerifyKey: secret,
+	})
+}
+
+// SetAccessTokenTTL задаст время жизни выпускаемых токенов
+func (m *JWTManager) SetAccessTokenTTL(ttl time.Duration) {
+	m.accessTTL = ttl
 }
 
-// BuildJWTString создаёт токен и возвращает его в виде строки.
-func BuildJWTString(userID string) (string, error) {
-	// создаём новый токен с алгоритмом подписи HS256 и утверждениями — Claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{
-		UserID: userID,
+// validateJWTKey проверит, что ключи подходят алгоритму подписи
+func validateJWTKey(key *JWTKey) error {
+	switch key.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret, ok := key.VerifyKey.([]byte)
+		if !ok || len(secret) < minHMACSecretSize {
+			return fmt.Errorf("hmac secret must be at least %d bytes", minHMACSecretSize)
+		}
+		if key.SignKey != nil {
+			if _, ok := key.SignKey.([]byte); !ok {
+				return errors.New("hmac signing key must be []byte")
+			}
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := key.VerifyKey.(ed25519.PublicKey); !ok {
+			return errors.New("ed25519 verification key must be ed25519.PublicKey")
+		}
+		if key.SignKey != nil {
+			if _, ok := key.SignKey.(ed25519.PrivateKey); !ok {
+				return errors.New("ed25519 signing key must be ed25519.PrivateKey")
+			}
+		}
+	default:
+		return errors.New("unsupported signing method")
+	}
+
+	return nil
+}
+
+// BuildJWTString создаёт токен, подписанный активным ключом, и возвращает его в виде строки.
+func (m *JWTManager) BuildJWTString(userID string) (string, error) {
+	token, _, err := m.BuildAccessToken(userID)
+	return token, err
+}
+
+// BuildAccessToken создаёт токен, подписанный активным ключом, и возвращает его вместе со временем истечения
+func (m *JWTManager) BuildAccessToken(userID string) (string, time.Time, error) {
+	return m.BuildSessionAccessToken(userID, "")
+}
+
+// BuildSessionAccessToken создаёт токен сеанса sessionID; токен перестаёт приниматься, когда сеанс отозван
+func (m *JWTManager) BuildSessionAccessToken(userID string, sessionID string) (string, time.Time, error) {
+	now := m.now()
+	expiresAt := now.Add(m.accessTTL)
+	token := jwt.NewWithClaims(m.active.Method, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		UserID:    userID,
+		SessionID: sessionID,
 	})
+	token.Header["kid"] = m.active.ID
 
-	// создаём строку токена
-	tokenString, err := token.SignedString([]byte(SecretKey))
+	tokenString, err := token.SignedString(m.active.SignKey)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return tokenString, expiresAt, nil
+}
+
+// ValidateAndGetUserID валидирует токен ключом из его заголовка kid и получает из него UserID;
+// для подлинного токена с истёкшим сроком действия вернёт ErrTokenExpired
+func (m *JWTManager) ValidateAndGetUserID(tokenString string) (string, error) {
+	claims, err := m.ValidateAccessToken(tokenString)
 	if err != nil {
 		return "", err
 	}
 
-	// возвращаем строку токена
-	return tokenString, nil
+	return claims.UserID, nil
 }
 
-// ValidateAndGetUserID валидирует токен и получает из него UserID
-func ValidateAndGetUserID(tokenString string) (string, error) {
+// ValidateAccessToken валидирует токен ключом из его заголовка kid и вернёт его утверждения;
+// для подлинного токена с истёкшим сроком действия вернёт ErrTokenExpired
+func (m *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims,
 		func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			kid, _ := t.Header["kid"].(string)
+			key, ok := m.keys[kid]
+			if !ok {
+				return nil, fmt.Errorf("unknown key id: %q", kid)
+			}
+			// алгоритм берётся из ключа, а не из заголовка токена
+			if t.Method.Alg() != key.Method.Alg() {
 				return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
 			}
-			return []byte(SecretKey), nil
-		})
+			return key.VerifyKey, nil
+		},
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(m.now))
+	if errors.Is(err, jwt.ErrTokenExpired) {
+		return nil, ErrTokenExpired
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if !token.Valid {
-		return "", fmt.Errorf("token is not valid")
+		return nil, fmt.Errorf("token is not valid")
 	}
 
-	return claims.UserID, nil
+	return claims, nil
 }
@@ -0,0 +1,209 @@
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func hmacKey(t *testing.T, kid string) JWTKey {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+	return JWTKey{ID: kid, Method: jwt.SigningMethodHS256, SignKey: secret, VerifyKey: secret}
+}
+
+func ed25519Key(t *testing.T, kid string) JWTKey {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return JWTKey{ID: kid, Method: jwt.SigningMethodEdDSA, SignKey: private, VerifyKey: public}
+}
+
+func TestJWTManager_RoundTrip(t *testing.T) {
+	for name, key := range map[string]JWTKey{
+		"hmac":    hmacKey(t, "k1"),
+		"ed25519": ed25519Key(t, "k1"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			m, err := NewJWTManager("k1", key)
+			require.NoError(t, err)
+
+			token, err := m.BuildJWTString("user")
+			require.NoError(t, err)
+			userID, err := m.ValidateAndGetUserID(token)
+			require.NoError(t, err)
+			require.Equal(t, "user", userID)
+		})
+	}
+}
+
+func TestJWTManager_Rotation(t *testing.T) {
+	oldKey, newKey := hmacKey(t, "old"), ed25519Key(t, "new")
+
+	before, err := NewJWTManager("old", oldKey)
+	require.NoError(t, err)
+	oldToken, err := before.BuildJWTString("user")
+	require.NoError(t, err)
+
+	// новый ключ подписывает, старый ещё принимается
+	after, err := NewJWTManager("new", oldKey, newKey)
+	require.NoError(t, err)
+	userID, err := after.ValidateAndGetUserID(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "user", userID)
+
+	newToken, err := after.BuildJWTString("user")
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &Claims{})
+	require.NoError(t, err)
+	require.Equal(t, "new", parsed.Header["kid"])
+
+	// старый ключ выведен из оборота
+	retired, err := NewJWTManager("new", newKey)
+	require.NoError(t, err)
+	_, err = retired.ValidateAndGetUserID(oldToken)
+	require.Error(t, err)
+}
+
+func TestJWTManager_ValidateErrors(t *testing.T) {
+	hmac, ed := hmacKey(t, "hmac"), ed25519Key(t, "ed")
+	m, err := NewJWTManager("hmac", hmac, ed)
+	require.NoError(t, err)
+
+	sign := func(method jwt.SigningMethod, kid interface{}, key interface{}) string {
+		token := jwt.NewWithClaims(method, Claims{
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+			UserID:           "user",
+		})
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		s, err := token.SignedString(key)
+		require.NoError(t, err)
+		return s
+	}
+
+	tests := map[string]string{
+		// прежние токены без kid, подписанные общеизвестным секретом
+		"no kid":      sign(jwt.SigningMethodHS256, nil, []byte("supersecretkey")),
+		"unknown kid": sign(jwt.SigningMethodHS256, "unknown", hmac.SignKey),
+		"wrong key":   sign(jwt.SigningMethodHS256, "hmac", []byte("0123456789abcdef0123456789abcdef")),
+		// открытый ключ Ed25519 в роли секрета HMAC
+		"alg confusion": sign(jwt.SigningMethodHS256, "ed", []byte(ed.VerifyKey.(ed25519.PublicKey))),
+		"malformed":     "token",
+		"no exp": func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, Claims{UserID: "user"})
+			token.Header["kid"] = "hmac"
+			s, err := token.SignedString(hmac.SignKey)
+			require.NoError(t, err)
+			return s
+		}(),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := m.ValidateAndGetUserID(token)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestJWTManager_Expiry(t *testing.T) {
+	key := hmacKey(t, "k1")
+	m, err := NewJWTManager("k1", key)
+	require.NoError(t, err)
+	now := time.Date(2024, 11, 1, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+	m.SetAccessTokenTTL(time.Minute)
+
+	token, expiresAt, err := m.BuildAccessToken("user")
+	require.NoError(t, err)
+	require.Equal(t, now.Add(time.Minute), expiresAt)
+
+	now = now.Add(30 * time.Second)
+	_, err = m.ValidateAndGetUserID(token)
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	_, err = m.ValidateAndGetUserID(token)
+	require.ErrorIs(t, err, ErrTokenExpired)
+
+	// подпись проверяется раньше срока действия
+	other, err := NewJWTManager("k1", hmacKey(t, "k1"))
+	require.NoError(t, err)
+	other.now = m.now
+	_, err = other.ValidateAndGetUserID(token)
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestNewJWTManager_Errors(t *testing.T) {
+	ed := ed25519Key(t, "ed")
+	verifyOnly := JWTKey{ID: "verify", Method: jwt.SigningMethodEdDSA, VerifyKey: ed.VerifyKey}
+	short := JWTKey{ID: "short", Method: jwt.SigningMethodHS256, SignKey: []byte("short"), VerifyKey: []byte("short")}
+
+	_, err := NewJWTManager("missing", ed)
+	require.Error(t, err)
+	_, err = NewJWTManager("verify", verifyOnly)
+	require.Error(t, err)
+	_, err = NewJWTManager("ed", ed, ed)
+	require.Error(t, err)
+	_, err = NewJWTManager("short", short)
+	require.Error(t, err)
+
+	_, err = NewJWTManager("ed", ed, verifyOnly)
+	require.NoError(t, err)
+}
+
+func TestParseJWTKeySet(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+	privatePEM := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	publicPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	secret := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	data, err := json.Marshal(jwtKeySetJSON{
+		ActiveKID: "ed",
+		Keys: []jwtKeyJSON{
+			{KID: "ed", Alg: "EdDSA", PrivateKey: privatePEM},
+			{KID: "ed-verify", Alg: "EdDSA", PublicKey: publicPEM},
+			{KID: "hmac", Alg: "HS512", Secret: secret},
+		},
+	})
+	require.NoError(t, err)
+
+	m, err := ParseJWTKeySet(data)
+	require.NoError(t, err)
+	require.Len(t, m.keys, 3)
+	require.Equal(t, jwt.SigningMethodHS512, m.keys["hmac"].Method)
+
+	token, err := m.BuildJWTString("user")
+	require.NoError(t, err)
+	userID, err := m.ValidateAndGetUserID(token)
+	require.NoError(t, err)
+	require.Equal(t, "user", userID)
+
+	for name, data := range map[string]string{
+		"invalid json":    `{`,
+		"empty":           `{"active_kid": "k", "keys": []}`,
+		"unsupported alg": `{"active_kid": "k", "keys": [{"kid": "k", "alg": "none"}]}`,
+		"bad secret":      `{"active_kid": "k", "keys": [{"kid": "k", "alg": "HS256", "secret": "!"}]}`,
+		"bad pem":         `{"active_kid": "k", "keys": [{"kid": "k", "alg": "EdDSA", "private_key": "key"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseJWTKeySet([]byte(data))
+			require.Error(t, err)
+		})
+	}
+}
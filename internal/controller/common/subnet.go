@@ -0,0 +1,25 @@
+package common
+
+import (
+	"fmt"
+	"net/netip"
+)
+
+// IsTrustedIP проверяет, входит ли IP-адрес в доверенную подсеть;
+// если подсеть или адрес не заданы, доступ запрещается
+func IsTrustedIP(trustedSubnet string, ipStr string) (bool, error) {
+	if trustedSubnet == "" || ipStr == "" {
+		return false, nil
+	}
+
+	network, err := netip.ParsePrefix(trustedSubnet)
+	if err != nil {
+		return false, fmt.Errorf("can not parse trusted subnet: %w", err)
+	}
+	ip, err := netip.ParseAddr(ipStr)
+	if err != nil {
+		return false, fmt.Errorf("can not parse ip: %w", err)
+	}
+
+	return network.Contains(ip), nil
+}
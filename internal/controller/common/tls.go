@@ -0,0 +1,71 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ServerTLSConfig соберёт настройки TLS сервера с сертификатом certFile и ключом keyFile.
+// Клиентские сертификаты проверяются по удостоверяющим центрам из clientCAFile; клиент без
+// сертификата всё равно подключится, но внутренние ручки ему будут недоступны
+func ServerTLSConfig(certFile string, keyFile string, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("can not load server certificate: %w", err)
+	}
+
+	data, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("can not read client ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("client ca has no certificates")
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    pool,
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+	}, nil
+}
+
+// IsTrustedClient проверяет, что клиент предъявил проверенный сертификат, а CN его субъекта
+// или один из SAN входит в trustedClients; если список пуст, подходит любой проверенный сертификат
+func IsTrustedClient(trustedClients []string, state *tls.ConnectionState) bool {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return false
+	}
+	if len(trustedClients) == 0 {
+		return true
+	}
+
+	for _, name := range certNames(state.VerifiedChains[0][0]) {
+		if slices.Contains(trustedClients, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// certNames вернёт CN субъекта и все SAN сертификата
+func certNames(cert *x509.Certificate) []string {
+	names := make([]string, 0, 1+len(cert.DNSNames)+len(cert.EmailAddresses)+len(cert.IPAddresses)+len(cert.URIs))
+	if cert.Subject.CommonName != "" {
+		names = append(names, cert.Subject.CommonName)
+	}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
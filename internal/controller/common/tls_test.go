@@ -0,0 +1,179 @@
+package common
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCA удостоверяющий центр для выпуска тестовых сертификатов
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key}
+}
+
+// issue выпустит сертификат, заполненный в tmpl
+func (ca *testCA) issue(t *testing.T, tmpl *x509.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func writePEM(t *testing.T, path string, blockType string, der []byte) {
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600))
+}
+
+// handshake соединит клиента с сервером и вернёт состояние соединения на стороне сервера
+func handshake(t *testing.T, serverConf *tls.Config, clientConf *tls.Config) (*tls.ConnectionState, error) {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConf)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := tls.Dial("tcp", listener.Addr().String(), clientConf)
+		if err == nil {
+			_, _ = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+	}()
+
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	server := conn.(*tls.Conn)
+	if err := server.Handshake(); err != nil {
+		return nil, err
+	}
+	state := server.ConnectionState()
+	return &state, nil
+}
+
+func TestServerTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	serverCA := newTestCA(t)
+	clientCA := newTestCA(t)
+	otherCA := newTestCA(t)
+
+	serverCert := serverCA.issue(t, &x509.Certificate{
+		DNSNames:    []string{"localhost"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, certFile, "CERTIFICATE", serverCert.Certificate[0])
+	keyDER, err := x509.MarshalPKCS8PrivateKey(serverCert.PrivateKey)
+	require.NoError(t, err)
+	writePEM(t, keyFile, "PRIVATE KEY", keyDER)
+	writePEM(t, caFile, "CERTIFICATE", clientCA.cert.Raw)
+
+	conf, err := ServerTLSConfig(certFile, keyFile, caFile)
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(serverCA.cert)
+	clientConf := func(certs ...tls.Certificate) *tls.Config {
+		return &tls.Config{RootCAs: roots, ServerName: "localhost", Certificates: certs}
+	}
+	clientAuth := []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	t.Run("Client certificate", func(t *testing.T) {
+		cert := clientCA.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}, ExtKeyUsage: clientAuth})
+		state, err := handshake(t, conf, clientConf(cert))
+		require.NoError(t, err)
+		assert.True(t, IsTrustedClient([]string{"ops"}, state))
+		assert.False(t, IsTrustedClient([]string{"backup"}, state))
+	})
+
+	t.Run("Without client certificate", func(t *testing.T) {
+		state, err := handshake(t, conf, clientConf())
+		require.NoError(t, err)
+		assert.False(t, IsTrustedClient(nil, state))
+	})
+
+	t.Run("Unknown client ca", func(t *testing.T) {
+		cert := otherCA.issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}, ExtKeyUsage: clientAuth})
+		_, err := handshake(t, conf, clientConf(cert))
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid client ca file", func(t *testing.T) {
+		_, err := ServerTLSConfig(certFile, keyFile, keyFile)
+		assert.Error(t, err)
+	})
+}
+
+func TestIsTrustedClient(t *testing.T) {
+	uri, err := url.Parse("spiffe://example.com/backup")
+	require.NoError(t, err)
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "ops"},
+		DNSNames:       []string{"stats.internal"},
+		EmailAddresses: []string{"admin@example.com"},
+		IPAddresses:    []net.IP{net.IPv4(10, 0, 0, 1)},
+		URIs:           []*url.URL{uri},
+	}
+	state := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+
+	tests := []struct {
+		name    string
+		trusted []string
+		state   *tls.ConnectionState
+		want    bool
+	}{
+		{name: "Any verified certificate", state: state, want: true},
+		{name: "Common name", trusted: []string{"ops"}, state: state, want: true},
+		{name: "DNS name", trusted: []string{"stats.internal"}, state: state, want: true},
+		{name: "Email", trusted: []string{"admin@example.com"}, state: state, want: true},
+		{name: "IP", trusted: []string{"10.0.0.1"}, state: state, want: true},
+		{name: "URI", trusted: []string{"spiffe://example.com/backup"}, state: state, want: true},
+		{name: "Untrusted", trusted: []string{"web"}, state: state, want: false},
+		{name: "Not verified", state: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}}, want: false},
+		{name: "Without TLS", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTrustedClient(tt.trusted, tt.state))
+		})
+	}
+}
@@ -5,8 +5,21 @@ import (
 	"fmt"
 
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"google.golang.org/grpc/metadata"
 )
 
+// getOwnerFromContext вернёт владельца ссылок вызова: рабочее пространство
+// из метаданных X-Workspace-ID или, если их нет, текущего пользователя
+func (c *grpcController) getOwnerFromContext(ctx context.Context) (model.Owner, error) {
+	if workspaceID, ok := ctx.Value(keyWorkspaceID).(string); ok {
+		return model.Owner{WorkspaceID: workspaceID}, nil
+	}
+
+	userID, err := c.getUserIDFromContext(ctx)
+	return model.Owner{UserID: userID}, err
+}
+
 func (c *grpcController) getUserIDFromContext(ctx context.Context) (string, error) {
 	ctxUserID := ctx.Value(keyUserID)
 	userID, ok := ctxUserID.(string)
@@ -18,3 +31,15 @@ func (c *grpcController) getUserIDFromContext(ctx context.Context) (string, erro
 
 	return userID, nil
 }
+
+// getSessionIDFromContext вернёт ID сеанса вызова; пусто, если токен выдан вне сеанса
+func getSessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(keySessionID).(string)
+	return sessionID
+}
+
+// getUserAgentFromContext вернёт клиента вызова из метаданных User-Agent
+func getUserAgentFromContext(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	return getUserAgent(md)
+}
@@ -2,6 +2,7 @@ package grpcserver
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"os"
@@ -10,20 +11,32 @@ import (
 	"syscall"
 
 	"github.com/KartoonYoko/go-url-shortener/config"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
 	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 type grpcController struct {
-	uc      UseCaseShortener
-	ucPing  UseCasePinger
-	ucAuth  useCaseAuther
-	ucStats UseCaseStats
+	uc       UseCaseShortener
+	ucPing   UseCasePinger
+	ucAuth   useCaseAuther
+	ucStats  UseCaseStats
+	ucBackup UseCaseBackup
+	ucWS     useCaseWorkspace
+	tokens   tokenManager
+	auth     *common.Authenticator
+	tls      *tls.Config
 
 	pb.PingServiceServer
 	pb.StatsServiceServer
 	pb.ShortenerServiceServer
+	pb.BackupServiceServer
+	pb.AuthServiceServer
+	pb.APIKeyServiceServer
+	pb.AdminServiceServer
+	pb.WorkspaceServiceServer
 
 	conf *config.Config
 }
@@ -33,17 +46,29 @@ func NewGRPCController(
 	uc UseCaseShortener,
 	ucPing UseCasePinger,
 	ucAuth useCaseAuther,
-	ucStats UseCaseStats) *grpcController {
+	ucStats UseCaseStats,
+	ucBackup UseCaseBackup,
+	ucWS useCaseWorkspace,
+	tokens tokenManager) *grpcController {
 	c := new(grpcController)
 	c.conf = conf
 	c.uc = uc
 	c.ucAuth = ucAuth
 	c.ucPing = ucPing
 	c.ucStats = ucStats
+	c.ucBackup = ucBackup
+	c.ucWS = ucWS
+	c.tokens = tokens
+	c.auth = common.NewAuthenticator(tokens, ucAuth)
 
 	return c
 }
 
+// SetTLSConfig включит mTLS: сервер будет принимать соединения только по TLS с настройками conf
+func (c *grpcController) SetTLSConfig(conf *tls.Config) {
+	c.tls = conf
+}
+
 func (c *grpcController) Serve(ctx context.Context) error {
 	_, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -53,13 +78,27 @@ func (c *grpcController) Serve(ctx context.Context) error {
 		return fmt.Errorf("failed to start grpc server: %w", err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
-		c.interceptorRequestTime,
-		c.interceptorAuth,
-	))
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			c.interceptorRequestTime,
+			c.interceptorAuth,
+		),
+		grpc.ChainStreamInterceptor(
+			c.streamInterceptorAuth,
+		),
+	}
+	if c.tls != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(c.tls)))
+	}
+	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterPingServiceServer(grpcServer, c)
 	pb.RegisterStatsServiceServer(grpcServer, c)
 	pb.RegisterShortenerServiceServer(grpcServer, c)
+	pb.RegisterBackupServiceServer(grpcServer, c)
+	pb.RegisterAuthServiceServer(grpcServer, c)
+	pb.RegisterAPIKeyServiceServer(grpcServer, c)
+	pb.RegisterAdminServiceServer(grpcServer, c)
+	pb.RegisterWorkspaceServiceServer(grpcServer, c)
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
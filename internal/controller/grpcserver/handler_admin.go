@@ -0,0 +1,80 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	usecaseShortener "github.com/KartoonYoko/go-url-shortener/internal/usecase/shortener"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListUsers вернёт страницу пользователей в порядке возрастания ID
+func (c *grpcController) ListUsers(ctx context.Context, r *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	if r.Limit < 0 {
+		return nil, status.Error(codes.InvalidArgument, "limit must not be negative")
+	}
+
+	users, err := c.ucAuth.ListUsers(ctx, r.AfterId, int(r.Limit))
+	if err != nil {
+		logger.Log.Error("list users error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	res := new(pb.ListUsersResponse)
+	res.Users = make([]*pb.User, 0, len(users))
+	for i := range users {
+		res.Users = append(res.Users, newUserResponse(&users[i]))
+	}
+
+	return res, nil
+}
+
+// UpdateUser изменит роль или состояние пользователя; не переданные поля не меняются
+func (c *grpcController) UpdateUser(ctx context.Context, r *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	user, err := c.ucAuth.UpdateUser(ctx, r.Id, modelAuth.UpdateUserRequest{
+		Role:     r.Role,
+		Disabled: r.Disabled,
+	})
+	if errors.Is(err, usecaseAuth.ErrInvalidRole) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, usecaseAuth.ErrUserNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("update user error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	res := new(pb.UpdateUserResponse)
+	res.User = newUserResponse(user)
+
+	return res, nil
+}
+
+// TakeDownURL удалит URL у всех его владельцев
+func (c *grpcController) TakeDownURL(ctx context.Context, r *pb.TakeDownURLRequest) (*pb.TakeDownURLResponse, error) {
+	err := c.uc.TakeDownURL(ctx, r.Id)
+	if errors.Is(err, usecaseShortener.ErrURLNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	return new(pb.TakeDownURLResponse), nil
+}
+
+func newUserResponse(user *modelAuth.User) *pb.User {
+	return &pb.User{
+		Id:       user.ID,
+		Role:     user.Role,
+		Disabled: user.Disabled,
+	}
+}
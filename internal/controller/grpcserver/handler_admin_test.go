@@ -0,0 +1,168 @@
+package grpcserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/mocks"
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseShortener "github.com/KartoonYoko/go-url-shortener/internal/usecase/shortener"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// adminContext заведёт администратора и вернёт контекст с его токеном доступа
+func adminContext(t *testing.T) context.Context {
+	ctx := context.Background()
+	userID, err := controller.ucAuth.GetNewUserID(ctx)
+	require.NoError(t, err)
+	role := modelAuth.RoleAdmin
+	_, err = controller.ucAuth.UpdateUser(ctx, userID, modelAuth.UpdateUserRequest{Role: &role})
+	require.NoError(t, err)
+
+	return userContext(t, userID)
+}
+
+// userContext вернёт контекст с токеном доступа пользователя
+func userContext(t *testing.T, userID string) context.Context {
+	jwt, _, err := controller.tokens.BuildAccessToken(userID)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), metadataAuthorization, fmt.Sprintf("Bearer %s", jwt))
+}
+
+func Test_grpcController_Admin(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockUseCaseShortener(ctrl)
+	m.EXPECT().TakeDownURL(gomock.Any(), "existing").Return(nil)
+	m.EXPECT().TakeDownURL(gomock.Any(), "unknown").Return(usecaseShortener.ErrURLNotFound)
+	controller.uc = m
+
+	c := pb.NewAdminServiceClient(conn)
+	adminCtx := adminContext(t)
+	userID, err := controller.ucAuth.GetNewUserID(context.Background())
+	require.NoError(t, err)
+	userCtx := userContext(t, userID)
+
+	// обычному пользователю методы администратора недоступны
+	_, err = c.ListUsers(userCtx, new(pb.ListUsersRequest))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	list, err := c.ListUsers(adminCtx, &pb.ListUsersRequest{AfterId: userID, Limit: 1})
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(list.Users), 1)
+	list, err = c.ListUsers(adminCtx, new(pb.ListUsersRequest))
+	require.NoError(t, err)
+	var found bool
+	for _, u := range list.Users {
+		if u.Id == userID {
+			found = true
+			require.Equal(t, modelAuth.RoleUser, u.Role)
+		}
+	}
+	require.True(t, found)
+
+	// отключённому пользователю недоступны никакие методы
+	disabled := true
+	updated, err := c.UpdateUser(adminCtx, &pb.UpdateUserRequest{Id: userID, Disabled: &disabled})
+	require.NoError(t, err)
+	require.True(t, updated.User.Disabled)
+	require.Equal(t, modelAuth.RoleUser, updated.User.Role)
+	_, err = pb.NewAPIKeyServiceClient(conn).ListAPIKeys(userCtx, new(pb.ListAPIKeysRequest))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	role := "root"
+	_, err = c.UpdateUser(adminCtx, &pb.UpdateUserRequest{Id: userID, Role: &role})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = c.UpdateUser(adminCtx, &pb.UpdateUserRequest{Id: "unknown", Disabled: &disabled})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = c.TakeDownURL(adminCtx, &pb.TakeDownURLRequest{Id: "existing"})
+	require.NoError(t, err)
+	_, err = c.TakeDownURL(adminCtx, &pb.TakeDownURLRequest{Id: "unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_grpcController_streamInterceptorAuth(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := pb.NewBackupServiceClient(conn)
+	userID, err := controller.ucAuth.GetNewUserID(context.Background())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		statusErrorCode codes.Code
+	}{
+		{name: "Without token", ctx: context.Background(), statusErrorCode: codes.Unauthenticated},
+		{name: "Not admin", ctx: userContext(t, userID), statusErrorCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := c.Backup(tt.ctx, new(pb.BackupRequest))
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			require.Equal(t, tt.statusErrorCode, status.Code(err))
+		})
+	}
+}
+
+func Test_grpcController_checkTrustedClient(t *testing.T) {
+	controller.conf.TLSClientCAFile = "ca.pem"
+	controller.conf.TrustedClients = []string{"ops"}
+	defer func() {
+		controller.conf.TLSClientCAFile = ""
+		controller.conf.TrustedClients = nil
+	}()
+
+	t.Run("Without TLS", func(t *testing.T) {
+		conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		_, err = pb.NewAdminServiceClient(conn).ListUsers(adminContext(t), new(pb.ListUsersRequest))
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	peerContext := func(state tls.ConnectionState) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+	}
+	clientState := func(commonName string) tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		statusErrorCode codes.Code
+	}{
+		{name: "Trusted client", ctx: peerContext(clientState("ops"))},
+		{name: "Untrusted client", ctx: peerContext(clientState("web")), statusErrorCode: codes.PermissionDenied},
+		{name: "Without certificate", ctx: peerContext(tls.ConnectionState{}), statusErrorCode: codes.PermissionDenied},
+		{name: "Without peer", ctx: context.Background(), statusErrorCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := controller.checkTrustedClient(tt.ctx)
+			require.Equal(t, tt.statusErrorCode, status.Code(err))
+		})
+	}
+}
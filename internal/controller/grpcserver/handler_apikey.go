@@ -0,0 +1,91 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateAPIKey создаст API-ключ пользователя; сам ключ возвращается только в этом ответе
+func (c *grpcController) CreateAPIKey(ctx context.Context, r *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	apiKey, key, err := c.ucAuth.CreateAPIKey(ctx, userID, r.Name, r.Scopes)
+	if errors.Is(err, usecaseAuth.ErrInvalidScopes) || errors.Is(err, usecaseAuth.ErrInvalidAPIKeyName) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("create api key error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	res := new(pb.CreateAPIKeyResponse)
+	res.ApiKey = newAPIKeyResponse(key)
+	res.Key = apiKey
+
+	return res, nil
+}
+
+// ListAPIKeys вернёт API-ключи пользователя без секретной части
+func (c *grpcController) ListAPIKeys(ctx context.Context, r *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	keys, err := c.ucAuth.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		logger.Log.Error("get api keys error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	res := new(pb.ListAPIKeysResponse)
+	res.ApiKeys = make([]*pb.APIKey, 0, len(keys))
+	for i := range keys {
+		res.ApiKeys = append(res.ApiKeys, newAPIKeyResponse(&keys[i]))
+	}
+
+	return res, nil
+}
+
+// RevokeAPIKey отзовёт API-ключ пользователя
+func (c *grpcController) RevokeAPIKey(ctx context.Context, r *pb.RevokeAPIKeyRequest) (*pb.RevokeAPIKeyResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	err = c.ucAuth.RevokeAPIKey(ctx, userID, r.Id)
+	if errors.Is(err, usecaseAuth.ErrAPIKeyNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("revoke api key error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	return new(pb.RevokeAPIKeyResponse), nil
+}
+
+func newAPIKeyResponse(key *modelAuth.APIKey) *pb.APIKey {
+	res := new(pb.APIKey)
+	res.Id = key.ID
+	res.Name = key.Name
+	res.Scopes = key.Scopes
+	res.CreatedAt = key.CreatedAt.Unix()
+	if key.LastUsedAt != nil {
+		res.LastUsedAt = key.LastUsedAt.Unix()
+	}
+
+	return res
+}
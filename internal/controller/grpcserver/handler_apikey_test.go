@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/mocks"
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_grpcController_APIKeys(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockUseCaseStats(ctrl)
+	m.EXPECT().GetStats(gomock.Any()).Return(new(modelStats.StatsResponse), nil).AnyTimes()
+	controller.ucStats = m
+
+	c := pb.NewAPIKeyServiceClient(conn)
+	// статистика доступна только ключам администратора
+	authCtx := adminContext(t)
+	statsKey, err := c.CreateAPIKey(authCtx,
+		&pb.CreateAPIKeyRequest{Name: "stats", Scopes: []string{modelAuth.ScopeStatsRead}})
+	require.NoError(t, err)
+	require.NotEmpty(t, statsKey.Key)
+	linksKey, err := c.CreateAPIKey(authCtx, &pb.CreateAPIKeyRequest{Scopes: []string{modelAuth.ScopeLinksRead}})
+	require.NoError(t, err)
+
+	list, err := c.ListAPIKeys(authCtx, new(pb.ListAPIKeysRequest))
+	require.NoError(t, err)
+	require.Len(t, list.ApiKeys, 2)
+	require.Equal(t, int64(0), list.ApiKeys[0].LastUsedAt)
+
+	// ключ действует в пределах своих прав
+	statsCtx := metadata.AppendToOutgoingContext(ctx, metadataAPIKey, statsKey.Key)
+	_, err = pb.NewStatsServiceClient(conn).GetStats(statsCtx, new(pb.GetStatsRequest))
+	require.NoError(t, err)
+	linksCtx := metadata.AppendToOutgoingContext(ctx, metadataAPIKey, linksKey.Key)
+	_, err = pb.NewStatsServiceClient(conn).GetStats(linksCtx, new(pb.GetStatsRequest))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// управлять ключами по API-ключу нельзя
+	_, err = c.ListAPIKeys(statsCtx, new(pb.ListAPIKeysRequest))
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// отозванный ключ не принимается
+	_, err = c.RevokeAPIKey(authCtx, &pb.RevokeAPIKeyRequest{Id: statsKey.ApiKey.Id})
+	require.NoError(t, err)
+	_, err = pb.NewStatsServiceClient(conn).GetStats(statsCtx, new(pb.GetStatsRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.RevokeAPIKey(authCtx, &pb.RevokeAPIKeyRequest{Id: statsKey.ApiKey.Id})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = c.CreateAPIKey(authCtx, &pb.CreateAPIKeyRequest{Scopes: []string{"links:admin"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,170 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Refresh обменяет токен обновления на новую пару токенов; переданный токен обновления больше не принимается
+func (c *grpcController) Refresh(ctx context.Context, r *pb.RefreshRequest) (*pb.RefreshResponse, error) {
+	refreshToken, err := c.ucAuth.Refresh(ctx, r.RefreshToken, getUserAgentFromContext(ctx))
+	if errors.Is(err, usecaseAuth.ErrInvalidRefreshToken) {
+		return nil, status.Error(codes.Unauthenticated, "refresh token is invalid")
+	}
+	if err != nil {
+		logger.Log.Error("refresh token error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	tokens, err := c.auth.BuildTokens(refreshToken)
+	if err != nil {
+		logger.Log.Error("build access token error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	res := new(pb.RefreshResponse)
+	res.AccessToken = tokens.AccessToken
+	res.AccessTokenExpiresAt = tokens.AccessTokenExpiresAt.Unix()
+	res.RefreshToken = tokens.RefreshToken
+	res.RefreshTokenExpiresAt = tokens.RefreshTokenExpiresAt.Unix()
+
+	return res, nil
+}
+
+// Register заведёт учётную запись текущему пользователю; ссылки, сокращённые им до регистрации,
+// остаются за учётной записью
+func (c *grpcController) Register(ctx context.Context, r *pb.CredentialsRequest) (*pb.RegisterResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	err = c.ucAuth.Register(ctx, userID, r.Email, r.Password)
+	if errors.Is(err, usecaseAuth.ErrInvalidEmail) || errors.Is(err, usecaseAuth.ErrInvalidPassword) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, usecaseAuth.ErrAccountExists) {
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("register error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	return new(pb.RegisterResponse), nil
+}
+
+// Login проверит почту и пароль и выдаст токены пользователя учётной записи
+func (c *grpcController) Login(ctx context.Context, r *pb.CredentialsRequest) (*pb.LoginResponse, error) {
+	userID, err := c.ucAuth.Login(ctx, r.Email, r.Password)
+	if errors.Is(err, usecaseAuth.ErrInvalidCredentials) {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("login error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	tokens, err := c.auth.IssueTokens(ctx, userID, getUserAgentFromContext(ctx))
+	if err != nil {
+		logger.Log.Error("issue tokens error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	res := new(pb.LoginResponse)
+	res.AccessToken = tokens.AccessToken
+	res.AccessTokenExpiresAt = tokens.AccessTokenExpiresAt.Unix()
+	res.RefreshToken = tokens.RefreshToken
+	res.RefreshTokenExpiresAt = tokens.RefreshTokenExpiresAt.Unix()
+
+	return res, nil
+}
+
+// Logout отзовёт текущий сеанс; его токены больше не принимаются
+func (c *grpcController) Logout(ctx context.Context, r *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	// токен, выданный до появления сеансов, отозвать нельзя: он действует до истечения
+	if sessionID := getSessionIDFromContext(ctx); sessionID != "" {
+		err = c.ucAuth.RevokeSession(ctx, userID, sessionID)
+		if err != nil && !errors.Is(err, usecaseAuth.ErrSessionNotFound) {
+			logger.Log.Error("logout error: ", zap.Error(err))
+			return nil, status.Errorf(codes.Internal, "internal error")
+		}
+	}
+
+	return new(pb.LogoutResponse), nil
+}
+
+// LogoutAll отзовёт все сеансы пользователя на всех устройствах
+func (c *grpcController) LogoutAll(ctx context.Context, r *pb.LogoutAllRequest) (*pb.LogoutAllResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	if err = c.ucAuth.RevokeUserSessions(ctx, userID); err != nil {
+		logger.Log.Error("logout everywhere error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	return new(pb.LogoutAllResponse), nil
+}
+
+// ListSessions вернёт действующие сеансы пользователя; текущий сеанс отмечен полем current
+func (c *grpcController) ListSessions(ctx context.Context, r *pb.ListSessionsRequest) (*pb.ListSessionsResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	sessions, err := c.ucAuth.GetUserSessions(ctx, userID)
+	if err != nil {
+		logger.Log.Error("get sessions error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	currentID := getSessionIDFromContext(ctx)
+	res := new(pb.ListSessionsResponse)
+	res.Sessions = make([]*pb.Session, 0, len(sessions))
+	for _, session := range sessions {
+		res.Sessions = append(res.Sessions, &pb.Session{
+			Id:         session.ID,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt.Unix(),
+			LastSeenAt: session.LastSeenAt.Unix(),
+			Current:    session.ID == currentID,
+		})
+	}
+
+	return res, nil
+}
+
+// RevokeSession отзовёт сеанс пользователя на другом устройстве
+func (c *grpcController) RevokeSession(ctx context.Context, r *pb.RevokeSessionRequest) (*pb.RevokeSessionResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	err = c.ucAuth.RevokeSession(ctx, userID, r.Id)
+	if errors.Is(err, usecaseAuth.ErrSessionNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("revoke session error: ", zap.Error(err))
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	return new(pb.RevokeSessionResponse), nil
+}
@@ -0,0 +1,205 @@
+package grpcserver
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/mocks"
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_grpcController_Refresh(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockUseCaseShortener(ctrl)
+	m.EXPECT().SaveURL(gomock.Any(), gomock.Any(), gomock.Any()).Return("", nil)
+	controller.uc = m
+
+	// новый пользователь заводится при сокращении и получает оба токена в заголовках
+	var header metadata.MD
+	_, err = pb.NewShortenerServiceClient(conn).
+		SetURL(ctx, &pb.SetURLRequest{Url: "https://example.com/refresh"}, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get(metadataRefreshToken), 1)
+	refreshToken := header.Get(metadataRefreshToken)[0]
+
+	c := pb.NewAuthServiceClient(conn)
+	res, err := c.Refresh(ctx, &pb.RefreshRequest{RefreshToken: refreshToken})
+	require.NoError(t, err)
+	require.NotEqual(t, refreshToken, res.RefreshToken)
+	require.Greater(t, res.RefreshTokenExpiresAt, res.AccessTokenExpiresAt)
+
+	// новый токен доступа принимается
+	authCtx := metadata.AppendToOutgoingContext(ctx, metadataAuthorization, "Bearer "+res.AccessToken)
+	_, err = pb.NewAPIKeyServiceClient(conn).ListAPIKeys(authCtx, new(pb.ListAPIKeysRequest))
+	require.NoError(t, err)
+
+	// использованный токен обновления не принимается
+	_, err = c.Refresh(ctx, &pb.RefreshRequest{RefreshToken: refreshToken})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func Test_grpcController_RegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := pb.NewAuthServiceClient(conn)
+	credentials := &pb.CredentialsRequest{Email: "grpc-user@example.com", Password: "secret-password"}
+
+	// анонимный пользователь регистрируется и получает токены в заголовках
+	var header metadata.MD
+	_, err = c.Register(ctx, credentials, grpc.Header(&header))
+	require.NoError(t, err)
+	require.Len(t, header.Get(metadataAuthorization), 1)
+	userID, err := controller.tokens.ValidateAndGetUserID(header.Get(metadataAuthorization)[0][len("Bearer "):])
+	require.NoError(t, err)
+
+	// повторная регистрация того же пользователя отклоняется
+	authCtx := metadata.AppendToOutgoingContext(ctx, metadataAuthorization, header.Get(metadataAuthorization)[0])
+	_, err = c.Register(authCtx, &pb.CredentialsRequest{Email: "grpc-other@example.com", Password: "secret-password"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// вход выдаёт токены того же пользователя
+	res, err := c.Login(ctx, credentials)
+	require.NoError(t, err)
+	loggedInUserID, err := controller.tokens.ValidateAndGetUserID(res.AccessToken)
+	require.NoError(t, err)
+	require.Equal(t, userID, loggedInUserID)
+	require.NotEmpty(t, res.RefreshToken)
+
+	_, err = c.Login(ctx, &pb.CredentialsRequest{Email: credentials.Email, Password: "wrong-password"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = c.Register(ctx, &pb.CredentialsRequest{Email: "invalid", Password: "secret-password"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_grpcController_interceptorAuth_Expired(t *testing.T) {
+	ctx := context.Background()
+
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	tokens := controller.tokens.(*common.JWTManager)
+	tokens.SetAccessTokenTTL(-time.Minute)
+	expired, _, err := tokens.BuildAccessToken("user")
+	tokens.SetAccessTokenTTL(common.DefaultAccessTokenTTL)
+	require.NoError(t, err)
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, metadataAuthorization, "Bearer "+expired)
+	_, err = pb.NewStatsServiceClient(conn).GetStats(authCtx, new(pb.GetStatsRequest))
+	st := status.Convert(err)
+	require.Equal(t, codes.Unauthenticated, st.Code())
+	require.Len(t, st.Details(), 1)
+	info, ok := st.Details()[0].(*errdetails.ErrorInfo)
+	require.True(t, ok)
+	require.Equal(t, reasonTokenExpired, info.Reason)
+
+	// неверный токен не считается истёкшим
+	authCtx = metadata.AppendToOutgoingContext(ctx, metadataAuthorization, "Bearer token")
+	_, err = pb.NewStatsServiceClient(conn).GetStats(authCtx, new(pb.GetStatsRequest))
+	st = status.Convert(err)
+	require.Equal(t, codes.Unauthenticated, st.Code())
+	require.Empty(t, st.Details())
+}
+
+func Test_anonymousPolicy(t *testing.T) {
+	require.Equal(t, common.AnonymousReject, anonymousPolicy(pb.ShortenerService_GetUserURLs_FullMethodName))
+	require.Equal(t, common.AnonymousReject, anonymousPolicy(pb.BackupService_Backup_FullMethodName))
+	require.Equal(t, common.AnonymousReject, anonymousPolicy(pb.StatsService_GetStats_FullMethodName))
+	require.Equal(t, common.AnonymousNewUser, anonymousPolicy(pb.ShortenerService_SetURL_FullMethodName))
+	require.Equal(t, common.AnonymousNewUser, anonymousPolicy(pb.ShortenerService_DeleteUserURLs_FullMethodName))
+	require.Equal(t, common.AnonymousAllow, anonymousPolicy(pb.ShortenerService_GetURL_FullMethodName))
+	require.Equal(t, common.AnonymousAllow, anonymousPolicy(pb.PingService_Ping_FullMethodName))
+	require.Equal(t, common.AnonymousAllow, anonymousPolicy("/proto.UnknownService/Call"))
+
+	require.True(t, isUnauthenticated(pb.AuthService_Login_FullMethodName))
+	require.True(t, isUnauthenticated(pb.AuthService_Refresh_FullMethodName))
+	require.False(t, isUnauthenticated(pb.AuthService_Register_FullMethodName))
+	require.False(t, isUnauthenticated("/proto.UnknownService/Call"))
+}
+
+func Test_grpcController_Sessions(t *testing.T) {
+	ctx := context.Background()
+	credentials := &pb.CredentialsRequest{Email: "grpc-sessions@example.com", Password: "secret-password"}
+
+	// dial откроет соединение от имени клиента userAgent
+	dial := func(userAgent string) *grpc.ClientConn {
+		conn, err := grpc.NewClient(bootstrapAddressgRPC,
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUserAgent(userAgent))
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	laptopConn, phoneConn := dial("laptop"), dial("phone")
+
+	var header metadata.MD
+	_, err := pb.NewAuthServiceClient(laptopConn).Register(ctx, credentials, grpc.Header(&header))
+	require.NoError(t, err)
+	laptopCtx := metadata.AppendToOutgoingContext(ctx, metadataAuthorization, header.Get(metadataAuthorization)[0])
+	login, err := pb.NewAuthServiceClient(phoneConn).Login(ctx, credentials)
+	require.NoError(t, err)
+	phoneCtx := metadata.AppendToOutgoingContext(ctx, metadataAuthorization, "Bearer "+login.AccessToken)
+
+	// сеансы видны с любого устройства, текущий отмечен
+	phone := pb.NewAuthServiceClient(phoneConn)
+	sessions, err := phone.ListSessions(phoneCtx, new(pb.ListSessionsRequest))
+	require.NoError(t, err)
+	require.Len(t, sessions.Sessions, 2)
+	var laptopSessionID string
+	for _, session := range sessions.Sessions {
+		if strings.HasPrefix(session.UserAgent, "laptop") {
+			laptopSessionID = session.Id
+			require.False(t, session.Current)
+		} else {
+			require.True(t, strings.HasPrefix(session.UserAgent, "phone"))
+			require.True(t, session.Current)
+		}
+	}
+
+	// отозванный сеанс больше не принимается
+	_, err = phone.RevokeSession(phoneCtx, &pb.RevokeSessionRequest{Id: laptopSessionID})
+	require.NoError(t, err)
+	_, err = pb.NewAPIKeyServiceClient(laptopConn).ListAPIKeys(laptopCtx, new(pb.ListAPIKeysRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = phone.RevokeSession(phoneCtx, &pb.RevokeSessionRequest{Id: laptopSessionID + "-unknown"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	// выход завершает текущий сеанс
+	_, err = phone.Logout(phoneCtx, new(pb.LogoutRequest))
+	require.NoError(t, err)
+	_, err = phone.ListSessions(phoneCtx, new(pb.ListSessionsRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// выход на всех устройствах завершает все сеансы
+	login, err = phone.Login(ctx, credentials)
+	require.NoError(t, err)
+	phoneCtx = metadata.AppendToOutgoingContext(ctx, metadataAuthorization, "Bearer "+login.AccessToken)
+	_, err = phone.LogoutAll(phoneCtx, new(pb.LogoutAllRequest))
+	require.NoError(t, err)
+	_, err = phone.Refresh(ctx, &pb.RefreshRequest{RefreshToken: login.RefreshToken})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// без токена доступа сеансы не запросить
+	_, err = phone.ListSessions(ctx, new(pb.ListSessionsRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,107 @@
+package grpcserver
+
+import (
+	"bufio"
+	"errors"
+	"io"
+
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository"
+	ucBackup "github.com/KartoonYoko/go-url-shortener/internal/usecase/backup"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// backupChunkSize размер части снимка в одном сообщении
+const backupChunkSize = 64 * 1024
+
+// backupStreamWriter отправляет записанные данные сообщениями BackupChunk
+type backupStreamWriter struct {
+	stream pb.BackupService_BackupServer
+}
+
+func (w *backupStreamWriter) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&pb.BackupChunk{Data: p}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// restoreStreamReader читает данные из сообщений RestoreChunk
+type restoreStreamReader struct {
+	stream pb.BackupService_RestoreServer
+	buf    []byte
+}
+
+func (r *restoreStreamReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.buf = chunk.Data
+	}
+
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (c *grpcController) Backup(r *pb.BackupRequest, stream pb.BackupService_BackupServer) error {
+	w := bufio.NewWriterSize(&backupStreamWriter{stream: stream}, backupChunkSize)
+
+	summary, err := c.ucBackup.Backup(stream.Context(), w)
+	if err != nil {
+		logger.Log.Error("backup error", zap.Error(err))
+		return status.Errorf(codes.Internal, "internal error")
+	}
+	if err = w.Flush(); err != nil {
+		logger.Log.Error("backup send error", zap.Error(err))
+		return status.Errorf(codes.Internal, "internal error")
+	}
+
+	logger.Log.Info("backup completed",
+		zap.Int("users", summary.Users),
+		zap.Int("urls", summary.URLs),
+		zap.Int("accounts", summary.Accounts),
+		zap.Int("identities", summary.Identities),
+		zap.Int("api_keys", summary.APIKeys),
+		zap.Int("workspaces", summary.Workspaces))
+	return nil
+}
+
+func (c *grpcController) Restore(stream pb.BackupService_RestoreServer) error {
+	summary, err := c.ucBackup.Restore(stream.Context(), &restoreStreamReader{stream: stream})
+	if err != nil {
+		logger.Log.Error("restore error", zap.Error(err))
+		switch {
+		case errors.Is(err, ucBackup.ErrInvalidSnapshot):
+			return status.Error(codes.InvalidArgument, err.Error())
+		case errors.Is(err, repository.ErrImportConflict):
+			return status.Error(codes.FailedPrecondition, err.Error())
+		default:
+			return status.Errorf(codes.Internal, "internal error")
+		}
+	}
+
+	// дочитываем поток до конца, чтобы клиент мог закрыть отправку
+	for {
+		if _, err = stream.Recv(); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			break
+		}
+	}
+
+	return stream.SendAndClose(&pb.RestoreResponse{
+		Users:      int64(summary.Users),
+		Urls:       int64(summary.URLs),
+		Accounts:   int64(summary.Accounts),
+		Identities: int64(summary.Identities),
+		ApiKeys:    int64(summary.APIKeys),
+		Workspaces: int64(summary.Workspaces),
+	})
+}
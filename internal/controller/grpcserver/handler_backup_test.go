@@ -0,0 +1,197 @@
+package grpcserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/mocks"
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	ucBackup "github.com/KartoonYoko/go-url-shortener/internal/usecase/backup"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_grpcController_Backup(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	controller.conf.TrustedSubnets = "192.168.1.0/24"
+	defer func() { controller.conf.TrustedSubnets = "" }()
+
+	c := pb.NewBackupServiceClient(conn)
+	data := bytes.Repeat([]byte("snapshot"), 2*backupChunkSize/8+1)
+	type test struct {
+		name            string
+		ip              string
+		prepare         func(mock *mocks.MockUseCaseBackup)
+		statusErrorCode codes.Code
+	}
+	tests := []test{
+		{
+			name: "Success",
+			ip:   "192.168.1.10",
+			prepare: func(m *mocks.MockUseCaseBackup) {
+				m.EXPECT().Backup(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, w io.Writer) (*snapshot.Summary, error) {
+						_, err := w.Write(data)
+						return &snapshot.Summary{}, err
+					})
+			},
+		},
+		{
+			name:            "Untrusted IP",
+			ip:              "10.0.0.1",
+			statusErrorCode: codes.PermissionDenied,
+		},
+		{
+			name:            "Without IP",
+			statusErrorCode: codes.PermissionDenied,
+		},
+		{
+			name: "Error",
+			ip:   "192.168.1.10",
+			prepare: func(m *mocks.MockUseCaseBackup) {
+				m.EXPECT().Backup(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("some unexpected error"))
+			},
+			statusErrorCode: codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mocks.NewMockUseCaseBackup(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(m)
+			}
+			controller.ucBackup = m
+
+			ctx := adminContext(t)
+			if tt.ip != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "X-Real-IP", tt.ip)
+			}
+			stream, err := c.Backup(ctx, new(pb.BackupRequest))
+			require.NoError(t, err)
+
+			got := new(bytes.Buffer)
+			for {
+				var chunk *pb.BackupChunk
+				chunk, err = stream.Recv()
+				if err != nil {
+					break
+				}
+				got.Write(chunk.Data)
+			}
+
+			if tt.statusErrorCode == 0 {
+				require.ErrorIs(t, err, io.EOF)
+				require.Equal(t, data, got.Bytes())
+			} else {
+				e, ok := status.FromError(err)
+				require.True(t, ok, "unexpected error: %v", err)
+				require.Equal(t, tt.statusErrorCode, e.Code())
+			}
+		})
+	}
+}
+
+func Test_grpcController_Restore(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	controller.conf.TrustedSubnets = "192.168.1.0/24"
+	defer func() { controller.conf.TrustedSubnets = "" }()
+
+	c := pb.NewBackupServiceClient(conn)
+	data := bytes.Repeat([]byte("snapshot"), 1000)
+	type test struct {
+		name            string
+		ip              string
+		prepare         func(mock *mocks.MockUseCaseBackup)
+		statusErrorCode codes.Code
+	}
+	tests := []test{
+		{
+			name: "Success",
+			ip:   "192.168.1.10",
+			prepare: func(m *mocks.MockUseCaseBackup) {
+				m.EXPECT().Restore(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, r io.Reader) (*snapshot.Summary, error) {
+						got, err := io.ReadAll(r)
+						if err != nil {
+							return nil, err
+						}
+						if !bytes.Equal(data, got) {
+							return nil, errors.New("unexpected data")
+						}
+						return &snapshot.Summary{Users: 1, URLs: 2, Accounts: 3, Identities: 4, APIKeys: 5, Workspaces: 6}, nil
+					})
+			},
+		},
+		{
+			name:            "Untrusted IP",
+			ip:              "10.0.0.1",
+			statusErrorCode: codes.PermissionDenied,
+		},
+		{
+			name: "Invalid snapshot",
+			ip:   "192.168.1.10",
+			prepare: func(m *mocks.MockUseCaseBackup) {
+				m.EXPECT().Restore(gomock.Any(), gomock.Any()).Return(nil, ucBackup.ErrInvalidSnapshot)
+			},
+			statusErrorCode: codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			m := mocks.NewMockUseCaseBackup(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(m)
+			}
+			controller.ucBackup = m
+
+			ctx := metadata.AppendToOutgoingContext(adminContext(t), "X-Real-IP", tt.ip)
+			stream, err := c.Restore(ctx)
+			require.NoError(t, err)
+			for i := 0; i < len(data); i += 1000 {
+				// сервер может завершить вызов раньше, чем получит все части
+				if err = stream.Send(&pb.RestoreChunk{Data: data[i : i+1000]}); err != nil {
+					break
+				}
+			}
+			res, err := stream.CloseAndRecv()
+
+			if tt.statusErrorCode == 0 {
+				require.NoError(t, err)
+				require.Equal(t, int64(1), res.Users)
+				require.Equal(t, int64(2), res.Urls)
+				require.Equal(t, int64(3), res.Accounts)
+				require.Equal(t, int64(4), res.Identities)
+				require.Equal(t, int64(5), res.ApiKeys)
+				require.Equal(t, int64(6), res.Workspaces)
+			} else {
+				e, ok := status.FromError(err)
+				require.True(t, ok, "unexpected error: %v", err)
+				require.Equal(t, tt.statusErrorCode, e.Code())
+			}
+		})
+	}
+}
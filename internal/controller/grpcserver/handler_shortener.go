@@ -12,13 +12,13 @@ import (
 )
 
 func (c *grpcController) SetURL(ctx context.Context, r *pb.SetURLRequest) (*pb.SetURLResponse, error) {
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		logger.Log.Error("can not get user ID: ", zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "internal error")
 	}
 
-	shortURL, err := c.uc.SaveURL(ctx, r.Url, userID)
+	shortURL, err := c.uc.SaveURL(ctx, r.Url, owner)
 	if err != nil {
 		logger.Log.Error("can not save url: ", zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "internal error")
@@ -44,7 +44,7 @@ func (c *grpcController) GetURL(ctx context.Context, r *pb.GetURLRequest) (*pb.G
 }
 
 func (c *grpcController) SetURLsBatch(ctx context.Context, r *pb.SetURLsBatchRequest) (*pb.SetURLsBatchResponse, error) {
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		logger.Log.Error("can not get user ID: ", zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "internal error")
@@ -57,7 +57,7 @@ func (c *grpcController) SetURLsBatch(ctx context.Context, r *pb.SetURLsBatchReq
 			CorrelationID: item.CorrelationId,
 		})
 	}
-	response, err := c.uc.SaveURLsBatch(ctx, request, userID)
+	response, err := c.uc.SaveURLsBatch(ctx, request, owner)
 	if err != nil {
 		logger.Log.Error("can not save URLs: ", zap.Error(err))
 		return nil, status.Errorf(codes.Internal, "internal error")
@@ -77,12 +77,12 @@ func (c *grpcController) SetURLsBatch(ctx context.Context, r *pb.SetURLsBatchReq
 }
 
 func (c *grpcController) GetUserURLs(ctx context.Context, r *pb.GetUserURLsRequest) (*pb.GetUserURLsResponse, error) {
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		logger.Log.Error("can not get user ID: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, "internal error")
 	}
-	res, err := c.uc.GetUserURLs(ctx, userID)
+	res, err := c.uc.GetUserURLs(ctx, owner)
 	if err != nil {
 		logger.Log.Error("can not get user urls: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, "internal error")
@@ -100,7 +100,7 @@ func (c *grpcController) GetUserURLs(ctx context.Context, r *pb.GetUserURLsReque
 }
 
 func (c *grpcController) DeleteUserURLs(ctx context.Context, r *pb.DeleteUserURLsRequest) (*pb.DeleteUserURLsResponse, error) {
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		logger.Log.Error("can not get user ID: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, "internal error")
@@ -110,7 +110,7 @@ func (c *grpcController) DeleteUserURLs(ctx context.Context, r *pb.DeleteUserURL
 	for _, item := range r.Items {
 		urlIDs = append(urlIDs, item.UrlId)
 	}
-	if err = c.uc.DeleteURLs(ctx, userID, urlIDs); err != nil {
+	if err = c.uc.DeleteURLs(ctx, owner, urlIDs); err != nil {
 		logger.Log.Error("can not delete user urls: ", zap.Error(err))
 		return nil, status.Error(codes.Internal, "internal error")
 	}
@@ -198,7 +198,7 @@ func Test_grpcController_SetURLsBatch(t *testing.T) {
 }
 
 func Test_grpcController_GetUserURLs(t *testing.T) {
-	ctx := context.Background()
+	ctx := userContext(t, "user")
 
 	// устанавливаем соединение с сервером
 	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -258,6 +258,17 @@ func Test_grpcController_GetUserURLs(t *testing.T) {
 	}
 }
 
+func Test_grpcController_GetUserURLs_Anonymous(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := pb.NewShortenerServiceClient(conn)
+
+	// у нового пользователя нет ссылок, поэтому он не заводится
+	_, err = c.GetUserURLs(context.Background(), new(pb.GetUserURLsRequest))
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
 func Test_grpcController_DeleteUserURLs(t *testing.T) {
 	ctx := context.Background()
 
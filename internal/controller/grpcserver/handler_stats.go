@@ -17,6 +17,15 @@ func (c *grpcController) GetStats(ctx context.Context, r *pb.GetStatsRequest) (*
 	res := new(pb.GetStatsResponse)
 	res.Urls = int64(stats.URLs)
 	res.Users = int64(stats.Users)
+	if stats.Cache != nil {
+		res.CacheHits = stats.Cache.Hits
+		res.CacheMisses = stats.Cache.Misses
+	}
+	if stats.Bloom != nil {
+		res.BloomFalsePositiveRate = stats.Bloom.FalsePositiveRate
+		res.BloomRejected = stats.Bloom.Rejected
+		res.BloomFalsePositives = stats.Bloom.FalsePositives
+	}
 
 	return res, nil
 }
@@ -17,7 +17,9 @@ import (
 )
 
 func Test_grpcController_GetStats(t *testing.T) {
-	ctx := context.Background()
+	ctx := adminContext(t)
+	userID, err := controller.ucAuth.GetNewUserID(context.Background())
+	require.NoError(t, err)
 
 	// устанавливаем соединение с сервером
 	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
@@ -29,6 +31,7 @@ func Test_grpcController_GetStats(t *testing.T) {
 	c := pb.NewStatsServiceClient(conn)
 	type test struct {
 		name            string
+		ctx             context.Context
 		prepare         func(mock *mocks.MockUseCaseStats)
 		statusErrorCode codes.Code
 	}
@@ -47,6 +50,16 @@ func Test_grpcController_GetStats(t *testing.T) {
 			},
 			statusErrorCode: codes.Internal,
 		},
+		{
+			name:            "Anonymous",
+			ctx:             context.Background(),
+			statusErrorCode: codes.Unauthenticated,
+		},
+		{
+			name:            "Not admin",
+			ctx:             userContext(t, userID),
+			statusErrorCode: codes.PermissionDenied,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -61,7 +74,11 @@ func Test_grpcController_GetStats(t *testing.T) {
 			controller.ucStats = m
 
 			request := new(pb.GetStatsRequest)
-			_, err := c.GetStats(ctx, request)
+			callCtx := ctx
+			if tt.ctx != nil {
+				callCtx = tt.ctx
+			}
+			_, err := c.GetStats(callCtx, request)
 
 			if tt.statusErrorCode == 0 {
 				require.NoError(t, err)
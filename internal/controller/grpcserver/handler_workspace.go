@@ -0,0 +1,165 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	usecaseWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CreateWorkspace создаст рабочее пространство, владельцем которого станет текущий пользователь
+func (c *grpcController) CreateWorkspace(ctx context.Context, r *pb.CreateWorkspaceRequest) (*pb.CreateWorkspaceResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	membership, err := c.ucWS.CreateWorkspace(ctx, userID, r.Name)
+	if err != nil {
+		return nil, workspaceError("create workspace error: ", err)
+	}
+
+	res := new(pb.CreateWorkspaceResponse)
+	res.Workspace = newWorkspaceResponse(membership)
+
+	return res, nil
+}
+
+// ListWorkspaces вернёт рабочие пространства пользователя с его ролью в каждом
+func (c *grpcController) ListWorkspaces(ctx context.Context, r *pb.ListWorkspacesRequest) (*pb.ListWorkspacesResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	memberships, err := c.ucWS.GetUserWorkspaces(ctx, userID)
+	if err != nil {
+		return nil, workspaceError("get workspaces error: ", err)
+	}
+
+	res := new(pb.ListWorkspacesResponse)
+	res.Workspaces = make([]*pb.Workspace, 0, len(memberships))
+	for i := range memberships {
+		res.Workspaces = append(res.Workspaces, newWorkspaceResponse(&memberships[i]))
+	}
+
+	return res, nil
+}
+
+// ListMembers вернёт участников пространства; доступно любому участнику
+func (c *grpcController) ListMembers(ctx context.Context, r *pb.ListMembersRequest) (*pb.ListMembersResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	members, err := c.ucWS.GetMembers(ctx, userID, r.WorkspaceId)
+	if err != nil {
+		return nil, workspaceError("get workspace members error: ", err)
+	}
+
+	res := new(pb.ListMembersResponse)
+	res.Members = make([]*pb.WorkspaceMember, 0, len(members))
+	for i := range members {
+		res.Members = append(res.Members, newMemberResponse(&members[i]))
+	}
+
+	return res, nil
+}
+
+// SetMember добавит пользователя в пространство или сменит его роль; доступно владельцам
+func (c *grpcController) SetMember(ctx context.Context, r *pb.SetMemberRequest) (*pb.SetMemberResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	member, err := c.ucWS.SetMember(ctx, userID, r.WorkspaceId, r.UserId, r.Role)
+	if err != nil {
+		return nil, workspaceError("set workspace member error: ", err)
+	}
+
+	res := new(pb.SetMemberResponse)
+	res.Member = newMemberResponse(member)
+
+	return res, nil
+}
+
+// RemoveMember исключит пользователя из пространства; доступно владельцам,
+// а участник может покинуть пространство сам
+func (c *grpcController) RemoveMember(ctx context.Context, r *pb.RemoveMemberRequest) (*pb.RemoveMemberResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	if err = c.ucWS.RemoveMember(ctx, userID, r.WorkspaceId, r.UserId); err != nil {
+		return nil, workspaceError("remove workspace member error: ", err)
+	}
+
+	return new(pb.RemoveMemberResponse), nil
+}
+
+// GetWorkspaceStats вернёт количество ссылок и участников пространства; доступно любому участнику
+func (c *grpcController) GetWorkspaceStats(ctx context.Context,
+	r *pb.GetWorkspaceStatsRequest) (*pb.GetWorkspaceStatsResponse, error) {
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "internal error")
+	}
+
+	stats, err := c.ucWS.GetStats(ctx, userID, r.WorkspaceId)
+	if err != nil {
+		return nil, workspaceError("get workspace stats error: ", err)
+	}
+
+	res := new(pb.GetWorkspaceStatsResponse)
+	res.Urls = int64(stats.URLs)
+	res.Members = int64(stats.Members)
+
+	return res, nil
+}
+
+// workspaceError вернёт статус, соответствующий ошибке usecase'а рабочих пространств
+func workspaceError(msg string, err error) error {
+	switch {
+	case errors.Is(err, usecaseWorkspace.ErrWorkspaceNotFound),
+		errors.Is(err, usecaseWorkspace.ErrMemberNotFound),
+		errors.Is(err, usecaseWorkspace.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, usecaseWorkspace.ErrForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, usecaseWorkspace.ErrInvalidName),
+		errors.Is(err, usecaseWorkspace.ErrInvalidRole):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, usecaseWorkspace.ErrLastOwner):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		logger.Log.Error(msg, zap.Error(err))
+		return status.Errorf(codes.Internal, "internal error")
+	}
+}
+
+func newWorkspaceResponse(m *modelWorkspace.Membership) *pb.Workspace {
+	res := new(pb.Workspace)
+	res.Id = m.Workspace.ID
+	res.Name = m.Workspace.Name
+	res.Role = m.Role
+	res.CreatedAt = m.Workspace.CreatedAt.Unix()
+
+	return res
+}
+
+func newMemberResponse(m *modelWorkspace.Member) *pb.WorkspaceMember {
+	res := new(pb.WorkspaceMember)
+	res.UserId = m.UserID
+	res.Role = m.Role
+
+	return res
+}
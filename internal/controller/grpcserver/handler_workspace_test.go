@@ -0,0 +1,115 @@
+package grpcserver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/mocks"
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func Test_grpcController_Workspace(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := pb.NewWorkspaceServiceClient(conn)
+
+	ownerID := newWorkspaceUser(t)
+	viewerID := newWorkspaceUser(t)
+	ownerCtx := userContext(t, ownerID)
+	viewerCtx := userContext(t, viewerID)
+	outsiderCtx := userContext(t, newWorkspaceUser(t))
+
+	_, err = c.CreateWorkspace(ownerCtx, &pb.CreateWorkspaceRequest{Name: ""})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	created, err := c.CreateWorkspace(ownerCtx, &pb.CreateWorkspaceRequest{Name: "campaigns"})
+	require.NoError(t, err)
+	require.Equal(t, "campaigns", created.Workspace.Name)
+	require.Equal(t, modelWorkspace.RoleOwner, created.Workspace.Role)
+	wsID := created.Workspace.Id
+
+	set, err := c.SetMember(ownerCtx, &pb.SetMemberRequest{WorkspaceId: wsID, UserId: viewerID, Role: modelWorkspace.RoleViewer})
+	require.NoError(t, err)
+	require.Equal(t, viewerID, set.Member.UserId)
+	_, err = c.SetMember(viewerCtx, &pb.SetMemberRequest{WorkspaceId: wsID, UserId: viewerID, Role: modelWorkspace.RoleOwner})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = c.RemoveMember(ownerCtx, &pb.RemoveMemberRequest{WorkspaceId: wsID, UserId: ownerID})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	list, err := c.ListWorkspaces(viewerCtx, new(pb.ListWorkspacesRequest))
+	require.NoError(t, err)
+	require.Len(t, list.Workspaces, 1)
+	require.Equal(t, modelWorkspace.RoleViewer, list.Workspaces[0].Role)
+	members, err := c.ListMembers(viewerCtx, &pb.ListMembersRequest{WorkspaceId: wsID})
+	require.NoError(t, err)
+	require.Len(t, members.Members, 2)
+	_, err = c.ListMembers(outsiderCtx, &pb.ListMembersRequest{WorkspaceId: wsID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	stats, err := c.GetWorkspaceStats(viewerCtx, &pb.GetWorkspaceStatsRequest{WorkspaceId: wsID})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.Members)
+
+	_, err = c.RemoveMember(viewerCtx, &pb.RemoveMemberRequest{WorkspaceId: wsID, UserId: viewerID})
+	require.NoError(t, err)
+	_, err = c.GetWorkspaceStats(viewerCtx, &pb.GetWorkspaceStatsRequest{WorkspaceId: wsID})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_grpcController_WorkspaceLinks(t *testing.T) {
+	conn, err := grpc.NewClient(bootstrapAddressgRPC, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	c := pb.NewShortenerServiceClient(conn)
+
+	ctx := context.Background()
+	ownerID := newWorkspaceUser(t)
+	viewerID := newWorkspaceUser(t)
+	ws, err := controller.ucWS.CreateWorkspace(ctx, ownerID, "campaigns")
+	require.NoError(t, err)
+	wsID := ws.Workspace.ID
+	_, err = controller.ucWS.SetMember(ctx, ownerID, wsID, viewerID, modelWorkspace.RoleViewer)
+	require.NoError(t, err)
+
+	ctrl := gomock.NewController(t)
+	m := mocks.NewMockUseCaseShortener(ctrl)
+	m.EXPECT().SaveURL(gomock.Any(), "https://example.com/campaign", model.Owner{WorkspaceID: wsID}).Return("id", nil)
+	m.EXPECT().GetUserURLs(gomock.Any(), model.Owner{WorkspaceID: wsID}).Return(nil, nil)
+	m.EXPECT().GetUserURLs(gomock.Any(), model.Owner{UserID: viewerID}).Return(nil, nil)
+	controller.uc = m
+
+	ownerCtx := metadata.AppendToOutgoingContext(userContext(t, ownerID), metadataWorkspaceID, wsID)
+	viewerCtx := metadata.AppendToOutgoingContext(userContext(t, viewerID), metadataWorkspaceID, wsID)
+	outsiderCtx := metadata.AppendToOutgoingContext(userContext(t, newWorkspaceUser(t)), metadataWorkspaceID, wsID)
+
+	_, err = c.SetURL(ownerCtx, &pb.SetURLRequest{Url: "https://example.com/campaign"})
+	require.NoError(t, err)
+	_, err = c.GetUserURLs(viewerCtx, new(pb.GetUserURLsRequest))
+	require.NoError(t, err)
+	// без метаданных пространства вызов работает со ссылками пользователя
+	_, err = c.GetUserURLs(userContext(t, viewerID), new(pb.GetUserURLsRequest))
+	require.NoError(t, err)
+
+	_, err = c.SetURL(viewerCtx, &pb.SetURLRequest{Url: "https://example.com/viewer"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = c.DeleteUserURLs(viewerCtx, &pb.DeleteUserURLsRequest{})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = c.GetUserURLs(outsiderCtx, new(pb.GetUserURLsRequest))
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// newWorkspaceUser заведёт пользователя и вернёт его ID
+func newWorkspaceUser(t *testing.T) string {
+	userID, err := controller.ucAuth.GetNewUserID(context.Background())
+	require.NoError(t, err)
+	return userID
+}
@@ -2,30 +2,81 @@ package grpcserver
 
 import (
 	"context"
-	"fmt"
+	"crypto/tls"
+	"errors"
 	"strings"
 	"time"
 
 	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	pb "github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver/proto"
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	usecaseWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 // InterceptorAuthKey тип ключа контекста для перехватчика аутентификации
 type InterceptorAuthKey int
 
 const (
-	keyUserID InterceptorAuthKey = iota // ключ для ID пользователя
+	keyUserID      InterceptorAuthKey = iota // ключ для ID пользователя
+	keyAPIKey                                // ключ для API-ключа, если вызов выполнен по нему
+	keyUser                                  // ключ для роли и состояния пользователя
+	keyWorkspaceID                           // ключ для ID рабочего пространства, ссылками которого управляет вызов
+	keySessionID                             // ключ для ID сеанса, если токен выдан в сеансе
 )
 
-// interceptorAuth проверяет наличие симметрично подписанного токена
+// Метаданные аутентификации
+const (
+	metadataAuthorization = "Authorization"  // токен доступа
+	metadataRefreshToken  = "Refresh-Token"  // токен обновления
+	metadataAPIKey        = "X-API-Key"      // API-ключ
+	metadataWorkspaceID   = "X-Workspace-ID" // ID рабочего пространства, от имени которого выполняется вызов
+	metadataUserAgent     = "User-Agent"     // клиент, в котором начинается сеанс
+)
+
+// reasonTokenExpired причина в ErrorInfo ошибки истёкшего токена; такой токен можно обновить
+const reasonTokenExpired = "TOKEN_EXPIRED"
+
+// apiKeyMethodScopes права, которые нужны API-ключу для вызова метода; пустая строка - подходит любой ключ.
+// Методы, которых нет в списке, по API-ключу недоступны; публичные методы ключ не проверяют
+var apiKeyMethodScopes = map[string]string{
+	pb.ShortenerService_SetURL_FullMethodName:         modelAuth.ScopeLinksWrite,
+	pb.ShortenerService_SetURLsBatch_FullMethodName:   modelAuth.ScopeLinksWrite,
+	pb.ShortenerService_DeleteUserURLs_FullMethodName: modelAuth.ScopeLinksWrite,
+	pb.ShortenerService_GetUserURLs_FullMethodName:    modelAuth.ScopeLinksRead,
+	pb.StatsService_GetStats_FullMethodName:           modelAuth.ScopeStatsRead,
+}
+
+// workspaceMethodRoles роли в рабочем пространстве, которые нужны для вызова метода от его имени;
+// для остальных методов метаданные X-Workspace-ID не учитываются
+var workspaceMethodRoles = map[string]string{
+	pb.ShortenerService_SetURL_FullMethodName:         modelWorkspace.RoleEditor,
+	pb.ShortenerService_SetURLsBatch_FullMethodName:   modelWorkspace.RoleEditor,
+	pb.ShortenerService_DeleteUserURLs_FullMethodName: modelWorkspace.RoleEditor,
+	pb.ShortenerService_GetUserURLs_FullMethodName:    modelWorkspace.RoleViewer,
+}
+
+// interceptorAuth проверяет наличие симметрично подписанного токена или API-ключа
 func (c *grpcController) interceptorAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	var err error
-	var userID string
+	policy := anonymousPolicy(info.FullMethod)
+	// публичному методу пользователь не нужен: ни токен, ни API-ключ не проверяются
+	if isUnauthenticated(info.FullMethod) || policy == common.AnonymousAllow {
+		return handler(ctx, req)
+	}
 
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
@@ -33,44 +84,295 @@ func (c *grpcController) interceptorAuth(ctx context.Context, req interface{}, i
 		return nil, status.Error(codes.Internal, "")
 	}
 
-	sl := md.Get("Authorization")
-	if len(sl) == 0 {
-		userID, err = c.setAuthorizationMetadata(ctx)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		token, _ := strings.CutPrefix(sl[0], "Bearer ")
-		userID, err = common.ValidateAndGetUserID(token)
-		if err != nil {
-			logger.Log.Error("can not validate and get user ID: ", zap.Error(err))
-			return nil, status.Error(codes.Unauthenticated, "token is wrong")
-		}
+	if sl := md.Get(metadataAPIKey); len(sl) > 0 {
+		return c.authAPIKey(ctx, req, info, handler, sl[0])
+	}
+
+	auth, err := c.authenticate(ctx, md, policy)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, keyUserID, auth.UserID)
+	ctx = context.WithValue(ctx, keySessionID, auth.SessionID)
+
+	return c.authorize(ctx, req, info, handler, auth.UserID)
+}
+
+// Политика для вызовов без токена доступа задаётся у каждого метода в proto параметрами
+// (anonymous) и (unauthenticated) из options.proto
+
+// methodOptions вернёт параметры метода fullMethod вида /пакет.Сервис/Метод из его описания в proto
+func methodOptions(fullMethod string) (*descriptorpb.MethodOptions, bool) {
+	name := protoreflect.FullName(strings.ReplaceAll(strings.TrimPrefix(fullMethod, "/"), "/", "."))
+	desc, err := protoregistry.GlobalFiles.FindDescriptorByName(name)
+	if err != nil {
+		return nil, false
+	}
+	method, ok := desc.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, false
+	}
+	opts, ok := method.Options().(*descriptorpb.MethodOptions)
+	return opts, ok && opts != nil
+}
+
+// anonymousPolicy вернёт политику метода для вызовов без токена доступа
+func anonymousPolicy(fullMethod string) common.AnonymousPolicy {
+	opts, ok := methodOptions(fullMethod)
+	if !ok {
+		return common.AnonymousAllow
+	}
+	switch proto.GetExtension(opts, pb.E_Anonymous).(pb.AnonymousPolicy) {
+	case pb.AnonymousPolicy_ANONYMOUS_POLICY_NEW_USER:
+		return common.AnonymousNewUser
+	case pb.AnonymousPolicy_ANONYMOUS_POLICY_REJECT:
+		return common.AnonymousReject
+	default:
+		return common.AnonymousAllow
+	}
+}
+
+// isUnauthenticated проверит, что метод доступен без аутентификации
+func isUnauthenticated(fullMethod string) bool {
+	opts, ok := methodOptions(fullMethod)
+	return ok && proto.GetExtension(opts, pb.E_Unauthenticated).(bool)
+}
+
+// authenticate проверит токен доступа из метаданных Authorization; новому пользователю
+// токены отправляются в метаданных заголовка ответа
+func (c *grpcController) authenticate(ctx context.Context,
+	md metadata.MD, policy common.AnonymousPolicy) (*common.Authentication, error) {
+	var credentials string
+	if sl := md.Get(metadataAuthorization); len(sl) > 0 {
+		credentials = sl[0]
+	}
+
+	auth, err := c.auth.Authenticate(ctx, credentials, getUserAgent(md), policy)
+	if errors.Is(err, common.ErrNoCredentials) {
+		return nil, status.Error(codes.Unauthenticated, "token is required")
+	}
+	if errors.Is(err, common.ErrTokenExpired) {
+		return nil, errTokenExpired()
+	}
+	if errors.Is(err, common.ErrInvalidToken) {
+		logger.Log.Error("can not validate and get user ID: ", zap.Error(err))
+		return nil, status.Error(codes.Unauthenticated, "token is wrong")
+	}
+	if err != nil {
+		logger.Log.Error("can not authenticate user: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "")
+	}
+	if auth.Tokens != nil {
+		grpc.SetHeader(ctx, metadata.New(map[string]string{
+			metadataAuthorization: common.FormatBearer(auth.Tokens.AccessToken),
+			metadataRefreshToken:  auth.Tokens.RefreshToken,
+		}))
+	}
+
+	return auth, nil
+}
+
+// getUserAgent вернёт клиента из метаданных User-Agent
+func getUserAgent(md metadata.MD) string {
+	if sl := md.Get(metadataUserAgent); len(sl) > 0 {
+		return sl[0]
+	}
+	return ""
+}
+
+// authAPIKey выполнит вызов от имени владельца API-ключа, если у ключа есть право на метод
+func (c *grpcController) authAPIKey(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler, apiKey string) (interface{}, error) {
+	key, err := c.ucAuth.AuthenticateAPIKey(ctx, apiKey)
+	if errors.Is(err, usecaseAuth.ErrInvalidAPIKey) {
+		return nil, status.Error(codes.Unauthenticated, "api key is invalid")
+	}
+	if err != nil {
+		logger.Log.Error("can not authenticate api key: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "")
+	}
+
+	scope, ok := apiKeyMethodScopes[info.FullMethod]
+	if !ok {
+		return nil, status.Error(codes.PermissionDenied, "not allowed with api key")
+	}
+	if scope != "" && !key.HasScope(scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "api key has no %s scope", scope)
+	}
+
+	ctx = context.WithValue(ctx, keyUserID, key.UserID)
+	ctx = context.WithValue(ctx, keyAPIKey, key)
+
+	return c.authorize(ctx, req, info, handler, key.UserID)
+}
+
+// authorize выполнит вызов, если пользователю он доступен, и запишет роль пользователя в контекст
+func (c *grpcController) authorize(ctx context.Context, req interface{},
+	info *grpc.UnaryServerInfo, handler grpc.UnaryHandler, userID string) (interface{}, error) {
+	user, err := c.checkAccess(ctx, info.FullMethod, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	ctx = context.WithValue(ctx, keyUserID, userID)
+	ctx = context.WithValue(ctx, keyUser, user)
+	ctx, err = c.authorizeWorkspace(ctx, info.FullMethod, userID)
+	if err != nil {
+		return nil, err
+	}
 
 	return handler(ctx, req)
 }
 
-func (c *grpcController) setAuthorizationMetadata(ctx context.Context) (string, error) {
-	var err error
-	var userID string
+// authorizeWorkspace переключит вызов на ссылки рабочего пространства из метаданных X-Workspace-ID,
+// если у пользователя есть в нём нужные методу права
+func (c *grpcController) authorizeWorkspace(ctx context.Context,
+	fullMethod string, userID string) (context.Context, error) {
+	role, ok := workspaceMethodRoles[fullMethod]
+	if !ok {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	sl := md.Get(metadataWorkspaceID)
+	if len(sl) == 0 || sl[0] == "" {
+		return ctx, nil
+	}
 
-	userID, err = c.ucAuth.GetNewUserID(ctx)
+	err := c.ucWS.Authorize(ctx, userID, sl[0], role)
+	if errors.Is(err, usecaseWorkspace.ErrWorkspaceNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, usecaseWorkspace.ErrForbidden) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
+	if err != nil {
+		logger.Log.Error("can not authorize workspace: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "")
+	}
+
+	return context.WithValue(ctx, keyWorkspaceID, sl[0]), nil
+}
+
+// checkAccess проверит, что пользователь не отключён, а методы администратора вызывает администратор
+// из доверенной подсети и с доверенным клиентским сертификатом, если они заданы
+func (c *grpcController) checkAccess(ctx context.Context, fullMethod string, userID string) (*modelAuth.User, error) {
+	user, err := c.ucAuth.Authorize(ctx, userID)
+	if errors.Is(err, usecaseAuth.ErrUserDisabled) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	}
 	if err != nil {
-		logger.Log.Error("can not get new user ID: ", zap.Error(err))
-		return "", status.Error(codes.Internal, "")
+		logger.Log.Error("can not authorize user: ", zap.Error(err))
+		return nil, status.Error(codes.Internal, "")
+	}
+
+	if !isAdminMethod(fullMethod) {
+		return user, nil
+	}
+	if !user.HasRole(modelAuth.RoleAdmin) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s role required", modelAuth.RoleAdmin)
 	}
-	jwt, err := common.BuildJWTString(userID)
+	if err = c.checkTrustedSubnet(ctx); err != nil {
+		return nil, err
+	}
+	if err = c.checkTrustedClient(ctx); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// errTokenExpired ошибка истёкшего токена; отличается от неверного токена причиной в ErrorInfo
+func errTokenExpired() error {
+	st, err := status.New(codes.Unauthenticated, "token is expired").
+		WithDetails(&errdetails.ErrorInfo{Reason: reasonTokenExpired})
 	if err != nil {
-		logger.Log.Error("can not build JWT string: ", zap.Error(err))
-		return "", status.Error(codes.Internal, "")
+		return status.Error(codes.Unauthenticated, "token is expired")
 	}
-	bearerStr := fmt.Sprintf("Bearer %s", jwt)
-	grpc.SetHeader(ctx, metadata.New(map[string]string{"Authorization": bearerStr}))
+	return st.Err()
+}
+
+// adminServices сервисы, доступные только администраторам
+var adminServices = []string{
+	pb.StatsService_ServiceDesc.ServiceName,
+	pb.BackupService_ServiceDesc.ServiceName,
+	pb.AdminService_ServiceDesc.ServiceName,
+}
 
-	return userID, nil
+// streamInterceptorAuth пропускает потоковые вызовы сервисов adminServices только администраторам
+// с токеном доступа; новый пользователь для таких вызовов не создаётся
+func (c *grpcController) streamInterceptorAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if !isAdminMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	md, _ := metadata.FromIncomingContext(ss.Context())
+	if len(md.Get(metadataAPIKey)) > 0 {
+		return status.Error(codes.PermissionDenied, "not allowed with api key")
+	}
+	auth, err := c.authenticate(ss.Context(), md, anonymousPolicy(info.FullMethod))
+	if err != nil {
+		return err
+	}
+
+	if _, err = c.checkAccess(ss.Context(), info.FullMethod, auth.UserID); err != nil {
+		return err
+	}
+
+	return handler(srv, ss)
+}
+
+// checkTrustedSubnet проверит, что вызов сделан с IP-адреса из доверенной подсети;
+// адрес передаётся в метаданных X-Real-IP. Если подсеть не задана, проверка не выполняется
+func (c *grpcController) checkTrustedSubnet(ctx context.Context) error {
+	if c.conf.TrustedSubnets == "" {
+		return nil
+	}
+
+	var ipStr string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if sl := md.Get("X-Real-IP"); len(sl) > 0 {
+			ipStr = sl[0]
+		}
+	}
+
+	ok, err := common.IsTrustedIP(c.conf.TrustedSubnets, ipStr)
+	if err != nil {
+		logger.Log.Error("interceptor trusted subnet error: ", zap.Error(err))
+		return status.Error(codes.Internal, "")
+	}
+	if !ok {
+		return status.Error(codes.PermissionDenied, "access denied")
+	}
+
+	return nil
+}
+
+// checkTrustedClient проверит, что клиент предъявил доверенный сертификат;
+// если удостоверяющий центр клиентов не задан, проверка не выполняется
+func (c *grpcController) checkTrustedClient(ctx context.Context) error {
+	if c.conf.TLSClientCAFile == "" {
+		return nil
+	}
+
+	var state *tls.ConnectionState
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+	if !common.IsTrustedClient(c.conf.TrustedClients, state) {
+		return status.Error(codes.PermissionDenied, "trusted client certificate required")
+	}
+
+	return nil
+}
+
+func isAdminMethod(fullMethod string) bool {
+	for _, service := range adminServices {
+		if strings.HasPrefix(fullMethod, "/"+service+"/") {
+			return true
+		}
+	}
+	return false
 }
 
 // interceptorRequestTime замеряет время запроса
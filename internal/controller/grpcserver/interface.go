@@ -2,18 +2,25 @@ package grpcserver
 
 import (
 	"context"
+	"io"
+	"time"
 
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
 	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
 )
 
 type UseCaseShortener interface {
 	GetURLByID(ctx context.Context, urlID string) (string, error)
-	SaveURL(ctx context.Context, url string, userID string) (string, error)
+	SaveURL(ctx context.Context, url string, owner model.Owner) (string, error)
 	SaveURLsBatch(ctx context.Context,
-		request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error)
-	GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error)
-	DeleteURLs(ctx context.Context, userID string, urlsIDs []string) error
+		request []model.CreateShortenURLBatchItemRequest, owner model.Owner) ([]model.CreateShortenURLBatchItemResponse, error)
+	GetUserURLs(ctx context.Context, owner model.Owner) ([]model.GetUserURLsItemResponse, error)
+	DeleteURLs(ctx context.Context, owner model.Owner, urlsIDs []string) error
+	TakeDownURL(ctx context.Context, id string) error
 }
 
 type UseCasePinger interface {
@@ -22,8 +29,46 @@ type UseCasePinger interface {
 
 type useCaseAuther interface {
 	GetNewUserID(ctx context.Context) (string, error)
+	StartSession(ctx context.Context, userID string, userAgent string) (*modelAuth.IssuedRefreshToken, error)
+	SessionActive(ctx context.Context, userID string, sessionID string) (bool, error)
+	Refresh(ctx context.Context, refreshToken string, userAgent string) (*modelAuth.IssuedRefreshToken, error)
+	GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error)
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
+	RevokeUserSessions(ctx context.Context, userID string) error
+	Register(ctx context.Context, userID string, email string, password string) error
+	Login(ctx context.Context, email string, password string) (string, error)
+	CreateAPIKey(ctx context.Context, userID string, name string, scopes []string) (string, *modelAuth.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID string, id string) error
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (*modelAuth.APIKey, error)
+	Authorize(ctx context.Context, userID string) (*modelAuth.User, error)
+	ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error)
+	UpdateUser(ctx context.Context, userID string, request modelAuth.UpdateUserRequest) (*modelAuth.User, error)
+}
+
+type useCaseWorkspace interface {
+	CreateWorkspace(ctx context.Context, userID string, name string) (*modelWorkspace.Membership, error)
+	GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error)
+	Authorize(ctx context.Context, userID string, workspaceID string, role string) error
+	GetMembers(ctx context.Context, userID string, workspaceID string) ([]modelWorkspace.Member, error)
+	SetMember(ctx context.Context,
+		userID string, workspaceID string, memberID string, role string) (*modelWorkspace.Member, error)
+	RemoveMember(ctx context.Context, userID string, workspaceID string, memberID string) error
+	GetStats(ctx context.Context, userID string, workspaceID string) (*modelWorkspace.StatsResponse, error)
+}
+
+type tokenManager interface {
+	BuildAccessToken(userID string) (string, time.Time, error)
+	BuildSessionAccessToken(userID string, sessionID string) (string, time.Time, error)
+	ValidateAndGetUserID(tokenString string) (string, error)
+	ValidateAccessToken(tokenString string) (*common.Claims, error)
 }
 
 type UseCaseStats interface {
 	GetStats(ctx context.Context) (*modelStats.StatsResponse, error)
 }
+
+type UseCaseBackup interface {
+	Backup(ctx context.Context, w io.Writer) (*snapshot.Summary, error)
+	Restore(ctx context.Context, r io.Reader) (*snapshot.Summary, error)
+}
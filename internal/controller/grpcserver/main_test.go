@@ -8,22 +8,19 @@ import (
 	"time"
 
 	"github.com/KartoonYoko/go-url-shortener/config"
-	"github.com/google/uuid"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	ucAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	ucWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
 	controller           *grpcController
 	bootstrapAddressgRPC string
+	authRepo             *inmr.InMemoryRepo
 )
 
-// auther - это реализация useCaseAuther, которая позволяет вызывать GetNewUserID сколь угодно раз
-type auther struct{}
-
-func (a *auther) GetNewUserID(ctx context.Context) (string, error) {
-	id := uuid.New()
-	return id.String(), nil
-}
-
 func TestMain(m *testing.M) {
 	bootstrapAddressgRPC = ":8080"
 	ctx, cancel := context.WithCancel(context.Background())
@@ -51,8 +48,16 @@ func createTestMock() *grpcController {
 		BootstrapAddressgRPC: bootstrapAddressgRPC,
 		BaseURLAddress:       "http://localhost:8080",
 	}
-	auther := new(auther)
-	c := NewGRPCController(conf, nil, nil, auther, nil)
+	authRepo = inmr.NewInMemoryRepo()
+	auther := ucAuth.NewAuthUseCase(authRepo)
+	if err := auther.SetPasswordHashCost(bcrypt.MinCost); err != nil {
+		log.Fatal(err)
+	}
+	tokens, err := common.NewRandomJWTManager()
+	if err != nil {
+		log.Fatal(err)
+	}
+	c := NewGRPCController(conf, nil, nil, auther, nil, nil, ucWorkspace.New(authRepo), tokens)
 
 	return c
 }
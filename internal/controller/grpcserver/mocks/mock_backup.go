@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver (interfaces: UseCaseBackup)
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod --destination=./internal/controller/grpcserver/mocks/mock_backup.go --package=mocks github.com/KartoonYoko/go-url-shortener/internal/controller/grpcserver UseCaseBackup
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	snapshot "github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUseCaseBackup is a mock of UseCaseBackup interface.
+type MockUseCaseBackup struct {
+	ctrl     *gomock.Controller
+	recorder *MockUseCaseBackupMockRecorder
+}
+
+// MockUseCaseBackupMockRecorder is the mock recorder for MockUseCaseBackup.
+type MockUseCaseBackupMockRecorder struct {
+	mock *MockUseCaseBackup
+}
+
+// NewMockUseCaseBackup creates a new mock instance.
+func NewMockUseCaseBackup(ctrl *gomock.Controller) *MockUseCaseBackup {
+	mock := &MockUseCaseBackup{ctrl: ctrl}
+	mock.recorder = &MockUseCaseBackupMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUseCaseBackup) EXPECT() *MockUseCaseBackupMockRecorder {
+	return m.recorder
+}
+
+// Backup mocks base method.
+func (m *MockUseCaseBackup) Backup(arg0 context.Context, arg1 io.Writer) (*snapshot.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backup", arg0, arg1)
+	ret0, _ := ret[0].(*snapshot.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backup indicates an expected call of Backup.
+func (mr *MockUseCaseBackupMockRecorder) Backup(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backup", reflect.TypeOf((*MockUseCaseBackup)(nil).Backup), arg0, arg1)
+}
+
+// Restore mocks base method.
+func (m *MockUseCaseBackup) Restore(arg0 context.Context, arg1 io.Reader) (*snapshot.Summary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", arg0, arg1)
+	ret0, _ := ret[0].(*snapshot.Summary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUseCaseBackupMockRecorder) Restore(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUseCaseBackup)(nil).Restore), arg0, arg1)
+}
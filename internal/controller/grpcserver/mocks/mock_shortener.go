@@ -41,7 +41,7 @@ func (m *MockUseCaseShortener) EXPECT() *MockUseCaseShortenerMockRecorder {
 }
 
 // DeleteURLs mocks base method.
-func (m *MockUseCaseShortener) DeleteURLs(arg0 context.Context, arg1 string, arg2 []string) error {
+func (m *MockUseCaseShortener) DeleteURLs(arg0 context.Context, arg1 shortener.Owner, arg2 []string) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "DeleteURLs", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
@@ -70,7 +70,7 @@ func (mr *MockUseCaseShortenerMockRecorder) GetURLByID(arg0, arg1 any) *gomock.C
 }
 
 // GetUserURLs mocks base method.
-func (m *MockUseCaseShortener) GetUserURLs(arg0 context.Context, arg1 string) ([]shortener.GetUserURLsItemResponse, error) {
+func (m *MockUseCaseShortener) GetUserURLs(arg0 context.Context, arg1 shortener.Owner) ([]shortener.GetUserURLsItemResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetUserURLs", arg0, arg1)
 	ret0, _ := ret[0].([]shortener.GetUserURLsItemResponse)
@@ -85,7 +85,7 @@ func (mr *MockUseCaseShortenerMockRecorder) GetUserURLs(arg0, arg1 any) *gomock.
 }
 
 // SaveURL mocks base method.
-func (m *MockUseCaseShortener) SaveURL(arg0 context.Context, arg1, arg2 string) (string, error) {
+func (m *MockUseCaseShortener) SaveURL(arg0 context.Context, arg1 string, arg2 shortener.Owner) (string, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SaveURL", arg0, arg1, arg2)
 	ret0, _ := ret[0].(string)
@@ -100,7 +100,7 @@ func (mr *MockUseCaseShortenerMockRecorder) SaveURL(arg0, arg1, arg2 any) *gomoc
 }
 
 // SaveURLsBatch mocks base method.
-func (m *MockUseCaseShortener) SaveURLsBatch(arg0 context.Context, arg1 []shortener.CreateShortenURLBatchItemRequest, arg2 string) ([]shortener.CreateShortenURLBatchItemResponse, error) {
+func (m *MockUseCaseShortener) SaveURLsBatch(arg0 context.Context, arg1 []shortener.CreateShortenURLBatchItemRequest, arg2 shortener.Owner) ([]shortener.CreateShortenURLBatchItemResponse, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "SaveURLsBatch", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]shortener.CreateShortenURLBatchItemResponse)
@@ -113,3 +113,17 @@ func (mr *MockUseCaseShortenerMockRecorder) SaveURLsBatch(arg0, arg1, arg2 any)
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveURLsBatch", reflect.TypeOf((*MockUseCaseShortener)(nil).SaveURLsBatch), arg0, arg1, arg2)
 }
+
+// TakeDownURL mocks base method.
+func (m *MockUseCaseShortener) TakeDownURL(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TakeDownURL", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TakeDownURL indicates an expected call of TakeDownURL.
+func (mr *MockUseCaseShortenerMockRecorder) TakeDownURL(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TakeDownURL", reflect.TypeOf((*MockUseCaseShortener)(nil).TakeDownURL), arg0, arg1)
+}
@@ -0,0 +1,208 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v5.26.1
+// source: proto/backup.proto
+
+package proto
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	BackupService_Backup_FullMethodName  = "/proto.BackupService/Backup"
+	BackupService_Restore_FullMethodName = "/proto.BackupService/Restore"
+)
+
+// BackupServiceClient is the client API for BackupService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BackupServiceClient interface {
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (BackupService_BackupClient, error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (BackupService_RestoreClient, error)
+}
+
+type backupServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBackupServiceClient(cc grpc.ClientConnInterface) BackupServiceClient {
+	return &backupServiceClient{cc}
+}
+
+func (c *backupServiceClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (BackupService_BackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackupService_ServiceDesc.Streams[0], BackupService_Backup_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backupServiceBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BackupService_BackupClient interface {
+	Recv() (*BackupChunk, error)
+	grpc.ClientStream
+}
+
+type backupServiceBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *backupServiceBackupClient) Recv() (*BackupChunk, error) {
+	m := new(BackupChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *backupServiceClient) Restore(ctx context.Context, opts ...grpc.CallOption) (BackupService_RestoreClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BackupService_ServiceDesc.Streams[1], BackupService_Restore_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &backupServiceRestoreClient{stream}
+	return x, nil
+}
+
+type BackupService_RestoreClient interface {
+	Send(*RestoreChunk) error
+	CloseAndRecv() (*RestoreResponse, error)
+	grpc.ClientStream
+}
+
+type backupServiceRestoreClient struct {
+	grpc.ClientStream
+}
+
+func (x *backupServiceRestoreClient) Send(m *RestoreChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *backupServiceRestoreClient) CloseAndRecv() (*RestoreResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(RestoreResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BackupServiceServer is the server API for BackupService service.
+// All implementations must embed UnimplementedBackupServiceServer
+// for forward compatibility
+type BackupServiceServer interface {
+	Backup(*BackupRequest, BackupService_BackupServer) error
+	Restore(BackupService_RestoreServer) error
+	mustEmbedUnimplementedBackupServiceServer()
+}
+
+// UnimplementedBackupServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedBackupServiceServer struct {
+}
+
+func (UnimplementedBackupServiceServer) Backup(*BackupRequest, BackupService_BackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (UnimplementedBackupServiceServer) Restore(BackupService_RestoreServer) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (UnimplementedBackupServiceServer) mustEmbedUnimplementedBackupServiceServer() {}
+
+// UnsafeBackupServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BackupServiceServer will
+// result in compilation errors.
+type UnsafeBackupServiceServer interface {
+	mustEmbedUnimplementedBackupServiceServer()
+}
+
+func RegisterBackupServiceServer(s grpc.ServiceRegistrar, srv BackupServiceServer) {
+	s.RegisterService(&BackupService_ServiceDesc, srv)
+}
+
+func _BackupService_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BackupServiceServer).Backup(m, &backupServiceBackupServer{stream})
+}
+
+type BackupService_BackupServer interface {
+	Send(*BackupChunk) error
+	grpc.ServerStream
+}
+
+type backupServiceBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *backupServiceBackupServer) Send(m *BackupChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _BackupService_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(BackupServiceServer).Restore(&backupServiceRestoreServer{stream})
+}
+
+type BackupService_RestoreServer interface {
+	SendAndClose(*RestoreResponse) error
+	Recv() (*RestoreChunk, error)
+	grpc.ServerStream
+}
+
+type backupServiceRestoreServer struct {
+	grpc.ServerStream
+}
+
+func (x *backupServiceRestoreServer) SendAndClose(m *RestoreResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *backupServiceRestoreServer) Recv() (*RestoreChunk, error) {
+	m := new(RestoreChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BackupService_ServiceDesc is the grpc.ServiceDesc for BackupService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BackupService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.BackupService",
+	HandlerType: (*BackupServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Backup",
+			Handler:       _BackupService_Backup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _BackupService_Restore_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/backup.proto",
+}
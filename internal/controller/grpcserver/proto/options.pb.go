@@ -0,0 +1,190 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.1
+// 	protoc        v5.26.1
+// source: proto/options.proto
+
+package proto
+
+import (
+	reflect "reflect"
+	sync "sync"
+
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AnonymousPolicy что делать с вызовом без токена доступа
+type AnonymousPolicy int32
+
+const (
+	AnonymousPolicy_ANONYMOUS_POLICY_UNSPECIFIED AnonymousPolicy = 0 // методу пользователь не нужен: токен не проверяется, вызов идёт без пользователя
+	AnonymousPolicy_ANONYMOUS_POLICY_REJECT      AnonymousPolicy = 1 // отклонить вызов: у нового пользователя нечего запрашивать
+	AnonymousPolicy_ANONYMOUS_POLICY_NEW_USER    AnonymousPolicy = 2 // завести нового пользователя и выдать ему токены
+)
+
+// Enum value maps for AnonymousPolicy.
+var (
+	AnonymousPolicy_name = map[int32]string{
+		0: "ANONYMOUS_POLICY_UNSPECIFIED",
+		1: "ANONYMOUS_POLICY_REJECT",
+		2: "ANONYMOUS_POLICY_NEW_USER",
+	}
+	AnonymousPolicy_value = map[string]int32{
+		"ANONYMOUS_POLICY_UNSPECIFIED": 0,
+		"ANONYMOUS_POLICY_REJECT":      1,
+		"ANONYMOUS_POLICY_NEW_USER":    2,
+	}
+)
+
+func (x AnonymousPolicy) Enum() *AnonymousPolicy {
+	p := new(AnonymousPolicy)
+	*p = x
+	return p
+}
+
+func (x AnonymousPolicy) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AnonymousPolicy) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_options_proto_enumTypes[0].Descriptor()
+}
+
+func (AnonymousPolicy) Type() protoreflect.EnumType {
+	return &file_proto_options_proto_enumTypes[0]
+}
+
+func (x AnonymousPolicy) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AnonymousPolicy.Descriptor instead.
+func (AnonymousPolicy) EnumDescriptor() ([]byte, []int) {
+	return file_proto_options_proto_rawDescGZIP(), []int{0}
+}
+
+var file_proto_options_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*AnonymousPolicy)(nil),
+		Field:         50001,
+		Name:          "proto.anonymous",
+		Tag:           "varint,50001,opt,name=anonymous,enum=proto.AnonymousPolicy",
+		Filename:      "proto/options.proto",
+	},
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: (*bool)(nil),
+		Field:         50002,
+		Name:          "proto.unauthenticated",
+		Tag:           "varint,50002,opt,name=unauthenticated",
+		Filename:      "proto/options.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// anonymous политика метода для вызовов без токена доступа
+	//
+	// optional proto.AnonymousPolicy anonymous = 50001;
+	E_Anonymous = &file_proto_options_proto_extTypes[0]
+	// unauthenticated метод доступен без аутентификации: токен доступа не проверяется
+	//
+	// optional bool unauthenticated = 50002;
+	E_Unauthenticated = &file_proto_options_proto_extTypes[1]
+)
+
+var File_proto_options_proto protoreflect.FileDescriptor
+
+var file_proto_options_proto_rawDesc = []byte{
+	0x0a, 0x13, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x6f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x20, 0x67, 0x6f,
+	0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x65,
+	0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2a, 0x6f,
+	0x0a, 0x0f, 0x41, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63,
+	0x79, 0x12, 0x20, 0x0a, 0x1c, 0x41, 0x4e, 0x4f, 0x4e, 0x59, 0x4d, 0x4f, 0x55, 0x53, 0x5f, 0x50,
+	0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45,
+	0x44, 0x10, 0x00, 0x12, 0x1b, 0x0a, 0x17, 0x41, 0x4e, 0x4f, 0x4e, 0x59, 0x4d, 0x4f, 0x55, 0x53,
+	0x5f, 0x50, 0x4f, 0x4c, 0x49, 0x43, 0x59, 0x5f, 0x52, 0x45, 0x4a, 0x45, 0x43, 0x54, 0x10, 0x01,
+	0x12, 0x1d, 0x0a, 0x19, 0x41, 0x4e, 0x4f, 0x4e, 0x59, 0x4d, 0x4f, 0x55, 0x53, 0x5f, 0x50, 0x4f,
+	0x4c, 0x49, 0x43, 0x59, 0x5f, 0x4e, 0x45, 0x57, 0x5f, 0x55, 0x53, 0x45, 0x52, 0x10, 0x02, 0x3a,
+	0x56, 0x0a, 0x09, 0x61, 0x6e, 0x6f, 0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73, 0x12, 0x1e, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d,
+	0x65, 0x74, 0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xd1, 0x86, 0x03,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x41, 0x6e, 0x6f,
+	0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x52, 0x09, 0x61, 0x6e,
+	0x6f, 0x6e, 0x79, 0x6d, 0x6f, 0x75, 0x73, 0x3a, 0x4a, 0x0a, 0x0f, 0x75, 0x6e, 0x61, 0x75, 0x74,
+	0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61, 0x74, 0x65, 0x64, 0x12, 0x1e, 0x2e, 0x67, 0x6f, 0x6f,
+	0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x4d, 0x65, 0x74,
+	0x68, 0x6f, 0x64, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0xd2, 0x86, 0x03, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x0f, 0x75, 0x6e, 0x61, 0x75, 0x74, 0x68, 0x65, 0x6e, 0x74, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x64, 0x42, 0x4e, 0x5a, 0x4c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x4b, 0x61, 0x72, 0x74, 0x6f, 0x6f, 0x6e, 0x59, 0x6f, 0x6b, 0x6f, 0x2f, 0x67, 0x6f,
+	0x2d, 0x75, 0x72, 0x6c, 0x2d, 0x73, 0x68, 0x6f, 0x72, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x2f, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x6c,
+	0x65, 0x72, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x73, 0x65, 0x72, 0x76, 0x65, 0x72, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_proto_options_proto_rawDescOnce sync.Once
+	file_proto_options_proto_rawDescData = file_proto_options_proto_rawDesc
+)
+
+func file_proto_options_proto_rawDescGZIP() []byte {
+	file_proto_options_proto_rawDescOnce.Do(func() {
+		file_proto_options_proto_rawDescData = protoimpl.X.CompressGZIP(file_proto_options_proto_rawDescData)
+	})
+	return file_proto_options_proto_rawDescData
+}
+
+var file_proto_options_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_proto_options_proto_goTypes = []interface{}{
+	(AnonymousPolicy)(0),               // 0: proto.AnonymousPolicy
+	(*descriptorpb.MethodOptions)(nil), // 1: google.protobuf.MethodOptions
+}
+var file_proto_options_proto_depIdxs = []int32{
+	1, // 0: proto.anonymous:extendee -> google.protobuf.MethodOptions
+	1, // 1: proto.unauthenticated:extendee -> google.protobuf.MethodOptions
+	0, // 2: proto.anonymous:type_name -> proto.AnonymousPolicy
+	3, // [3:3] is the sub-list for method output_type
+	3, // [3:3] is the sub-list for method input_type
+	2, // [2:3] is the sub-list for extension type_name
+	0, // [0:2] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_proto_options_proto_init() }
+func file_proto_options_proto_init() {
+	if File_proto_options_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_proto_options_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   0,
+			NumExtensions: 2,
+			NumServices:   0,
+		},
+		GoTypes:           file_proto_options_proto_goTypes,
+		DependencyIndexes: file_proto_options_proto_depIdxs,
+		EnumInfos:         file_proto_options_proto_enumTypes,
+		ExtensionInfos:    file_proto_options_proto_extTypes,
+	}.Build()
+	File_proto_options_proto = out.File
+	file_proto_options_proto_rawDesc = nil
+	file_proto_options_proto_goTypes = nil
+	file_proto_options_proto_depIdxs = nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 	"github.com/stretchr/testify/require"
 )
 
@@ -13,12 +14,12 @@ func BenchmarkHandlerAPIUserURLsGET(b *testing.B) {
 	controller := createTestMock()
 	userID, err := controller.ucAuth.GetNewUserID(ctx)
 	require.NoError(b, err)
-	_, err = controller.uc.SaveURL(ctx, "https://music.yandex.ru/home", userID)
+	_, err = controller.uc.SaveURL(ctx, "https://music.yandex.ru/home", model.Owner{UserID: userID})
 	require.NoError(b, err)
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, err = controller.uc.GetUserURLs(ctx, userID)
+		_, err = controller.uc.GetUserURLs(ctx, model.Owner{UserID: userID})
 		require.NoError(b, err)
 	}
 }
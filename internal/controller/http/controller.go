@@ -5,11 +5,13 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/big"
 	"net"
@@ -20,20 +22,26 @@ import (
 	"time"
 
 	"github.com/KartoonYoko/go-url-shortener/config"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
 	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	"github.com/KartoonYoko/go-url-shortener/internal/oidc"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 type useCaseShortener interface {
 	GetURLByID(ctx context.Context, urlID string) (string, error)
-	SaveURL(ctx context.Context, url string, userID string) (string, error)
+	SaveURL(ctx context.Context, url string, owner model.Owner) (string, error)
 	SaveURLsBatch(ctx context.Context,
-		request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error)
-	GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error)
-	DeleteURLs(ctx context.Context, userID string, urlsIDs []string) error
+		request []model.CreateShortenURLBatchItemRequest, owner model.Owner) ([]model.CreateShortenURLBatchItemResponse, error)
+	GetUserURLs(ctx context.Context, owner model.Owner) ([]model.GetUserURLsItemResponse, error)
+	DeleteURLs(ctx context.Context, owner model.Owner, urlsIDs []string) error
+	TakeDownURL(ctx context.Context, id string) error
 }
 
 type useCasePinger interface {
@@ -42,19 +50,69 @@ type useCasePinger interface {
 
 type useCaseAuther interface {
 	GetNewUserID(ctx context.Context) (string, error)
+	StartSession(ctx context.Context, userID string, userAgent string) (*modelAuth.IssuedRefreshToken, error)
+	SessionActive(ctx context.Context, userID string, sessionID string) (bool, error)
+	Refresh(ctx context.Context, refreshToken string, userAgent string) (*modelAuth.IssuedRefreshToken, error)
+	GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error)
+	RevokeSession(ctx context.Context, userID string, sessionID string) error
+	RevokeUserSessions(ctx context.Context, userID string) error
+	Register(ctx context.Context, userID string, email string, password string) error
+	Login(ctx context.Context, email string, password string) (string, error)
+	LoginIdentity(ctx context.Context, identity modelAuth.Identity) (string, error)
+	CreateAPIKey(ctx context.Context, userID string, name string, scopes []string) (string, *modelAuth.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID string, id string) error
+	AuthenticateAPIKey(ctx context.Context, apiKey string) (*modelAuth.APIKey, error)
+	Authorize(ctx context.Context, userID string) (*modelAuth.User, error)
+	ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error)
+	UpdateUser(ctx context.Context, userID string, request modelAuth.UpdateUserRequest) (*modelAuth.User, error)
 }
 
 type useCaseStats interface {
 	GetStats(ctx context.Context) (*modelStats.StatsResponse, error)
 }
 
+type useCaseBackup interface {
+	Backup(ctx context.Context, w io.Writer) (*snapshot.Summary, error)
+	Restore(ctx context.Context, r io.Reader) (*snapshot.Summary, error)
+}
+
+type useCaseWorkspace interface {
+	CreateWorkspace(ctx context.Context, userID string, name string) (*modelWorkspace.Membership, error)
+	GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error)
+	Authorize(ctx context.Context, userID string, workspaceID string, role string) error
+	GetMembers(ctx context.Context, userID string, workspaceID string) ([]modelWorkspace.Member, error)
+	SetMember(ctx context.Context,
+		userID string, workspaceID string, memberID string, role string) (*modelWorkspace.Member, error)
+	RemoveMember(ctx context.Context, userID string, workspaceID string, memberID string) error
+	GetStats(ctx context.Context, userID string, workspaceID string) (*modelWorkspace.StatsResponse, error)
+}
+
+type tokenManager interface {
+	BuildAccessToken(userID string) (string, time.Time, error)
+	BuildSessionAccessToken(userID string, sessionID string) (string, time.Time, error)
+	ValidateAndGetUserID(tokenString string) (string, error)
+	ValidateAccessToken(tokenString string) (*common.Claims, error)
+}
+
+type oidcProvider interface {
+	AuthCodeURL(ctx context.Context) (*oidc.AuthRequest, error)
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*modelAuth.Identity, error)
+}
+
 type shortenerController struct {
-	uc      useCaseShortener
-	ucPing  useCasePinger
-	ucAuth  useCaseAuther
-	ucStats useCaseStats
-	router  *chi.Mux
-	conf    *config.Config
+	uc       useCaseShortener
+	ucPing   useCasePinger
+	ucAuth   useCaseAuther
+	ucStats  useCaseStats
+	ucBackup useCaseBackup
+	ucWS     useCaseWorkspace
+	tokens   tokenManager
+	auth     *common.Authenticator
+	oidc     oidcProvider
+	tls      *tls.Config
+	router   *chi.Mux
+	conf     *config.Config
 }
 
 // NewShortenerController собирает http контроллер, определяя endpoint'ы, middleware'ы
@@ -63,25 +121,31 @@ func NewShortenerController(
 	ucPing useCasePinger,
 	ucAuth useCaseAuther,
 	ucStats useCaseStats,
+	ucBackup useCaseBackup,
+	ucWS useCaseWorkspace,
+	tokens tokenManager,
 	conf *config.Config) *shortenerController {
 	c := &shortenerController{
-		uc:      uc,
-		ucAuth:  ucAuth,
-		ucPing:  ucPing,
-		ucStats: ucStats,
-		conf:    conf,
+		uc:       uc,
+		ucAuth:   ucAuth,
+		ucPing:   ucPing,
+		ucStats:  ucStats,
+		ucBackup: ucBackup,
+		ucWS:     ucWS,
+		tokens:   tokens,
+		auth:     common.NewAuthenticator(tokens, ucAuth),
+		conf:     conf,
 	}
 	r := chi.NewRouter()
 
 	// middlewares
 	r.Use(logRequestTimeMiddleware)
 	r.Use(decompressRequestGZIPMiddleware)
-	r.Use(c.authJWTCookieMiddleware)
 	r.Use(compressResponseGZIPMiddleware)
 	r.Use(logResponseInfoMiddleware)
 
 	// routes
-	r.Mount("/debug", middleware.Profiler())
+	r.With(c.authJWTCookieMiddleware(common.AnonymousAllow)).Mount("/debug", middleware.Profiler())
 	routeRoot(r, c)
 	routeAPI(r, c)
 	routePing(r, c)
@@ -90,29 +154,121 @@ func NewShortenerController(
 	return c
 }
 
+// SetOIDCProvider включит вход через поставщика удостоверений OpenID Connect
+func (c *shortenerController) SetOIDCProvider(provider oidcProvider) {
+	c.oidc = provider
+}
+
+// SetTLSConfig включит mTLS: сервер будет работать по HTTPS с настройками conf
+// вместо самоподписанного сертификата
+func (c *shortenerController) SetTLSConfig(conf *tls.Config) {
+	c.tls = conf
+}
+
+// Политика для запросов без токена доступа задаётся у каждого маршрута middleware'ом authJWTCookieMiddleware;
+// маршруты без него доступны без аутентификации
+
 func routeRoot(r *chi.Mux, c *shortenerController) {
-	r.Get("/favicon.ico", c.handlerFaviconGET)
-	r.Get("/{id}", c.handlerRootGET)
-	r.Post("/", c.handlerRootPOST)
+	r.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousAllow))
+
+		r.Get("/favicon.ico", c.handlerFaviconGET)
+		r.Get("/{id}", c.handlerRootGET)
+	})
+
+	r.With(c.authJWTCookieMiddleware(common.AnonymousNewUser),
+		requireScopeMiddleware(modelAuth.ScopeLinksWrite), c.workspaceMiddleware(modelWorkspace.RoleEditor)).
+		Post("/", c.handlerRootPOST)
 }
 
 func routeAPI(r *chi.Mux, c *shortenerController) {
 	apiRouter := chi.NewRouter()
 
 	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousNewUser))
+		r.Use(requireScopeMiddleware(modelAuth.ScopeLinksWrite))
+		r.Use(c.workspaceMiddleware(modelWorkspace.RoleEditor))
+
 		r.Post("/shorten", c.handlerAPIShortenPOST)
 		r.Post("/shorten/batch", c.handlerAPIShortenBatchPOST)
 	})
 
+	// вход и обновление токенов доступны без токена доступа
 	apiRouter.Group(func(r chi.Router) {
-		r.Get("/user/urls", c.handlerAPIUserURLsGET)
-		r.Delete("/user/urls", c.handlerAPIUserURLsDELETE)
+		r.Post("/auth/refresh", c.handlerAuthRefreshPOST)
+		r.Post("/auth/login", c.handlerAuthLoginPOST)
+		r.Get("/auth/oidc/login", c.handlerOIDCLoginGET)
+		r.Get("/auth/oidc/callback", c.handlerOIDCCallbackGET)
 	})
 
 	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousNewUser))
+		r.Use(denyAPIKeyMiddleware)
+
+		r.Post("/auth/register", c.handlerAuthRegisterPOST)
+	})
+
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousReject))
+		r.Use(denyAPIKeyMiddleware)
+
+		r.Post("/auth/logout", c.handlerAuthLogoutPOST)
+		r.Post("/auth/logout-all", c.handlerAuthLogoutAllPOST)
+		r.Get("/user/sessions", c.handlerSessionsGET)
+		r.Delete("/user/sessions/{id}", c.handlerSessionsDELETE)
+	})
+
+	apiRouter.Group(func(r chi.Router) {
+		r.With(c.authJWTCookieMiddleware(common.AnonymousReject),
+			requireScopeMiddleware(modelAuth.ScopeLinksRead), c.workspaceMiddleware(modelWorkspace.RoleViewer)).
+			Get("/user/urls", c.handlerAPIUserURLsGET)
+		r.With(c.authJWTCookieMiddleware(common.AnonymousNewUser),
+			requireScopeMiddleware(modelAuth.ScopeLinksWrite), c.workspaceMiddleware(modelWorkspace.RoleEditor)).
+			Delete("/user/urls", c.handlerAPIUserURLsDELETE)
+	})
+
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousReject))
+		r.Use(denyAPIKeyMiddleware)
+
+		r.Get("/workspaces", c.handlerWorkspacesGET)
+		r.Post("/workspaces", c.handlerWorkspacesPOST)
+		r.Get("/workspaces/{id}/members", c.handlerWorkspaceMembersGET)
+		r.Put("/workspaces/{id}/members/{userID}", c.handlerWorkspaceMemberPUT)
+		r.Delete("/workspaces/{id}/members/{userID}", c.handlerWorkspaceMemberDELETE)
+		r.Get("/workspaces/{id}/stats", c.handlerWorkspaceStatsGET)
+	})
+
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousReject))
+		r.Use(denyAPIKeyMiddleware)
+
+		r.Get("/user/api-keys", c.handlerAPIKeysGET)
+		r.Post("/user/api-keys", c.handlerAPIKeysPOST)
+		r.Delete("/user/api-keys/{id}", c.handlerAPIKeysDELETE)
+	})
+
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousReject))
+		r.Use(requireRoleMiddleware(modelAuth.RoleAdmin))
+		r.Use(c.guardIPMiddleware)
+		r.Use(c.guardClientCertMiddleware)
+
+		r.With(requireScopeMiddleware(modelAuth.ScopeStatsRead)).Get("/internal/stats", c.handlerStatsGET)
+		r.With(denyAPIKeyMiddleware).Get("/internal/backup", c.handlerBackupGET)
+		r.With(denyAPIKeyMiddleware).Post("/internal/restore", c.handlerRestorePOST)
+	})
+
+	apiRouter.Group(func(r chi.Router) {
+		r.Use(c.authJWTCookieMiddleware(common.AnonymousReject))
+		r.Use(denyAPIKeyMiddleware)
+		r.Use(requireRoleMiddleware(modelAuth.RoleAdmin))
 		r.Use(c.guardIPMiddleware)
+		r.Use(c.guardClientCertMiddleware)
 
-		r.Get("/internal/stats", c.handlerStatsGET)
+		r.Get("/admin/users", c.handlerAdminUsersGET)
+		r.Patch("/admin/users/{id}", c.handlerAdminUserPATCH)
+		r.Delete("/admin/urls/{id}", c.handlerAdminURLDELETE)
 	})
 
 	r.Mount("/api", apiRouter)
@@ -120,7 +276,7 @@ func routeAPI(r *chi.Mux, c *shortenerController) {
 
 func routePing(r *chi.Mux, c *shortenerController) {
 	pingRouter := chi.NewRouter()
-	pingRouter.Get("/", c.ping)
+	pingRouter.With(c.authJWTCookieMiddleware(common.AnonymousAllow)).Get("/", c.ping)
 
 	r.Mount("/ping", pingRouter)
 }
@@ -159,12 +315,22 @@ func (c *shortenerController) Serve(ctx context.Context) error {
 
 	// run server
 	logger.Log.Info(fmt.Sprintf("server serve on %s", server.Addr))
-	if c.conf.EnableHTTPS {
-		cert, key, err := createCert()
-		if err != nil {
-			return fmt.Errorf("create cert error: %w", err)
+	if c.tls != nil {
+		server.TLSConfig = c.tls
+		err := server.ListenAndServeTLS("", "")
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server serve error: %w", err)
+		}
+	} else if c.conf.EnableHTTPS {
+		cert, key := c.conf.TLSCertFile, c.conf.TLSKeyFile
+		if cert == "" {
+			var err error
+			cert, key, err = createCert()
+			if err != nil {
+				return fmt.Errorf("create cert error: %w", err)
+			}
 		}
-		err = server.ListenAndServeTLS(cert, key)
+		err := server.ListenAndServeTLS(cert, key)
 		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			return fmt.Errorf("server serve error: %w", err)
 		}
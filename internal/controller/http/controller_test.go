@@ -10,16 +10,22 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/KartoonYoko/go-url-shortener/config"
 	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 	"github.com/KartoonYoko/go-url-shortener/internal/repository"
 	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	ucAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	ucBackup "github.com/KartoonYoko/go-url-shortener/internal/usecase/backup"
 	ucShortener "github.com/KartoonYoko/go-url-shortener/internal/usecase/shortener"
+	ucWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
 	"github.com/go-resty/resty/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var (
@@ -47,20 +53,29 @@ func TearDownTest(t *testing.T) {
 // Пока непонятно как правильно инициализировать данные, поэтому пока так.
 func createTestMock() *shortenerController {
 	ucMock = &useCaseMock{
-		repo:           *inmr.NewInMemoryRepo(),
+		repo:           inmr.NewInMemoryRepo(),
 		baseAddressURL: "http://127.0.0.1:8080", // задаём любой URL, который попадёт под регулярку в тестах
 	}
-	c := NewShortenerController(ucMock, ucMock, ucMock, nil, &config.Config{})
+	tokens, err := common.NewRandomJWTManager()
+	if err != nil {
+		panic(err)
+	}
+	c := NewShortenerController(ucMock, ucMock, ucMock, nil, ucBackup.New(ucMock.repo), ucWorkspace.New(ucMock.repo), tokens, &config.Config{})
 	return c
 }
 
 type useCaseMock struct {
-	repo           inmr.InMemoryRepo
+	repo           *inmr.InMemoryRepo
 	baseAddressURL string
 }
 
-func (s *useCaseMock) SaveURL(ctx context.Context, url string, userID string) (string, error) {
-	id, err := s.repo.SaveURL(ctx, url, userID)
+func (s *useCaseMock) SaveURL(ctx context.Context, url string, owner model.Owner) (string, error) {
+	id, err := s.repo.SaveURL(ctx, url, owner.UserID)
+	if owner.IsWorkspace() && id != "" {
+		if errLink := s.repo.AddWorkspaceURLs(ctx, owner.WorkspaceID, []string{id}); errLink != nil {
+			return "", errLink
+		}
+	}
 	if err != nil {
 		var repoErrURLAlreadyExists *repository.URLAlreadyExistsError
 		if errors.As(err, &repoErrURLAlreadyExists) {
@@ -76,20 +91,116 @@ func (s *useCaseMock) GetURLByID(ctx context.Context, id string) (string, error)
 	return s.repo.GetURLByID(ctx, id)
 }
 
-func (s *useCaseMock) GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error) {
-	return s.repo.GetUserURLs(ctx, userID)
+func (s *useCaseMock) GetUserURLs(ctx context.Context, owner model.Owner) ([]model.GetUserURLsItemResponse, error) {
+	if owner.IsWorkspace() {
+		return s.repo.GetWorkspaceURLs(ctx, owner.WorkspaceID)
+	}
+	return s.repo.GetUserURLs(ctx, owner.UserID)
 }
 
 func (s *useCaseMock) SaveURLsBatch(ctx context.Context,
-	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
-	return s.repo.SaveURLsBatch(ctx, request, userID)
+	request []model.CreateShortenURLBatchItemRequest, owner model.Owner) ([]model.CreateShortenURLBatchItemResponse, error) {
+	response, err := s.repo.SaveURLsBatch(ctx, request, owner.UserID)
+	if err != nil || !owner.IsWorkspace() {
+		return response, err
+	}
+
+	ids := make([]string, 0, len(response))
+	for _, v := range response {
+		ids = append(ids, v.ShortURL)
+	}
+	return response, s.repo.AddWorkspaceURLs(ctx, owner.WorkspaceID, ids)
 }
 
 func (s *useCaseMock) GetNewUserID(ctx context.Context) (string, error) {
 	return s.repo.GetNewUserID(ctx)
 }
 
-func (s *useCaseMock) DeleteURLs(ctx context.Context, userID string, urlsIDs []string) error {
+func (s *useCaseMock) StartSession(ctx context.Context,
+	userID string, userAgent string) (*modelAuth.IssuedRefreshToken, error) {
+	return ucAuth.NewAuthUseCase(s.repo).StartSession(ctx, userID, userAgent)
+}
+
+func (s *useCaseMock) SessionActive(ctx context.Context, userID string, sessionID string) (bool, error) {
+	return ucAuth.NewAuthUseCase(s.repo).SessionActive(ctx, userID, sessionID)
+}
+
+func (s *useCaseMock) Refresh(ctx context.Context,
+	refreshToken string, userAgent string) (*modelAuth.IssuedRefreshToken, error) {
+	return ucAuth.NewAuthUseCase(s.repo).Refresh(ctx, refreshToken, userAgent)
+}
+
+func (s *useCaseMock) GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error) {
+	return ucAuth.NewAuthUseCase(s.repo).GetUserSessions(ctx, userID)
+}
+
+func (s *useCaseMock) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	return ucAuth.NewAuthUseCase(s.repo).RevokeSession(ctx, userID, sessionID)
+}
+
+func (s *useCaseMock) RevokeUserSessions(ctx context.Context, userID string) error {
+	return ucAuth.NewAuthUseCase(s.repo).RevokeUserSessions(ctx, userID)
+}
+
+func (s *useCaseMock) Register(ctx context.Context, userID string, email string, password string) error {
+	uc := ucAuth.NewAuthUseCase(s.repo)
+	if err := uc.SetPasswordHashCost(bcrypt.MinCost); err != nil {
+		return err
+	}
+	return uc.Register(ctx, userID, email, password)
+}
+
+func (s *useCaseMock) Login(ctx context.Context, email string, password string) (string, error) {
+	uc := ucAuth.NewAuthUseCase(s.repo)
+	if err := uc.SetPasswordHashCost(bcrypt.MinCost); err != nil {
+		return "", err
+	}
+	return uc.Login(ctx, email, password)
+}
+
+func (s *useCaseMock) LoginIdentity(ctx context.Context, identity modelAuth.Identity) (string, error) {
+	return ucAuth.NewAuthUseCase(s.repo).LoginIdentity(ctx, identity)
+}
+
+func (s *useCaseMock) CreateAPIKey(ctx context.Context,
+	userID string, name string, scopes []string) (string, *modelAuth.APIKey, error) {
+	return ucAuth.NewAuthUseCase(s.repo).CreateAPIKey(ctx, userID, name, scopes)
+}
+
+func (s *useCaseMock) GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error) {
+	return ucAuth.NewAuthUseCase(s.repo).GetUserAPIKeys(ctx, userID)
+}
+
+func (s *useCaseMock) RevokeAPIKey(ctx context.Context, userID string, id string) error {
+	return ucAuth.NewAuthUseCase(s.repo).RevokeAPIKey(ctx, userID, id)
+}
+
+func (s *useCaseMock) AuthenticateAPIKey(ctx context.Context, apiKey string) (*modelAuth.APIKey, error) {
+	return ucAuth.NewAuthUseCase(s.repo).AuthenticateAPIKey(ctx, apiKey)
+}
+
+func (s *useCaseMock) Authorize(ctx context.Context, userID string) (*modelAuth.User, error) {
+	return ucAuth.NewAuthUseCase(s.repo).Authorize(ctx, userID)
+}
+
+func (s *useCaseMock) ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error) {
+	return ucAuth.NewAuthUseCase(s.repo).ListUsers(ctx, afterID, limit)
+}
+
+func (s *useCaseMock) UpdateUser(ctx context.Context,
+	userID string, request modelAuth.UpdateUserRequest) (*modelAuth.User, error) {
+	return ucAuth.NewAuthUseCase(s.repo).UpdateUser(ctx, userID, request)
+}
+
+func (s *useCaseMock) TakeDownURL(ctx context.Context, id string) error {
+	err := s.repo.TakeDownURL(ctx, id)
+	if errors.Is(err, repository.ErrNotFoundKey) {
+		return ucShortener.ErrURLNotFound
+	}
+	return err
+}
+
+func (s *useCaseMock) DeleteURLs(ctx context.Context, owner model.Owner, urlsIDs []string) error {
 	return nil
 }
 
@@ -371,7 +482,7 @@ func TestGet(t *testing.T) {
 		{urlID: "", url: "https://gist.github.com/brydavis/0c7da92bd508195744708eeb2b54ac96"},
 	}
 	for i, urc := range urlsToCheck {
-		urc.urlID, _ = controller.uc.SaveURL(ctx, urc.url, "some user id")
+		urc.urlID, _ = controller.uc.SaveURL(ctx, urc.url, model.Owner{UserID: "some user id"})
 		tests = append(tests, testData{
 			name:    fmt.Sprintf("Positive request #%d", i+1),
 			urlData: urc,
@@ -401,6 +512,8 @@ func TestGet(t *testing.T) {
 			statusErr := assert.Equal(t, test.want.code, res.StatusCode())
 			// проверяем url
 			urlErr := assert.Equal(t, test.urlData.url, res.Header().Get("Location"))
+			// переход по ссылке не заводит пользователя
+			assert.Empty(t, res.Header().Values("Set-Cookie"))
 
 			if !statusErr || !urlErr {
 				t.Logf("Requested url: %s", res.Request.URL)
@@ -510,9 +623,9 @@ func auth(t *testing.T, jar *cookiejar.Jar) {
 	pURL, err := url.Parse(srv.URL)
 	require.NoError(t, err)
 
-	jwt, err := common.BuildJWTString(userID)
+	jwt, _, err := controller.tokens.BuildAccessToken(userID)
 	require.NoError(t, err)
 	bearerStr := fmt.Sprintf("Bearer %s", jwt)
-	cookie := createAuthCookie(bearerStr)
+	cookie := createAuthCookie(bearerStr, time.Now().Add(time.Hour))
 	jar.SetCookies(pURL, []*http.Cookie{&cookie})
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/KartoonYoko/go-url-shortener/config"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
 	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
 )
 
@@ -13,10 +14,14 @@ func Example() {
 	defer cancel()
 
 	uc := &useCaseMock{
-		repo:           *inmr.NewInMemoryRepo(),
+		repo:           inmr.NewInMemoryRepo(),
 		baseAddressURL: "http://127.0.0.1:8080", // задаём любой URL, который попадёт под регулярку в тестах
 	}
-	c := NewShortenerController(uc, nil, uc, nil, &config.Config{})
+	tokens, err := common.NewRandomJWTManager()
+	if err != nil {
+		return
+	}
+	c := NewShortenerController(uc, nil, uc, nil, nil, nil, tokens, &config.Config{})
 
 	c.Serve(ctx)
 }
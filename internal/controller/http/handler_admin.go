@@ -0,0 +1,124 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	usecaseShortener "github.com/KartoonYoko/go-url-shortener/internal/usecase/shortener"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Эндпоинт с методом GET и путём /api/admin/users.
+// Возвращает страницу пользователей в порядке возрастания ID: после ID из параметра after,
+// не больше limit; если пользователей нет, отдаёт 204 No Content.
+func (c *shortenerController) handlerAdminUsersGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 0 {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	users, err := c.ucAuth.ListUsers(ctx, r.URL.Query().Get("after"), limit)
+	if err != nil {
+		logger.Log.Error("list users error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if len(users) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]modelAuth.UserResponse, 0, len(users))
+	for i := range users {
+		response = append(response, newUserResponse(&users[i]))
+	}
+	res, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Can not serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// Эндпоинт с методом PATCH и путём /api/admin/users/{id}.
+// Принимает JSON {"role": "admin", "disabled": true}; не переданные поля не меняются.
+func (c *shortenerController) handlerAdminUserPATCH(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusBadRequest)
+		return
+	}
+	var request modelAuth.UpdateUserRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	user, err := c.ucAuth.UpdateUser(ctx, chi.URLParam(r, "id"), request)
+	if errors.Is(err, usecaseAuth.ErrInvalidRole) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, usecaseAuth.ErrUserNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("update user error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(newUserResponse(user))
+	if err != nil {
+		http.Error(w, "Can not serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// Эндпоинт с методом DELETE и путём /api/admin/urls/{id}.
+// Удаляет сокращённый URL у всех его владельцев.
+func (c *shortenerController) handlerAdminURLDELETE(w http.ResponseWriter, r *http.Request) {
+	err := c.uc.TakeDownURL(r.Context(), chi.URLParam(r, "id"))
+	if errors.Is(err, usecaseShortener.ErrURLNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newUserResponse(user *modelAuth.User) modelAuth.UserResponse {
+	return modelAuth.UserResponse{
+		ID:       user.ID,
+		Role:     user.Role,
+		Disabled: user.Disabled,
+	}
+}
@@ -0,0 +1,172 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// authAdmin заведёт администратора, запишет его токен в jar и вернёт его ID
+func authAdmin(t *testing.T, jar *cookiejar.Jar) string {
+	userID := authUser(t, jar)
+	grantAdmin(t, userID)
+
+	return userID
+}
+
+// authUser заведёт пользователя, запишет его токен в jar и вернёт его ID
+func authUser(t *testing.T, jar *cookiejar.Jar) string {
+	userID, err := ucMock.GetNewUserID(context.Background())
+	require.NoError(t, err)
+
+	pURL, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	jwt, _, err := controller.tokens.BuildAccessToken(userID)
+	require.NoError(t, err)
+	cookie := createAuthCookie(fmt.Sprintf("Bearer %s", jwt), time.Now().Add(time.Hour))
+	jar.SetCookies(pURL, []*http.Cookie{&cookie})
+
+	return userID
+}
+
+// grantAdmin выдаст пользователю роль администратора, при необходимости восстановив его после очистки хранилища
+func grantAdmin(t *testing.T, userID string) {
+	ctx := context.Background()
+	require.NoError(t, ucMock.repo.ImportUsers(ctx, []snapshot.User{{ID: userID}}))
+	role := modelAuth.RoleAdmin
+	_, err := ucMock.UpdateUser(ctx, userID, modelAuth.UpdateUserRequest{Role: &role})
+	require.NoError(t, err)
+}
+
+func Test_shortenerController_handlerAdmin(t *testing.T) {
+	defer TearDownTest(t)
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	adminID := authAdmin(t, jar)
+	adminClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(jar)
+
+	userJar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	userClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(userJar)
+	res, err := userClient.R().SetBody("https://example.com/admin").Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	id := string(res.Body())
+
+	// обычному пользователю административные ручки недоступны
+	res, err = userClient.R().Get("/api/admin/users")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+	res, err = userClient.R().Delete("/api/admin/urls/" + id)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	res, err = adminClient.R().Get("/api/admin/users")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var users []modelAuth.UserResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &users))
+	require.Len(t, users, 2)
+	var userID string
+	for _, u := range users {
+		if u.ID != adminID {
+			userID = u.ID
+			assert.Equal(t, modelAuth.RoleUser, u.Role)
+		}
+	}
+	require.NotEmpty(t, userID)
+
+	res, err = adminClient.R().SetQueryParams(map[string]string{"after": users[0].ID, "limit": "1"}).Get("/api/admin/users")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	require.NoError(t, json.Unmarshal(res.Body(), &users))
+	require.Len(t, users, 1)
+
+	// снятая ссылка удаляется для всех
+	res, err = adminClient.R().Delete("/api/admin/urls/" + id)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+	res, err = adminClient.R().Delete("/api/admin/urls/unknown")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+	_, err = ucMock.GetURLByID(context.Background(), id)
+	assert.ErrorIs(t, err, repository.ErrURLDeleted)
+
+	// отключённый пользователь получает 403 на любой запрос
+	res, err = adminClient.R().SetBody(`{"disabled": true}`).Patch("/api/admin/users/" + userID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var user modelAuth.UserResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &user))
+	assert.Equal(t, modelAuth.UserResponse{ID: userID, Role: modelAuth.RoleUser, Disabled: true}, user)
+	res, err = userClient.R().Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	res, err = adminClient.R().SetBody(`{"role": "root"}`).Patch("/api/admin/users/" + userID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+	res, err = adminClient.R().SetBody(`{"disabled": false}`).Patch("/api/admin/users/unknown")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+}
+
+func Test_shortenerController_clientCert(t *testing.T) {
+	defer TearDownTest(t)
+
+	controller.conf.TLSClientCAFile = "ca.pem"
+	controller.conf.TrustedClients = []string{"ops"}
+	defer func() {
+		controller.conf.TLSClientCAFile = ""
+		controller.conf.TrustedClients = nil
+	}()
+
+	userID, err := ucMock.GetNewUserID(context.Background())
+	require.NoError(t, err)
+	grantAdmin(t, userID)
+	token, _, err := controller.tokens.BuildAccessToken(userID)
+	require.NoError(t, err)
+
+	clientState := func(commonName string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	}
+	tests := []struct {
+		name  string
+		state *tls.ConnectionState
+		want  int
+	}{
+		{name: "Trusted client", state: clientState("ops"), want: http.StatusOK},
+		{name: "Untrusted client", state: clientState("web"), want: http.StatusForbidden},
+		{name: "Without certificate", state: &tls.ConnectionState{}, want: http.StatusForbidden},
+		{name: "Without TLS", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			r.Header.Set("Authorization", common.FormatBearer(token))
+			r.TLS = tt.state
+			w := httptest.NewRecorder()
+
+			controller.router.ServeHTTP(w, r)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Эндпоинт с методом POST и путём /api/user/api-keys.
+// Принимает JSON {"name": "ci", "scopes": ["links:write", "links:read"]} и создаёт API-ключ пользователя;
+// сам ключ возвращается в поле key только в этом ответе.
+func (c *shortenerController) handlerAPIKeysPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusBadRequest)
+		return
+	}
+	var request modelAuth.CreateAPIKeyRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	apiKey, key, err := c.ucAuth.CreateAPIKey(ctx, userID, request.Name, request.Scopes)
+	if errors.Is(err, usecaseAuth.ErrInvalidScopes) || errors.Is(err, usecaseAuth.ErrInvalidAPIKeyName) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("create api key error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := json.Marshal(modelAuth.CreateAPIKeyResponse{
+		APIKeyResponse: newAPIKeyResponse(key),
+		Key:            apiKey,
+	})
+	if err != nil {
+		http.Error(w, "Can not serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	w.Write(res)
+}
+
+// Эндпоинт с методом GET и путём /api/user/api-keys.
+// Возвращает API-ключи пользователя без секретной части; если ключей нет, отдаёт 204 No Content.
+func (c *shortenerController) handlerAPIKeysGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	keys, err := c.ucAuth.GetUserAPIKeys(ctx, userID)
+	if err != nil {
+		logger.Log.Error("get api keys error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if len(keys) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]modelAuth.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		response = append(response, newAPIKeyResponse(&keys[i]))
+	}
+	res, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Can not serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// Эндпоинт с методом DELETE и путём /api/user/api-keys/{id}.
+// Отзывает API-ключ пользователя; запросы с ним сразу перестают приниматься.
+func (c *shortenerController) handlerAPIKeysDELETE(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = c.ucAuth.RevokeAPIKey(ctx, userID, chi.URLParam(r, "id"))
+	if errors.Is(err, usecaseAuth.ErrAPIKeyNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("revoke api key error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newAPIKeyResponse(key *modelAuth.APIKey) modelAuth.APIKeyResponse {
+	return modelAuth.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
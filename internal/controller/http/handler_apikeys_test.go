@@ -0,0 +1,105 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_shortenerController_handlerAPIKeys(t *testing.T) {
+	defer TearDownTest(t)
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	httpClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(jar)
+
+	res, err := httpClient.R().SetBody("https://example.com/api-key").Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	shortURL := string(res.Body())
+
+	res, err = httpClient.R().Get("/api/user/api-keys")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+
+	res, err = httpClient.R().
+		SetBody(modelAuth.CreateAPIKeyRequest{Name: "bot", Scopes: []string{modelAuth.ScopeLinksRead}}).
+		Post("/api/user/api-keys")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	var created modelAuth.CreateAPIKeyResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &created))
+	require.NotEmpty(t, created.Key)
+	assert.Equal(t, "bot", created.Name)
+
+	// ключ действует от имени пользователя в пределах своих прав и не получает куки
+	keyClient := resty.New().SetBaseURL(srv.URL).SetHeader(headerAPIKey, created.Key)
+	res, err = keyClient.R().Get("/api/user/urls")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Contains(t, string(res.Body()), shortURL)
+	assert.Empty(t, res.Cookies())
+
+	res, err = keyClient.R().SetBody("https://example.com/forbidden").Post("/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	// управлять ключами по API-ключу нельзя
+	res, err = keyClient.R().Get("/api/user/api-keys")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	res, err = httpClient.R().Get("/api/user/api-keys")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var keys []modelAuth.APIKeyResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &keys))
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.ID, keys[0].ID)
+	assert.NotNil(t, keys[0].LastUsedAt)
+	assert.NotContains(t, string(res.Body()), created.Key)
+
+	// отозванный ключ не принимается
+	res, err = httpClient.R().Delete("/api/user/api-keys/" + created.ID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+	res, err = keyClient.R().Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	res, err = httpClient.R().Delete("/api/user/api-keys/" + created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+}
+
+func Test_shortenerController_handlerAPIKeysPOST_Invalid(t *testing.T) {
+	defer TearDownTest(t)
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	authUser(t, jar)
+	httpClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(jar)
+
+	tests := []struct {
+		name string
+		body interface{}
+	}{
+		{name: "invalid body", body: "{"},
+		{name: "no scopes", body: modelAuth.CreateAPIKeyRequest{Name: "bot"}},
+		{name: "unknown scope", body: modelAuth.CreateAPIKeyRequest{Scopes: []string{"links:admin"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := httpClient.R().SetBody(tt.body).Post("/api/user/api-keys")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+		})
+	}
+
+	res, err := httpClient.R().SetHeader(headerAPIKey, "unknown.secret").Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	"go.uber.org/zap"
+)
+
+// Эндпоинт с методом POST и путём /api/auth/refresh.
+// Принимает токен обновления в JSON {"refresh_token": "..."} или в куке Refresh-Token
+// и возвращает новую пару токенов; переданный токен обновления больше не принимается.
+func (c *shortenerController) handlerAuthRefreshPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var request modelAuth.RefreshRequest
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusBadRequest)
+		return
+	}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &request); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if request.RefreshToken == "" {
+		if cookie, err := r.Cookie(cookieRefreshToken); err == nil {
+			request.RefreshToken = cookie.Value
+		}
+	}
+
+	refreshToken, err := c.ucAuth.Refresh(ctx, request.RefreshToken, r.UserAgent())
+	if errors.Is(err, usecaseAuth.ErrInvalidRefreshToken) {
+		http.Error(w, "refresh token is invalid", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("refresh token error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := c.auth.BuildTokens(refreshToken)
+	if err != nil {
+		logger.Log.Error("build access token error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeTokens(w, tokens)
+}
+
+// Эндпоинт с методом POST и путём /api/auth/register.
+// Принимает JSON {"email": "...", "password": "..."} и заводит учётную запись текущему пользователю:
+// ссылки, сокращённые им до регистрации, остаются за учётной записью.
+func (c *shortenerController) handlerAuthRegisterPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	request, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	err = c.ucAuth.Register(ctx, userID, request.Email, request.Password)
+	if errors.Is(err, usecaseAuth.ErrInvalidEmail) || errors.Is(err, usecaseAuth.ErrInvalidPassword) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, usecaseAuth.ErrAccountExists) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("register error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// Эндпоинт с методом POST и путём /api/auth/login.
+// Принимает JSON {"email": "...", "password": "..."} и возвращает токены пользователя учётной записи;
+// с ними на любом устройстве доступны его ссылки.
+func (c *shortenerController) handlerAuthLoginPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	request, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+
+	userID, err := c.ucAuth.Login(ctx, request.Email, request.Password)
+	if errors.Is(err, usecaseAuth.ErrInvalidCredentials) {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("login error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := c.auth.IssueTokens(ctx, userID, r.UserAgent())
+	if err != nil {
+		logger.Log.Error("issue tokens error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeTokens(w, tokens)
+}
+
+// readCredentials прочитает почту и пароль из тела запроса; при ошибке ответ уже записан
+func readCredentials(w http.ResponseWriter, r *http.Request) (*modelAuth.CredentialsRequest, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusBadRequest)
+		return nil, false
+	}
+
+	request := new(modelAuth.CredentialsRequest)
+	if err = json.Unmarshal(body, request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	return request, true
+}
+
+// writeTokens запишет токены в куки и в тело ответа
+func writeTokens(w http.ResponseWriter, tokens *modelAuth.TokensResponse) {
+	res, err := json.Marshal(tokens)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	setTokenCookies(w, tokens)
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
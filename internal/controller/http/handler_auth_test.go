@@ -0,0 +1,247 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/config"
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cookieValue вернёт значение куки из ответа
+func cookieValue(res *resty.Response, name string) string {
+	for _, cookie := range res.Cookies() {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func Test_shortenerController_handlerAuthRefreshPOST(t *testing.T) {
+	defer TearDownTest(t)
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	httpClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(jar)
+
+	// новый пользователь получает оба токена
+	res, err := httpClient.R().SetBody("https://example.com").Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	refreshToken := cookieValue(res, cookieRefreshToken)
+	require.NotEmpty(t, refreshToken)
+	userID, err := controller.tokens.ValidateAndGetUserID(cookieValue(res, cookieAuthorization)[len("Bearer "):])
+	require.NoError(t, err)
+
+	// токен обновления из куки
+	res, err = httpClient.R().Post(pathAuthRefresh)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var tokens modelAuth.TokensResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &tokens))
+	assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+	assert.Equal(t, tokens.RefreshToken, cookieValue(res, cookieRefreshToken))
+	refreshedUserID, err := controller.tokens.ValidateAndGetUserID(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, refreshedUserID)
+
+	// использованный токен обновления не принимается
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetBody(modelAuth.RefreshRequest{RefreshToken: refreshToken}).
+		Post(pathAuthRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+
+	// токен обновления в теле запроса
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetBody(modelAuth.RefreshRequest{RefreshToken: tokens.RefreshToken}).
+		Post(pathAuthRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode())
+}
+
+func Test_shortenerController_handlerAuthRefreshPOST_Invalid(t *testing.T) {
+	httpClient := resty.New().SetBaseURL(srv.URL)
+
+	res, err := httpClient.R().Post(pathAuthRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+
+	res, err = httpClient.R().SetBody("{").Post(pathAuthRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+}
+
+func Test_shortenerController_handlerAuthRegisterAndLogin(t *testing.T) {
+	defer TearDownTest(t)
+	credentials := modelAuth.CredentialsRequest{Email: "user@example.com", Password: "secret-password"}
+
+	// анонимный пользователь сокращает ссылку и регистрируется
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	httpClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(jar)
+	res, err := httpClient.R().SetBody("https://example.com/registered").Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	shortURL := string(res.Body())
+
+	res, err = httpClient.R().SetBody(credentials).Post(pathAuthRegister)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+
+	// повторная регистрация того же пользователя отклоняется
+	res, err = httpClient.R().
+		SetBody(modelAuth.CredentialsRequest{Email: "other@example.com", Password: "secret-password"}).
+		Post(pathAuthRegister)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, res.StatusCode())
+
+	// вход с другого устройства открывает ссылки учётной записи
+	otherJar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	otherClient := resty.New().SetBaseURL(srv.URL).SetCookieJar(otherJar)
+	res, err = otherClient.R().SetBody(credentials).Post(pathAuthLogin)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var tokens modelAuth.TokensResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &tokens))
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	res, err = otherClient.R().Get("/api/user/urls")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Contains(t, string(res.Body()), shortURL)
+}
+
+func Test_shortenerController_handlerAuthRegisterAndLogin_Invalid(t *testing.T) {
+	defer TearDownTest(t)
+	httpClient := resty.New().SetBaseURL(srv.URL)
+
+	res, err := httpClient.R().
+		SetBody(modelAuth.CredentialsRequest{Email: "user@example.com", Password: "secret-password"}).
+		Post(pathAuthRegister)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+
+	tests := []struct {
+		name       string
+		path       string
+		body       interface{}
+		wantStatus int
+	}{
+		{name: "register invalid body", path: pathAuthRegister, body: "{", wantStatus: http.StatusBadRequest},
+		{
+			name:       "register invalid email",
+			path:       pathAuthRegister,
+			body:       modelAuth.CredentialsRequest{Email: "user", Password: "secret-password"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "register short password",
+			path:       pathAuthRegister,
+			body:       modelAuth.CredentialsRequest{Email: "other@example.com", Password: "short"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "register email taken",
+			path:       pathAuthRegister,
+			body:       modelAuth.CredentialsRequest{Email: "user@example.com", Password: "secret-password"},
+			wantStatus: http.StatusConflict,
+		},
+		{name: "login invalid body", path: pathAuthLogin, body: "{", wantStatus: http.StatusBadRequest},
+		{
+			name:       "login wrong password",
+			path:       pathAuthLogin,
+			body:       modelAuth.CredentialsRequest{Email: "user@example.com", Password: "wrong-password"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "login unknown email",
+			path:       pathAuthLogin,
+			body:       modelAuth.CredentialsRequest{Email: "unknown@example.com", Password: "secret-password"},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := resty.New().SetBaseURL(srv.URL).R().SetBody(tt.body).Post(tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, res.StatusCode())
+		})
+	}
+}
+
+func Test_shortenerController_authJWTCookieMiddleware_Expired(t *testing.T) {
+	tokens, err := common.NewRandomJWTManager()
+	require.NoError(t, err)
+	tokens.SetAccessTokenTTL(-time.Minute)
+	c := NewShortenerController(ucMock, ucMock, ucMock, nil, nil, nil, tokens, &config.Config{})
+	expiredSrv := httptest.NewServer(c.router)
+	defer expiredSrv.Close()
+
+	expired, _, err := tokens.BuildAccessToken("user")
+	require.NoError(t, err)
+	other, err := common.NewRandomJWTManager()
+	require.NoError(t, err)
+	forged, _, err := other.BuildAccessToken("user")
+	require.NoError(t, err)
+
+	httpClient := resty.New().SetBaseURL(expiredSrv.URL)
+	res, err := httpClient.R().
+		SetCookie(&http.Cookie{Name: cookieAuthorization, Value: "Bearer " + expired}).
+		Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	assert.Contains(t, res.Header().Get("WWW-Authenticate"), "token expired")
+
+	res, err = httpClient.R().
+		SetCookie(&http.Cookie{Name: cookieAuthorization, Value: "Bearer " + forged}).
+		Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	assert.NotContains(t, res.Header().Get("WWW-Authenticate"), "token expired")
+}
+
+func Test_shortenerController_authJWTCookieMiddleware_Header(t *testing.T) {
+	defer TearDownTest(t)
+
+	res, err := resty.New().SetBaseURL(srv.URL).R().SetBody("https://example.com/header").Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	shortURL := string(res.Body())
+	accessToken := common.BearerToken(cookieValue(res, cookieAuthorization))
+
+	// токен доступа принимается в заголовке с любым регистром схемы и без неё
+	for _, value := range []string{"Bearer " + accessToken, "bearer " + accessToken, accessToken} {
+		res, err = resty.New().SetBaseURL(srv.URL).R().
+			SetHeader("Authorization", value).
+			Get("/api/user/urls")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode(), value)
+		assert.Contains(t, string(res.Body()), shortURL)
+		assert.Empty(t, cookieValue(res, cookieAuthorization))
+	}
+
+	// заголовок важнее куки
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetHeader("Authorization", "Bearer wrong").
+		SetCookie(&http.Cookie{Name: cookieAuthorization, Value: "Bearer " + accessToken}).
+		Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+
+	// без токена ссылки пользователя не запросить
+	res, err = resty.New().SetBaseURL(srv.URL).R().Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	assert.Equal(t, "Bearer", res.Header().Get("WWW-Authenticate"))
+}
@@ -0,0 +1,68 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository"
+	ucBackup "github.com/KartoonYoko/go-url-shortener/internal/usecase/backup"
+	"go.uber.org/zap"
+)
+
+// handlerBackupGET отдаёт снимок всех данных в виде gzip-файла
+func (c *shortenerController) handlerBackupGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filename := fmt.Sprintf("shortener-%s.jsonl.gz", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("content-type", "application/gzip")
+	w.Header().Set("content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	summary, err := c.ucBackup.Backup(ctx, w)
+	if err != nil {
+		// заголовки уже отправлены; обрываем ответ, чтобы клиент не принял неполный снимок
+		logger.Log.Error("backup error", zap.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+
+	logger.Log.Info("backup completed",
+		zap.Int("users", summary.Users),
+		zap.Int("urls", summary.URLs),
+		zap.Int("accounts", summary.Accounts),
+		zap.Int("identities", summary.Identities),
+		zap.Int("api_keys", summary.APIKeys),
+		zap.Int("workspaces", summary.Workspaces))
+}
+
+// handlerRestorePOST загружает в хранилище снимок из тела запроса
+func (c *shortenerController) handlerRestorePOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	summary, err := c.ucBackup.Restore(ctx, r.Body)
+	if err != nil {
+		logger.Log.Error("restore error", zap.Error(err))
+		switch {
+		case errors.Is(err, ucBackup.ErrInvalidSnapshot):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, repository.ErrImportConflict):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	res, err := json.Marshal(summary)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_shortenerController_handlerBackupGET(t *testing.T) {
+	defer TearDownTest(t)
+	ctx := context.Background()
+
+	controller.conf.TrustedSubnets = "192.168.1.0/24"
+	defer func() { controller.conf.TrustedSubnets = "" }()
+
+	_, err := ucMock.SaveURL(ctx, "https://example.com/backup", model.Owner{UserID: "user"})
+	require.NoError(t, err)
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	adminID := authAdmin(t, jar)
+	httpClient := resty.New().
+		SetBaseURL(srv.URL).
+		SetCookieJar(jar)
+
+	t.Run("Forbidden", func(t *testing.T) {
+		res, err := httpClient.R().
+			SetHeader("X-Real-IP", "10.0.0.1").
+			Get("/api/internal/backup")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+		res, err = httpClient.R().
+			SetHeader("X-Real-IP", "10.0.0.1").
+			SetBody([]byte("snapshot")).
+			Post("/api/internal/restore")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+		// из доверенной подсети без роли администратора
+		userJar, err := cookiejar.New(nil)
+		require.NoError(t, err)
+		authUser(t, userJar)
+		res, err = resty.New().SetBaseURL(srv.URL).SetCookieJar(userJar).R().
+			SetHeader("X-Real-IP", "192.168.1.10").
+			Get("/api/internal/backup")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, res.StatusCode())
+	})
+
+	t.Run("Backup and restore", func(t *testing.T) {
+		res, err := httpClient.R().
+			SetHeader("X-Real-IP", "192.168.1.10").
+			SetDoNotParseResponse(true).
+			Get("/api/internal/backup")
+		require.NoError(t, err)
+		defer res.RawBody().Close()
+		require.Equal(t, http.StatusOK, res.StatusCode())
+		assert.Equal(t, "application/gzip", res.Header().Get("Content-Type"))
+
+		data := new(bytes.Buffer)
+		_, err = data.ReadFrom(res.RawBody())
+		require.NoError(t, err)
+
+		require.NoError(t, ucMock.Clear(ctx))
+		grantAdmin(t, adminID)
+		res, err = httpClient.R().
+			SetHeader("X-Real-IP", "192.168.1.10").
+			SetHeader("Content-Type", "application/gzip").
+			SetBody(data.Bytes()).
+			Post("/api/internal/restore")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode())
+
+		summary := new(snapshot.Summary)
+		require.NoError(t, json.Unmarshal(res.Body(), summary))
+		assert.Equal(t, 1, summary.URLs)
+
+		urls, err := ucMock.GetUserURLs(ctx, model.Owner{UserID: "user"})
+		require.NoError(t, err)
+		require.Len(t, urls, 1)
+		assert.Equal(t, "https://example.com/backup", urls[0].OriginalURL)
+	})
+
+	t.Run("Invalid snapshot", func(t *testing.T) {
+		res, err := httpClient.R().
+			SetHeader("X-Real-IP", "192.168.1.10").
+			SetBody([]byte("not a snapshot")).
+			Post("/api/internal/restore")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+	})
+}
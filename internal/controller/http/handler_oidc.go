@@ -0,0 +1,148 @@
+package http
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"github.com/KartoonYoko/go-url-shortener/internal/oidc"
+	"go.uber.org/zap"
+)
+
+// cookieOIDCLogin кука с начатым входом через поставщика удостоверений
+const cookieOIDCLogin = "OIDC-Login"
+
+// oidcLoginTTL время, за которое нужно войти у поставщика удостоверений
+const oidcLoginTTL = 10 * time.Minute
+
+// oidcLogin одноразовые значения начатого входа; хранятся в куке браузера,
+// поэтому вернуться от поставщика можно на любой экземпляр сервиса
+type oidcLogin struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+// Эндпоинт с методом GET и путём /api/auth/oidc/login.
+// Начинает вход через поставщика удостоверений: перенаправляет на его страницу входа
+// с запросом кода авторизации и PKCE.
+func (c *shortenerController) handlerOIDCLoginGET(w http.ResponseWriter, r *http.Request) {
+	if c.oidc == nil {
+		http.Error(w, "oidc login is not configured", http.StatusNotFound)
+		return
+	}
+
+	request, err := c.oidc.AuthCodeURL(r.Context())
+	if err != nil {
+		logger.Log.Error("oidc auth url error: ", zap.Error(err))
+		http.Error(w, "identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	value, err := json.Marshal(oidcLogin{
+		State:        request.State,
+		Nonce:        request.Nonce,
+		CodeVerifier: request.CodeVerifier,
+	})
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	cookie := createOIDCLoginCookie(base64.RawURLEncoding.EncodeToString(value))
+	cookie.MaxAge = int(oidcLoginTTL.Seconds())
+	http.SetCookie(w, &cookie)
+
+	http.Redirect(w, r, request.URL, http.StatusFound)
+}
+
+// Эндпоинт с методом GET и путём /api/auth/oidc/callback.
+// Принимает код авторизации от поставщика удостоверений и выдаёт токены пользователя,
+// к которому привязано удостоверение; при первом входе заводится новый пользователь.
+func (c *shortenerController) handlerOIDCCallbackGET(w http.ResponseWriter, r *http.Request) {
+	if c.oidc == nil {
+		http.Error(w, "oidc login is not configured", http.StatusNotFound)
+		return
+	}
+	ctx := r.Context()
+	query := r.URL.Query()
+
+	login, ok := readOIDCLogin(r)
+	// начатый вход одноразовый
+	cookie := createOIDCLoginCookie("")
+	cookie.MaxAge = -1
+	http.SetCookie(w, &cookie)
+	if !ok {
+		http.Error(w, "login is not started or expired", http.StatusBadRequest)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(login.State)) != 1 {
+		http.Error(w, "state mismatch", http.StatusBadRequest)
+		return
+	}
+	if errCode := query.Get("error"); errCode != "" {
+		http.Error(w, "identity provider rejected login: "+errCode, http.StatusUnauthorized)
+		return
+	}
+
+	identity, err := c.oidc.Exchange(ctx, query.Get("code"), login.CodeVerifier, login.Nonce)
+	if errors.Is(err, oidc.ErrCodeRejected) || errors.Is(err, oidc.ErrInvalidIDToken) {
+		logger.Log.Info("oidc login rejected: ", zap.Error(err))
+		http.Error(w, "login is rejected", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("oidc exchange error: ", zap.Error(err))
+		http.Error(w, "identity provider is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	userID, err := c.ucAuth.LoginIdentity(ctx, *identity)
+	if err != nil {
+		logger.Log.Error("oidc login error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	tokens, err := c.auth.IssueTokens(ctx, userID, r.UserAgent())
+	if err != nil {
+		logger.Log.Error("issue tokens error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	writeTokens(w, tokens)
+}
+
+// readOIDCLogin прочитает начатый вход из куки
+func readOIDCLogin(r *http.Request) (*oidcLogin, bool) {
+	cookie, err := r.Cookie(cookieOIDCLogin)
+	if err != nil {
+		return nil, false
+	}
+	value, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return nil, false
+	}
+	login := new(oidcLogin)
+	if err = json.Unmarshal(value, login); err != nil || login.State == "" {
+		return nil, false
+	}
+
+	return login, true
+}
+
+// createOIDCLoginCookie кука начатого входа; SameSite=Lax, чтобы она пришла
+// при перенаправлении со страницы поставщика
+func createOIDCLoginCookie(value string) http.Cookie {
+	return http.Cookie{
+		Name:     cookieOIDCLogin,
+		Value:    value,
+		Path:     "/api/auth/oidc",
+		HttpOnly: true,
+		Secure:   false,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,132 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/KartoonYoko/go-url-shortener/internal/oidc"
+	"github.com/KartoonYoko/go-url-shortener/internal/oidc/oidctest"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setUpOIDC включит у контроллера вход через поставщика удостоверений, запущенного в тесте
+func setUpOIDC(t *testing.T) *oidctest.Provider {
+	idp := oidctest.NewProvider("shortener", "secret")
+	provider, err := oidc.New(oidc.Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     idp.ClientID,
+		ClientSecret: idp.ClientSecret,
+		RedirectURL:  srv.URL + pathAuthOIDCCallback,
+	})
+	require.NoError(t, err)
+	controller.SetOIDCProvider(provider)
+	t.Cleanup(func() {
+		controller.SetOIDCProvider(nil)
+		idp.Close()
+	})
+
+	return idp
+}
+
+// newBrowser вернёт клиента, который хранит куки и следует перенаправлениям, как браузер
+func newBrowser(t *testing.T) *resty.Client {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	return resty.New().SetBaseURL(srv.URL).SetCookieJar(jar)
+}
+
+func Test_shortenerController_handlerOIDC(t *testing.T) {
+	defer TearDownTest(t)
+	idp := setUpOIDC(t)
+	idp.SetUser("alice", "alice@example.com")
+
+	// вход через поставщика выдаёт токены, как вход по паролю
+	browser := newBrowser(t)
+	res, err := browser.R().Get(pathAuthOIDCLogin)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode(), string(res.Body()))
+	var tokens modelAuth.TokensResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &tokens))
+	userID, err := controller.tokens.ValidateAndGetUserID(tokens.AccessToken)
+	require.NoError(t, err)
+
+	res, err = browser.R().SetBody("https://example.com/sso").Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	shortURL := string(res.Body())
+
+	// на другом устройстве то же удостоверение открывает ссылки того же пользователя
+	other := newBrowser(t)
+	res, err = other.R().Get(pathAuthOIDCLogin)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	require.NoError(t, json.Unmarshal(res.Body(), &tokens))
+	otherUserID, err := controller.tokens.ValidateAndGetUserID(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, userID, otherUserID)
+	res, err = other.R().Get("/api/user/urls")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Contains(t, string(res.Body()), shortURL)
+
+	// другое удостоверение - другой пользователь
+	idp.SetUser("bob", "bob@example.com")
+	res, err = newBrowser(t).R().Get(pathAuthOIDCLogin)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	require.NoError(t, json.Unmarshal(res.Body(), &tokens))
+	bobUserID, err := controller.tokens.ValidateAndGetUserID(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, userID, bobUserID)
+}
+
+func Test_shortenerController_handlerOIDCCallback_Invalid(t *testing.T) {
+	defer TearDownTest(t)
+	setUpOIDC(t)
+
+	// начать вход, но не возвращаться от поставщика
+	browser := newBrowser(t).SetRedirectPolicy(resty.NoRedirectPolicy())
+	res, err := browser.R().Get(pathAuthOIDCLogin)
+	require.Error(t, err)
+	require.Equal(t, http.StatusFound, res.StatusCode())
+	assert.NotEmpty(t, cookieValue(res, cookieOIDCLogin))
+
+	// state должен совпасть с начатым входом
+	res, err = browser.R().SetQueryParams(map[string]string{"code": "code", "state": "other"}).Get(pathAuthOIDCCallback)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+	assert.Empty(t, cookieValue(res, cookieAuthorization))
+
+	// начатый вход одноразовый
+	res, err = browser.R().SetQueryParams(map[string]string{"code": "code", "state": "other"}).Get(pathAuthOIDCCallback)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+
+	// поставщик не примет чужой код
+	res, err = browser.R().Get(pathAuthOIDCLogin)
+	require.Error(t, err)
+	location, err := res.RawResponse.Location()
+	require.NoError(t, err)
+	res, err = browser.R().
+		SetQueryParams(map[string]string{"code": "forged", "state": location.Query().Get("state")}).
+		Get(pathAuthOIDCCallback)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	assert.Empty(t, cookieValue(res, cookieAuthorization))
+}
+
+func Test_shortenerController_handlerOIDC_NotConfigured(t *testing.T) {
+	httpClient := resty.New().SetBaseURL(srv.URL)
+
+	for _, path := range []string{pathAuthOIDCLogin, pathAuthOIDCCallback} {
+		res, err := httpClient.R().Get(path)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode(), path)
+		assert.Empty(t, cookieValue(res, cookieAuthorization), path)
+	}
+}
@@ -17,4 +17,5 @@ func Test_shortenerController_ping(t *testing.T) {
 
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusOK, res.StatusCode())
+	assert.Empty(t, res.Header().Values("Set-Cookie"))
 }
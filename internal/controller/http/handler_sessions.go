@@ -0,0 +1,132 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Эндпоинт с методом POST и путём /api/auth/logout.
+// Отзывает текущий сеанс и удаляет куки с токенами; токены сеанса больше не принимаются.
+func (c *shortenerController) handlerAuthLogoutPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	// токен, выданный до появления сеансов, отозвать нельзя: он действует до истечения
+	if sessionID := getSessionIDFromContext(ctx); sessionID != "" {
+		err = c.ucAuth.RevokeSession(ctx, userID, sessionID)
+		if err != nil && !errors.Is(err, usecaseAuth.ErrSessionNotFound) {
+			logger.Log.Error("logout error: ", zap.Error(err))
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Эндпоинт с методом POST и путём /api/auth/logout-all.
+// Отзывает все сеансы пользователя на всех устройствах и удаляет куки с токенами.
+func (c *shortenerController) handlerAuthLogoutAllPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	if err = c.ucAuth.RevokeUserSessions(ctx, userID); err != nil {
+		logger.Log.Error("logout everywhere error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	clearTokenCookies(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Эндпоинт с методом GET и путём /api/user/sessions.
+// Возвращает действующие сеансы пользователя с User-Agent и временем последнего запроса;
+// текущий сеанс отмечен полем current. Если сеансов нет, отдаёт 204 No Content.
+func (c *shortenerController) handlerSessionsGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	sessions, err := c.ucAuth.GetUserSessions(ctx, userID)
+	if err != nil {
+		logger.Log.Error("get sessions error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+	if len(sessions) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	currentID := getSessionIDFromContext(ctx)
+	response := make([]modelAuth.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, modelAuth.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID == currentID,
+		})
+	}
+	res, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Can not serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(res)
+}
+
+// Эндпоинт с методом DELETE и путём /api/user/sessions/{id}.
+// Отзывает сеанс пользователя на другом устройстве; его токены сразу перестают приниматься.
+func (c *shortenerController) handlerSessionsDELETE(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = c.ucAuth.RevokeSession(ctx, userID, chi.URLParam(r, "id"))
+	if errors.Is(err, usecaseAuth.ErrSessionNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Log.Error("revoke session error: ", zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getSessionIDFromContext вернёт ID сеанса запроса; пусто, если токен выдан вне сеанса
+func getSessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(keySessionID).(string)
+	return sessionID
+}
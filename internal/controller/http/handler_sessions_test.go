@@ -0,0 +1,133 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_shortenerController_handlerSessions(t *testing.T) {
+	defer TearDownTest(t)
+	credentials := modelAuth.CredentialsRequest{Email: "sessions@example.com", Password: "secret-password"}
+
+	// первое устройство регистрирует пользователя, второе и третье входят в ту же учётную запись
+	res, err := resty.New().SetBaseURL(srv.URL).R().
+		SetHeader("User-Agent", "laptop").
+		SetBody("https://example.com/sessions").
+		Post("/")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	laptop := common.BearerToken(cookieValue(res, cookieAuthorization))
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetAuthToken(laptop).
+		SetBody(credentials).
+		Post(pathAuthRegister)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+
+	login := func(userAgent string) *modelAuth.TokensResponse {
+		res, err := resty.New().SetBaseURL(srv.URL).R().
+			SetHeader("User-Agent", userAgent).
+			SetBody(credentials).
+			Post(pathAuthLogin)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode())
+		var tokens modelAuth.TokensResponse
+		require.NoError(t, json.Unmarshal(res.Body(), &tokens))
+		return &tokens
+	}
+	phone := login("phone")
+	tablet := login("tablet")
+
+	listSessions := func(accessToken string) []modelAuth.SessionResponse {
+		res, err := resty.New().SetBaseURL(srv.URL).R().SetAuthToken(accessToken).Get("/api/user/sessions")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode())
+		var sessions []modelAuth.SessionResponse
+		require.NoError(t, json.Unmarshal(res.Body(), &sessions))
+		return sessions
+	}
+
+	// каждое устройство видит все сеансы и отмечен текущим только свой
+	sessions := listSessions(phone.AccessToken)
+	require.Len(t, sessions, 3)
+	userAgents := make(map[string]bool)
+	var tabletSessionID string
+	for _, session := range sessions {
+		userAgents[session.UserAgent] = session.Current
+		if session.UserAgent == "tablet" {
+			tabletSessionID = session.ID
+		}
+	}
+	assert.Equal(t, map[string]bool{"laptop": false, "phone": true, "tablet": false}, userAgents)
+
+	// отозванный с другого устройства сеанс больше не принимается
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetAuthToken(phone.AccessToken).
+		Delete("/api/user/sessions/" + tabletSessionID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+	res, err = resty.New().SetBaseURL(srv.URL).R().SetAuthToken(tablet.AccessToken).Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetBody(modelAuth.RefreshRequest{RefreshToken: tablet.RefreshToken}).
+		Post(pathAuthRefresh)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	assert.Len(t, listSessions(phone.AccessToken), 2)
+
+	res, err = resty.New().SetBaseURL(srv.URL).R().
+		SetAuthToken(phone.AccessToken).
+		Delete("/api/user/sessions/" + tabletSessionID + "-unknown")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+
+	// выход завершает только текущий сеанс и удаляет куки
+	res, err = resty.New().SetBaseURL(srv.URL).R().SetAuthToken(laptop).Post(pathAuthLogout)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+	for _, cookie := range res.Cookies() {
+		assert.Empty(t, cookie.Value, cookie.Name)
+		assert.Negative(t, cookie.MaxAge, cookie.Name)
+	}
+	res, err = resty.New().SetBaseURL(srv.URL).R().SetAuthToken(laptop).Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	sessions = listSessions(phone.AccessToken)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "phone", sessions[0].UserAgent)
+
+	// выход на всех устройствах завершает и текущий сеанс
+	phone = login("phone")
+	res, err = resty.New().SetBaseURL(srv.URL).R().SetAuthToken(phone.AccessToken).Post(pathAuthLogoutAll)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+	res, err = resty.New().SetBaseURL(srv.URL).R().SetAuthToken(phone.AccessToken).Get("/api/user/sessions")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+
+	// войти заново можно
+	assert.Len(t, listSessions(login("phone").AccessToken), 1)
+}
+
+func Test_shortenerController_handlerSessions_Anonymous(t *testing.T) {
+	httpClient := resty.New().SetBaseURL(srv.URL)
+
+	for _, path := range []string{pathAuthLogout, pathAuthLogoutAll} {
+		res, err := httpClient.R().Post(path)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode(), path)
+		assert.Empty(t, cookieValue(res, cookieAuthorization), path)
+	}
+
+	res, err := httpClient.R().Get("/api/user/sessions")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+}
@@ -32,14 +32,14 @@ func (c *shortenerController) handlerRootPOST(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusBadRequest)
 		return
 	}
 
 	// - вернуть сокращенный url с помощью сервиса
-	url, err := c.uc.SaveURL(ctx, string(body), userID)
+	url, err := c.uc.SaveURL(ctx, string(body), owner)
 	if err != nil {
 		var alreadyExistsErr *usecaseShortener.URLAlreadyExistsError
 		if errors.As(err, &alreadyExistsErr) {
@@ -98,13 +98,13 @@ func (c *shortenerController) handlerAPIShortenPOST(w http.ResponseWriter, r *ht
 		return
 	}
 
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		http.Error(w, "Empty body not allowed", http.StatusBadRequest)
 		return
 	}
 
-	url, err := c.uc.SaveURL(ctx, string(request.URL), userID)
+	url, err := c.uc.SaveURL(ctx, string(request.URL), owner)
 	if err != nil {
 		var alreadyExistsErr *usecaseShortener.URLAlreadyExistsError
 		if errors.As(err, &alreadyExistsErr) {
@@ -148,13 +148,13 @@ func (c *shortenerController) handlerAPIShortenBatchPOST(w http.ResponseWriter,
 		return
 	}
 
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
 
-	response, err := c.uc.SaveURLsBatch(ctx, request, userID)
+	response, err := c.uc.SaveURLsBatch(ctx, request, owner)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
@@ -184,12 +184,12 @@ func (c *shortenerController) handlerAPIShortenBatchPOST(w http.ResponseWriter,
 // При отсутствии сокращённых пользователем URL хендлер должен отдавать HTTP-статус 204 No Content.
 func (c *shortenerController) handlerAPIUserURLsGET(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
 	}
-	response, err := c.uc.GetUserURLs(ctx, userID)
+	response, err := c.uc.GetUserURLs(ctx, owner)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
@@ -212,7 +212,7 @@ func (c *shortenerController) handlerAPIUserURLsGET(w http.ResponseWriter, r *ht
 
 func (c *shortenerController) handlerAPIUserURLsDELETE(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	userID, err := c.getUserIDFromContext(ctx)
+	owner, err := c.getOwnerFromContext(ctx)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
@@ -224,7 +224,7 @@ func (c *shortenerController) handlerAPIUserURLsDELETE(w http.ResponseWriter, r
 		return
 	}
 
-	err = c.uc.DeleteURLs(ctx, userID, request)
+	err = c.uc.DeleteURLs(ctx, owner, request)
 	if err != nil {
 		http.Error(w, "Server error", http.StatusInternalServerError)
 		return
@@ -233,6 +233,17 @@ func (c *shortenerController) handlerAPIUserURLsDELETE(w http.ResponseWriter, r
 	w.WriteHeader(http.StatusAccepted)
 }
 
+// getOwnerFromContext вернёт владельца ссылок запроса: рабочее пространство
+// из заголовка X-Workspace-ID или, если его нет, текущего пользователя
+func (c *shortenerController) getOwnerFromContext(ctx context.Context) (model.Owner, error) {
+	if workspaceID, ok := ctx.Value(keyWorkspaceID).(string); ok {
+		return model.Owner{WorkspaceID: workspaceID}, nil
+	}
+
+	userID, err := c.getUserIDFromContext(ctx)
+	return model.Owner{UserID: userID}, err
+}
+
 func (c *shortenerController) getUserIDFromContext(ctx context.Context) (string, error) {
 	ctxUserID := ctx.Value(keyUserID)
 	userID, ok := ctxUserID.(string)
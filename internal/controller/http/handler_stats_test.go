@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"net/http/cookiejar"
 	"testing"
 
 	"github.com/go-resty/resty/v2"
@@ -13,8 +14,16 @@ func Test_shortenerController_handlerStatsGET(t *testing.T) {
 	httpClient := resty.New().
 		SetBaseURL(srv.URL)
 
+	// анонимный запрос не заводит пользователя
 	res, err := httpClient.R().Get("/api/internal/stats")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode())
+	assert.Empty(t, res.Cookies())
 
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	authUser(t, jar)
+	res, err = httpClient.SetCookieJar(jar).R().Get("/api/internal/stats")
 	require.NoError(t, err)
 	assert.Equal(t, http.StatusForbidden, res.StatusCode())
 }
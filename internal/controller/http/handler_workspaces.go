@@ -0,0 +1,214 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	usecaseWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Эндпоинт с методом POST и путём /api/workspaces.
+// Принимает JSON {"name": "campaigns"} и создаёт рабочее пространство, владельцем которого
+// становится текущий пользователь.
+func (c *shortenerController) handlerWorkspacesPOST(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusBadRequest)
+		return
+	}
+	var request modelWorkspace.CreateWorkspaceRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	membership, err := c.ucWS.CreateWorkspace(ctx, userID, request.Name)
+	if err != nil {
+		writeWorkspaceError(w, "create workspace error: ", err)
+		return
+	}
+
+	writeWorkspaceJSON(w, http.StatusCreated, newWorkspaceResponse(membership))
+}
+
+// Эндпоинт с методом GET и путём /api/workspaces.
+// Возвращает рабочие пространства пользователя с его ролью в каждом;
+// если пользователь ни в одном не состоит, отдаёт 204 No Content.
+func (c *shortenerController) handlerWorkspacesGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	memberships, err := c.ucWS.GetUserWorkspaces(ctx, userID)
+	if err != nil {
+		writeWorkspaceError(w, "get workspaces error: ", err)
+		return
+	}
+	if len(memberships) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	response := make([]modelWorkspace.WorkspaceResponse, 0, len(memberships))
+	for i := range memberships {
+		response = append(response, newWorkspaceResponse(&memberships[i]))
+	}
+	writeWorkspaceJSON(w, http.StatusOK, response)
+}
+
+// Эндпоинт с методом GET и путём /api/workspaces/{id}/members.
+// Возвращает участников пространства; доступен любому участнику.
+func (c *shortenerController) handlerWorkspaceMembersGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	members, err := c.ucWS.GetMembers(ctx, userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeWorkspaceError(w, "get workspace members error: ", err)
+		return
+	}
+
+	response := make([]modelWorkspace.MemberResponse, 0, len(members))
+	for i := range members {
+		response = append(response, newMemberResponse(&members[i]))
+	}
+	writeWorkspaceJSON(w, http.StatusOK, response)
+}
+
+// Эндпоинт с методом PUT и путём /api/workspaces/{id}/members/{userID}.
+// Принимает JSON {"role": "editor"}, добавляет пользователя в пространство или меняет его роль;
+// доступен владельцам пространства.
+func (c *shortenerController) handlerWorkspaceMemberPUT(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusBadRequest)
+		return
+	}
+	var request modelWorkspace.SetMemberRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	member, err := c.ucWS.SetMember(ctx, userID, chi.URLParam(r, "id"), chi.URLParam(r, "userID"), request.Role)
+	if err != nil {
+		writeWorkspaceError(w, "set workspace member error: ", err)
+		return
+	}
+
+	writeWorkspaceJSON(w, http.StatusOK, newMemberResponse(member))
+}
+
+// Эндпоинт с методом DELETE и путём /api/workspaces/{id}/members/{userID}.
+// Исключает пользователя из пространства; доступен владельцам, а участник может покинуть пространство сам.
+func (c *shortenerController) handlerWorkspaceMemberDELETE(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	err = c.ucWS.RemoveMember(ctx, userID, chi.URLParam(r, "id"), chi.URLParam(r, "userID"))
+	if err != nil {
+		writeWorkspaceError(w, "remove workspace member error: ", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Эндпоинт с методом GET и путём /api/workspaces/{id}/stats.
+// Возвращает количество ссылок и участников пространства; доступен любому участнику.
+func (c *shortenerController) handlerWorkspaceStatsGET(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID, err := c.getUserIDFromContext(ctx)
+	if err != nil {
+		http.Error(w, "Server error", http.StatusInternalServerError)
+		return
+	}
+
+	stats, err := c.ucWS.GetStats(ctx, userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeWorkspaceError(w, "get workspace stats error: ", err)
+		return
+	}
+
+	writeWorkspaceJSON(w, http.StatusOK, stats)
+}
+
+// writeWorkspaceError ответит статусом, соответствующим ошибке usecase'а рабочих пространств
+func writeWorkspaceError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, usecaseWorkspace.ErrWorkspaceNotFound),
+		errors.Is(err, usecaseWorkspace.ErrMemberNotFound),
+		errors.Is(err, usecaseWorkspace.ErrUserNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, usecaseWorkspace.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, usecaseWorkspace.ErrInvalidName),
+		errors.Is(err, usecaseWorkspace.ErrInvalidRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, usecaseWorkspace.ErrLastOwner):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		logger.Log.Error(msg, zap.Error(err))
+		http.Error(w, "Server error", http.StatusInternalServerError)
+	}
+}
+
+// writeWorkspaceJSON ответит статусом status и телом response в JSON
+func writeWorkspaceJSON(w http.ResponseWriter, status int, response interface{}) {
+	res, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "Can not serialize response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	w.Write(res)
+}
+
+func newWorkspaceResponse(m *modelWorkspace.Membership) modelWorkspace.WorkspaceResponse {
+	return modelWorkspace.WorkspaceResponse{
+		ID:        m.Workspace.ID,
+		Name:      m.Workspace.Name,
+		Role:      m.Role,
+		CreatedAt: m.Workspace.CreatedAt,
+	}
+}
+
+func newMemberResponse(m *modelWorkspace.Member) modelWorkspace.MemberResponse {
+	return modelWorkspace.MemberResponse{
+		UserID: m.UserID,
+		Role:   m.Role,
+	}
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	"github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_shortenerController_handlerWorkspaces(t *testing.T) {
+	defer TearDownTest(t)
+	owner, ownerID := newWorkspaceClient(t)
+	editor, editorID := newWorkspaceClient(t)
+	viewer, viewerID := newWorkspaceClient(t)
+	outsider, _ := newWorkspaceClient(t)
+
+	res, err := owner.R().SetBody(`{"name": ""}`).Post("/api/workspaces")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+
+	res, err = owner.R().SetBody(`{"name": "campaigns"}`).Post("/api/workspaces")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	var ws modelWorkspace.WorkspaceResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &ws))
+	assert.Equal(t, "campaigns", ws.Name)
+	assert.Equal(t, modelWorkspace.RoleOwner, ws.Role)
+	membersURL := "/api/workspaces/" + ws.ID + "/members/"
+
+	res, err = owner.R().SetBody(`{"role": "editor"}`).Put(membersURL + editorID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	res, err = owner.R().SetBody(`{"role": "viewer"}`).Put(membersURL + viewerID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	res, err = owner.R().SetBody(`{"role": "admin"}`).Put(membersURL + viewerID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode())
+
+	// участниками управляют только владельцы
+	res, err = editor.R().SetBody(`{"role": "owner"}`).Put(membersURL + editorID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+	res, err = owner.R().Delete(membersURL + ownerID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, res.StatusCode())
+
+	res, err = editor.R().Get("/api/workspaces")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var workspaces []modelWorkspace.WorkspaceResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &workspaces))
+	require.Len(t, workspaces, 1)
+	assert.Equal(t, modelWorkspace.RoleEditor, workspaces[0].Role)
+	res, err = outsider.R().Get("/api/workspaces")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode())
+
+	// редактор сокращает ссылки пространства, а зритель - нет
+	res, err = editor.R().SetHeader(headerWorkspaceID, ws.ID).
+		SetBody(`{"url": "https://example.com/campaign"}`).Post("/api/shorten")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, res.StatusCode())
+	res, err = viewer.R().SetHeader(headerWorkspaceID, ws.ID).SetBody("https://example.com/viewer").Post("/")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	// ссылки пространства видны всем его участникам и не видны остальным
+	res, err = viewer.R().SetHeader(headerWorkspaceID, ws.ID).Get("/api/user/urls")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var urls []model.GetUserURLsItemResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &urls))
+	require.Len(t, urls, 1)
+	assert.Equal(t, "https://example.com/campaign", urls[0].OriginalURL)
+	res, err = editor.R().Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, res.StatusCode())
+	res, err = outsider.R().SetHeader(headerWorkspaceID, ws.ID).Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+	res, err = viewer.R().SetHeader(headerWorkspaceID, ws.ID).SetBody(`["id"]`).Delete("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode())
+
+	res, err = viewer.R().Get("/api/workspaces/" + ws.ID + "/stats")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var stats modelWorkspace.StatsResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &stats))
+	assert.Equal(t, modelWorkspace.StatsResponse{URLs: 1, Members: 3}, stats)
+	res, err = outsider.R().Get("/api/workspaces/" + ws.ID + "/stats")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+
+	// участник может покинуть пространство сам
+	res, err = viewer.R().Delete(membersURL + viewerID)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, res.StatusCode())
+	res, err = viewer.R().SetHeader(headerWorkspaceID, ws.ID).Get("/api/user/urls")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, res.StatusCode())
+
+	res, err = owner.R().Get("/api/workspaces/" + ws.ID + "/members")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, res.StatusCode())
+	var members []modelWorkspace.MemberResponse
+	require.NoError(t, json.Unmarshal(res.Body(), &members))
+	assert.ElementsMatch(t, []modelWorkspace.MemberResponse{
+		{UserID: ownerID, Role: modelWorkspace.RoleOwner},
+		{UserID: editorID, Role: modelWorkspace.RoleEditor},
+	}, members)
+}
+
+// newWorkspaceClient заведёт пользователя и вернёт клиента с его токеном и его ID
+func newWorkspaceClient(t *testing.T) (*resty.Client, string) {
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	userID := authUser(t, jar)
+
+	return resty.New().SetBaseURL(srv.URL).SetCookieJar(jar), userID
+}
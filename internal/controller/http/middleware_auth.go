@@ -5,10 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strings"
+	"time"
 
 	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	usecaseAuth "github.com/KartoonYoko/go-url-shortener/internal/usecase/auth"
 	"go.uber.org/zap"
 )
 
@@ -16,93 +18,213 @@ import (
 type MiddlewareAuthKey int
 
 const (
-	keyUserID MiddlewareAuthKey = iota // ключ для ID пользователя
+	keyUserID      MiddlewareAuthKey = iota // ключ для ID пользователя
+	keyAPIKey                               // ключ для API-ключа, если запрос выполнен по нему
+	keyUser                                 // ключ для роли и состояния пользователя
+	keyWorkspaceID                          // ключ для ID рабочего пространства, ссылками которого управляет запрос
+	keySessionID                            // ключ для ID сеанса, если токен выдан в сеансе
+)
+
+// headerAPIKey заголовок с API-ключом
+const headerAPIKey = "X-API-Key"
+
+// Куки аутентификации
+const (
+	cookieAuthorization = "Authorization" // токен доступа
+	cookieRefreshToken  = "Refresh-Token" // токен обновления; отправляется только на /api/auth
+)
+
+// пути аутентификации
+const (
+	pathAuthRefresh   = "/api/auth/refresh"
+	pathAuthRegister  = "/api/auth/register"
+	pathAuthLogin     = "/api/auth/login"
+	pathAuthLogout    = "/api/auth/logout"
+	pathAuthLogoutAll = "/api/auth/logout-all"
+
+	pathAuthOIDCLogin    = "/api/auth/oidc/login"
+	pathAuthOIDCCallback = "/api/auth/oidc/callback"
 )
 
 // Сервис должен:
 //   - Выдавать пользователю симметрично подписанную куку, содержащую уникальный идентификатор пользователя,
 //     если такой куки не существует или она не проходит проверку подлинности.
 //   - Если кука не содержит ID пользователя, хендлер должен возвращать HTTP-статус 401 Unauthorized.
-func (c *shortenerController) authJWTCookieMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		var userID string
-		ctx := r.Context()
-		cookie, err := r.Cookie("Authorization")
-		if err != nil {
-			if errors.Is(err, http.ErrNoCookie) {
-				// если это запрос на получение URL'ов пользователя,
-				// то возвращаем 401, т.к. куки не найден
-				if r.URL.Path == "/api/user/urls" && r.Method == http.MethodGet {
-					w.WriteHeader(http.StatusUnauthorized)
-					return
-				}
-				userID, err = handleCookieError(ctx, w, c)
-				if err != nil {
-					logger.Log.Error("middleware auth error: ", zap.Error(err))
-					http.Error(w, "unexpected auth error", http.StatusInternalServerError)
-					return
-				}
-			} else {
-				logger.Log.Error("middleware auth error: ", zap.Error(err))
-				http.Error(w, "unexpected auth error", http.StatusInternalServerError)
+//
+// Токен доступа принимается в заголовке Authorization и в одноимённой куке; заголовок важнее.
+// Запрос без токена доступа обрабатывается по политике маршрута policy.
+func (c *shortenerController) authJWTCookieMiddleware(policy common.AnonymousPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// публичному маршруту пользователь не нужен: ни токен, ни API-ключ не проверяются
+			if policy == common.AnonymousAllow {
+				next.ServeHTTP(w, r)
 				return
 			}
-		} else {
-			var cookieValue string
-			arr := strings.Split(cookie.Value, " ")
-			if len(arr) == 2 {
-				cookieValue = arr[1]
+
+			if apiKey := r.Header.Get(headerAPIKey); apiKey != "" {
+				c.authAPIKey(w, r, next, apiKey)
+				return
 			}
 
-			userID, err = common.ValidateAndGetUserID(cookieValue)
-			// вернуть 401
-			if err != nil {
+			credentials := r.Header.Get(cookieAuthorization)
+			if credentials == "" {
+				if cookie, err := r.Cookie(cookieAuthorization); err == nil {
+					credentials = cookie.Value
+				}
+			}
+
+			auth, err := c.auth.Authenticate(r.Context(), credentials, r.UserAgent(), policy)
+			if errors.Is(err, common.ErrNoCredentials) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
 				w.WriteHeader(http.StatusUnauthorized)
 				return
 			}
-		}
-
-		ctx = context.WithValue(r.Context(), keyUserID, userID)
-		r = r.WithContext(ctx)
+			// вернуть 401; истёкший токен можно обновить, поэтому он отличается от неверного
+			if errors.Is(err, common.ErrTokenExpired) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="token expired"`)
+				http.Error(w, "token expired", http.StatusUnauthorized)
+				return
+			}
+			if errors.Is(err, common.ErrInvalidToken) {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if err != nil {
+				logger.Log.Error("middleware auth error: ", zap.Error(err))
+				http.Error(w, "unexpected auth error", http.StatusInternalServerError)
+				return
+			}
+			if auth.Tokens != nil {
+				setTokenCookies(w, auth.Tokens)
+			}
 
-		next.ServeHTTP(w, r)
-	})
+			ctx := context.WithValue(r.Context(), keyUserID, auth.UserID)
+			ctx = context.WithValue(ctx, keySessionID, auth.SessionID)
+			c.authorize(w, r.WithContext(ctx), next, auth.UserID)
+		})
+	}
 }
 
-// handleCookieError генерирует новый userID и записывает его в куки
-func handleCookieError(ctx context.Context, w http.ResponseWriter, c *shortenerController) (string, error) {
-	userID, err := c.ucAuth.GetNewUserID(ctx)
-	if err != nil {
-		return "", err
+// authAPIKey пропустит запрос от имени владельца API-ключа; куки при этом не выдаются
+func (c *shortenerController) authAPIKey(w http.ResponseWriter, r *http.Request, next http.Handler, apiKey string) {
+	key, err := c.ucAuth.AuthenticateAPIKey(r.Context(), apiKey)
+	if errors.Is(err, usecaseAuth.ErrInvalidAPIKey) {
+		http.Error(w, "api key is invalid", http.StatusUnauthorized)
+		return
 	}
-	err = createJWTAndSaveAsCookie(w, userID)
 	if err != nil {
-		return "", err
+		logger.Log.Error("middleware auth error: ", zap.Error(err))
+		http.Error(w, "unexpected auth error", http.StatusInternalServerError)
+		return
 	}
 
-	return userID, err
+	ctx := context.WithValue(r.Context(), keyUserID, key.UserID)
+	ctx = context.WithValue(ctx, keyAPIKey, key)
+	c.authorize(w, r.WithContext(ctx), next, key.UserID)
 }
 
-func createJWTAndSaveAsCookie(w http.ResponseWriter, userID string) error {
-	jwt, err := common.BuildJWTString(userID)
+// authorize пропустит запрос, если пользователь не отключён, и запишет его роль в контекст
+func (c *shortenerController) authorize(w http.ResponseWriter, r *http.Request, next http.Handler, userID string) {
+	user, err := c.ucAuth.Authorize(r.Context(), userID)
+	if errors.Is(err, usecaseAuth.ErrUserDisabled) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
 	if err != nil {
-		return err
+		logger.Log.Error("middleware auth error: ", zap.Error(err))
+		http.Error(w, "unexpected auth error", http.StatusInternalServerError)
+		return
 	}
-	bearerStr := fmt.Sprintf("Bearer %s", jwt)
-	cookie := createAuthCookie(bearerStr)
 
-	http.SetCookie(w, &cookie)
-	return nil
+	ctx := context.WithValue(r.Context(), keyUser, user)
+	next.ServeHTTP(w, r.WithContext(ctx))
 }
 
-func createAuthCookie(bearerStr string) http.Cookie {
+// requireRoleMiddleware пропускает запросы только пользователей с правами роли role
+func requireRoleMiddleware(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(keyUser).(*modelAuth.User)
+			if !ok || !user.HasRole(role) {
+				http.Error(w, fmt.Sprintf("%s role required", role), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requireScopeMiddleware пропускает запросы по API-ключу, только если у ключа есть право scope;
+// запросы с токеном доступа пропускаются всегда
+func requireScopeMiddleware(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := r.Context().Value(keyAPIKey).(*modelAuth.APIKey)
+			if ok && !key.HasScope(scope) {
+				http.Error(w, fmt.Sprintf("api key has no %s scope", scope), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// denyAPIKeyMiddleware не пропускает запросы по API-ключу: управление ключами
+// и учётной записью доступно только с токеном доступа
+func denyAPIKeyMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(keyAPIKey).(*modelAuth.APIKey); ok {
+			http.Error(w, "not allowed with api key", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// setTokenCookies запишет токены в куки; кука с токеном доступа живёт, пока его можно обновить:
+// иначе истёкший токен не дойдёт до сервера и вместо обновления будет создан новый пользователь
+func setTokenCookies(w http.ResponseWriter, tokens *modelAuth.TokensResponse) {
+	authCookie := createAuthCookie(common.FormatBearer(tokens.AccessToken), tokens.RefreshTokenExpiresAt)
+	http.SetCookie(w, &authCookie)
+	refreshCookie := createRefreshCookie(tokens.RefreshToken, tokens.RefreshTokenExpiresAt)
+	http.SetCookie(w, &refreshCookie)
+}
+
+// clearTokenCookies удалит куки с токенами
+func clearTokenCookies(w http.ResponseWriter) {
+	authCookie := createAuthCookie("", time.Unix(0, 0))
+	authCookie.MaxAge = -1
+	http.SetCookie(w, &authCookie)
+	refreshCookie := createRefreshCookie("", time.Unix(0, 0))
+	refreshCookie.MaxAge = -1
+	http.SetCookie(w, &refreshCookie)
+}
+
+func createAuthCookie(bearerStr string, expiresAt time.Time) http.Cookie {
 	return http.Cookie{
-		Name:     "Authorization",
+		Name:     cookieAuthorization,
 		Value:    bearerStr,
 		Path:     "/",
-		MaxAge:   0,
+		Expires:  expiresAt,
 		HttpOnly: true,
 		Secure:   false,
 		SameSite: http.SameSiteLaxMode,
 	}
 }
+
+func createRefreshCookie(refreshToken string, expiresAt time.Time) http.Cookie {
+	return http.Cookie{
+		Name:     cookieRefreshToken,
+		Value:    refreshToken,
+		Path:     "/api/auth",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   false,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
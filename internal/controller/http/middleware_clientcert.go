@@ -0,0 +1,25 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
+)
+
+// guardClientCertMiddleware пропускает только клиентов с доверенным сертификатом;
+// если удостоверяющий центр клиентов не задан, проверка не выполняется
+func (c *shortenerController) guardClientCertMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.conf.TLSClientCAFile == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !common.IsTrustedClient(c.conf.TrustedClients, r.TLS) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
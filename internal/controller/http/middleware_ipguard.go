@@ -2,35 +2,22 @@ package http
 
 import (
 	"net/http"
-	"net/netip"
 
+	"github.com/KartoonYoko/go-url-shortener/internal/controller/common"
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
 	"go.uber.org/zap"
 )
 
-// guardIPMiddleware запрещает/разрешает доступ на основе переданной подсети
+// guardIPMiddleware запрещает/разрешает доступ на основе переданной подсети;
+// если подсеть не задана, проверка не выполняется
 func (c *shortenerController) guardIPMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ipStr := r.Header.Get("X-Real-IP")
-		// если нет заголовка - запрещаем доступ
-		if ipStr == "" {
-			w.WriteHeader(http.StatusForbidden)
-			return
-		}
-
-		// если подсеть не задана - запрещаем доступ
 		if c.conf.TrustedSubnets == "" {
-			w.WriteHeader(http.StatusForbidden)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		network, err := netip.ParsePrefix(c.conf.TrustedSubnets)
-		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			logger.Log.Error("middleware ipguard error: ", zap.Error(err))
-			return
-		}
-		ip, err := netip.ParseAddr(ipStr)
+		ok, err := common.IsTrustedIP(c.conf.TrustedSubnets, r.Header.Get("X-Real-IP"))
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			logger.Log.Error("middleware ipguard error: ", zap.Error(err))
@@ -38,7 +25,7 @@ func (c *shortenerController) guardIPMiddleware(next http.Handler) http.Handler
 		}
 
 		// если не входит в подсеть - запрещаем доступ
-		if !network.Contains(ip) {
+		if !ok {
 			w.WriteHeader(http.StatusForbidden)
 			return
 		}
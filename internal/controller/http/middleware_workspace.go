@@ -0,0 +1,52 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	usecaseWorkspace "github.com/KartoonYoko/go-url-shortener/internal/usecase/workspace"
+	"go.uber.org/zap"
+)
+
+// headerWorkspaceID заголовок с ID рабочего пространства, от имени которого выполняется запрос
+const headerWorkspaceID = "X-Workspace-ID"
+
+// workspaceMiddleware переключает запрос на ссылки рабочего пространства из заголовка X-Workspace-ID,
+// если у пользователя есть в нём права роли role; запросы без заголовка работают со ссылками пользователя
+func (c *shortenerController) workspaceMiddleware(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			workspaceID := r.Header.Get(headerWorkspaceID)
+			if workspaceID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			userID, err := c.getUserIDFromContext(ctx)
+			if err != nil {
+				http.Error(w, "Server error", http.StatusInternalServerError)
+				return
+			}
+			err = c.ucWS.Authorize(ctx, userID, workspaceID, role)
+			if errors.Is(err, usecaseWorkspace.ErrWorkspaceNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, usecaseWorkspace.ErrForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				logger.Log.Error("middleware workspace error: ", zap.Error(err))
+				http.Error(w, "Server error", http.StatusInternalServerError)
+				return
+			}
+
+			ctx = context.WithValue(ctx, keyWorkspaceID, workspaceID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package auth
+
+import "time"
+
+// Account учётная запись зарегистрированного пользователя
+type Account struct {
+	UserID       string    // пользователь, которому принадлежат ссылки учётной записи
+	Email        string    // адрес почты в нижнем регистре
+	PasswordHash string    // хеш пароля bcrypt
+	CreatedAt    time.Time // время регистрации
+}
+
+// CredentialsRequest запрос регистрации или входа по почте и паролю
+type CredentialsRequest struct {
+	Email    string `json:"email"`    // адрес почты
+	Password string `json:"password"` // пароль
+}
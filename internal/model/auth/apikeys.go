@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"slices"
+	"time"
+)
+
+// Права API-ключей
+const (
+	ScopeLinksWrite = "links:write" // сокращение и удаление ссылок
+	ScopeLinksRead  = "links:read"  // чтение ссылок пользователя
+	ScopeStatsRead  = "stats:read"  // чтение статистики сервиса
+)
+
+// Scopes все права, которые можно выдать API-ключу
+var Scopes = []string{ScopeLinksWrite, ScopeLinksRead, ScopeStatsRead}
+
+// APIKey API-ключ пользователя; сам ключ не хранится, только хеш его секретной части
+type APIKey struct {
+	ID         string     // открытая часть ключа, по которой он ищется
+	UserID     string     // пользователь, от имени которого действует ключ
+	Hash       string     // хеш секретной части ключа
+	Name       string     // название ключа, заданное пользователем
+	Scopes     []string   // права ключа
+	CreatedAt  time.Time  // время создания
+	LastUsedAt *time.Time // время последнего использования; nil, если ключ не использовался
+}
+
+// HasScope проверит, есть ли у ключа право scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// CreateAPIKeyRequest запрос создания API-ключа
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`   // название ключа
+	Scopes []string `json:"scopes"` // права ключа
+}
+
+// CreateAPIKeyResponse созданный API-ключ; сам ключ возвращается только при создании
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"` // ключ для заголовка X-API-Key
+}
+
+// APIKeyResponse данные API-ключа без секретной части
+type APIKeyResponse struct {
+	ID         string     `json:"id"`                     // открытая часть ключа
+	Name       string     `json:"name"`                   // название ключа
+	Scopes     []string   `json:"scopes"`                 // права ключа
+	CreatedAt  time.Time  `json:"created_at"`             // время создания
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // время последнего использования
+}
@@ -0,0 +1,4 @@
+/*
+Package auth - модели для usecase'а auth
+*/
+package auth
@@ -0,0 +1,12 @@
+package auth
+
+import "time"
+
+// Identity учётная запись пользователя у внешнего поставщика удостоверений OpenID Connect
+type Identity struct {
+	Issuer    string    // издатель удостоверения
+	Subject   string    // ID пользователя у издателя
+	UserID    string    // пользователь, которому принадлежат ссылки
+	Email     string    // адрес почты, если издатель его сообщил
+	CreatedAt time.Time // время первого входа
+}
@@ -0,0 +1,31 @@
+package auth
+
+import "time"
+
+// Session сеанс пользователя на устройстве; токены доступа и обновления сеанса
+// перестают приниматься, когда сеанс отозван
+type Session struct {
+	ID         string     // ID сеанса, утверждение sid токена доступа
+	UserID     string     // пользователь сеанса
+	UserAgent  string     // User-Agent клиента, начавшего сеанс
+	CreatedAt  time.Time  // время начала сеанса
+	LastSeenAt time.Time  // время последнего запроса в сеансе
+	RevokedAt  *time.Time // время отзыва; nil, если сеанс действует
+}
+
+// IssuedRefreshToken токен обновления, выданный в сеансе
+type IssuedRefreshToken struct {
+	Token     string    // токен обновления
+	UserID    string    // пользователь сеанса
+	SessionID string    // сеанс, в котором выдан токен
+	ExpiresAt time.Time // время истечения токена
+}
+
+// SessionResponse сеанс пользователя
+type SessionResponse struct {
+	ID         string    `json:"id"`           // ID сеанса
+	UserAgent  string    `json:"user_agent"`   // User-Agent клиента
+	CreatedAt  time.Time `json:"created_at"`   // время начала сеанса
+	LastSeenAt time.Time `json:"last_seen_at"` // время последнего запроса
+	Current    bool      `json:"current"`      // сеанс, в котором выполнен запрос
+}
@@ -0,0 +1,24 @@
+package auth
+
+import "time"
+
+// RefreshToken токен обновления, хранящийся на сервере; сам токен не хранится, только его хеш
+type RefreshToken struct {
+	Hash      string    // хеш токена
+	UserID    string    // пользователь, которому выдан токен
+	SessionID string    // сеанс, в котором выдан токен; пусто у токенов, выданных до появления сеансов
+	ExpiresAt time.Time // время истечения токена
+}
+
+// RefreshRequest запрос обновления токенов
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"` // токен обновления; если не передан, берётся из куки
+}
+
+// TokensResponse выданные пользователю токены
+type TokensResponse struct {
+	AccessToken           string    `json:"access_token"`             // токен доступа
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`  // время истечения токена доступа
+	RefreshToken          string    `json:"refresh_token"`            // токен обновления, одноразовый
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"` // время истечения токена обновления
+}
@@ -0,0 +1,35 @@
+package auth
+
+// Роли пользователей
+const (
+	RoleUser  = "user"  // обычный пользователь; роль по умолчанию
+	RoleAdmin = "admin" // администратор сервиса
+)
+
+// Roles все роли пользователей
+var Roles = []string{RoleUser, RoleAdmin}
+
+// User роль и состояние пользователя
+type User struct {
+	ID       string // ID пользователя
+	Role     string // роль пользователя
+	Disabled bool   // пользователь отключён администратором
+}
+
+// UserResponse данные пользователя для администратора
+type UserResponse struct {
+	ID       string `json:"id"`       // ID пользователя
+	Role     string `json:"role"`     // роль пользователя
+	Disabled bool   `json:"disabled"` // пользователь отключён
+}
+
+// UpdateUserRequest запрос изменения пользователя администратором; не переданные поля не меняются
+type UpdateUserRequest struct {
+	Role     *string `json:"role,omitempty"`     // новая роль
+	Disabled *bool   `json:"disabled,omitempty"` // отключить или включить пользователя
+}
+
+// HasRole проверит, есть ли у пользователя права роли role; администратору доступно всё
+func (u *User) HasRole(role string) bool {
+	return u.Role == role || u.Role == RoleAdmin
+}
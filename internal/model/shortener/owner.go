@@ -0,0 +1,12 @@
+package shortener
+
+// Owner владелец ссылок: пользователь или рабочее пространство
+type Owner struct {
+	UserID      string // пользователь; не используется, если задано рабочее пространство
+	WorkspaceID string // рабочее пространство, от имени которого выполняется запрос
+}
+
+// IsWorkspace сообщит, что ссылками владеет рабочее пространство
+func (o Owner) IsWorkspace() bool {
+	return o.WorkspaceID != ""
+}
@@ -0,0 +1,4 @@
+/*
+Package snapshot - модели для переноса данных между хранилищами
+*/
+package snapshot
@@ -0,0 +1,93 @@
+package snapshot
+
+import "time"
+
+// User пользователь с его ролью и состоянием
+type User struct {
+	ID string `json:"id"` // ID пользователя
+	// роль пользователя; пустая строка - роль неизвестна (снимок первой версии),
+	// при загрузке роль и состояние существующего пользователя не меняются
+	Role     string `json:"role,omitempty"`
+	Disabled bool   `json:"disabled,omitempty"` // пользователь отключён администратором
+}
+
+// URL сокращённый URL со всеми своими данными
+type URL struct {
+	ID          string   `json:"id"`           // ID сокращённого URL'а
+	OriginalURL string   `json:"original_url"` // оригинальный URL
+	Deleted     bool     `json:"is_deleted"`   // флаг удаления
+	UserIDs     []string `json:"user_ids"`     // пользователи, которые когда-либо сокращали URL
+}
+
+// Account учётная запись зарегистрированного пользователя
+type Account struct {
+	UserID       string    `json:"user_id"`       // пользователь учётной записи
+	Email        string    `json:"email"`         // адрес почты
+	PasswordHash string    `json:"password_hash"` // хеш пароля
+	CreatedAt    time.Time `json:"created_at"`    // время регистрации
+}
+
+// Identity удостоверение пользователя у поставщика OpenID Connect
+type Identity struct {
+	Issuer    string    `json:"issuer"`     // издатель удостоверения
+	Subject   string    `json:"subject"`    // ID пользователя у издателя
+	UserID    string    `json:"user_id"`    // пользователь, которому принадлежит удостоверение
+	Email     string    `json:"email"`      // адрес почты
+	CreatedAt time.Time `json:"created_at"` // время первого входа
+}
+
+// Key ключ удостоверения, по которому удостоверения упорядочиваются при выгрузке.
+// Издатель не может содержать '#', поэтому ключ однозначен
+func (i Identity) Key() string {
+	return i.Issuer + "#" + i.Subject
+}
+
+// APIKey API-ключ пользователя
+type APIKey struct {
+	ID         string     `json:"id"`                     // открытая часть ключа
+	UserID     string     `json:"user_id"`                // пользователь ключа
+	Hash       string     `json:"hash"`                   // хеш секретной части ключа
+	Name       string     `json:"name"`                   // название ключа
+	Scopes     []string   `json:"scopes"`                 // права ключа
+	CreatedAt  time.Time  `json:"created_at"`             // время создания
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"` // время последнего использования
+}
+
+// Workspace рабочее пространство с участниками и ссылками
+type Workspace struct {
+	ID        string    `json:"id"`         // ID пространства
+	Name      string    `json:"name"`       // название пространства
+	CreatedAt time.Time `json:"created_at"` // время создания
+	Members   []Member  `json:"members"`    // участники в порядке возрастания ID пользователя
+	URLIDs    []string  `json:"url_ids"`    // ID ссылок пространства в порядке возрастания
+}
+
+// Member участник рабочего пространства
+type Member struct {
+	UserID string `json:"user_id"` // ID пользователя
+	Role   string `json:"role"`    // роль участника
+}
+
+// Writer получатель выгружаемых из хранилища данных.
+//
+// Данные передаются в порядке зависимостей: пользователи, URL'ы, учётные записи,
+// удостоверения, API-ключи, рабочие пространства; внутри каждого вида - в порядке возрастания ID.
+// Сеансы и токены обновления не выгружаются: после переноса пользователи входят заново
+type Writer interface {
+	WriteUser(user User) error
+	WriteURL(url URL) error
+	WriteAccount(account Account) error
+	WriteIdentity(identity Identity) error
+	WriteAPIKey(key APIKey) error
+	WriteWorkspace(ws Workspace) error
+}
+
+// Summary количество записей в снимке
+type Summary struct {
+	Users      int `json:"users"`      // количество пользователей
+	URLs       int `json:"urls"`       // количество URL'ов
+	Accounts   int `json:"accounts"`   // количество учётных записей
+	Identities int `json:"identities"` // количество удостоверений
+	APIKeys    int `json:"api_keys"`   // количество API-ключей
+	Workspaces int `json:"workspaces"` // количество рабочих пространств
+}
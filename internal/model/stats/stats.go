@@ -2,6 +2,25 @@ package stats
 
 // StatsResponse ответ на запрос получения статистики
 type StatsResponse struct {
-	URLs  int `json:"urls"`
-	Users int `json:"users"`
+	URLs  int         `json:"urls"`
+	Users int         `json:"users"`
+	Cache *CacheStats `json:"cache,omitempty"` // статистика кеша URL'ов, если он включён
+	Bloom *BloomStats `json:"bloom,omitempty"` // статистика фильтра Блума, если он включён
+}
+
+// CacheStats статистика кеша URL'ов
+type CacheStats struct {
+	Hits   int64 `json:"hits"`   // количество попаданий
+	Misses int64 `json:"misses"` // количество промахов
+	Size   int   `json:"size"`   // текущее количество записей
+}
+
+// BloomStats статистика фильтра Блума несуществующих ID
+type BloomStats struct {
+	Items             int     `json:"items"`               // количество ID в фильтре
+	Bits              uint64  `json:"bits"`                // размер фильтра в битах
+	Hashes            int     `json:"hashes"`              // количество хеш-функций
+	FalsePositiveRate float64 `json:"false_positive_rate"` // оценка вероятности ложного срабатывания
+	Rejected          int64   `json:"rejected"`            // количество запросов, отсечённых без обращения к хранилищу
+	FalsePositives    int64   `json:"false_positives"`     // количество пропущенных фильтром запросов несуществующих ID
 }
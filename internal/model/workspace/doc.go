@@ -0,0 +1,4 @@
+/*
+Package workspace - модели для usecase'а workspace
+*/
+package workspace
@@ -0,0 +1,78 @@
+package workspace
+
+import "time"
+
+// Роли участников рабочего пространства
+const (
+	RoleViewer = "viewer" // просматривает ссылки пространства
+	RoleEditor = "editor" // сокращает и удаляет ссылки пространства
+	RoleOwner  = "owner"  // управляет участниками пространства
+)
+
+// Roles все роли участников в порядке возрастания прав
+var Roles = []string{RoleViewer, RoleEditor, RoleOwner}
+
+// Workspace рабочее пространство; ссылки пространства принадлежат его ID
+type Workspace struct {
+	ID        string    // ID пространства
+	Name      string    // название пространства
+	CreatedAt time.Time // время создания
+}
+
+// Member участник рабочего пространства
+type Member struct {
+	WorkspaceID string // ID пространства
+	UserID      string // ID пользователя
+	Role        string // роль участника
+}
+
+// Membership пространство, в котором состоит пользователь, и его роль в нём
+type Membership struct {
+	Workspace Workspace // пространство
+	Role      string    // роль пользователя в пространстве
+}
+
+// HasRole проверит, есть ли у участника права роли role; старшие роли включают права младших
+func (m *Member) HasRole(role string) bool {
+	return roleRank(m.Role) >= roleRank(role)
+}
+
+// roleRank вернёт старшинство роли; -1 для неизвестной роли
+func roleRank(role string) int {
+	for i, r := range Roles {
+		if r == role {
+			return i
+		}
+	}
+	return -1
+}
+
+// CreateWorkspaceRequest запрос создания рабочего пространства
+type CreateWorkspaceRequest struct {
+	Name string `json:"name"` // название пространства
+}
+
+// WorkspaceResponse рабочее пространство и роль в нём текущего пользователя
+type WorkspaceResponse struct {
+	ID        string    `json:"id"`         // ID пространства
+	Name      string    `json:"name"`       // название пространства
+	Role      string    `json:"role"`       // роль текущего пользователя
+	CreatedAt time.Time `json:"created_at"` // время создания
+}
+
+// SetMemberRequest запрос добавления участника или смены его роли
+type SetMemberRequest struct {
+	Role string `json:"role"` // роль участника
+}
+
+// MemberResponse участник рабочего пространства
+type MemberResponse struct {
+	UserID string `json:"user_id"` // ID пользователя
+	Role   string `json:"role"`    // роль участника
+}
+
+// StatsResponse статистика рабочего пространства
+type StatsResponse struct {
+	URLs    int `json:"urls"`    // количество ссылок пространства
+	Members int `json:"members"` // количество участников
+}
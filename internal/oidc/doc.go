@@ -0,0 +1,5 @@
+/*
+Package oidc это клиент OpenID Connect: вход через поставщика удостоверений
+по коду авторизации с PKCE и проверка выданного им ID-токена.
+*/
+package oidc
@@ -0,0 +1,370 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// discoveryPath путь метаданных издателя относительно его адреса
+const discoveryPath = "/.well-known/openid-configuration"
+
+const (
+	// randomSize размер state, nonce и code_verifier в байтах
+	randomSize = 32
+	// requestTimeout таймаут запросов к издателю
+	requestTimeout = 10 * time.Second
+	// maxResponseSize предельный размер ответа издателя
+	maxResponseSize = 1 << 20
+	// keysRefreshInterval ключи издателя перезагружаются из-за неизвестного kid не чаще этого интервала
+	keysRefreshInterval = time.Minute
+	// clockSkew допустимое расхождение часов с издателем
+	clockSkew = time.Minute
+)
+
+// scopes запрашиваемые у издателя права
+var scopes = []string{"openid", "email"}
+
+// Ошибки входа через издателя
+var (
+	// ErrCodeRejected издатель не принял код авторизации: код истёк, уже использован или не совпал code_verifier
+	ErrCodeRejected = errors.New("authorization code is rejected")
+	// ErrInvalidIDToken ID-токен не прошёл проверку
+	ErrInvalidIDToken = errors.New("id token is invalid")
+)
+
+// Config настройки клиента OpenID Connect
+type Config struct {
+	// Issuer адрес издателя; метаданные загружаются с Issuer + /.well-known/openid-configuration
+	Issuer   string
+	ClientID string
+	// ClientSecret секрет клиента; пусто у публичного клиента, который полагается только на PKCE
+	ClientSecret string
+	// RedirectURL адрес возврата с кодом авторизации; должен быть зарегистрирован у издателя
+	RedirectURL string
+}
+
+// AuthRequest начатый вход: state, nonce и code_verifier нужно сохранить до возврата от издателя
+type AuthRequest struct {
+	URL          string // адрес страницы входа издателя
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// metadata метаданные издателя
+type metadata struct {
+	Issuer                string   `json:"issuer"`
+	AuthorizationEndpoint string   `json:"authorization_endpoint"`
+	TokenEndpoint         string   `json:"token_endpoint"`
+	JWKSURI               string   `json:"jwks_uri"`
+	CodeChallengeMethods  []string `json:"code_challenge_methods_supported"`
+}
+
+// idTokenClaims утверждения ID-токена
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce           string `json:"nonce"`
+	AuthorizedParty string `json:"azp"`
+	Email           string `json:"email"`
+}
+
+// Provider клиент издателя; метаданные и ключи издателя загружаются при первом входе
+type Provider struct {
+	conf   Config
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *metadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+// New создаст клиент издателя conf.Issuer
+func New(conf Config) (*Provider, error) {
+	issuer, err := url.Parse(conf.Issuer)
+	if err != nil || (issuer.Scheme != "https" && issuer.Scheme != "http") || issuer.Host == "" {
+		return nil, fmt.Errorf("oidc issuer %q must be absolute http(s) url", conf.Issuer)
+	}
+	if issuer.RawQuery != "" || issuer.Fragment != "" {
+		return nil, fmt.Errorf("oidc issuer %q must not contain query or fragment", conf.Issuer)
+	}
+	if conf.ClientID == "" {
+		return nil, errors.New("oidc client id is empty")
+	}
+	redirect, err := url.Parse(conf.RedirectURL)
+	if err != nil || !redirect.IsAbs() {
+		return nil, fmt.Errorf("oidc redirect url %q must be absolute", conf.RedirectURL)
+	}
+
+	return &Provider{
+		conf:   conf,
+		client: &http.Client{Timeout: requestTimeout},
+		now:    time.Now,
+	}, nil
+}
+
+// SetHTTPClient задаст клиент для запросов к издателю
+func (p *Provider) SetHTTPClient(client *http.Client) {
+	p.client = client
+}
+
+// AuthCodeURL начнёт вход: вернёт адрес страницы входа издателя с запросом кода авторизации
+// и одноразовые значения, которые проверяются при возврате
+func (p *Provider) AuthCodeURL(ctx context.Context) (*AuthRequest, error) {
+	meta, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	request := new(AuthRequest)
+	for _, v := range []*string{&request.State, &request.Nonce, &request.CodeVerifier} {
+		if *v, err = randomString(); err != nil {
+			return nil, err
+		}
+	}
+
+	authURL, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse authorization endpoint: %w", err)
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.conf.ClientID)
+	query.Set("redirect_uri", p.conf.RedirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", request.State)
+	query.Set("nonce", request.Nonce)
+	query.Set("code_challenge", codeChallenge(request.CodeVerifier))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+	request.URL = authURL.String()
+
+	return request, nil
+}
+
+// Exchange обменяет код авторизации на ID-токен, проверит его и вернёт удостоверение пользователя
+// без привязки к пользователю сервиса
+func (p *Provider) Exchange(ctx context.Context,
+	code string, codeVerifier string, nonce string) (*modelAuth.Identity, error) {
+	meta, err := p.getMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := p.exchangeCode(ctx, meta.TokenEndpoint, code, codeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.verifyIDToken(ctx, meta.JWKSURI, rawIDToken, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &modelAuth.Identity{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}, nil
+}
+
+// exchangeCode вернёт ID-токен, выданный издателем за код авторизации
+func (p *Provider) exchangeCode(ctx context.Context,
+	tokenEndpoint string, code string, codeVerifier string) (string, error) {
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.conf.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.conf.ClientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.conf.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.conf.ClientID), url.QueryEscape(p.conf.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&body)
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnauthorized {
+		return "", fmt.Errorf("%w: %s %s", ErrCodeRejected, body.Error, body.ErrorDescription)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("exchange code: unexpected status %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return "", fmt.Errorf("decode token response: %w", decodeErr)
+	}
+	if body.IDToken == "" {
+		return "", fmt.Errorf("%w: token response has no id token", ErrInvalidIDToken)
+	}
+
+	return body.IDToken, nil
+}
+
+// verifyIDToken проверит подпись ID-токена ключами издателя, издателя, получателя, срок действия и nonce
+func (p *Provider) verifyIDToken(ctx context.Context,
+	jwksURI string, rawIDToken string, nonce string) (*idTokenClaims, error) {
+	claims := new(idTokenClaims)
+	_, err := jwt.ParseWithClaims(rawIDToken, claims,
+		func(token *jwt.Token) (interface{}, error) {
+			kid, _ := token.Header["kid"].(string)
+			return p.getKey(ctx, jwksURI, kid)
+		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(p.conf.Issuer),
+		jwt.WithAudience(p.conf.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(p.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidIDToken, err)
+	}
+
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != p.conf.ClientID {
+		return nil, fmt.Errorf("%w: token is issued to %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: subject is empty", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// getMetadata вернёт метаданные издателя; пока они не загружены, каждый вход пробует их загрузить
+func (p *Provider) getMetadata(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	meta := new(metadata)
+	if err := p.getJSON(ctx, strings.TrimSuffix(p.conf.Issuer, "/")+discoveryPath, meta); err != nil {
+		return nil, fmt.Errorf("load issuer metadata: %w", err)
+	}
+	if meta.Issuer != p.conf.Issuer {
+		return nil, fmt.Errorf("issuer metadata is issued by %q", meta.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("issuer metadata has no authorization, token or jwks endpoint")
+	}
+	if len(meta.CodeChallengeMethods) > 0 && !slices.Contains(meta.CodeChallengeMethods, "S256") {
+		return nil, errors.New("issuer does not support pkce with S256")
+	}
+	p.metadata = meta
+
+	return meta, nil
+}
+
+// getKey вернёт ключ издателя kid; неизвестный ключ мог появиться при смене ключей,
+// поэтому набор ключей перезагружается, но не чаще keysRefreshInterval
+func (p *Provider) getKey(ctx context.Context, jwksURI string, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if !p.keysFetchedAt.IsZero() && p.now().Sub(p.keysFetchedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+
+	var set jsonWebKeySet
+	if err := p.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("load issuer keys: %w", err)
+	}
+	keys, err := set.publicKeys()
+	if err != nil {
+		return nil, err
+	}
+	p.keys = keys
+	p.keysFetchedAt = p.now()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// lookupKey найдёт загруженный ключ; токен без kid подписан единственным ключом издателя
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// getJSON загрузит JSON-документ издателя
+func (p *Provider) getJSON(ctx context.Context, address string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(v)
+}
+
+// randomString вернёт случайную строку для state, nonce и code_verifier
+func randomString() (string, error) {
+	b := make([]byte, randomSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// codeChallenge вернёт code_challenge метода S256 для code_verifier
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
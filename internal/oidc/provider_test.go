@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testRedirectURL = "http://localhost:8080/api/auth/oidc/callback"
+
+func newTestProvider(t *testing.T, clientSecret string) (*Provider, *oidctest.Provider) {
+	idp := oidctest.NewProvider("shortener", clientSecret)
+	t.Cleanup(idp.Close)
+
+	p, err := New(Config{
+		Issuer:       idp.Issuer(),
+		ClientID:     "shortener",
+		ClientSecret: clientSecret,
+		RedirectURL:  testRedirectURL,
+	})
+	require.NoError(t, err)
+	return p, idp
+}
+
+// authorize пройдёт вход у издателя и вернёт код авторизации
+func authorize(t *testing.T, p *Provider) (*AuthRequest, string) {
+	request, err := p.AuthCodeURL(context.Background())
+	require.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	resp, err := client.Get(request.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := resp.Location()
+	require.NoError(t, err)
+	require.Equal(t, testRedirectURL, location.Scheme+"://"+location.Host+location.Path)
+	require.Equal(t, request.State, location.Query().Get("state"))
+
+	return request, location.Query().Get("code")
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	for _, secret := range []string{"secret", ""} {
+		p, idp := newTestProvider(t, secret)
+		idp.SetUser("alice", "alice@example.com")
+
+		request, code := authorize(t, p)
+		identity, err := p.Exchange(context.Background(), code, request.CodeVerifier, request.Nonce)
+		require.NoError(t, err)
+		require.Equal(t, idp.Issuer(), identity.Issuer)
+		require.Equal(t, "alice", identity.Subject)
+		require.Equal(t, "alice@example.com", identity.Email)
+
+		// код принимается один раз
+		_, err = p.Exchange(context.Background(), code, request.CodeVerifier, request.Nonce)
+		require.ErrorIs(t, err, ErrCodeRejected)
+	}
+}
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	p, _ := newTestProvider(t, "secret")
+
+	first, err := p.AuthCodeURL(context.Background())
+	require.NoError(t, err)
+	second, err := p.AuthCodeURL(context.Background())
+	require.NoError(t, err)
+	require.NotEqual(t, first.State, second.State)
+	require.NotEqual(t, first.Nonce, second.Nonce)
+	require.NotEqual(t, first.CodeVerifier, second.CodeVerifier)
+
+	authURL, err := url.Parse(first.URL)
+	require.NoError(t, err)
+	query := authURL.Query()
+	require.Equal(t, "S256", query.Get("code_challenge_method"))
+	require.Equal(t, codeChallenge(first.CodeVerifier), query.Get("code_challenge"))
+	require.NotContains(t, first.URL, first.CodeVerifier)
+	require.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+}
+
+func TestProvider_ExchangeInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims func(claims jwt.MapClaims)
+		nonce  string
+		err    error
+	}{
+		{name: "Other nonce", nonce: "other", err: ErrInvalidIDToken},
+		{name: "Other audience", claims: func(c jwt.MapClaims) { c["aud"] = "other" }, err: ErrInvalidIDToken},
+		{name: "Other issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, err: ErrInvalidIDToken},
+		{
+			name:   "Expired",
+			claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+			err:    ErrInvalidIDToken,
+		},
+		{name: "Without expiration", claims: func(c jwt.MapClaims) { delete(c, "exp") }, err: ErrInvalidIDToken},
+		{name: "Without subject", claims: func(c jwt.MapClaims) { delete(c, "sub") }, err: ErrInvalidIDToken},
+		{
+			name: "Issued to other party",
+			claims: func(c jwt.MapClaims) {
+				c["aud"] = []string{"shortener", "other"}
+				c["azp"] = "other"
+			},
+			err: ErrInvalidIDToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, idp := newTestProvider(t, "secret")
+			idp.SetClaims(tt.claims)
+
+			request, code := authorize(t, p)
+			nonce := request.Nonce
+			if tt.nonce != "" {
+				nonce = tt.nonce
+			}
+			_, err := p.Exchange(context.Background(), code, request.CodeVerifier, nonce)
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	t.Run("Other code verifier", func(t *testing.T) {
+		p, _ := newTestProvider(t, "secret")
+		request, code := authorize(t, p)
+		_, err := p.Exchange(context.Background(), code, request.CodeVerifier+"x", request.Nonce)
+		require.ErrorIs(t, err, ErrCodeRejected)
+	})
+}
+
+func TestProvider_KeyRotation(t *testing.T) {
+	p, idp := newTestProvider(t, "secret")
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	request, code := authorize(t, p)
+	_, err := p.Exchange(context.Background(), code, request.CodeVerifier, request.Nonce)
+	require.NoError(t, err)
+
+	// неизвестный ключ не перезагружается чаще интервала
+	idp.RotateKey()
+	request, code = authorize(t, p)
+	_, err = p.Exchange(context.Background(), code, request.CodeVerifier, request.Nonce)
+	require.ErrorIs(t, err, ErrInvalidIDToken)
+
+	now = now.Add(keysRefreshInterval)
+	request, code = authorize(t, p)
+	_, err = p.Exchange(context.Background(), code, request.CodeVerifier, request.Nonce)
+	require.NoError(t, err)
+}
+
+func TestNew(t *testing.T) {
+	valid := Config{Issuer: "https://idp.example.com", ClientID: "shortener", RedirectURL: testRedirectURL}
+	_, err := New(valid)
+	require.NoError(t, err)
+
+	for name, conf := range map[string]Config{
+		"Relative issuer":   {Issuer: "idp.example.com", ClientID: "shortener", RedirectURL: testRedirectURL},
+		"Issuer with query": {Issuer: "https://idp.example.com?tenant=1", ClientID: "shortener", RedirectURL: testRedirectURL},
+		"Without client":    {Issuer: "https://idp.example.com", RedirectURL: testRedirectURL},
+		"Relative redirect": {Issuer: "https://idp.example.com", ClientID: "shortener", RedirectURL: "/callback"},
+	} {
+		_, err = New(conf)
+		require.Error(t, err, name)
+	}
+}
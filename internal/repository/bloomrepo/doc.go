@@ -0,0 +1,5 @@
+/*
+Package bloomrepo реализует декоратор хранилища URL'ов, который отсекает поиск
+заведомо несуществующих ID фильтром Блума, не обращаясь к хранилищу.
+*/
+package bloomrepo
@@ -0,0 +1,73 @@
+package bloomrepo
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// bloomFilter фильтр Блума; не потокобезопасен
+type bloomFilter struct {
+	bits  []uint64
+	m     uint64 // количество бит
+	k     int    // количество хеш-функций
+	n     int    // количество добавленных элементов
+	seeds [2]maphash.Seed
+}
+
+// newBloomFilter создаст фильтр для capacity элементов с вероятностью ложного срабатывания p
+func newBloomFilter(capacity int, p float64) *bloomFilter {
+	if capacity < 1 {
+		capacity = 1
+	}
+	// оптимальные размер и количество хеш-функций
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := int(math.Round(float64(m) / float64(capacity) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+
+	return &bloomFilter{
+		bits:  make([]uint64, (m+63)/64),
+		m:     m,
+		k:     k,
+		seeds: [2]maphash.Seed{maphash.MakeSeed(), maphash.MakeSeed()},
+	}
+}
+
+// add добавит элемент
+func (f *bloomFilter) add(key string) {
+	h1, h2 := f.hash(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.n++
+}
+
+// mayContain вернёт false, если элемент точно не добавлялся
+func (f *bloomFilter) mayContain(key string) bool {
+	h1, h2 := f.hash(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hash вернёт два хеша ключа, из которых получаются позиции всех k бит (схема Кирша-Митценмахера)
+func (f *bloomFilter) hash(key string) (uint64, uint64) {
+	h1 := maphash.String(f.seeds[0], key)
+	// нечётный шаг не зацикливается на части позиций
+	h2 := maphash.String(f.seeds[1], key) | 1
+	return h1, h2
+}
+
+// falsePositiveRate оценит вероятность ложного срабатывания при текущем заполнении
+func (f *bloomFilter) falsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.n)/float64(f.m)), float64(f.k))
+}
@@ -0,0 +1,45 @@
+package bloomrepo
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_bloomFilter_NoFalseNegatives(t *testing.T) {
+	f := newBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.add("id" + strconv.Itoa(i))
+	}
+
+	for i := 0; i < 1000; i++ {
+		require.True(t, f.mayContain("id"+strconv.Itoa(i)))
+	}
+	require.Equal(t, 1000, f.n)
+}
+
+func Test_bloomFilter_FalsePositiveRate(t *testing.T) {
+	const n = 10000
+	f := newBloomFilter(n, 0.01)
+	for i := 0; i < n; i++ {
+		f.add("id" + strconv.Itoa(i))
+	}
+
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if f.mayContain("unknown" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+
+	require.Less(t, float64(falsePositives)/n, 0.02)
+	require.InDelta(t, 0.01, f.falsePositiveRate(), 0.005)
+}
+
+func Test_bloomFilter_Empty(t *testing.T) {
+	f := newBloomFilter(0, 0.01)
+
+	require.False(t, f.mayContain("id"))
+	require.Zero(t, f.falsePositiveRate())
+}
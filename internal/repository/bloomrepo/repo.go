@@ -0,0 +1,287 @@
+package bloomrepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Параметры построения фильтра
+const (
+	// loadPageSize количество URL'ов, загружаемых из хранилища за раз
+	loadPageSize = 1000
+	// minCapacity наименьшее количество элементов, на которое рассчитывается фильтр
+	minCapacity = 1024
+	// growthFactor запас ёмкости фильтра на новые URL'ы до следующего перестроения
+	growthFactor = 2
+)
+
+// ShortenerRepo интерфейс декорируемого хранилища
+type ShortenerRepo interface {
+	SaveURL(ctx context.Context, url string, userID string) (string, error)
+	SaveURLsBatch(ctx context.Context,
+		request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error)
+	GetURLByID(ctx context.Context, id string) (string, error)
+	GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error
+	GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateWorkspaceURLsDeletedFlag(ctx context.Context, workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	TakeDownURL(ctx context.Context, id string) error
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error)
+}
+
+// FilteredRepo декоратор хранилища, отсекающий поиск заведомо несуществующих ID фильтром Блума.
+// Фильтр строится из сохранённых URL'ов, пополняется при каждом сохранении и периодически
+// перестраивается, чтобы убрать удалённые URL'ы; после перестроения удалённый URL ищется как отсутствующий
+type FilteredRepo struct {
+	ShortenerRepo
+
+	falsePositiveRate float64
+
+	mu sync.RWMutex
+	// filter nil, пока фильтр не построен: поиск идёт в хранилище
+	filter *bloomFilter
+	// added ID, сохранённые во время перестроения; nil, если перестроения нет
+	added []string
+
+	rebuildCh chan struct{}
+	stop      context.CancelFunc
+	done      chan struct{}
+
+	rejected       atomic.Int64
+	falsePositives atomic.Int64
+}
+
+// New оборачивает хранилище repo фильтром Блума с вероятностью ложного срабатывания falsePositiveRate.
+// Фильтр строится вызовом Rebuild, а до этого поиск идёт в хранилище: так подписаться на изменения
+// других экземпляров можно до построения. Если rebuildInterval больше нуля, фильтр перестраивается
+// с этим интервалом до Close
+func New(repo ShortenerRepo, falsePositiveRate float64, rebuildInterval time.Duration) (*FilteredRepo, error) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, errors.New("bloom repo: false positive rate must be between 0 and 1")
+	}
+
+	r := &FilteredRepo{
+		ShortenerRepo:     repo,
+		falsePositiveRate: falsePositiveRate,
+		rebuildCh:         make(chan struct{}, 1),
+		done:              make(chan struct{}),
+	}
+
+	bgCtx, cancel := context.WithCancel(context.Background())
+	r.stop = cancel
+	go r.rebuildLoop(bgCtx, rebuildInterval)
+
+	return r, nil
+}
+
+// Close остановит перестроение фильтра
+func (r *FilteredRepo) Close() error {
+	r.stop()
+	<-r.done
+	return nil
+}
+
+// rebuildLoop перестраивает фильтр по таймеру и по запросу InvalidateAll
+func (r *FilteredRepo) rebuildLoop(ctx context.Context, interval time.Duration) {
+	defer close(r.done)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-r.rebuildCh:
+		}
+
+		if err := r.Rebuild(ctx); err != nil && ctx.Err() == nil {
+			logger.Log.Error("bloom filter rebuild error", zap.Error(err))
+		}
+	}
+}
+
+// Rebuild построит фильтр заново из неудалённых URL'ов хранилища;
+// URL'ы, сохранённые во время построения, тоже попадут в фильтр
+func (r *FilteredRepo) Rebuild(ctx context.Context) error {
+	r.mu.Lock()
+	r.added = make([]string, 0)
+	r.mu.Unlock()
+
+	ids, err := r.loadIDs(ctx)
+	if err != nil {
+		r.mu.Lock()
+		r.added = nil
+		r.mu.Unlock()
+		return err
+	}
+
+	capacity := len(ids) * growthFactor
+	if capacity < minCapacity {
+		capacity = minCapacity
+	}
+	f := newBloomFilter(capacity, r.falsePositiveRate)
+	for _, id := range ids {
+		f.add(id)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range r.added {
+		f.add(id)
+	}
+	r.added = nil
+	r.filter = f
+
+	return nil
+}
+
+// loadIDs вернёт ID всех неудалённых URL'ов хранилища
+func (r *FilteredRepo) loadIDs(ctx context.Context) ([]string, error) {
+	ids := make([]string, 0)
+	afterID := ""
+	for {
+		page, err := r.ShortenerRepo.GetURLsPage(ctx, afterID, loadPageSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			return ids, nil
+		}
+		for _, u := range page {
+			if !u.Deleted {
+				ids = append(ids, u.ID)
+			}
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// add добавит ID в фильтр, а во время перестроения запомнит их для нового фильтра
+func (r *FilteredRepo) add(ids ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.filter != nil {
+		for _, id := range ids {
+			r.filter.add(id)
+		}
+	}
+	if r.added != nil {
+		r.added = append(r.added, ids...)
+	}
+}
+
+// mayContain вернёт false, если URL'а с таким ID точно нет
+func (r *FilteredRepo) mayContain(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.filter == nil || r.filter.mayContain(id)
+}
+
+// GetURLByID вернёт URL из хранилища; заведомо несуществующие ID отсекаются без обращения к нему
+func (r *FilteredRepo) GetURLByID(ctx context.Context, id string) (string, error) {
+	if !r.mayContain(id) {
+		r.rejected.Add(1)
+		return "", repoCommon.ErrNotFoundKey
+	}
+
+	url, err := r.ShortenerRepo.GetURLByID(ctx, id)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		r.falsePositives.Add(1)
+	}
+
+	return url, err
+}
+
+// SaveURL сохранит URL и добавит его ID в фильтр
+func (r *FilteredRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
+	id, err := r.ShortenerRepo.SaveURL(ctx, url, userID)
+	if err != nil {
+		var errAlreadyExists *repoCommon.URLAlreadyExistsError
+		if errors.As(err, &errAlreadyExists) {
+			r.add(errAlreadyExists.ID)
+		}
+		return id, err
+	}
+
+	r.add(id)
+	return id, nil
+}
+
+// SaveURLsBatch сохранит URL'ы пачкой и добавит их ID в фильтр
+func (r *FilteredRepo) SaveURLsBatch(ctx context.Context,
+	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
+	response, err := r.ShortenerRepo.SaveURLsBatch(ctx, request, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(response))
+	for _, v := range response {
+		ids = append(ids, v.ShortURL)
+	}
+	r.add(ids...)
+
+	return response, nil
+}
+
+// Invalidate добавит в фильтр ID URL'ов, изменённых другим экземпляром приложения
+func (r *FilteredRepo) Invalidate(ids ...string) {
+	r.add(ids...)
+}
+
+// InvalidateAll отключит фильтр до перестроения: изменения других экземпляров могли быть пропущены
+func (r *FilteredRepo) InvalidateAll() {
+	r.mu.Lock()
+	r.filter = nil
+	r.mu.Unlock()
+
+	select {
+	case r.rebuildCh <- struct{}{}:
+	default:
+	}
+}
+
+// Stats вернёт состояние фильтра и счётчики отсечённых запросов
+func (r *FilteredRepo) Stats() modelStats.BloomStats {
+	stats := modelStats.BloomStats{
+		Rejected:       r.rejected.Load(),
+		FalsePositives: r.falsePositives.Load(),
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.filter != nil {
+		stats.Items = r.filter.n
+		stats.Bits = r.filter.m
+		stats.Hashes = r.filter.k
+		stats.FalsePositiveRate = r.filter.falsePositiveRate()
+	}
+
+	return stats
+}
+
+// FillStats дополнит статистику состоянием фильтра
+func (r *FilteredRepo) FillStats(response *modelStats.StatsResponse) {
+	stats := r.Stats()
+	response.Bloom = &stats
+}
@@ -0,0 +1,156 @@
+package bloomrepo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	inmrRepo "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRepo хранилище, считающее обращения к GetURLByID
+type countingRepo struct {
+	*inmrRepo.InMemoryRepo
+	calls int
+}
+
+func (r *countingRepo) GetURLByID(ctx context.Context, id string) (string, error) {
+	r.calls++
+	return r.InMemoryRepo.GetURLByID(ctx, id)
+}
+
+func newFilteredRepo(t *testing.T, repo *countingRepo) *FilteredRepo {
+	r, err := New(repo, 0.01, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	require.NoError(t, r.Rebuild(context.Background()))
+	return r
+}
+
+func TestFilteredRepo_GetURLByID(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+	inner.AddURL("existing", "http://example.com", "user")
+	r := newFilteredRepo(t, inner)
+
+	url, err := r.GetURLByID(ctx, "existing")
+	require.NoError(t, err)
+	require.Equal(t, "http://example.com", url)
+	require.Equal(t, 1, inner.calls)
+
+	_, err = r.GetURLByID(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Equal(t, 1, inner.calls)
+
+	stats := r.Stats()
+	require.Equal(t, 1, stats.Items)
+	require.EqualValues(t, 1, stats.Rejected)
+	require.Zero(t, stats.FalsePositives)
+	require.NotZero(t, stats.Bits)
+	require.NotZero(t, stats.Hashes)
+}
+
+func TestFilteredRepo_Save(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+	r := newFilteredRepo(t, inner)
+
+	id, err := r.SaveURL(ctx, "http://example.com/1", "user")
+	require.NoError(t, err)
+	response, err := r.SaveURLsBatch(ctx, []model.CreateShortenURLBatchItemRequest{
+		{CorrelationID: "1", OriginalURL: "http://example.com/2"},
+		{CorrelationID: "2", OriginalURL: "http://example.com/3"},
+	}, "user")
+	require.NoError(t, err)
+
+	ids := []string{id, response[0].ShortURL, response[1].ShortURL}
+	for _, id := range ids {
+		_, err = r.GetURLByID(ctx, id)
+		require.NoError(t, err)
+	}
+	require.Equal(t, len(ids), inner.calls)
+	require.Zero(t, r.Stats().Rejected)
+}
+
+func TestFilteredRepo_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+	inner.AddURL("deleted", "http://example.com/1", "user")
+	inner.AddURL("alive", "http://example.com/2", "user")
+	r := newFilteredRepo(t, inner)
+
+	// до перестроения удалённый URL ищется в хранилище
+	inner.MarkURLsDeleted("deleted")
+	_, err := r.GetURLByID(ctx, "deleted")
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+	require.Equal(t, 1, inner.calls)
+
+	require.NoError(t, r.Rebuild(ctx))
+	_, err = r.GetURLByID(ctx, "deleted")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Equal(t, 1, inner.calls)
+
+	_, err = r.GetURLByID(ctx, "alive")
+	require.NoError(t, err)
+	require.Equal(t, 1, r.Stats().Items)
+}
+
+func TestFilteredRepo_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+	r := newFilteredRepo(t, inner)
+
+	// URL сохранён другим экземпляром приложения
+	inner.AddURL("remote", "http://example.com", "user")
+	_, err := r.GetURLByID(ctx, "remote")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Zero(t, inner.calls)
+
+	r.Invalidate("remote")
+	_, err = r.GetURLByID(ctx, "remote")
+	require.NoError(t, err)
+}
+
+func TestFilteredRepo_InvalidateAll(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+	r := newFilteredRepo(t, inner)
+
+	inner.AddURL("remote", "http://example.com", "user")
+	r.InvalidateAll()
+
+	// до перестроения фильтр ничего не отсекает, после - знает о новом URL'е
+	_, err := r.GetURLByID(ctx, "remote")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return r.Stats().Items == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = r.GetURLByID(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+func TestNew_NotBuilt(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+	r, err := New(inner, 0.01, 0)
+	require.NoError(t, err)
+	defer r.Close()
+
+	// до построения фильтр ничего не отсекает
+	_, err = r.GetURLByID(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Equal(t, 1, inner.calls)
+}
+
+func TestNew_InvalidFalsePositiveRate(t *testing.T) {
+	inner := &countingRepo{InMemoryRepo: inmrRepo.NewInMemoryRepo()}
+
+	_, err := New(inner, 0, 0)
+	require.Error(t, err)
+	_, err = New(inner, 1, 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,5 @@
+/*
+Package cacherepo реализует декоратор хранилища URL'ов с кешированием
+результатов GetURLByID в ограниченном LRU кеше.
+*/
+package cacherepo
@@ -0,0 +1,159 @@
+package cacherepo
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// cacheItem запись кеша
+type cacheItem struct {
+	key       string    // ID URL'а
+	url       string    // оригинальный URL
+	err       error     // закешированная ошибка: URL не найден или удалён
+	expiresAt time.Time // время, после которого запись считается устаревшей
+}
+
+// lruCache потокобезопасный LRU кеш ограниченного размера
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List               // список записей; в начале - недавно использованные
+	items    map[string]*list.Element // ключ - ID URL'а
+	loads    map[string]*load         // незавершённые чтения из хранилища
+	epoch    uint64                   // растёт при каждой полной очистке
+}
+
+// load незавершённые чтения ключа из хранилища
+type load struct {
+	readers int    // количество читающих
+	gen     uint64 // растёт при каждом сбросе ключа во время чтения
+}
+
+// loadToken состояние ключа на момент начала чтения
+type loadToken struct {
+	gen   uint64
+	epoch uint64
+}
+
+func newLRUCache(capacity int) *lruCache {
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+		loads:    make(map[string]*load),
+	}
+}
+
+// get вернёт актуальную запись кеша; устаревшая запись удаляется
+func (c *lruCache) get(key string, now time.Time) (cacheItem, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		return cacheItem{}, false
+	}
+
+	item := el.Value.(*cacheItem)
+	if now.After(item.expiresAt) {
+		c.removeElement(el)
+		return cacheItem{}, false
+	}
+
+	c.ll.MoveToFront(el)
+	return *item, true
+}
+
+// set добавит или обновит запись, вытесняя давно неиспользуемые при переполнении
+func (c *lruCache) set(item cacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setLocked(item)
+}
+
+// beginLoad отметит начало чтения key из хранилища при промахе
+func (c *lruCache) beginLoad(key string) loadToken {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, ok := c.loads[key]
+	if !ok {
+		l = &load{}
+		c.loads[key] = l
+	}
+	l.readers++
+
+	return loadToken{gen: l.gen, epoch: c.epoch}
+}
+
+// finishLoad завершит чтение key и запомнит item, если за время чтения ключ не сбрасывался:
+// иначе прочитанное значение могло устареть, например URL удалили сразу после чтения.
+// item равный nil только завершает чтение
+func (c *lruCache) finishLoad(key string, token loadToken, item *cacheItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l := c.loads[key]
+	fresh := l.gen == token.gen && c.epoch == token.epoch
+	l.readers--
+	if l.readers == 0 {
+		delete(c.loads, key)
+	}
+
+	if item != nil && fresh {
+		c.setLocked(*item)
+	}
+}
+
+func (c *lruCache) setLocked(item cacheItem) {
+	if el, ok := c.items[item.key]; ok {
+		el.Value = &item
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[item.key] = c.ll.PushFront(&item)
+	for c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// remove удалит записи с указанными ключами
+func (c *lruCache) remove(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+		if l, ok := c.loads[key]; ok {
+			l.gen++
+		}
+	}
+}
+
+// clear удалит все записи
+func (c *lruCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ll.Init()
+	c.items = make(map[string]*list.Element, c.capacity)
+	c.epoch++
+}
+
+// len вернёт количество записей в кеше
+func (c *lruCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *lruCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*cacheItem).key)
+}
@@ -0,0 +1,63 @@
+package cacherepo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_lruCache_Eviction(t *testing.T) {
+	now := time.Now()
+	expiresAt := now.Add(time.Minute)
+	c := newLRUCache(2)
+
+	c.set(cacheItem{key: "a", url: "A", expiresAt: expiresAt})
+	c.set(cacheItem{key: "b", url: "B", expiresAt: expiresAt})
+	// "a" становится недавно использованным, вытеснен будет "b"
+	_, ok := c.get("a", now)
+	require.True(t, ok)
+	c.set(cacheItem{key: "c", url: "C", expiresAt: expiresAt})
+
+	require.Equal(t, 2, c.len())
+	_, ok = c.get("b", now)
+	require.False(t, ok)
+	item, ok := c.get("a", now)
+	require.True(t, ok)
+	require.Equal(t, "A", item.url)
+	_, ok = c.get("c", now)
+	require.True(t, ok)
+}
+
+func Test_lruCache_Update(t *testing.T) {
+	now := time.Now()
+	c := newLRUCache(2)
+
+	c.set(cacheItem{key: "a", url: "A", expiresAt: now.Add(time.Minute)})
+	c.set(cacheItem{key: "a", url: "AA", expiresAt: now.Add(time.Minute)})
+
+	require.Equal(t, 1, c.len())
+	item, ok := c.get("a", now)
+	require.True(t, ok)
+	require.Equal(t, "AA", item.url)
+
+	c.remove("a")
+	require.Equal(t, 0, c.len())
+}
+
+func Test_lruCache_Clear(t *testing.T) {
+	now := time.Now()
+	c := newLRUCache(2)
+	c.set(cacheItem{key: "a", url: "A", expiresAt: now.Add(time.Minute)})
+	c.set(cacheItem{key: "b", url: "B", expiresAt: now.Add(time.Minute)})
+
+	c.clear()
+	require.Equal(t, 0, c.len())
+	_, ok := c.get("a", now)
+	require.False(t, ok)
+
+	// после очистки кеш продолжает работать
+	c.set(cacheItem{key: "c", url: "C", expiresAt: now.Add(time.Minute)})
+	_, ok = c.get("c", now)
+	require.True(t, ok)
+}
@@ -0,0 +1,230 @@
+package cacherepo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// ShortenerRepo интерфейс декорируемого хранилища
+type ShortenerRepo interface {
+	SaveURL(ctx context.Context, url string, userID string) (string, error)
+	SaveURLsBatch(ctx context.Context,
+		request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error)
+	GetURLByID(ctx context.Context, id string) (string, error)
+	GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error
+	GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateWorkspaceURLsDeletedFlag(ctx context.Context, workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	TakeDownURL(ctx context.Context, id string) error
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// CachedRepo декоратор хранилища, кеширующий результаты GetURLByID:
+// найденные URL'ы, а также отсутствующие и удалённые URL'ы (негативное кеширование)
+type CachedRepo struct {
+	ShortenerRepo
+
+	cache       *lruCache
+	ttl         time.Duration // время жизни найденного URL'а
+	negativeTTL time.Duration // время жизни записи об отсутствующем или удалённом URL'е
+	now         func() time.Time
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// New оборачивает хранилище repo кешем ёмкостью size записей
+func New(repo ShortenerRepo, size int, ttl time.Duration, negativeTTL time.Duration) *CachedRepo {
+	return &CachedRepo{
+		ShortenerRepo: repo,
+		cache:         newLRUCache(size),
+		ttl:           ttl,
+		negativeTTL:   negativeTTL,
+		now:           time.Now,
+	}
+}
+
+// txKey ключ контекста с транзакцией декоратора r
+type txKey struct {
+	repo *CachedRepo
+}
+
+// cacheTx ID URL'ов, изменённых в транзакции
+type cacheTx struct {
+	mu  sync.Mutex
+	ids []string
+}
+
+// WithinTx выполнит fn в транзакции хранилища. Внутри транзакции кеш не заполняется,
+// а записи об изменённых URL'ах сбрасываются ещё раз после её завершения,
+// чтобы в кеше не осталось незафиксированных или откаченных данных
+func (r *CachedRepo) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if r.tx(ctx) != nil {
+		return r.ShortenerRepo.WithinTx(ctx, fn)
+	}
+
+	tx := &cacheTx{}
+	err := r.ShortenerRepo.WithinTx(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, txKey{repo: r}, tx))
+	})
+	r.cache.remove(tx.ids...)
+
+	return err
+}
+
+// tx вернёт текущую транзакцию или nil
+func (r *CachedRepo) tx(ctx context.Context) *cacheTx {
+	tx, _ := ctx.Value(txKey{repo: r}).(*cacheTx)
+	return tx
+}
+
+// invalidate сбросит записи с указанными ID, а внутри транзакции запомнит их
+// для повторного сброса после её завершения
+func (r *CachedRepo) invalidate(ctx context.Context, ids ...string) {
+	r.cache.remove(ids...)
+	if tx := r.tx(ctx); tx != nil {
+		tx.mu.Lock()
+		tx.ids = append(tx.ids, ids...)
+		tx.mu.Unlock()
+	}
+}
+
+// GetURLByID вернёт URL из кеша или из хранилища, запомнив результат
+func (r *CachedRepo) GetURLByID(ctx context.Context, id string) (string, error) {
+	// внутри транзакции читаем мимо кеша
+	if r.tx(ctx) != nil {
+		return r.ShortenerRepo.GetURLByID(ctx, id)
+	}
+
+	now := r.now()
+	if item, ok := r.cache.get(id, now); ok {
+		r.hits.Add(1)
+		return item.url, item.err
+	}
+	r.misses.Add(1)
+
+	// если URL изменят, пока он читается, результат чтения в кеш не попадёт
+	token := r.cache.beginLoad(id)
+	url, err := r.ShortenerRepo.GetURLByID(ctx, id)
+	var item *cacheItem
+	switch {
+	case err == nil:
+		item = &cacheItem{key: id, url: url, expiresAt: now.Add(r.ttl)}
+	case errors.Is(err, repoCommon.ErrNotFoundKey), errors.Is(err, repoCommon.ErrURLDeleted):
+		item = &cacheItem{key: id, err: err, expiresAt: now.Add(r.negativeTTL)}
+	}
+	r.cache.finishLoad(id, token, item)
+
+	return url, err
+}
+
+// SaveURL сохранит URL и сбросит закешированную запись о его ID
+func (r *CachedRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
+	id, err := r.ShortenerRepo.SaveURL(ctx, url, userID)
+	if err != nil {
+		var errAlreadyExists *repoCommon.URLAlreadyExistsError
+		if errors.As(err, &errAlreadyExists) {
+			r.invalidate(ctx, errAlreadyExists.ID)
+		}
+		return id, err
+	}
+
+	r.invalidate(ctx, id)
+	return id, nil
+}
+
+// SaveURLsBatch сохранит URL'ы пачкой и сбросит закешированные записи об их ID
+func (r *CachedRepo) SaveURLsBatch(ctx context.Context,
+	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
+	response, err := r.ShortenerRepo.SaveURLsBatch(ctx, request, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(response))
+	for _, v := range response {
+		ids = append(ids, v.ShortURL)
+	}
+	r.invalidate(ctx, ids...)
+
+	return response, nil
+}
+
+// UpdateURLsDeletedFlag пометит URL'ы удалёнными и сбросит их закешированные записи
+func (r *CachedRepo) UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	return r.markDeleted(ctx, modelsCh, func(ch <-chan model.UpdateURLDeletedFlag) error {
+		return r.ShortenerRepo.UpdateURLsDeletedFlag(ctx, userID, ch)
+	})
+}
+
+// UpdateWorkspaceURLsDeletedFlag пометит URL'ы рабочего пространства удалёнными
+// и сбросит их закешированные записи
+func (r *CachedRepo) UpdateWorkspaceURLsDeletedFlag(ctx context.Context,
+	workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	return r.markDeleted(ctx, modelsCh, func(ch <-chan model.UpdateURLDeletedFlag) error {
+		return r.ShortenerRepo.UpdateWorkspaceURLsDeletedFlag(ctx, workspaceID, ch)
+	})
+}
+
+// markDeleted передаст modelsCh в update и сбросит закешированные записи переданных URL'ов
+func (r *CachedRepo) markDeleted(ctx context.Context,
+	modelsCh <-chan model.UpdateURLDeletedFlag, update func(ch <-chan model.UpdateURLDeletedFlag) error) error {
+	ids := make([]string, 0)
+	teeCh := make(chan model.UpdateURLDeletedFlag)
+	go func() {
+		defer close(teeCh)
+		for m := range modelsCh {
+			ids = append(ids, m.URLID)
+			teeCh <- m
+		}
+	}()
+
+	err := update(teeCh)
+	// дочитаем канал, если хранилище завершилось раньше
+	for range teeCh {
+	}
+	r.invalidate(ctx, ids...)
+
+	return err
+}
+
+// TakeDownURL пометит URL удалённым для всех владельцев и сбросит его закешированную запись
+func (r *CachedRepo) TakeDownURL(ctx context.Context, id string) error {
+	err := r.ShortenerRepo.TakeDownURL(ctx, id)
+	r.invalidate(ctx, id)
+
+	return err
+}
+
+// Invalidate сбросит закешированные записи с указанными ID
+func (r *CachedRepo) Invalidate(ids ...string) {
+	r.cache.remove(ids...)
+}
+
+// InvalidateAll сбросит все закешированные записи
+func (r *CachedRepo) InvalidateAll() {
+	r.cache.clear()
+}
+
+// Stats вернёт счётчики попаданий и промахов кеша
+func (r *CachedRepo) Stats() modelStats.CacheStats {
+	return modelStats.CacheStats{
+		Hits:   r.hits.Load(),
+		Misses: r.misses.Load(),
+		Size:   r.cache.len(),
+	}
+}
+
+// FillStats дополнит статистику счётчиками кеша
+func (r *CachedRepo) FillStats(response *modelStats.StatsResponse) {
+	stats := r.Stats()
+	response.Cache = &stats
+}
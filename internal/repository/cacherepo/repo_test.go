@@ -0,0 +1,331 @@
+package cacherepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// repoMock хранилище, считающее обращения к GetURLByID
+type repoMock struct {
+	urls    map[string]string // ID -> URL
+	deleted map[string]bool
+	calls   int
+	// afterGet вызывается после чтения URL'а, имитируя изменение, параллельное чтению
+	afterGet func()
+}
+
+func newRepoMock() *repoMock {
+	return &repoMock{
+		urls:    map[string]string{},
+		deleted: map[string]bool{},
+	}
+}
+
+func (m *repoMock) SaveURL(ctx context.Context, url string, userID string) (string, error) {
+	for id, u := range m.urls {
+		if u == url {
+			return id, repoCommon.NewURLAlreadyExistsError(id, url)
+		}
+	}
+	id := url[len(url)-1:]
+	m.urls[id] = url
+	return id, nil
+}
+
+func (m *repoMock) SaveURLsBatch(ctx context.Context,
+	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
+	response := make([]model.CreateShortenURLBatchItemResponse, 0, len(request))
+	for _, v := range request {
+		id, err := m.SaveURL(ctx, v.OriginalURL, userID)
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, model.CreateShortenURLBatchItemResponse{CorrelationID: v.CorrelationID, ShortURL: id})
+	}
+	return response, nil
+}
+
+func (m *repoMock) GetURLByID(ctx context.Context, id string) (string, error) {
+	m.calls++
+	url, ok := m.urls[id]
+	deleted := m.deleted[id]
+	if m.afterGet != nil {
+		m.afterGet()
+	}
+	if !ok {
+		return "", repoCommon.ErrNotFoundKey
+	}
+	if deleted {
+		return "", repoCommon.ErrURLDeleted
+	}
+	return url, nil
+}
+
+func (m *repoMock) GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *repoMock) UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	for v := range modelsCh {
+		m.deleted[v.URLID] = true
+	}
+	return nil
+}
+
+func (m *repoMock) AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error {
+	return nil
+}
+
+func (m *repoMock) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *repoMock) UpdateWorkspaceURLsDeletedFlag(ctx context.Context,
+	workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	return m.UpdateURLsDeletedFlag(ctx, workspaceID, modelsCh)
+}
+
+func (m *repoMock) TakeDownURL(ctx context.Context, id string) error {
+	if _, ok := m.urls[id]; !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	m.deleted[id] = true
+	return nil
+}
+
+// WithinTx откатывает сохранённые в fn URL'ы, если fn вернула ошибку
+func (m *repoMock) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	saved := make(map[string]string, len(m.urls))
+	for id, url := range m.urls {
+		saved[id] = url
+	}
+
+	err := fn(ctx)
+	if err != nil {
+		m.urls = saved
+	}
+	return err
+}
+
+func TestCachedRepo_GetURLByID(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+
+	id, err := r.SaveURL(ctx, "https://example.com/a", "")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		url, err := r.GetURLByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/a", url)
+	}
+	require.Equal(t, 1, inner.calls)
+
+	stats := r.Stats()
+	require.Equal(t, int64(2), stats.Hits)
+	require.Equal(t, int64(1), stats.Misses)
+	require.Equal(t, 1, stats.Size)
+}
+
+func TestCachedRepo_NegativeCaching(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := r.GetURLByID(ctx, "b")
+		require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	}
+	require.Equal(t, 1, inner.calls)
+
+	// сохранение URL'а сбрасывает негативную запись
+	id, err := r.SaveURL(ctx, "https://example.com/b", "")
+	require.NoError(t, err)
+	url, err := r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/b", url)
+	require.Equal(t, 2, inner.calls)
+}
+
+func TestCachedRepo_InvalidateOnDelete(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+
+	id, err := r.SaveURL(ctx, "https://example.com/c", "")
+	require.NoError(t, err)
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+
+	modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: id}
+	close(modelsCh)
+	require.NoError(t, r.UpdateURLsDeletedFlag(ctx, "", modelsCh))
+
+	// удалённое состояние тоже кешируется
+	for i := 0; i < 2; i++ {
+		_, err = r.GetURLByID(ctx, id)
+		require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+	}
+	require.Equal(t, 2, inner.calls)
+}
+
+func TestCachedRepo_InvalidateOnWorkspaceDelete(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+
+	id, err := r.SaveURL(ctx, "https://example.com/w", "")
+	require.NoError(t, err)
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+
+	modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: id}
+	close(modelsCh)
+	require.NoError(t, r.UpdateWorkspaceURLsDeletedFlag(ctx, "ws-1", modelsCh))
+
+	_, err = r.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+	require.Equal(t, 2, inner.calls)
+}
+
+// TestCachedRepo_DeleteDuringMiss проверяет, что URL, удалённый во время чтения при промахе,
+// не попадает в кеш прочитанным до удаления
+func TestCachedRepo_DeleteDuringMiss(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+	id, err := r.SaveURL(ctx, "https://example.com/a", "")
+	require.NoError(t, err)
+
+	inner.afterGet = func() {
+		inner.afterGet = nil
+		modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+		modelsCh <- model.UpdateURLDeletedFlag{URLID: id}
+		close(modelsCh)
+		require.NoError(t, r.UpdateURLsDeletedFlag(ctx, "", modelsCh))
+	}
+	url, err := r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/a", url)
+
+	_, err = r.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+	require.Equal(t, 2, inner.calls)
+
+	// полная очистка во время чтения тоже не даёт запомнить прочитанное
+	id, err = r.SaveURL(ctx, "https://example.com/b", "")
+	require.NoError(t, err)
+	inner.afterGet = func() {
+		inner.afterGet = nil
+		r.InvalidateAll()
+	}
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, 0, r.Stats().Size)
+}
+
+func TestCachedRepo_Expiration(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Second)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	id, err := r.SaveURL(ctx, "https://example.com/d", "")
+	require.NoError(t, err)
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	_, err = r.GetURLByID(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Equal(t, 2, inner.calls)
+
+	// негативная запись устарела, а найденный URL ещё нет
+	now = now.Add(2 * time.Second)
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	_, err = r.GetURLByID(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Equal(t, 3, inner.calls)
+
+	now = now.Add(2 * time.Minute)
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, 4, inner.calls)
+}
+
+func TestCachedRepo_TakeDownURL(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+
+	id, err := r.SaveURL(ctx, "https://example.com/t", "")
+	require.NoError(t, err)
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+
+	require.NoError(t, r.TakeDownURL(ctx, id))
+	_, err = r.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+	require.Equal(t, 2, inner.calls)
+}
+
+func TestCachedRepo_Invalidate(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+
+	_, err := r.GetURLByID(ctx, "e")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+
+	// URL сохранён в обход декоратора
+	inner.urls["e"] = "https://example.com/e"
+	r.Invalidate("e")
+
+	url, err := r.GetURLByID(ctx, "e")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/e", url)
+}
+
+func TestCachedRepo_WithinTx(t *testing.T) {
+	ctx := context.Background()
+	inner := newRepoMock()
+	r := New(inner, 10, time.Minute, time.Minute)
+	errRollback := errors.New("rollback")
+
+	err := r.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := r.SaveURL(ctx, "https://example.com/f", "")
+		require.NoError(t, err)
+
+		// внутри транзакции кеш не заполняется
+		url, err := r.GetURLByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/f", url)
+		require.Equal(t, 0, r.Stats().Size)
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	// откаченный URL не остался в кеше
+	_, err = r.GetURLByID(ctx, "f")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+
+	// после фиксации негативная запись сброшена
+	err = r.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := r.SaveURL(ctx, "https://example.com/f", "")
+		return err
+	})
+	require.NoError(t, err)
+	url, err := r.GetURLByID(ctx, "f")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/f", url)
+}
@@ -1,8 +1,8 @@
 package repository
 
 import (
-	"encoding/base64"
 	"hash"
+	"strconv"
 )
 
 // var letterRunes = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
@@ -15,14 +15,48 @@ import (
 // 	return string(b)
 // }
 
+// MaxURLIDAttempts количество попыток подобрать ID URL'а, если хеши разных URL'ов совпали
+const MaxURLIDAttempts = 10
+
+// urlIDAlphabet символы base62, из которых состоит ID URL'а: без '-', '_' и '='
+const urlIDAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// urlIDLength длина ID URL'а: 7 символов base62 вмещают 5 байт хеша
+const urlIDLength = 7
+
+// HashFunc конструктор хеша, из которого получаются ID URL'ов
+type HashFunc func() hash.Hash
+
 // GenerateURLUniqueHash генерирует уникальный хэш для переданной строки
 func GenerateURLUniqueHash(h hash.Hash, url string) (string, error) {
+	return GenerateURLID(h, url, 0)
+}
+
+// GenerateURLID генерирует ID URL'а для попытки attempt: первые 5 байт хеша в base62.
+// Нулевая попытка совпадает с GenerateURLUniqueHash,
+// следующие подмешивают номер попытки, чтобы при коллизии получить другой ID
+func GenerateURLID(h hash.Hash, url string, attempt int) (string, error) {
 	h.Reset()
+	if attempt > 0 {
+		_, err := h.Write([]byte(strconv.Itoa(attempt) + "#"))
+		if err != nil {
+			return "", err
+		}
+	}
 	_, err := h.Write([]byte(url))
 	if err != nil {
 		return "", err
 	}
-	hash := base64.URLEncoding.EncodeToString(h.Sum(nil)[:5])
 
-	return hash, nil
+	var n uint64
+	for _, b := range h.Sum(nil)[:5] {
+		n = n<<8 | uint64(b)
+	}
+	id := make([]byte, urlIDLength)
+	for i := len(id) - 1; i >= 0; i-- {
+		id[i] = urlIDAlphabet[n%uint64(len(urlIDAlphabet))]
+		n /= uint64(len(urlIDAlphabet))
+	}
+
+	return string(id), nil
 }
@@ -0,0 +1,448 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// AuthRepo интерфейс хранилища данных аутентификации
+type AuthRepo interface {
+	Repo
+	SaveRefreshToken(ctx context.Context, token modelAuth.RefreshToken) error
+	ConsumeRefreshToken(ctx context.Context, hash string) (*modelAuth.RefreshToken, error)
+	CreateAccount(ctx context.Context, account modelAuth.Account) error
+	GetAccountByEmail(ctx context.Context, email string) (*modelAuth.Account, error)
+	CreateIdentity(ctx context.Context, identity modelAuth.Identity) error
+	GetIdentity(ctx context.Context, issuer string, subject string) (*modelAuth.Identity, error)
+	SaveAPIKey(ctx context.Context, key modelAuth.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (*modelAuth.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID string, id string) error
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+	CreateSession(ctx context.Context, session modelAuth.Session) error
+	GetSession(ctx context.Context, id string) (*modelAuth.Session, error)
+	GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error)
+	TouchSession(ctx context.Context, id string, seenAt time.Time) error
+	RevokeSession(ctx context.Context, userID string, id string, revokedAt time.Time) error
+	RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error
+	GetUser(ctx context.Context, userID string) (*modelAuth.User, error)
+	ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error)
+	UpdateUser(ctx context.Context, user modelAuth.User) error
+	TakeDownURL(ctx context.Context, id string) error
+}
+
+// NewAuthRepoFunc создаёт пустое хранилище для очередного сценария
+type NewAuthRepoFunc func(t *testing.T) AuthRepo
+
+// RunAuth прогоняет сценарии хранения данных аутентификации
+func RunAuth(t *testing.T, newRepo NewAuthRepoFunc) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r AuthRepo)
+	}{
+		{name: "RefreshTokenConsume", run: testRefreshTokenConsume},
+		{name: "RefreshTokenUnknown", run: testRefreshTokenUnknown},
+		{name: "AccountCreate", run: testAccountCreate},
+		{name: "AccountExists", run: testAccountExists},
+		{name: "AccountUnknown", run: testAccountUnknown},
+		{name: "Identities", run: testIdentities},
+		{name: "APIKeys", run: testAPIKeys},
+		{name: "APIKeyDelete", run: testAPIKeyDelete},
+		{name: "Sessions", run: testSessions},
+		{name: "SessionRevoke", run: testSessionRevoke},
+		{name: "UserRoles", run: testUserRoles},
+		{name: "UserUnknown", run: testUserUnknown},
+		{name: "TakeDownURL", run: testTakeDownURL},
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			sc.run(t, newRepo(t))
+		})
+	}
+}
+
+// testRefreshTokenConsume токен обновления возвращается один раз
+func testRefreshTokenConsume(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	// время с точностью до миллисекунд хранится во всех хранилищах
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+
+	first := modelAuth.RefreshToken{Hash: "hash-1", UserID: userID, SessionID: "session-1", ExpiresAt: expiresAt}
+	second := modelAuth.RefreshToken{Hash: "hash-2", UserID: userID, ExpiresAt: expiresAt}
+	require.NoError(t, r.SaveRefreshToken(ctx, first))
+	require.NoError(t, r.SaveRefreshToken(ctx, second))
+
+	got, err := r.ConsumeRefreshToken(ctx, first.Hash)
+	require.NoError(t, err)
+	require.Equal(t, first.Hash, got.Hash)
+	require.Equal(t, userID, got.UserID)
+	require.Equal(t, first.SessionID, got.SessionID)
+	require.True(t, expiresAt.Equal(got.ExpiresAt), "expires at %v, got %v", expiresAt, got.ExpiresAt)
+
+	_, err = r.ConsumeRefreshToken(ctx, first.Hash)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+
+	// остальные токены пользователя не затрагиваются
+	got, err = r.ConsumeRefreshToken(ctx, second.Hash)
+	require.NoError(t, err)
+	require.Equal(t, second.Hash, got.Hash)
+}
+
+// testRefreshTokenUnknown неизвестный токен обновления не найден
+func testRefreshTokenUnknown(t *testing.T, r AuthRepo) {
+	_, err := r.ConsumeRefreshToken(context.Background(), "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+// testAccountCreate учётная запись находится по адресу почты
+func testAccountCreate(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	account := modelAuth.Account{
+		UserID:       newUser(t, r),
+		Email:        "user@example.com",
+		PasswordHash: "hash",
+		CreatedAt:    time.Now().Truncate(time.Millisecond),
+	}
+	require.NoError(t, r.CreateAccount(ctx, account))
+
+	got, err := r.GetAccountByEmail(ctx, account.Email)
+	require.NoError(t, err)
+	require.Equal(t, account.UserID, got.UserID)
+	require.Equal(t, account.Email, got.Email)
+	require.Equal(t, account.PasswordHash, got.PasswordHash)
+	require.True(t, account.CreatedAt.Equal(got.CreatedAt), "created at %v, got %v", account.CreatedAt, got.CreatedAt)
+}
+
+// testAccountExists почту нельзя занять дважды, а пользователю нельзя завести вторую учётную запись
+func testAccountExists(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	account := modelAuth.Account{UserID: userID, Email: "user@example.com", PasswordHash: "hash", CreatedAt: time.Now()}
+	require.NoError(t, r.CreateAccount(ctx, account))
+
+	other := modelAuth.Account{UserID: newUser(t, r), Email: account.Email, PasswordHash: "other", CreatedAt: time.Now()}
+	require.ErrorIs(t, r.CreateAccount(ctx, other), repoCommon.ErrAccountExists)
+
+	second := modelAuth.Account{UserID: userID, Email: "second@example.com", PasswordHash: "other", CreatedAt: time.Now()}
+	require.ErrorIs(t, r.CreateAccount(ctx, second), repoCommon.ErrAccountExists)
+
+	// отклонённые учётные записи не сохраняются
+	_, err := r.GetAccountByEmail(ctx, second.Email)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	got, err := r.GetAccountByEmail(ctx, account.Email)
+	require.NoError(t, err)
+	require.Equal(t, userID, got.UserID)
+}
+
+// testAccountUnknown неизвестная почта не найдена
+func testAccountUnknown(t *testing.T, r AuthRepo) {
+	_, err := r.GetAccountByEmail(context.Background(), "unknown@example.com")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+// testIdentities удостоверение находится по издателю и ID у издателя и привязывается к пользователю один раз
+func testIdentities(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	identity := modelAuth.Identity{
+		Issuer:    "https://idp.example.com",
+		Subject:   "subject",
+		UserID:    newUser(t, r),
+		Email:     "user@example.com",
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+	}
+	require.NoError(t, r.CreateIdentity(ctx, identity))
+
+	got, err := r.GetIdentity(ctx, identity.Issuer, identity.Subject)
+	require.NoError(t, err)
+	require.Equal(t, identity.UserID, got.UserID)
+	require.Equal(t, identity.Email, got.Email)
+	require.True(t, identity.CreatedAt.Equal(got.CreatedAt), "created at %v, got %v", identity.CreatedAt, got.CreatedAt)
+
+	// тот же ID у другого издателя - другое удостоверение
+	_, err = r.GetIdentity(ctx, "https://other.example.com", identity.Subject)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	other := identity
+	other.Issuer = "https://other.example.com"
+	other.UserID = newUser(t, r)
+	require.NoError(t, r.CreateIdentity(ctx, other))
+
+	// привязанное удостоверение не переходит к другому пользователю
+	taken := identity
+	taken.UserID = other.UserID
+	require.ErrorIs(t, r.CreateIdentity(ctx, taken), repoCommon.ErrIdentityExists)
+	got, err = r.GetIdentity(ctx, identity.Issuer, identity.Subject)
+	require.NoError(t, err)
+	require.Equal(t, identity.UserID, got.UserID)
+}
+
+// testAPIKeys API-ключи находятся по ID и перечисляются в порядке создания
+func testAPIKeys(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	otherUserID := newUser(t, r)
+	createdAt := time.Now().Truncate(time.Millisecond)
+
+	first := modelAuth.APIKey{
+		ID:        "key-1",
+		UserID:    userID,
+		Hash:      "hash-1",
+		Name:      "ci",
+		Scopes:    []string{modelAuth.ScopeLinksWrite, modelAuth.ScopeLinksRead},
+		CreatedAt: createdAt,
+	}
+	second := modelAuth.APIKey{
+		ID:        "key-2",
+		UserID:    userID,
+		Hash:      "hash-2",
+		Scopes:    []string{modelAuth.ScopeStatsRead},
+		CreatedAt: createdAt.Add(time.Second),
+	}
+	other := modelAuth.APIKey{
+		ID:        "key-3",
+		UserID:    otherUserID,
+		Hash:      "hash-3",
+		Scopes:    []string{modelAuth.ScopeLinksRead},
+		CreatedAt: createdAt,
+	}
+	require.NoError(t, r.SaveAPIKey(ctx, second))
+	require.NoError(t, r.SaveAPIKey(ctx, first))
+	require.NoError(t, r.SaveAPIKey(ctx, other))
+
+	got, err := r.GetAPIKey(ctx, first.ID)
+	require.NoError(t, err)
+	requireAPIKey(t, first, *got)
+	require.Nil(t, got.LastUsedAt)
+
+	keys, err := r.GetUserAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	requireAPIKey(t, first, keys[0])
+	requireAPIKey(t, second, keys[1])
+
+	usedAt := createdAt.Add(time.Minute)
+	require.NoError(t, r.TouchAPIKey(ctx, first.ID, usedAt))
+	got, err = r.GetAPIKey(ctx, first.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+	require.True(t, usedAt.Equal(*got.LastUsedAt), "last used at %v, got %v", usedAt, *got.LastUsedAt)
+
+	keys, err = r.GetUserAPIKeys(context.Background(), newUser(t, r))
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	_, err = r.GetAPIKey(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.ErrorIs(t, r.TouchAPIKey(ctx, "unknown", usedAt), repoCommon.ErrNotFoundKey)
+}
+
+// testAPIKeyDelete API-ключ удаляет только его владелец
+func testAPIKeyDelete(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	key := modelAuth.APIKey{
+		ID:        "key-1",
+		UserID:    userID,
+		Hash:      "hash-1",
+		Scopes:    []string{modelAuth.ScopeLinksRead},
+		CreatedAt: time.Now(),
+	}
+	require.NoError(t, r.SaveAPIKey(ctx, key))
+
+	require.ErrorIs(t, r.DeleteAPIKey(ctx, newUser(t, r), key.ID), repoCommon.ErrNotFoundKey)
+	_, err := r.GetAPIKey(ctx, key.ID)
+	require.NoError(t, err)
+
+	require.NoError(t, r.DeleteAPIKey(ctx, userID, key.ID))
+	_, err = r.GetAPIKey(ctx, key.ID)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	keys, err := r.GetUserAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	require.ErrorIs(t, r.DeleteAPIKey(ctx, userID, key.ID), repoCommon.ErrNotFoundKey)
+}
+
+func requireAPIKey(t *testing.T, want modelAuth.APIKey, got modelAuth.APIKey) {
+	require.Equal(t, want.ID, got.ID)
+	require.Equal(t, want.UserID, got.UserID)
+	require.Equal(t, want.Hash, got.Hash)
+	require.Equal(t, want.Name, got.Name)
+	require.Equal(t, want.Scopes, got.Scopes)
+	require.True(t, want.CreatedAt.Equal(got.CreatedAt), "created at %v, got %v", want.CreatedAt, got.CreatedAt)
+}
+
+// testSessions сеансы находятся по ID и перечисляются в порядке начала
+func testSessions(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	createdAt := time.Now().Truncate(time.Millisecond)
+
+	first := modelAuth.Session{
+		ID:         "session-1",
+		UserID:     userID,
+		UserAgent:  "curl/8.0",
+		CreatedAt:  createdAt,
+		LastSeenAt: createdAt,
+	}
+	second := modelAuth.Session{
+		ID:         "session-2",
+		UserID:     userID,
+		UserAgent:  "grpc-go/1.63.0",
+		CreatedAt:  createdAt.Add(time.Second),
+		LastSeenAt: createdAt.Add(time.Second),
+	}
+	other := modelAuth.Session{ID: "session-3", UserID: newUser(t, r), CreatedAt: createdAt, LastSeenAt: createdAt}
+	require.NoError(t, r.CreateSession(ctx, second))
+	require.NoError(t, r.CreateSession(ctx, first))
+	require.NoError(t, r.CreateSession(ctx, other))
+
+	got, err := r.GetSession(ctx, first.ID)
+	require.NoError(t, err)
+	requireSession(t, first, *got)
+
+	sessions, err := r.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	requireSession(t, first, sessions[0])
+	requireSession(t, second, sessions[1])
+
+	first.LastSeenAt = createdAt.Add(time.Minute)
+	require.NoError(t, r.TouchSession(ctx, first.ID, first.LastSeenAt))
+	got, err = r.GetSession(ctx, first.ID)
+	require.NoError(t, err)
+	requireSession(t, first, *got)
+
+	sessions, err = r.GetUserSessions(ctx, newUser(t, r))
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+
+	_, err = r.GetSession(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.ErrorIs(t, r.TouchSession(ctx, "unknown", createdAt), repoCommon.ErrNotFoundKey)
+}
+
+// testSessionRevoke сеанс отзывает только его пользователь; отзыв всех сеансов не затрагивает чужие
+func testSessionRevoke(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	otherUserID := newUser(t, r)
+	createdAt := time.Now().Truncate(time.Millisecond)
+	for _, session := range []modelAuth.Session{
+		{ID: "session-1", UserID: userID, CreatedAt: createdAt, LastSeenAt: createdAt},
+		{ID: "session-2", UserID: userID, CreatedAt: createdAt.Add(time.Second), LastSeenAt: createdAt},
+		{ID: "session-3", UserID: userID, CreatedAt: createdAt.Add(2 * time.Second), LastSeenAt: createdAt},
+		{ID: "session-4", UserID: otherUserID, CreatedAt: createdAt, LastSeenAt: createdAt},
+	} {
+		require.NoError(t, r.CreateSession(ctx, session))
+	}
+
+	revokedAt := createdAt.Add(time.Minute)
+	require.ErrorIs(t, r.RevokeSession(ctx, otherUserID, "session-1", revokedAt), repoCommon.ErrNotFoundKey)
+	require.ErrorIs(t, r.RevokeSession(ctx, userID, "unknown", revokedAt), repoCommon.ErrNotFoundKey)
+	require.NoError(t, r.RevokeSession(ctx, userID, "session-1", revokedAt))
+	// повторный отзыв не меняет время отзыва
+	require.NoError(t, r.RevokeSession(ctx, userID, "session-1", revokedAt.Add(time.Minute)))
+
+	got, err := r.GetSession(ctx, "session-1")
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	require.True(t, revokedAt.Equal(*got.RevokedAt), "revoked at %v, got %v", revokedAt, *got.RevokedAt)
+	got, err = r.GetSession(ctx, "session-2")
+	require.NoError(t, err)
+	require.Nil(t, got.RevokedAt)
+
+	allRevokedAt := revokedAt.Add(time.Hour)
+	require.NoError(t, r.RevokeUserSessions(ctx, userID, allRevokedAt))
+	sessions, err := r.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 3)
+	require.True(t, revokedAt.Equal(*sessions[0].RevokedAt), "revoked at %v, got %v", revokedAt, *sessions[0].RevokedAt)
+	for _, session := range sessions[1:] {
+		require.NotNil(t, session.RevokedAt)
+		require.True(t, allRevokedAt.Equal(*session.RevokedAt), "revoked at %v, got %v", allRevokedAt, *session.RevokedAt)
+	}
+
+	got, err = r.GetSession(ctx, "session-4")
+	require.NoError(t, err)
+	require.Nil(t, got.RevokedAt)
+}
+
+func requireSession(t *testing.T, want modelAuth.Session, got modelAuth.Session) {
+	require.Equal(t, want.ID, got.ID)
+	require.Equal(t, want.UserID, got.UserID)
+	require.Equal(t, want.UserAgent, got.UserAgent)
+	require.True(t, want.CreatedAt.Equal(got.CreatedAt), "created at %v, got %v", want.CreatedAt, got.CreatedAt)
+	require.True(t, want.LastSeenAt.Equal(got.LastSeenAt), "last seen at %v, got %v", want.LastSeenAt, got.LastSeenAt)
+	require.Nil(t, got.RevokedAt)
+}
+
+// testUserRoles новый пользователь получает роль user; роль и отключение сохраняются
+func testUserRoles(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	adminID := newUser(t, r)
+
+	user, err := r.GetUser(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.User{ID: userID, Role: modelAuth.RoleUser}, *user)
+
+	admin := modelAuth.User{ID: adminID, Role: modelAuth.RoleAdmin}
+	require.NoError(t, r.UpdateUser(ctx, admin))
+	disabled := modelAuth.User{ID: userID, Role: modelAuth.RoleUser, Disabled: true}
+	require.NoError(t, r.UpdateUser(ctx, disabled))
+
+	user, err = r.GetUser(ctx, adminID)
+	require.NoError(t, err)
+	require.Equal(t, admin, *user)
+
+	want := []modelAuth.User{admin, disabled}
+	if userID < adminID {
+		want = []modelAuth.User{disabled, admin}
+	}
+	users, err := r.ListUsers(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, want, users)
+
+	users, err = r.ListUsers(ctx, want[0].ID, 1)
+	require.NoError(t, err)
+	require.Equal(t, want[1:], users)
+
+	// снятие отключения возвращает пользователя в исходное состояние
+	require.NoError(t, r.UpdateUser(ctx, modelAuth.User{ID: userID, Role: modelAuth.RoleUser}))
+	user, err = r.GetUser(ctx, userID)
+	require.NoError(t, err)
+	require.False(t, user.Disabled)
+}
+
+// testUserUnknown неизвестного пользователя нельзя получить и изменить
+func testUserUnknown(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+
+	_, err := r.GetUser(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	err = r.UpdateUser(ctx, modelAuth.User{ID: "unknown", Role: modelAuth.RoleAdmin})
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+// testTakeDownURL снятый URL удаляется для всех его владельцев
+func testTakeDownURL(t *testing.T, r AuthRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	id, err := r.SaveURL(ctx, "https://example.com/takedown", userID)
+	require.NoError(t, err)
+
+	require.NoError(t, r.TakeDownURL(ctx, id))
+	_, err = r.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+
+	require.ErrorIs(t, r.TakeDownURL(ctx, "notexists"), repoCommon.ErrNotFoundKey)
+}
@@ -0,0 +1,144 @@
+package conformance
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"testing"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/stretchr/testify/require"
+)
+
+// CollisionRepo интерфейс хранилища, в котором можно подменить генератор ID URL'ов
+type CollisionRepo interface {
+	Repo
+	SetIDGenerator(g idgen.Generator)
+}
+
+// NewCollisionRepoFunc создаёт пустое хранилище для очередного сценария
+type NewCollisionRepoFunc func(t *testing.T) CollisionRepo
+
+// collidingPrefix количество первых байт данных, которые учитывает CollidingHash
+const collidingPrefix = len("https://")
+
+// CollidingHash хеш, учитывающий только начало данных: у всех https-URL'ов совпадают ID,
+// пока к ним не подмешан номер попытки
+func CollidingHash() hash.Hash {
+	return &prefixHash{Hash: sha256.New()}
+}
+
+type prefixHash struct {
+	hash.Hash
+	written int
+}
+
+func (h *prefixHash) Write(p []byte) (int, error) {
+	n := collidingPrefix - h.written
+	if n > len(p) {
+		n = len(p)
+	}
+	if n > 0 {
+		h.written += n
+		if _, err := h.Hash.Write(p[:n]); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (h *prefixHash) Reset() {
+	h.Hash.Reset()
+	h.written = 0
+}
+
+// RunCollisions прогоняет сценарии коллизий ID; хранилищу назначается генератор на основе CollidingHash
+func RunCollisions(t *testing.T, newRepo NewCollisionRepoFunc) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r CollisionRepo)
+	}{
+		{name: "Save", run: testCollisionSave},
+		{name: "Batch", run: testCollisionBatch},
+		{name: "Exhausted", run: testCollisionExhausted},
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			r := newRepo(t)
+			r.SetIDGenerator(idgen.NewHash(CollidingHash))
+			sc.run(t, r)
+		})
+	}
+}
+
+func testCollisionSave(t *testing.T, r CollisionRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	first, err := r.SaveURL(ctx, "https://example.com/first", userID)
+	require.NoError(t, err)
+	second, err := r.SaveURL(ctx, "https://example.com/second", userID)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+
+	requireURL(t, r, first, "https://example.com/first")
+	requireURL(t, r, second, "https://example.com/second")
+
+	// повторное сокращение возвращает свой ID, а не ID URL'а с тем же хешем
+	_, err = r.SaveURL(ctx, "https://example.com/second", userID)
+	requireAlreadyExists(t, err, second)
+}
+
+func testCollisionBatch(t *testing.T, r CollisionRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	saved, err := r.SaveURL(ctx, "https://example.com/saved", userID)
+	require.NoError(t, err)
+
+	batch := []model.CreateShortenURLBatchItemRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/one"},
+		{CorrelationID: "2", OriginalURL: "https://example.com/saved"},
+		{CorrelationID: "3", OriginalURL: "https://example.com/two"},
+		{CorrelationID: "4", OriginalURL: "https://example.com/one"},
+	}
+	response, err := r.SaveURLsBatch(ctx, batch, userID)
+	require.NoError(t, err)
+	ids := requireBatchResponse(t, r, batch, response)
+
+	require.Equal(t, saved, ids["2"])
+	require.Equal(t, ids["1"], ids["4"])
+	require.NotEqual(t, ids["1"], ids["3"])
+	require.NotEqual(t, saved, ids["1"])
+	require.NotEqual(t, saved, ids["3"])
+}
+
+func testCollisionExhausted(t *testing.T, r CollisionRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	// каждый следующий URL занимает ещё одну попытку
+	for i := 0; i < repoCommon.MaxURLIDAttempts; i++ {
+		_, err := r.SaveURL(ctx, fmt.Sprintf("https://example.com/%d", i), userID)
+		require.NoError(t, err)
+	}
+
+	_, err := r.SaveURL(ctx, "https://example.com/last", userID)
+	require.True(t, errors.Is(err, repoCommon.ErrURLIDCollision), "expected ErrURLIDCollision, got %v", err)
+	_, err = r.SaveURLsBatch(ctx, []model.CreateShortenURLBatchItemRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/last"},
+	}, userID)
+	require.True(t, errors.Is(err, repoCommon.ErrURLIDCollision), "expected ErrURLIDCollision, got %v", err)
+}
+
+func requireURL(t *testing.T, r Repo, id string, url string) {
+	got, err := r.GetURLByID(context.Background(), id)
+	require.NoError(t, err)
+	require.Equal(t, url, got)
+}
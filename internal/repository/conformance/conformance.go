@@ -0,0 +1,288 @@
+/*
+Package conformance содержит общий набор сценариев, которому должна соответствовать
+каждая реализация хранилища URL'ов.
+
+Пакет предназначен для использования в тестах реализаций:
+
+	func TestConformance(t *testing.T) {
+		conformance.Run(t, func(t *testing.T) conformance.Repo {
+			return NewInMemoryRepo()
+		})
+	}
+*/
+package conformance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// Repo интерфейс проверяемого хранилища
+type Repo interface {
+	SaveURL(ctx context.Context, url string, userID string) (string, error)
+	SaveURLsBatch(ctx context.Context,
+		request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error)
+	GetURLByID(ctx context.Context, id string) (string, error)
+	GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	GetNewUserID(ctx context.Context) (string, error)
+	GetStats(ctx context.Context) (*modelStats.StatsResponse, error)
+}
+
+// NewRepoFunc создаёт пустое хранилище для очередного сценария
+type NewRepoFunc func(t *testing.T) Repo
+
+// Run прогоняет все сценарии; для каждого сценария создаётся новое хранилище
+func Run(t *testing.T, newRepo NewRepoFunc) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r Repo)
+	}{
+		{name: "SaveAndGet", run: testSaveAndGet},
+		{name: "NotFound", run: testNotFound},
+		{name: "Duplicates", run: testDuplicates},
+		{name: "Batch", run: testBatch},
+		{name: "BatchWithExisting", run: testBatchWithExisting},
+		{name: "Ownership", run: testOwnership},
+		{name: "Deletion", run: testDeletion},
+		{name: "Stats", run: testStats},
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			sc.run(t, newRepo(t))
+		})
+	}
+}
+
+func testSaveAndGet(t *testing.T, r Repo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	id, err := r.SaveURL(ctx, "https://example.com/save", userID)
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	url, err := r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/save", url)
+}
+
+func testNotFound(t *testing.T, r Repo) {
+	ctx := context.Background()
+
+	_, err := r.GetURLByID(ctx, "notexists")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+func testDuplicates(t *testing.T, r Repo) {
+	ctx := context.Background()
+	firstUserID := newUser(t, r)
+	secondUserID := newUser(t, r)
+	url := "https://example.com/duplicate"
+
+	id, err := r.SaveURL(ctx, url, firstUserID)
+	require.NoError(t, err)
+
+	// повторное сокращение тем же пользователем
+	_, err = r.SaveURL(ctx, url, firstUserID)
+	requireAlreadyExists(t, err, id)
+
+	// повторное сокращение другим пользователем добавляет его во владельцы
+	_, err = r.SaveURL(ctx, url, secondUserID)
+	requireAlreadyExists(t, err, id)
+
+	for _, userID := range []string{firstUserID, secondUserID} {
+		userURLs, err := r.GetUserURLs(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, userURLs, 1)
+		require.Equal(t, id, userURLs[0].ShortURL)
+		require.Equal(t, url, userURLs[0].OriginalURL)
+	}
+}
+
+func testBatch(t *testing.T, r Repo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	batch := make([]model.CreateShortenURLBatchItemRequest, 0)
+	for i := 0; i < 5; i++ {
+		batch = append(batch, model.CreateShortenURLBatchItemRequest{
+			CorrelationID: fmt.Sprintf("%d", i),
+			OriginalURL:   fmt.Sprintf("https://example.com/batch/%d", i),
+		})
+	}
+	// повтор внутри пачки
+	batch = append(batch, model.CreateShortenURLBatchItemRequest{
+		CorrelationID: "repeated",
+		OriginalURL:   batch[0].OriginalURL,
+	})
+
+	response, err := r.SaveURLsBatch(ctx, batch, userID)
+	require.NoError(t, err)
+	require.Len(t, response, len(batch))
+
+	ids := requireBatchResponse(t, r, batch, response)
+	require.Equal(t, ids["0"], ids["repeated"])
+
+	userURLs, err := r.GetUserURLs(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, len(batch)-1)
+}
+
+func testBatchWithExisting(t *testing.T, r Repo) {
+	ctx := context.Background()
+	firstUserID := newUser(t, r)
+	secondUserID := newUser(t, r)
+
+	existingID, err := r.SaveURL(ctx, "https://example.com/existing", firstUserID)
+	require.NoError(t, err)
+
+	batch := []model.CreateShortenURLBatchItemRequest{
+		{CorrelationID: "new", OriginalURL: "https://example.com/new"},
+		{CorrelationID: "existing", OriginalURL: "https://example.com/existing"},
+	}
+	response, err := r.SaveURLsBatch(ctx, batch, secondUserID)
+	require.NoError(t, err)
+	require.Len(t, response, len(batch))
+
+	ids := requireBatchResponse(t, r, batch, response)
+	require.Equal(t, existingID, ids["existing"])
+
+	userURLs, err := r.GetUserURLs(ctx, secondUserID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, len(batch))
+}
+
+func testOwnership(t *testing.T, r Repo) {
+	ctx := context.Background()
+	firstUserID := newUser(t, r)
+	secondUserID := newUser(t, r)
+	emptyUserID := newUser(t, r)
+
+	firstIDs := map[string]string{}
+	for i := 0; i < 3; i++ {
+		url := fmt.Sprintf("https://example.com/first/%d", i)
+		id, err := r.SaveURL(ctx, url, firstUserID)
+		require.NoError(t, err)
+		firstIDs[id] = url
+	}
+	_, err := r.SaveURL(ctx, "https://example.com/second", secondUserID)
+	require.NoError(t, err)
+
+	userURLs, err := r.GetUserURLs(ctx, firstUserID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, len(firstIDs))
+	for _, v := range userURLs {
+		require.Equal(t, firstIDs[v.ShortURL], v.OriginalURL)
+	}
+
+	userURLs, err = r.GetUserURLs(ctx, emptyUserID)
+	require.NoError(t, err)
+	require.Empty(t, userURLs)
+}
+
+func testDeletion(t *testing.T, r Repo) {
+	ctx := context.Background()
+	ownerID := newUser(t, r)
+	otherUserID := newUser(t, r)
+
+	ownID, err := r.SaveURL(ctx, "https://example.com/own", ownerID)
+	require.NoError(t, err)
+	foreignID, err := r.SaveURL(ctx, "https://example.com/foreign", otherUserID)
+	require.NoError(t, err)
+
+	// пользователь может удалить только свои URL'ы
+	err = r.UpdateURLsDeletedFlag(ctx, ownerID, toChannel(ownID, foreignID, "notexists"))
+	require.NoError(t, err)
+
+	_, err = r.GetURLByID(ctx, ownID)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+
+	url, err := r.GetURLByID(ctx, foreignID)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/foreign", url)
+
+	// пустой запрос на удаление ничего не делает
+	err = r.UpdateURLsDeletedFlag(ctx, otherUserID, toChannel())
+	require.NoError(t, err)
+}
+
+func testStats(t *testing.T, r Repo) {
+	ctx := context.Background()
+
+	stats, err := r.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 0, stats.URLs)
+	require.Equal(t, 0, stats.Users)
+
+	firstUserID := newUser(t, r)
+	secondUserID := newUser(t, r)
+	_, err = r.SaveURL(ctx, "https://example.com/stats/1", firstUserID)
+	require.NoError(t, err)
+	_, err = r.SaveURL(ctx, "https://example.com/stats/2", firstUserID)
+	require.NoError(t, err)
+	_, err = r.SaveURLsBatch(ctx, []model.CreateShortenURLBatchItemRequest{
+		{CorrelationID: "1", OriginalURL: "https://example.com/stats/1"},
+		{CorrelationID: "3", OriginalURL: "https://example.com/stats/3"},
+	}, secondUserID)
+	require.NoError(t, err)
+
+	stats, err = r.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.URLs)
+	require.Equal(t, 2, stats.Users)
+}
+
+func newUser(t *testing.T, r Repo) string {
+	userID, err := r.GetNewUserID(context.Background())
+	require.NoError(t, err)
+	require.NotEmpty(t, userID)
+	return userID
+}
+
+func requireAlreadyExists(t *testing.T, err error, id string) {
+	var errAlreadyExists *repoCommon.URLAlreadyExistsError
+	require.True(t, errors.As(err, &errAlreadyExists), "expected URLAlreadyExistsError, got %v", err)
+	require.Equal(t, id, errAlreadyExists.ID)
+}
+
+// requireBatchResponse проверяет, что ответ идёт в порядке запроса
+// и каждый его элемент ведёт на тот же URL; вернёт ID URL'ов по CorrelationID
+func requireBatchResponse(t *testing.T,
+	r Repo,
+	batch []model.CreateShortenURLBatchItemRequest,
+	response []model.CreateShortenURLBatchItemResponse) map[string]string {
+	require.Len(t, response, len(batch))
+
+	ids := make(map[string]string, len(response))
+	for i, b := range batch {
+		v := response[i]
+		require.Equal(t, b.CorrelationID, v.CorrelationID, "response item %d is out of order", i)
+		require.NotEmpty(t, v.ShortURL)
+		ids[v.CorrelationID] = v.ShortURL
+
+		url, err := r.GetURLByID(context.Background(), v.ShortURL)
+		require.NoError(t, err)
+		require.Equal(t, b.OriginalURL, url)
+	}
+
+	return ids
+}
+
+func toChannel(ids ...string) <-chan model.UpdateURLDeletedFlag {
+	ch := make(chan model.UpdateURLDeletedFlag, len(ids))
+	for _, id := range ids {
+		ch <- model.UpdateURLDeletedFlag{URLID: id}
+	}
+	close(ch)
+	return ch
+}
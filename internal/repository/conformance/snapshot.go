@@ -0,0 +1,596 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// SnapshotRepo интерфейс хранилища, поддерживающего выгрузку и загрузку данных
+type SnapshotRepo interface {
+	AuthRepo
+	WorkspaceRepo
+	GetUsersPage(ctx context.Context, afterID string, limit int) ([]snapshot.User, error)
+	GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error)
+	GetAccountsPage(ctx context.Context, afterUserID string, limit int) ([]snapshot.Account, error)
+	GetIdentitiesPage(ctx context.Context, afterKey string, limit int) ([]snapshot.Identity, error)
+	GetAPIKeysPage(ctx context.Context, afterID string, limit int) ([]snapshot.APIKey, error)
+	GetWorkspacesPage(ctx context.Context, afterID string, limit int) ([]snapshot.Workspace, error)
+	ImportUsers(ctx context.Context, users []snapshot.User) error
+	ImportURLs(ctx context.Context, urls []snapshot.URL) error
+	ImportAccounts(ctx context.Context, accounts []snapshot.Account) error
+	ImportIdentities(ctx context.Context, identities []snapshot.Identity) error
+	ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error
+	ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error
+	Export(ctx context.Context, w snapshot.Writer) error
+}
+
+// NewSnapshotRepoFunc создаёт пустое хранилище для очередного сценария
+type NewSnapshotRepoFunc func(t *testing.T) SnapshotRepo
+
+// RunSnapshot прогоняет сценарии выгрузки и загрузки данных
+func RunSnapshot(t *testing.T, newRepo NewSnapshotRepoFunc) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r SnapshotRepo)
+	}{
+		{name: "UsersPages", run: testUsersPages},
+		{name: "URLsPages", run: testURLsPages},
+		{name: "Import", run: testImport},
+		{name: "ImportIdempotent", run: testImportIdempotent},
+		{name: "ImportConflict", run: testImportConflict},
+		{name: "Export", run: testExport},
+		{name: "ImportUserRoles", run: testImportUserRoles},
+		{name: "ImportAuth", run: testImportAuth},
+		{name: "ImportAuthConflict", run: testImportAuthConflict},
+		{name: "ImportWorkspaces", run: testImportWorkspaces},
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			sc.run(t, newRepo(t))
+		})
+	}
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		testRoundTrip(t, newRepo)
+	})
+}
+
+func testUsersPages(t *testing.T, r SnapshotRepo) {
+	expected := make([]string, 0)
+	for i := 0; i < 7; i++ {
+		expected = append(expected, newUser(t, r))
+	}
+	sort.Strings(expected)
+
+	users := readUsers(t, r, 3)
+	require.Equal(t, expected, userIDs(users))
+	for _, u := range users {
+		require.Equal(t, snapshot.User{ID: u.ID, Role: modelAuth.RoleUser}, u)
+	}
+}
+
+func testURLsPages(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	firstUserID := newUser(t, r)
+	secondUserID := newUser(t, r)
+
+	expected := map[string]snapshot.URL{}
+	for i := 0; i < 7; i++ {
+		url := fmt.Sprintf("https://example.com/page/%d", i)
+		id, err := r.SaveURL(ctx, url, firstUserID)
+		require.NoError(t, err)
+		expected[id] = snapshot.URL{ID: id, OriginalURL: url, UserIDs: []string{firstUserID}}
+	}
+	sharedID, err := r.SaveURL(ctx, "https://example.com/page/0", secondUserID)
+	requireAlreadyExists(t, err, firstKey(t, expected, "https://example.com/page/0"))
+	shared := expected[sharedID]
+	shared.UserIDs = sortedStrings(firstUserID, secondUserID)
+	expected[sharedID] = shared
+
+	deletedID := firstKey(t, expected, "https://example.com/page/1")
+	err = r.UpdateURLsDeletedFlag(ctx, firstUserID, toChannel(deletedID))
+	require.NoError(t, err)
+	deleted := expected[deletedID]
+	deleted.Deleted = true
+	expected[deletedID] = deleted
+
+	got := readURLs(t, r, 3)
+	require.Len(t, got, len(expected))
+	require.True(t, sort.SliceIsSorted(got, func(i, j int) bool { return got[i].ID < got[j].ID }))
+	for _, u := range got {
+		require.Equal(t, expected[u.ID], u)
+	}
+}
+
+func testImport(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	users := sortedStrings("user-1", "user-2", "user-3")
+	urls := []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/1", UserIDs: sortedStrings("user-1", "user-2")},
+		{ID: "id-2", OriginalURL: "https://example.com/import/2", Deleted: true, UserIDs: []string{"user-2"}},
+		{ID: "id-3", OriginalURL: "https://example.com/import/3", UserIDs: []string{}},
+	}
+
+	require.NoError(t, r.ImportUsers(ctx, snapshotUsers(users...)))
+	require.NoError(t, r.ImportURLs(ctx, urls))
+
+	require.Equal(t, users, userIDs(readUsers(t, r, 2)))
+	require.Equal(t, urls, readURLs(t, r, 2))
+
+	url, err := r.GetURLByID(ctx, "id-1")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/import/1", url)
+	_, err = r.GetURLByID(ctx, "id-2")
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+
+	userURLs, err := r.GetUserURLs(ctx, "user-2")
+	require.NoError(t, err)
+	require.Len(t, userURLs, 2)
+
+	// импортированный URL не сохраняется повторно под новым ID
+	_, err = r.SaveURL(ctx, "https://example.com/import/3", "user-3")
+	requireAlreadyExists(t, err, "id-3")
+
+	stats, err := r.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.URLs)
+	require.Equal(t, 3, stats.Users)
+}
+
+func testImportIdempotent(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	users := sortedStrings("user-1", "user-2")
+	urls := []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/1", UserIDs: []string{"user-1"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, r.ImportUsers(ctx, snapshotUsers(users...)))
+		require.NoError(t, r.ImportURLs(ctx, urls))
+	}
+
+	// повторный импорт дополняет владельцев и флаг удаления
+	require.NoError(t, r.ImportURLs(ctx, []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/1", Deleted: true, UserIDs: []string{"user-2"}},
+	}))
+
+	require.Equal(t, users, userIDs(readUsers(t, r, 10)))
+	require.Equal(t, []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/1", Deleted: true, UserIDs: users},
+	}, readURLs(t, r, 10))
+
+	stats, err := r.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, stats.URLs)
+	require.Equal(t, 2, stats.Users)
+}
+
+func testImportConflict(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	require.NoError(t, r.ImportUsers(ctx, snapshotUsers("user-1")))
+	require.NoError(t, r.ImportURLs(ctx, []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/1", UserIDs: []string{"user-1"}},
+	}))
+
+	// ID занят другим URL'ом
+	err := r.ImportURLs(ctx, []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/other", UserIDs: []string{"user-1"}},
+	})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+
+	// URL сохранён под другим ID
+	err = r.ImportURLs(ctx, []snapshot.URL{
+		{ID: "id-2", OriginalURL: "https://example.com/import/1", UserIDs: []string{"user-1"}},
+	})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+
+	url, err := r.GetURLByID(ctx, "id-1")
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/import/1", url)
+	_, err = r.GetURLByID(ctx, "id-2")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+func testExport(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	firstUserID := newUser(t, r)
+	secondUserID := newUser(t, r)
+	for i := 0; i < 5; i++ {
+		_, err := r.SaveURL(ctx, fmt.Sprintf("https://example.com/export/%d", i), firstUserID)
+		require.NoError(t, err)
+	}
+	id, err := r.SaveURL(ctx, "https://example.com/export/own", secondUserID)
+	require.NoError(t, err)
+	require.NoError(t, r.UpdateURLsDeletedFlag(ctx, secondUserID, toChannel(id)))
+
+	w := &collectingWriter{}
+	require.NoError(t, r.Export(ctx, w))
+
+	require.Equal(t, readUsers(t, r, 2), w.users)
+	require.Equal(t, readURLs(t, r, 2), w.urls)
+}
+
+// testImportUserRoles загрузка задаёт роль и состояние, только если они известны
+func testImportUserRoles(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	require.NoError(t, r.ImportUsers(ctx, []snapshot.User{
+		{ID: "user-1", Role: modelAuth.RoleAdmin},
+		{ID: "user-2", Role: modelAuth.RoleUser, Disabled: true},
+	}))
+	// пользователь из снимка первой версии сохраняет роль
+	require.NoError(t, r.ImportUsers(ctx, snapshotUsers("user-1", "user-3")))
+
+	require.Equal(t, []snapshot.User{
+		{ID: "user-1", Role: modelAuth.RoleAdmin},
+		{ID: "user-2", Role: modelAuth.RoleUser, Disabled: true},
+		{ID: "user-3", Role: modelAuth.RoleUser},
+	}, readUsers(t, r, 2))
+
+	user, err := r.GetUser(ctx, "user-2")
+	require.NoError(t, err)
+	require.True(t, user.Disabled)
+
+	// известная роль заменяет прежнюю
+	require.NoError(t, r.ImportUsers(ctx, []snapshot.User{{ID: "user-1", Role: modelAuth.RoleUser}}))
+	user, err = r.GetUser(ctx, "user-1")
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.RoleUser, user.Role)
+}
+
+// testImportAuth загруженные учётные записи, удостоверения и ключи доступны обычным чтением
+func testImportAuth(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	createdAt := snapshotTime(-time.Hour)
+	usedAt := snapshotTime(0)
+	accounts := []snapshot.Account{
+		{UserID: "user-1", Email: "first@example.com", PasswordHash: "hash-1", CreatedAt: createdAt},
+		{UserID: "user-2", Email: "second@example.com", PasswordHash: "hash-2", CreatedAt: createdAt},
+	}
+	identities := []snapshot.Identity{
+		{Issuer: "https://a.example.com", Subject: "sub-1", UserID: "user-3", Email: "third@example.com", CreatedAt: createdAt},
+		{Issuer: "https://b.example.com", Subject: "sub-1", UserID: "user-1", CreatedAt: createdAt},
+	}
+	keys := []snapshot.APIKey{
+		{ID: "key-1", UserID: "user-1", Hash: "key-hash-1", Name: "ci",
+			Scopes: []string{modelAuth.ScopeLinksRead}, CreatedAt: createdAt, LastUsedAt: &usedAt},
+		{ID: "key-2", UserID: "user-2", Hash: "key-hash-2", Name: "stats",
+			Scopes: []string{modelAuth.ScopeLinksWrite, modelAuth.ScopeStatsRead}, CreatedAt: createdAt},
+	}
+
+	// повторная загрузка ничего не меняет
+	for i := 0; i < 2; i++ {
+		require.NoError(t, r.ImportAccounts(ctx, accounts))
+		require.NoError(t, r.ImportIdentities(ctx, identities))
+		require.NoError(t, r.ImportAPIKeys(ctx, keys))
+	}
+
+	require.Equal(t, accounts, readAccounts(t, r, 1))
+	require.Equal(t, identities, readIdentities(t, r, 1))
+	require.Equal(t, keys, readAPIKeys(t, r, 1))
+	require.Equal(t, []string{"user-1", "user-2", "user-3"}, userIDs(readUsers(t, r, 10)))
+
+	account, err := r.GetAccountByEmail(ctx, "second@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "user-2", account.UserID)
+	identity, err := r.GetIdentity(ctx, "https://a.example.com", "sub-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-3", identity.UserID)
+	key, err := r.GetAPIKey(ctx, "key-1")
+	require.NoError(t, err)
+	require.Equal(t, "key-hash-1", key.Hash)
+	require.Equal(t, usedAt, key.LastUsedAt.UTC())
+}
+
+// testImportAuthConflict данные, занятые другим пользователем, не загружаются
+func testImportAuthConflict(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	createdAt := snapshotTime(0)
+	require.NoError(t, r.ImportAccounts(ctx, []snapshot.Account{
+		{UserID: "user-1", Email: "first@example.com", PasswordHash: "hash", CreatedAt: createdAt},
+	}))
+	require.NoError(t, r.ImportIdentities(ctx, []snapshot.Identity{
+		{Issuer: "https://a.example.com", Subject: "sub-1", UserID: "user-1", CreatedAt: createdAt},
+	}))
+	require.NoError(t, r.ImportAPIKeys(ctx, []snapshot.APIKey{
+		{ID: "key-1", UserID: "user-1", Hash: "hash", Scopes: []string{}, CreatedAt: createdAt},
+	}))
+
+	// почта занята другим пользователем
+	err := r.ImportAccounts(ctx, []snapshot.Account{
+		{UserID: "user-2", Email: "first@example.com", PasswordHash: "hash", CreatedAt: createdAt},
+	})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+	// у пользователя уже другая учётная запись
+	err = r.ImportAccounts(ctx, []snapshot.Account{
+		{UserID: "user-1", Email: "other@example.com", PasswordHash: "hash", CreatedAt: createdAt},
+	})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+	err = r.ImportIdentities(ctx, []snapshot.Identity{
+		{Issuer: "https://a.example.com", Subject: "sub-1", UserID: "user-2", CreatedAt: createdAt},
+	})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+	err = r.ImportAPIKeys(ctx, []snapshot.APIKey{
+		{ID: "key-1", UserID: "user-2", Hash: "hash", Scopes: []string{}, CreatedAt: createdAt},
+	})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+
+	_, err = r.GetAccountByEmail(ctx, "other@example.com")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.Len(t, readAccounts(t, r, 10), 1)
+	require.Equal(t, "user-1", readIdentities(t, r, 10)[0].UserID)
+	require.Equal(t, "user-1", readAPIKeys(t, r, 10)[0].UserID)
+}
+
+// testImportWorkspaces загрузка дополняет участников и ссылки пространства
+func testImportWorkspaces(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	createdAt := snapshotTime(0)
+	require.NoError(t, r.ImportURLs(ctx, []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/import/1", UserIDs: []string{}},
+		{ID: "id-2", OriginalURL: "https://example.com/import/2", UserIDs: []string{}},
+	}))
+	ws := snapshot.Workspace{
+		ID: "ws-1", Name: "team", CreatedAt: createdAt,
+		Members: []snapshot.Member{
+			{UserID: "user-1", Role: modelWorkspace.RoleOwner},
+			{UserID: "user-2", Role: modelWorkspace.RoleViewer},
+		},
+		URLIDs: []string{"id-1"},
+	}
+	require.NoError(t, r.ImportWorkspaces(ctx, []snapshot.Workspace{ws}))
+	require.NoError(t, r.ImportWorkspaces(ctx, []snapshot.Workspace{ws}))
+	require.Equal(t, []snapshot.Workspace{ws}, readWorkspaces(t, r, 1))
+
+	// роли участников заменяются, ссылки дополняются, название не меняется
+	require.NoError(t, r.ImportWorkspaces(ctx, []snapshot.Workspace{{
+		ID: "ws-1", Name: "renamed", CreatedAt: createdAt,
+		Members: []snapshot.Member{{UserID: "user-2", Role: modelWorkspace.RoleEditor}},
+		URLIDs:  []string{"id-2"},
+	}}))
+	ws.Members[1].Role = modelWorkspace.RoleEditor
+	ws.URLIDs = []string{"id-1", "id-2"}
+	require.Equal(t, []snapshot.Workspace{ws}, readWorkspaces(t, r, 1))
+
+	member, err := r.GetWorkspaceMember(ctx, "ws-1", "user-2")
+	require.NoError(t, err)
+	require.Equal(t, modelWorkspace.RoleEditor, member.Role)
+	urls, err := r.GetWorkspaceURLs(ctx, "ws-1")
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+
+	// ссылка пространства должна быть загружена раньше него
+	err = r.ImportWorkspaces(ctx, []snapshot.Workspace{{
+		ID: "ws-2", Name: "other", CreatedAt: createdAt,
+		Members: []snapshot.Member{{UserID: "user-1", Role: modelWorkspace.RoleOwner}},
+		URLIDs:  []string{"id-unknown"},
+	}})
+	require.ErrorIs(t, err, repoCommon.ErrImportConflict)
+	_, err = r.GetWorkspace(ctx, "ws-2")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+// testRoundTrip снимок, загруженный в пустое хранилище, выгружается без изменений
+func testRoundTrip(t *testing.T, newRepo NewSnapshotRepoFunc) {
+	ctx := context.Background()
+	r := newRepo(t)
+	createdAt := snapshotTime(-time.Hour)
+
+	adminID := newUser(t, r)
+	memberID := newUser(t, r)
+	require.NoError(t, r.UpdateUser(ctx, modelAuth.User{ID: adminID, Role: modelAuth.RoleAdmin}))
+	require.NoError(t, r.UpdateUser(ctx, modelAuth.User{ID: memberID, Role: modelAuth.RoleUser, Disabled: true}))
+
+	ownID, err := r.SaveURL(ctx, "https://example.com/round-trip/own", adminID)
+	require.NoError(t, err)
+	wsURLID, err := r.SaveURL(ctx, "https://example.com/round-trip/workspace", "")
+	require.NoError(t, err)
+	require.NoError(t, r.UpdateURLsDeletedFlag(ctx, adminID, toChannel(ownID)))
+
+	require.NoError(t, r.CreateAccount(ctx, modelAuth.Account{
+		UserID: adminID, Email: "admin@example.com", PasswordHash: "hash", CreatedAt: createdAt,
+	}))
+	require.NoError(t, r.CreateIdentity(ctx, modelAuth.Identity{
+		Issuer: "https://issuer.example.com", Subject: "sub-1", UserID: memberID,
+		Email: "member@example.com", CreatedAt: createdAt,
+	}))
+	require.NoError(t, r.SaveAPIKey(ctx, modelAuth.APIKey{
+		ID: "key-1", UserID: adminID, Hash: "key-hash", Name: "ci",
+		Scopes: []string{modelAuth.ScopeLinksRead, modelAuth.ScopeStatsRead}, CreatedAt: createdAt,
+	}))
+	require.NoError(t, r.TouchAPIKey(ctx, "key-1", snapshotTime(0)))
+
+	ws := newWorkspace(t, r, "ws-1", adminID, createdAt)
+	require.NoError(t, r.SaveWorkspaceMember(ctx, modelWorkspace.Member{
+		WorkspaceID: ws.ID, UserID: memberID, Role: modelWorkspace.RoleViewer,
+	}))
+	require.NoError(t, r.AddWorkspaceURLs(ctx, ws.ID, []string{wsURLID}))
+
+	expected := &collectingWriter{}
+	require.NoError(t, r.Export(ctx, expected))
+	require.Len(t, expected.users, 2)
+	require.Len(t, expected.urls, 2)
+	require.Len(t, expected.accounts, 1)
+	require.Len(t, expected.identities, 1)
+	require.Len(t, expected.keys, 1)
+	require.Len(t, expected.workspaces, 1)
+
+	// для хранилищ с общим сервером исходное хранилище очищается
+	target := newRepo(t)
+	for i := 0; i < 2; i++ {
+		expected.importTo(t, target)
+	}
+
+	got := &collectingWriter{}
+	require.NoError(t, target.Export(ctx, got))
+	require.Equal(t, expected, got)
+
+	user, err := target.GetUser(ctx, adminID)
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.RoleAdmin, user.Role)
+	account, err := target.GetAccountByEmail(ctx, "admin@example.com")
+	require.NoError(t, err)
+	require.Equal(t, adminID, account.UserID)
+	member, err := target.GetWorkspaceMember(ctx, ws.ID, memberID)
+	require.NoError(t, err)
+	require.Equal(t, modelWorkspace.RoleViewer, member.Role)
+}
+
+// collectingWriter запоминает выгруженные данные и проверяет порядок их видов
+type collectingWriter struct {
+	kind       int
+	users      []snapshot.User
+	urls       []snapshot.URL
+	accounts   []snapshot.Account
+	identities []snapshot.Identity
+	keys       []snapshot.APIKey
+	workspaces []snapshot.Workspace
+}
+
+// advance проверит, что записи вида kind не идут после записей следующих видов
+func (w *collectingWriter) advance(kind int) error {
+	if kind < w.kind {
+		return fmt.Errorf("record of kind %d written after kind %d", kind, w.kind)
+	}
+	w.kind = kind
+	return nil
+}
+
+func (w *collectingWriter) WriteUser(user snapshot.User) error {
+	w.users = append(w.users, user)
+	return w.advance(0)
+}
+
+func (w *collectingWriter) WriteURL(url snapshot.URL) error {
+	w.urls = append(w.urls, url)
+	return w.advance(1)
+}
+
+func (w *collectingWriter) WriteAccount(account snapshot.Account) error {
+	w.accounts = append(w.accounts, account)
+	return w.advance(2)
+}
+
+func (w *collectingWriter) WriteIdentity(identity snapshot.Identity) error {
+	w.identities = append(w.identities, identity)
+	return w.advance(3)
+}
+
+func (w *collectingWriter) WriteAPIKey(key snapshot.APIKey) error {
+	w.keys = append(w.keys, key)
+	return w.advance(4)
+}
+
+func (w *collectingWriter) WriteWorkspace(ws snapshot.Workspace) error {
+	w.workspaces = append(w.workspaces, ws)
+	return w.advance(5)
+}
+
+// importTo загрузит выгруженные данные в хранилище в порядке зависимостей
+func (w *collectingWriter) importTo(t *testing.T, r SnapshotRepo) {
+	ctx := context.Background()
+	require.NoError(t, r.ImportUsers(ctx, w.users))
+	require.NoError(t, r.ImportURLs(ctx, w.urls))
+	require.NoError(t, r.ImportAccounts(ctx, w.accounts))
+	require.NoError(t, r.ImportIdentities(ctx, w.identities))
+	require.NoError(t, r.ImportAPIKeys(ctx, w.keys))
+	require.NoError(t, r.ImportWorkspaces(ctx, w.workspaces))
+}
+
+// readUsers выгрузит всех пользователей страницами размера limit
+func readUsers(t *testing.T, r SnapshotRepo, limit int) []snapshot.User {
+	return readPages(t, r.GetUsersPage, func(u snapshot.User) string { return u.ID }, limit)
+}
+
+// readURLs выгрузит все URL'ы страницами размера limit
+func readURLs(t *testing.T, r SnapshotRepo, limit int) []snapshot.URL {
+	return readPages(t, r.GetURLsPage, func(u snapshot.URL) string { return u.ID }, limit)
+}
+
+// readAccounts выгрузит все учётные записи страницами размера limit
+func readAccounts(t *testing.T, r SnapshotRepo, limit int) []snapshot.Account {
+	return readPages(t, r.GetAccountsPage, func(a snapshot.Account) string { return a.UserID }, limit)
+}
+
+// readIdentities выгрузит все удостоверения страницами размера limit
+func readIdentities(t *testing.T, r SnapshotRepo, limit int) []snapshot.Identity {
+	return readPages(t, r.GetIdentitiesPage, snapshot.Identity.Key, limit)
+}
+
+// readAPIKeys выгрузит все API-ключи страницами размера limit
+func readAPIKeys(t *testing.T, r SnapshotRepo, limit int) []snapshot.APIKey {
+	return readPages(t, r.GetAPIKeysPage, func(k snapshot.APIKey) string { return k.ID }, limit)
+}
+
+// readWorkspaces выгрузит все рабочие пространства страницами размера limit
+func readWorkspaces(t *testing.T, r SnapshotRepo, limit int) []snapshot.Workspace {
+	return readPages(t, r.GetWorkspacesPage, func(ws snapshot.Workspace) string { return ws.ID }, limit)
+}
+
+// readPages выгрузит все записи одного вида страницами размера limit
+func readPages[T any](t *testing.T,
+	fetch func(ctx context.Context, afterID string, limit int) ([]T, error),
+	key func(T) string,
+	limit int) []T {
+	result := make([]T, 0)
+	afterID := ""
+	for {
+		page, err := fetch(context.Background(), afterID, limit)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page), limit)
+		if len(page) == 0 {
+			return result
+		}
+
+		result = append(result, page...)
+		afterID = key(page[len(page)-1])
+	}
+}
+
+func firstKey(t *testing.T, urls map[string]snapshot.URL, url string) string {
+	for id, u := range urls {
+		if u.OriginalURL == url {
+			return id
+		}
+	}
+	require.FailNow(t, "url not found", url)
+	return ""
+}
+
+func sortedStrings(s ...string) []string {
+	sort.Strings(s)
+	return s
+}
+
+// snapshotUsers пользователи с неизвестной ролью, как в снимке первой версии
+func snapshotUsers(ids ...string) []snapshot.User {
+	users := make([]snapshot.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, snapshot.User{ID: id})
+	}
+	return users
+}
+
+func userIDs(users []snapshot.User) []string {
+	ids := make([]string, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+// snapshotTime текущее время со сдвигом d; время в UTC с точностью до миллисекунд
+// хранится во всех хранилищах без изменений
+func snapshotTime(d time.Duration) time.Time {
+	return time.Now().Add(d).UTC().Truncate(time.Millisecond)
+}
@@ -0,0 +1,171 @@
+package conformance
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// TxRepo интерфейс хранилища, поддерживающего транзакции с откатом
+type TxRepo interface {
+	Repo
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// NewTxRepoFunc создаёт пустое хранилище для очередного сценария
+type NewTxRepoFunc func(t *testing.T) TxRepo
+
+// errRollback ошибка, которой сценарии откатывают транзакцию
+var errRollback = errors.New("conformance: rollback")
+
+// RunTx прогоняет сценарии транзакций
+func RunTx(t *testing.T, newRepo NewTxRepoFunc) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r TxRepo)
+	}{
+		{name: "Commit", run: testTxCommit},
+		{name: "Rollback", run: testTxRollback},
+		{name: "Nested", run: testTxNested},
+		{name: "Panic", run: testTxPanic},
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			sc.run(t, newRepo(t))
+		})
+	}
+}
+
+func testTxCommit(t *testing.T, r TxRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+
+	var id string
+	err := r.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = r.SaveURL(ctx, "https://example.com/tx/commit", userID)
+		if err != nil {
+			return err
+		}
+		_, err = r.SaveURLsBatch(ctx, []model.CreateShortenURLBatchItemRequest{
+			{CorrelationID: "1", OriginalURL: "https://example.com/tx/commit/batch"},
+		}, userID)
+		return err
+	})
+	require.NoError(t, err)
+
+	url, err := r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/tx/commit", url)
+
+	userURLs, err := r.GetUserURLs(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, 2)
+}
+
+func testTxRollback(t *testing.T, r TxRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	existingID, err := r.SaveURL(ctx, "https://example.com/tx/existing", userID)
+	require.NoError(t, err)
+	statsBefore, err := r.GetStats(ctx)
+	require.NoError(t, err)
+
+	var id string
+	err = r.WithinTx(ctx, func(ctx context.Context) error {
+		otherUserID, err := r.GetNewUserID(ctx)
+		require.NoError(t, err)
+
+		id, err = r.SaveURL(ctx, "https://example.com/tx/rollback", userID)
+		require.NoError(t, err)
+		_, err = r.SaveURL(ctx, "https://example.com/tx/existing", otherUserID)
+		requireAlreadyExists(t, err, existingID)
+		_, err = r.SaveURLsBatch(ctx, []model.CreateShortenURLBatchItemRequest{
+			{CorrelationID: "1", OriginalURL: "https://example.com/tx/rollback/batch"},
+		}, userID)
+		require.NoError(t, err)
+		require.NoError(t, r.UpdateURLsDeletedFlag(ctx, userID, toChannel(existingID)))
+
+		// изменения видны внутри транзакции
+		url, err := r.GetURLByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, "https://example.com/tx/rollback", url)
+
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	requireRolledBack(t, r, userID, id, existingID)
+	statsAfter, err := r.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, statsBefore, statsAfter)
+}
+
+func testTxNested(t *testing.T, r TxRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	existingID, err := r.SaveURL(ctx, "https://example.com/tx/existing", userID)
+	require.NoError(t, err)
+
+	var id string
+	err = r.WithinTx(ctx, func(ctx context.Context) error {
+		err := r.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = r.SaveURL(ctx, "https://example.com/tx/nested", userID)
+			return err
+		})
+		require.NoError(t, err)
+
+		// вложенная транзакция присоединилась к внешней, поэтому откатывается вместе с ней
+		return r.WithinTx(ctx, func(ctx context.Context) error {
+			require.NoError(t, r.UpdateURLsDeletedFlag(ctx, userID, toChannel(existingID)))
+			return errRollback
+		})
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	requireRolledBack(t, r, userID, id, existingID)
+}
+
+func testTxPanic(t *testing.T, r TxRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	existingID, err := r.SaveURL(ctx, "https://example.com/tx/existing", userID)
+	require.NoError(t, err)
+
+	var id string
+	require.Panics(t, func() {
+		_ = r.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = r.SaveURL(ctx, "https://example.com/tx/panic", userID)
+			require.NoError(t, err)
+			panic(errRollback)
+		})
+	})
+
+	requireRolledBack(t, r, userID, id, existingID)
+}
+
+// requireRolledBack проверяет, что у пользователя остался только URL existingID,
+// не помеченный удалённым, а URL id не сохранён
+func requireRolledBack(t *testing.T, r Repo, userID string, id string, existingID string) {
+	ctx := context.Background()
+
+	_, err := r.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+
+	url, err := r.GetURLByID(ctx, existingID)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/tx/existing", url)
+
+	userURLs, err := r.GetUserURLs(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	require.Equal(t, existingID, userURLs[0].ShortURL)
+}
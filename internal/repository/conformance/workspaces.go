@@ -0,0 +1,189 @@
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// WorkspaceRepo интерфейс хранилища рабочих пространств
+type WorkspaceRepo interface {
+	Repo
+	CreateWorkspace(ctx context.Context, ws modelWorkspace.Workspace, owner modelWorkspace.Member) error
+	GetWorkspace(ctx context.Context, id string) (*modelWorkspace.Workspace, error)
+	GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error)
+	GetWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*modelWorkspace.Member, error)
+	GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]modelWorkspace.Member, error)
+	GetWorkspaceOwners(ctx context.Context, workspaceID string) ([]string, error)
+	SaveWorkspaceMember(ctx context.Context, member modelWorkspace.Member) error
+	DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error
+	AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error
+	GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateWorkspaceURLsDeletedFlag(ctx context.Context, workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+}
+
+// NewWorkspaceRepoFunc создаёт пустое хранилище для очередного сценария
+type NewWorkspaceRepoFunc func(t *testing.T) WorkspaceRepo
+
+// RunWorkspaces прогоняет сценарии хранения рабочих пространств
+func RunWorkspaces(t *testing.T, newRepo NewWorkspaceRepoFunc) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, r WorkspaceRepo)
+	}{
+		{name: "WorkspaceCreate", run: testWorkspaceCreate},
+		{name: "WorkspaceUnknown", run: testWorkspaceUnknown},
+		{name: "WorkspaceMembers", run: testWorkspaceMembers},
+		{name: "WorkspaceURLs", run: testWorkspaceURLs},
+	}
+
+	for _, sc := range scenarios {
+		sc := sc
+		t.Run(sc.name, func(t *testing.T) {
+			sc.run(t, newRepo(t))
+		})
+	}
+}
+
+// testWorkspaceCreate пространство создаётся вместе с владельцем
+func testWorkspaceCreate(t *testing.T, r WorkspaceRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	first := newWorkspace(t, r, "ws-1", userID, time.Now().Add(-time.Minute))
+	second := newWorkspace(t, r, "ws-2", userID, time.Now())
+
+	got, err := r.GetWorkspace(ctx, first.ID)
+	require.NoError(t, err)
+	require.Equal(t, first.ID, got.ID)
+	require.Equal(t, first.Name, got.Name)
+	require.True(t, first.CreatedAt.Equal(got.CreatedAt), "created at %v, got %v", first.CreatedAt, got.CreatedAt)
+
+	member, err := r.GetWorkspaceMember(ctx, first.ID, userID)
+	require.NoError(t, err)
+	require.Equal(t, modelWorkspace.Member{WorkspaceID: first.ID, UserID: userID, Role: modelWorkspace.RoleOwner}, *member)
+
+	// пространства перечисляются в порядке создания
+	memberships, err := r.GetUserWorkspaces(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, memberships, 2)
+	require.Equal(t, first.ID, memberships[0].Workspace.ID)
+	require.Equal(t, first.Name, memberships[0].Workspace.Name)
+	require.Equal(t, modelWorkspace.RoleOwner, memberships[0].Role)
+	require.Equal(t, second.ID, memberships[1].Workspace.ID)
+
+	memberships, err = r.GetUserWorkspaces(ctx, newUser(t, r))
+	require.NoError(t, err)
+	require.Empty(t, memberships)
+}
+
+// testWorkspaceUnknown неизвестное пространство и участник не найдены
+func testWorkspaceUnknown(t *testing.T, r WorkspaceRepo) {
+	ctx := context.Background()
+	_, err := r.GetWorkspace(ctx, "unknown")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+
+	ws := newWorkspace(t, r, "ws-1", newUser(t, r), time.Now())
+	_, err = r.GetWorkspaceMember(ctx, ws.ID, newUser(t, r))
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	require.ErrorIs(t, r.DeleteWorkspaceMember(ctx, ws.ID, "unknown"), repoCommon.ErrNotFoundKey)
+}
+
+// testWorkspaceMembers участники добавляются, меняют роль и удаляются
+func testWorkspaceMembers(t *testing.T, r WorkspaceRepo) {
+	ctx := context.Background()
+	ownerID := newUser(t, r)
+	memberID := newUser(t, r)
+	ws := newWorkspace(t, r, "ws-1", ownerID, time.Now())
+
+	member := modelWorkspace.Member{WorkspaceID: ws.ID, UserID: memberID, Role: modelWorkspace.RoleViewer}
+	require.NoError(t, r.SaveWorkspaceMember(ctx, member))
+	member.Role = modelWorkspace.RoleEditor
+	require.NoError(t, r.SaveWorkspaceMember(ctx, member))
+
+	got, err := r.GetWorkspaceMember(ctx, ws.ID, memberID)
+	require.NoError(t, err)
+	require.Equal(t, member, *got)
+
+	members, err := r.GetWorkspaceMembers(ctx, ws.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []modelWorkspace.Member{
+		{WorkspaceID: ws.ID, UserID: ownerID, Role: modelWorkspace.RoleOwner},
+		member,
+	}, members)
+
+	owners, err := r.GetWorkspaceOwners(ctx, ws.ID)
+	require.NoError(t, err)
+	require.Equal(t, []string{ownerID}, owners)
+
+	memberships, err := r.GetUserWorkspaces(ctx, memberID)
+	require.NoError(t, err)
+	require.Len(t, memberships, 1)
+	require.Equal(t, modelWorkspace.RoleEditor, memberships[0].Role)
+
+	require.NoError(t, r.DeleteWorkspaceMember(ctx, ws.ID, memberID))
+	_, err = r.GetWorkspaceMember(ctx, ws.ID, memberID)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+	memberships, err = r.GetUserWorkspaces(ctx, memberID)
+	require.NoError(t, err)
+	require.Empty(t, memberships)
+}
+
+// testWorkspaceURLs ссылки пространства хранятся отдельно от ссылок пользователей
+func testWorkspaceURLs(t *testing.T, r WorkspaceRepo) {
+	ctx := context.Background()
+	userID := newUser(t, r)
+	ws := newWorkspace(t, r, "ws-1", userID, time.Now())
+	other := newWorkspace(t, r, "ws-2", userID, time.Now())
+
+	// URL без владельца-пользователя
+	id, err := r.SaveURL(ctx, "https://example.com/campaign", "")
+	require.NoError(t, err)
+	require.NoError(t, r.AddWorkspaceURLs(ctx, ws.ID, []string{id}))
+	require.NoError(t, r.AddWorkspaceURLs(ctx, ws.ID, []string{id}))
+
+	urls, err := r.GetWorkspaceURLs(ctx, ws.ID)
+	require.NoError(t, err)
+	require.Equal(t, []model.GetUserURLsItemResponse{{ShortURL: id, OriginalURL: "https://example.com/campaign"}}, urls)
+	urls, err = r.GetWorkspaceURLs(ctx, other.ID)
+	require.NoError(t, err)
+	require.Empty(t, urls)
+	urls, err = r.GetUserURLs(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, urls)
+
+	// другое пространство и пользователь не удаляют чужие ссылки
+	deleteURL := func(del func(modelsCh <-chan model.UpdateURLDeletedFlag) error) {
+		modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+		modelsCh <- model.UpdateURLDeletedFlag{URLID: id}
+		close(modelsCh)
+		require.NoError(t, del(modelsCh))
+	}
+	deleteURL(func(modelsCh <-chan model.UpdateURLDeletedFlag) error {
+		return r.UpdateWorkspaceURLsDeletedFlag(ctx, other.ID, modelsCh)
+	})
+	deleteURL(func(modelsCh <-chan model.UpdateURLDeletedFlag) error {
+		return r.UpdateURLsDeletedFlag(ctx, userID, modelsCh)
+	})
+	_, err = r.GetURLByID(ctx, id)
+	require.NoError(t, err)
+
+	deleteURL(func(modelsCh <-chan model.UpdateURLDeletedFlag) error {
+		return r.UpdateWorkspaceURLsDeletedFlag(ctx, ws.ID, modelsCh)
+	})
+	_, err = r.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+}
+
+// newWorkspace создаст пространство с владельцем ownerID
+func newWorkspace(t *testing.T, r WorkspaceRepo, id string, ownerID string, createdAt time.Time) modelWorkspace.Workspace {
+	// время с точностью до миллисекунд хранится во всех хранилищах
+	ws := modelWorkspace.Workspace{ID: id, Name: "workspace " + id, CreatedAt: createdAt.Truncate(time.Millisecond)}
+	owner := modelWorkspace.Member{WorkspaceID: id, UserID: ownerID, Role: modelWorkspace.RoleOwner}
+	require.NoError(t, r.CreateWorkspace(context.Background(), ws, owner))
+	return ws
+}
@@ -29,3 +29,17 @@ func NewURLAlreadyExistsError(id string, url string) *URLAlreadyExistsError {
 func (e *URLAlreadyExistsError) Error() string {
 	return fmt.Sprintf("url %s already exists", e.URL)
 }
+
+// ErrImportConflict импортируемые данные противоречат уже сохранённым:
+// ID занят другим URL'ом, URL сохранён под другим ID, почта, удостоверение
+// или API-ключ принадлежат другому пользователю, ссылка пространства не найдена
+var ErrImportConflict = errors.New("repository: imported data conflicts with existing data")
+
+// ErrURLIDCollision ID URL'а не удалось подобрать: все попытки заняты другими URL'ами
+var ErrURLIDCollision = errors.New("repository: can not allocate unique url id")
+
+// ErrAccountExists почта уже занята или у пользователя уже есть учётная запись
+var ErrAccountExists = errors.New("repository: account already exists")
+
+// ErrIdentityExists удостоверение издателя уже привязано к пользователю
+var ErrIdentityExists = errors.New("repository: identity already exists")
@@ -0,0 +1,93 @@
+package filerepo
+
+import (
+	"context"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+)
+
+// SaveAPIKey сохранит API-ключ
+func (s *fileRepo) SaveAPIKey(ctx context.Context, key modelAuth.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.SaveAPIKey(ctx, key); err != nil {
+		return err
+	}
+
+	createdAt := key.CreatedAt
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:     key.UserID,
+		APIKeyID:   key.ID,
+		APIKeyHash: key.Hash,
+		APIKeyName: key.Name,
+		Scopes:     key.Scopes,
+		CreatedAt:  &createdAt,
+	})
+}
+
+// GetAPIKey вернёт API-ключ по его ID
+func (s *fileRepo) GetAPIKey(ctx context.Context, id string) (*modelAuth.APIKey, error) {
+	return s.repo.GetAPIKey(ctx, id)
+}
+
+// GetUserAPIKeys вернёт API-ключи пользователя в порядке создания
+func (s *fileRepo) GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error) {
+	return s.repo.GetUserAPIKeys(ctx, userID)
+}
+
+// DeleteAPIKey удалит API-ключ пользователя
+func (s *fileRepo) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.DeleteAPIKey(ctx, userID, id); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:      userID,
+		APIKeyID:    id,
+		DeletedFlag: true,
+	})
+}
+
+// TouchAPIKey запишет время последнего использования API-ключа
+func (s *fileRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.TouchAPIKey(ctx, id, usedAt); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		APIKeyID:   id,
+		LastUsedAt: &usedAt,
+	})
+}
+
+// applyAPIKeyRecord применит к памяти запись об API-ключе: создание, удаление или использование
+func (s *fileRepo) applyAPIKeyRecord(record *recordShorURL) {
+	ctx := context.Background()
+	switch {
+	case record.APIKeyHash != "":
+		key := modelAuth.APIKey{
+			ID:         record.APIKeyID,
+			UserID:     record.UserID,
+			Hash:       record.APIKeyHash,
+			Name:       record.APIKeyName,
+			Scopes:     record.Scopes,
+			LastUsedAt: record.LastUsedAt,
+		}
+		if record.CreatedAt != nil {
+			key.CreatedAt = *record.CreatedAt
+		}
+		s.repo.SaveAPIKey(ctx, key)
+	case record.DeletedFlag:
+		s.repo.DeleteAPIKey(ctx, record.UserID, record.APIKeyID)
+	case record.LastUsedAt != nil:
+		s.repo.TouchAPIKey(ctx, record.APIKeyID, *record.LastUsedAt)
+	}
+}
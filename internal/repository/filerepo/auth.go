@@ -0,0 +1,68 @@
+package filerepo
+
+import (
+	"context"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+)
+
+// SaveRefreshToken сохранит токен обновления
+func (s *fileRepo) SaveRefreshToken(ctx context.Context, token modelAuth.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.SaveRefreshToken(ctx, token); err != nil {
+		return err
+	}
+
+	expiresAt := token.ExpiresAt
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:           token.UserID,
+		RefreshTokenHash: token.Hash,
+		SessionID:        token.SessionID,
+		ExpiresAt:        &expiresAt,
+	})
+}
+
+// ConsumeRefreshToken удалит токен обновления и вернёт его; токен можно использовать только один раз
+func (s *fileRepo) ConsumeRefreshToken(ctx context.Context, hash string) (*modelAuth.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, err := s.repo.ConsumeRefreshToken(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.writeRecords(ctx, recordShorURL{
+		RefreshTokenHash: hash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// CreateAccount сохранит учётную запись пользователя
+func (s *fileRepo) CreateAccount(ctx context.Context, account modelAuth.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.CreateAccount(ctx, account); err != nil {
+		return err
+	}
+
+	createdAt := account.CreatedAt
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:       account.UserID,
+		Email:        account.Email,
+		PasswordHash: account.PasswordHash,
+		CreatedAt:    &createdAt,
+	})
+}
+
+// GetAccountByEmail вернёт учётную запись по адресу почты
+func (s *fileRepo) GetAccountByEmail(ctx context.Context, email string) (*modelAuth.Account, error) {
+	return s.repo.GetAccountByEmail(ctx, email)
+}
@@ -0,0 +1,266 @@
+package filerepo
+
+import (
+	"context"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+)
+
+// GetUsersPage вернёт не больше limit пользователей, следующих за afterID,
+// в порядке возрастания ID
+func (s *fileRepo) GetUsersPage(ctx context.Context, afterID string, limit int) ([]snapshot.User, error) {
+	return s.repo.GetUsersPage(ctx, afterID, limit)
+}
+
+// GetURLsPage вернёт не больше limit URL'ов, следующих за afterID,
+// в порядке возрастания ID
+func (s *fileRepo) GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error) {
+	return s.repo.GetURLsPage(ctx, afterID, limit)
+}
+
+// GetAccountsPage вернёт не больше limit учётных записей, следующих за учётной записью
+// пользователя afterUserID, в порядке возрастания ID пользователя
+func (s *fileRepo) GetAccountsPage(ctx context.Context, afterUserID string, limit int) ([]snapshot.Account, error) {
+	return s.repo.GetAccountsPage(ctx, afterUserID, limit)
+}
+
+// GetIdentitiesPage вернёт не больше limit удостоверений, следующих за afterKey,
+// в порядке возрастания snapshot.Identity.Key
+func (s *fileRepo) GetIdentitiesPage(ctx context.Context, afterKey string, limit int) ([]snapshot.Identity, error) {
+	return s.repo.GetIdentitiesPage(ctx, afterKey, limit)
+}
+
+// GetAPIKeysPage вернёт не больше limit API-ключей, следующих за afterID,
+// в порядке возрастания ID
+func (s *fileRepo) GetAPIKeysPage(ctx context.Context, afterID string, limit int) ([]snapshot.APIKey, error) {
+	return s.repo.GetAPIKeysPage(ctx, afterID, limit)
+}
+
+// GetWorkspacesPage вернёт не больше limit рабочих пространств, следующих за afterID,
+// в порядке возрастания ID
+func (s *fileRepo) GetWorkspacesPage(ctx context.Context, afterID string, limit int) ([]snapshot.Workspace, error) {
+	return s.repo.GetWorkspacesPage(ctx, afterID, limit)
+}
+
+// ImportUsers сохранит пользователей с заранее известными ID;
+// у существующих пользователей заменит роль и состояние, если они известны
+func (s *fileRepo) ImportUsers(ctx context.Context, users []snapshot.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.repo.ImportUsers(ctx, users)
+	if err != nil {
+		return err
+	}
+
+	records := make([]recordShorURL, 0, len(users))
+	for _, u := range users {
+		records = append(records, recordShorURL{
+			UserID: u.ID,
+		})
+		if u.Role != "" {
+			disabled := u.Disabled
+			records = append(records, recordShorURL{
+				UserID:   u.ID,
+				Role:     u.Role,
+				Disabled: &disabled,
+			})
+		}
+	}
+
+	return s.saveToFile(records...)
+}
+
+// ImportURLs сохранит URL'ы с заранее известными ID;
+// у существующих URL'ов дополнит владельцев и флаг удаления.
+// Повторная запись в журнал уже известных данных при воспроизведении ничего не меняет
+func (s *fileRepo) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.repo.ImportURLs(ctx, urls)
+	if err != nil {
+		return err
+	}
+
+	for _, u := range urls {
+		records := make([]recordShorURL, 0, len(u.UserIDs)+1)
+		for _, userID := range u.UserIDs {
+			records = append(records, recordShorURL{
+				ShortURL:    u.ID,
+				OriginalURL: u.OriginalURL,
+				UserID:      userID,
+			})
+		}
+		if len(records) == 0 {
+			records = append(records, recordShorURL{
+				ShortURL:    u.ID,
+				OriginalURL: u.OriginalURL,
+			})
+		}
+		if u.Deleted {
+			records = append(records, recordShorURL{
+				ShortURL:    u.ID,
+				DeletedFlag: true,
+			})
+		}
+
+		for _, r := range records {
+			if err = s.saveToFile(r); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ImportAccounts сохранит учётные записи вместе с их пользователями;
+// уже сохранённые учётные записи пропускаются, в том числе при воспроизведении журнала
+func (s *fileRepo) ImportAccounts(ctx context.Context, accounts []snapshot.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.repo.ImportAccounts(ctx, accounts)
+	if err != nil {
+		return err
+	}
+
+	records := make([]recordShorURL, 0, 2*len(accounts))
+	for _, a := range accounts {
+		createdAt := a.CreatedAt
+		records = append(records, recordShorURL{
+			UserID: a.UserID,
+		}, recordShorURL{
+			UserID:       a.UserID,
+			Email:        a.Email,
+			PasswordHash: a.PasswordHash,
+			CreatedAt:    &createdAt,
+		})
+	}
+
+	return s.saveToFile(records...)
+}
+
+// ImportIdentities сохранит удостоверения вместе с их пользователями;
+// уже сохранённые удостоверения пропускаются, в том числе при воспроизведении журнала
+func (s *fileRepo) ImportIdentities(ctx context.Context, identities []snapshot.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.repo.ImportIdentities(ctx, identities)
+	if err != nil {
+		return err
+	}
+
+	records := make([]recordShorURL, 0, 2*len(identities))
+	for _, i := range identities {
+		createdAt := i.CreatedAt
+		records = append(records, recordShorURL{
+			UserID: i.UserID,
+		}, recordShorURL{
+			UserID:    i.UserID,
+			Issuer:    i.Issuer,
+			Subject:   i.Subject,
+			Email:     i.Email,
+			CreatedAt: &createdAt,
+		})
+	}
+
+	return s.saveToFile(records...)
+}
+
+// ImportAPIKeys сохранит API-ключи вместе с их пользователями; уже сохранённые ключи пропускаются.
+// В журнал попадает итоговое состояние ключа, поэтому воспроизведение его не меняет
+func (s *fileRepo) ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.repo.ImportAPIKeys(ctx, keys)
+	if err != nil {
+		return err
+	}
+
+	records := make([]recordShorURL, 0, 2*len(keys))
+	for _, k := range keys {
+		key, err := s.repo.GetAPIKey(ctx, k.ID)
+		if err != nil {
+			return err
+		}
+
+		createdAt := key.CreatedAt
+		records = append(records, recordShorURL{
+			UserID: key.UserID,
+		}, recordShorURL{
+			UserID:     key.UserID,
+			APIKeyID:   key.ID,
+			APIKeyHash: key.Hash,
+			APIKeyName: key.Name,
+			Scopes:     key.Scopes,
+			CreatedAt:  &createdAt,
+			LastUsedAt: key.LastUsedAt,
+		})
+	}
+
+	return s.saveToFile(records...)
+}
+
+// ImportWorkspaces сохранит рабочие пространства; у существующих пространств
+// заменит роли перечисленных участников и дополнит ссылки
+func (s *fileRepo) ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := make(map[string]bool, len(workspaces))
+	for _, ws := range workspaces {
+		if _, err := s.repo.GetWorkspace(ctx, ws.ID); err != nil {
+			created[ws.ID] = true
+		}
+	}
+
+	err := s.repo.ImportWorkspaces(ctx, workspaces)
+	if err != nil {
+		return err
+	}
+
+	records := make([]recordShorURL, 0)
+	for _, ws := range workspaces {
+		members := ws.Members
+		// новое пространство воспроизводится созданием вместе с первым участником
+		if created[ws.ID] && len(members) > 0 {
+			createdAt := ws.CreatedAt
+			records = append(records, recordShorURL{
+				UserID: members[0].UserID,
+			}, recordShorURL{
+				UserID:        members[0].UserID,
+				WorkspaceID:   ws.ID,
+				WorkspaceName: ws.Name,
+				MemberRole:    members[0].Role,
+				CreatedAt:     &createdAt,
+			})
+			members = members[1:]
+		}
+		for _, m := range members {
+			records = append(records, recordShorURL{
+				UserID: m.UserID,
+			}, recordShorURL{
+				UserID:      m.UserID,
+				WorkspaceID: ws.ID,
+				MemberRole:  m.Role,
+			})
+		}
+		for _, urlID := range ws.URLIDs {
+			records = append(records, recordShorURL{
+				ShortURL:    urlID,
+				WorkspaceID: ws.ID,
+			})
+		}
+	}
+
+	return s.saveToFile(records...)
+}
+
+// Export выгрузит согласованный снимок всех данных в порядке возрастания ID
+func (s *fileRepo) Export(ctx context.Context, w snapshot.Writer) error {
+	return s.repo.Export(ctx, w)
+}
@@ -0,0 +1,31 @@
+package filerepo
+
+import (
+	"context"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+)
+
+// CreateIdentity привяжет удостоверение издателя к пользователю
+func (s *fileRepo) CreateIdentity(ctx context.Context, identity modelAuth.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.CreateIdentity(ctx, identity); err != nil {
+		return err
+	}
+
+	createdAt := identity.CreatedAt
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:    identity.UserID,
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: &createdAt,
+	})
+}
+
+// GetIdentity вернёт удостоверение по издателю и ID пользователя у издателя
+func (s *fileRepo) GetIdentity(ctx context.Context, issuer string, subject string) (*modelAuth.Identity, error) {
+	return s.repo.GetIdentity(ctx, issuer, subject)
+}
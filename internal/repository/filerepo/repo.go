@@ -7,26 +7,70 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
-	"io"
+	"errors"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
 	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
 )
 
-// строка записи в файле
+// строка записи в файле; файл - журнал изменений, который воспроизводится при запуске:
+//   - запись с ShortURL и OriginalURL - сохранение URL'а пользователем UserID;
+//   - запись только с ShortURL и UserID - пользователь UserID сократил уже сохранённый URL;
+//   - запись с ShortURL и DeletedFlag - удаление URL'а пользователем UserID,
+//     без UserID - удаление URL'а при импорте;
+//   - запись только с UserID - создание пользователя;
+//   - запись с WorkspaceID - изменение рабочего пространства, с ShortURL - его ссылок;
+//   - запись с RefreshTokenHash, UserID и ExpiresAt - выдача токена обновления,
+//     только с RefreshTokenHash - использование токена.
 type recordShorURL struct {
 	UUID        string `json:"uuid"`
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
-	// UserID      string `json:"user_id"`
+	ShortURL    string `json:"short_url,omitempty"`
+	OriginalURL string `json:"original_url,omitempty"`
+	UserID      string `json:"user_id,omitempty"`
+	DeletedFlag bool   `json:"is_deleted,omitempty"`
+
+	RefreshTokenHash string     `json:"refresh_token_hash,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+
+	SessionID  string     `json:"session_id,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	Email        string     `json:"email,omitempty"`
+	PasswordHash string     `json:"password_hash,omitempty"`
+	CreatedAt    *time.Time `json:"created_at,omitempty"`
+
+	Issuer  string `json:"issuer,omitempty"`
+	Subject string `json:"subject,omitempty"`
+
+	APIKeyID   string     `json:"api_key_id,omitempty"`
+	APIKeyHash string     `json:"api_key_hash,omitempty"`
+	APIKeyName string     `json:"api_key_name,omitempty"`
+	Scopes     []string   `json:"scopes,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+
+	Role     string `json:"role,omitempty"`
+	Disabled *bool  `json:"disabled,omitempty"`
+
+	WorkspaceID   string `json:"workspace_id,omitempty"`
+	WorkspaceName string `json:"workspace_name,omitempty"`
+	MemberRole    string `json:"member_role,omitempty"`
 }
 
 type fileRepo struct {
 	// хранилище адресов и их id'шников; ключ - id, значение - данные
-	repo         inmr.InMemoryRepo
+	repo *inmr.InMemoryRepo
+	// mu упорядочивает изменения в памяти и записи в файл
+	mu           sync.Mutex
 	lineLastUUID int
 	filename     string
 	file         *os.File
@@ -35,7 +79,7 @@ type fileRepo struct {
 // NewFileRepo Конструктор для хранилища-файла
 func NewFileRepo(fileName string) (*fileRepo, error) {
 	repo := &fileRepo{
-		repo:         *inmr.NewInMemoryRepo(),
+		repo:         inmr.NewInMemoryRepo(),
 		lineLastUUID: 0,
 		filename:     fileName,
 	}
@@ -55,25 +99,44 @@ func NewFileRepo(fileName string) (*fileRepo, error) {
 
 // SaveURL сохранит url и вернёт его id'шник
 func (s *fileRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owned := userID != "" && s.repo.IsOwner(url, userID)
 	hash, err := s.repo.SaveURL(ctx, url, userID)
 	if err != nil {
-		return "", err
+		var errAlreadyExists *repoCommon.URLAlreadyExistsError
+		if !errors.As(err, &errAlreadyExists) || userID == "" || owned {
+			return hash, err
+		}
+
+		// запомним, что пользователь тоже сокращал этот URL
+		if errSave := s.writeRecords(ctx, recordShorURL{
+			ShortURL: errAlreadyExists.ID,
+			UserID:   userID,
+		}); errSave != nil {
+			return "", errSave
+		}
+		return hash, err
 	}
-	record := recordShorURL{
-		UUID:        strconv.FormatInt(int64(s.lineLastUUID+1), 10),
+
+	err = s.writeRecords(ctx, recordShorURL{
 		ShortURL:    hash,
 		OriginalURL: url,
-		// UserID:      userID,
-	}
-	err = s.saveToFile(record)
+		UserID:      userID,
+	})
 	if err != nil {
 		return "", err
 	}
 
-	s.lineLastUUID++
 	return hash, nil
 }
 
+// SetIDGenerator заменит генератор ID URL'ов
+func (s *fileRepo) SetIDGenerator(g idgen.Generator) {
+	s.repo.SetIDGenerator(g)
+}
+
 // GetURLByID вернёт URL по его ID
 func (s *fileRepo) GetURLByID(ctx context.Context, id string) (string, error) {
 	return s.repo.GetURLByID(ctx, id)
@@ -95,22 +158,33 @@ func (s *fileRepo) Ping(ctx context.Context) error {
 	return s.repo.Ping(ctx)
 }
 
-// SaveURLsBatch сохраняет множество URL'ов
+// SaveURLsBatch сохраняет множество URL'ов в одной транзакции;
+// для существующих URL'ов возвращает их ID
 func (s *fileRepo) SaveURLsBatch(
 	ctx context.Context,
 	request []model.CreateShortenURLBatchItemRequest,
 	userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
-	response := make([]model.CreateShortenURLBatchItemResponse, len(request))
-	for _, v := range request {
-		hash, err := s.SaveURL(ctx, v.OriginalURL, userID)
-		if err != nil {
-			return nil, err
-		}
+	response := make([]model.CreateShortenURLBatchItemResponse, 0, len(request))
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		for _, v := range request {
+			hash, err := s.SaveURL(ctx, v.OriginalURL, userID)
+			if err != nil {
+				var errAlreadyExists *repoCommon.URLAlreadyExistsError
+				if !errors.As(err, &errAlreadyExists) {
+					return err
+				}
+				hash = errAlreadyExists.ID
+			}
 
-		response = append(response, model.CreateShortenURLBatchItemResponse{
-			CorrelationID: v.CorrelationID,
-			ShortURL:      hash,
-		})
+			response = append(response, model.CreateShortenURLBatchItemResponse{
+				CorrelationID: v.CorrelationID,
+				ShortURL:      hash,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return response, nil
@@ -118,12 +192,45 @@ func (s *fileRepo) SaveURLsBatch(
 
 // GetNewUserID сгенерировать новый ID для пользователя
 func (s *fileRepo) GetNewUserID(ctx context.Context) (string, error) {
-	return s.repo.GetNewUserID(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userID, err := s.repo.GetNewUserID(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.writeRecords(ctx, recordShorURL{
+		UserID: userID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return userID, nil
 }
 
-// UpdateURLsDeletedFlag пометит указанные URL'ы удалёнными
+// UpdateURLsDeletedFlag пометит указанные URL'ы пользователя удалёнными
 func (s *fileRepo) UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
-	return s.repo.UpdateURLsDeletedFlag(ctx, userID, modelsCh)
+	ids := make([]string, 0)
+	for m := range modelsCh {
+		ids = append(ids, m.URLID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := s.repo.MarkUserURLsDeleted(ctx, userID, ids)
+	records := make([]recordShorURL, 0, len(marked))
+	for _, id := range marked {
+		records = append(records, recordShorURL{
+			ShortURL:    id,
+			UserID:      userID,
+			DeletedFlag: true,
+		})
+	}
+
+	return s.writeRecords(ctx, records...)
 }
 
 func (s *fileRepo) loadAllData() error {
@@ -135,29 +242,18 @@ func (s *fileRepo) loadAllData() error {
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 
-	ctx := context.TODO()
 	scanner := bufio.NewScanner(file)
-	for {
-		if !scanner.Scan() {
-			return scanner.Err()
-		}
+	for scanner.Scan() {
 		record := &recordShorURL{}
-		data := scanner.Bytes()
-		err := json.Unmarshal(data, record)
-
+		err := json.Unmarshal(scanner.Bytes(), record)
 		if err != nil {
-			if err == io.EOF {
-				break
-			}
-
 			return err
 		}
 
-		_, err = s.repo.SaveURL(ctx, record.OriginalURL, "")
-		if err != nil {
-			return err
-		}
+		s.applyRecord(record)
+
 		parsed, err := strconv.ParseInt(record.UUID, 10, 32)
 		if err != nil {
 			return err
@@ -167,20 +263,93 @@ func (s *fileRepo) loadAllData() error {
 		}
 	}
 
-	return nil
+	return scanner.Err()
+}
+
+// applyRecord воспроизводит запись журнала в памяти
+func (s *fileRepo) applyRecord(record *recordShorURL) {
+	switch {
+	case record.RefreshTokenHash != "" && record.ExpiresAt != nil:
+		s.repo.SaveRefreshToken(context.Background(), modelAuth.RefreshToken{
+			Hash:      record.RefreshTokenHash,
+			UserID:    record.UserID,
+			SessionID: record.SessionID,
+			ExpiresAt: *record.ExpiresAt,
+		})
+	case record.RefreshTokenHash != "":
+		s.repo.ConsumeRefreshToken(context.Background(), record.RefreshTokenHash)
+	case record.SessionID != "" || record.RevokedAt != nil:
+		s.applySessionRecord(record)
+	case record.APIKeyID != "":
+		s.applyAPIKeyRecord(record)
+	case record.WorkspaceID != "":
+		s.applyWorkspaceRecord(record)
+	case record.Role != "":
+		s.repo.UpdateUser(context.Background(), modelAuth.User{
+			ID:       record.UserID,
+			Role:     record.Role,
+			Disabled: record.Disabled != nil && *record.Disabled,
+		})
+	case record.Issuer != "":
+		identity := modelAuth.Identity{
+			Issuer:  record.Issuer,
+			Subject: record.Subject,
+			UserID:  record.UserID,
+			Email:   record.Email,
+		}
+		if record.CreatedAt != nil {
+			identity.CreatedAt = *record.CreatedAt
+		}
+		s.repo.CreateIdentity(context.Background(), identity)
+	case record.Email != "":
+		account := modelAuth.Account{
+			UserID:       record.UserID,
+			Email:        record.Email,
+			PasswordHash: record.PasswordHash,
+		}
+		if record.CreatedAt != nil {
+			account.CreatedAt = *record.CreatedAt
+		}
+		s.repo.CreateAccount(context.Background(), account)
+	case record.DeletedFlag && record.UserID == "":
+		s.repo.MarkURLsDeleted(record.ShortURL)
+	case record.DeletedFlag:
+		s.repo.MarkUserURLsDeleted(context.Background(), record.UserID, []string{record.ShortURL})
+	case record.ShortURL != "" && record.OriginalURL == "":
+		s.repo.AddOwner(record.ShortURL, record.UserID)
+	case record.ShortURL != "":
+		s.repo.AddURL(record.ShortURL, record.OriginalURL, record.UserID)
+	case record.UserID != "":
+		s.repo.AddUser(record.UserID)
+	}
 }
 
-func (s *fileRepo) saveToFile(r recordShorURL) error {
-	data, err := json.Marshal(r)
+// saveToFile дописывает записи в файл одной порцией, присваивая им следующие номера
+func (s *fileRepo) saveToFile(records ...recordShorURL) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	data := make([]byte, 0)
+	for i, r := range records {
+		r.UUID = strconv.FormatInt(int64(s.lineLastUUID+i+1), 10)
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+
+		// добавляем перенос строки
+		data = append(data, line...)
+		data = append(data, '\n')
+	}
+
+	_, err := s.file.Write(data)
 	if err != nil {
 		return err
 	}
 
-	// добавляем перенос строки
-	data = append(data, '\n')
-
-	_, err = s.file.Write(data)
-	return err
+	s.lineLastUUID += len(records)
+	return nil
 }
 
 // GetStats возвращает статистику
@@ -0,0 +1,473 @@
+package filerepo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/conformance"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance проверяет хранилище общим набором сценариев
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) conformance.Repo {
+		return newTestRepo(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+// TestSnapshotConformance проверяет выгрузку и загрузку данных
+func TestSnapshotConformance(t *testing.T) {
+	conformance.RunSnapshot(t, func(t *testing.T) conformance.SnapshotRepo {
+		return newTestRepo(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+// TestTxConformance проверяет транзакции
+func TestTxConformance(t *testing.T) {
+	conformance.RunTx(t, func(t *testing.T) conformance.TxRepo {
+		return newTestRepo(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+// TestAuthConformance проверяет хранение данных аутентификации
+func TestAuthConformance(t *testing.T) {
+	conformance.RunAuth(t, func(t *testing.T) conformance.AuthRepo {
+		return newTestRepo(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+// TestWorkspaceConformance проверяет хранение рабочих пространств
+func TestWorkspaceConformance(t *testing.T) {
+	conformance.RunWorkspaces(t, func(t *testing.T) conformance.WorkspaceRepo {
+		return newTestRepo(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+// TestCollisionConformance проверяет подбор ID при коллизиях хешей
+func TestCollisionConformance(t *testing.T) {
+	conformance.RunCollisions(t, func(t *testing.T) conformance.CollisionRepo {
+		return newTestRepo(t, filepath.Join(t.TempDir(), "storage.json"))
+	})
+}
+
+// TestFileRepo_ReloadImported проверяет, что импортированные данные восстанавливаются после перезапуска
+func TestFileRepo_ReloadImported(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	urls := []snapshot.URL{
+		{ID: "id-1", OriginalURL: "https://example.com/1", UserIDs: []string{"user-1"}},
+		{ID: "id-2", OriginalURL: "https://example.com/2", Deleted: true, UserIDs: []string{}},
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Millisecond)
+	usedAt := createdAt.Add(time.Minute)
+	users := []snapshot.User{
+		{ID: "user-1", Role: modelAuth.RoleAdmin},
+		{ID: "user-2", Role: modelAuth.RoleUser, Disabled: true},
+	}
+	accounts := []snapshot.Account{
+		{UserID: "user-1", Email: "user@example.com", PasswordHash: "hash", CreatedAt: createdAt},
+	}
+	identities := []snapshot.Identity{
+		{Issuer: "https://idp.example.com", Subject: "subject", UserID: "user-2", CreatedAt: createdAt},
+	}
+	keys := []snapshot.APIKey{{
+		ID: "key-1", UserID: "user-1", Hash: "key-hash", Name: "ci",
+		Scopes: []string{modelAuth.ScopeLinksRead}, CreatedAt: createdAt, LastUsedAt: &usedAt,
+	}}
+	workspaces := []snapshot.Workspace{{
+		ID: "ws-1", Name: "team", CreatedAt: createdAt,
+		Members: []snapshot.Member{
+			{UserID: "user-1", Role: modelWorkspace.RoleOwner},
+			{UserID: "user-2", Role: modelWorkspace.RoleViewer},
+		},
+		URLIDs: []string{"id-2"},
+	}}
+
+	// повторная загрузка попадает в журнал, но при воспроизведении ничего не меняет
+	repo := newTestRepo(t, filename)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, repo.ImportUsers(ctx, users))
+		require.NoError(t, repo.ImportURLs(ctx, urls))
+		require.NoError(t, repo.ImportAccounts(ctx, accounts))
+		require.NoError(t, repo.ImportIdentities(ctx, identities))
+		require.NoError(t, repo.ImportAPIKeys(ctx, keys))
+		require.NoError(t, repo.ImportWorkspaces(ctx, workspaces))
+	}
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	gotURLs, err := reloaded.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, urls, gotURLs)
+	gotUsers, err := reloaded.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, users, gotUsers)
+	gotAccounts, err := reloaded.GetAccountsPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, accounts, gotAccounts)
+	gotIdentities, err := reloaded.GetIdentitiesPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, identities, gotIdentities)
+	gotKeys, err := reloaded.GetAPIKeysPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, keys, gotKeys)
+	gotWorkspaces, err := reloaded.GetWorkspacesPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, workspaces, gotWorkspaces)
+}
+
+// TestFileRepo_ReloadRefreshTokens проверяет, что после перезапуска выданные токены обновления
+// действуют, а использованные - нет
+func TestFileRepo_ReloadRefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	expiresAt := time.Now().Add(time.Hour)
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, repo.SaveRefreshToken(ctx, modelAuth.RefreshToken{Hash: "kept", UserID: userID, ExpiresAt: expiresAt}))
+	require.NoError(t, repo.SaveRefreshToken(ctx, modelAuth.RefreshToken{Hash: "consumed", UserID: userID, ExpiresAt: expiresAt}))
+	_, err = repo.ConsumeRefreshToken(ctx, "consumed")
+	require.NoError(t, err)
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	token, err := reloaded.ConsumeRefreshToken(ctx, "kept")
+	require.NoError(t, err)
+	require.Equal(t, userID, token.UserID)
+	_, err = reloaded.ConsumeRefreshToken(ctx, "consumed")
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+// TestFileRepo_ReloadAccounts проверяет, что после перезапуска учётные записи сохраняются
+func TestFileRepo_ReloadAccounts(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	account := modelAuth.Account{UserID: userID, Email: "user@example.com", PasswordHash: "hash", CreatedAt: time.Now()}
+	require.NoError(t, repo.CreateAccount(ctx, account))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	got, err := reloaded.GetAccountByEmail(ctx, account.Email)
+	require.NoError(t, err)
+	require.Equal(t, userID, got.UserID)
+	require.Equal(t, account.PasswordHash, got.PasswordHash)
+	require.ErrorIs(t, reloaded.CreateAccount(ctx, account), repoCommon.ErrAccountExists)
+}
+
+// TestFileRepo_ReloadAPIKeys проверяет, что после перезапуска API-ключи, время их использования
+// и отзыв сохраняются
+func TestFileRepo_ReloadIdentities(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	identity := modelAuth.Identity{
+		Issuer:    "https://idp.example.com",
+		Subject:   "subject",
+		UserID:    userID,
+		Email:     "user@example.com",
+		CreatedAt: time.Now().Truncate(time.Millisecond),
+	}
+	require.NoError(t, repo.CreateIdentity(ctx, identity))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	got, err := reloaded.GetIdentity(ctx, identity.Issuer, identity.Subject)
+	require.NoError(t, err)
+	require.Equal(t, userID, got.UserID)
+	require.Equal(t, identity.Email, got.Email)
+	require.True(t, identity.CreatedAt.Equal(got.CreatedAt))
+	// почта удостоверения не становится учётной записью
+	_, err = reloaded.GetAccountByEmail(ctx, identity.Email)
+	require.ErrorIs(t, err, repoCommon.ErrNotFoundKey)
+}
+
+func TestFileRepo_ReloadAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	usedAt := time.Now().Truncate(time.Millisecond)
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	kept := modelAuth.APIKey{ID: "kept", UserID: userID, Hash: "hash", Scopes: []string{modelAuth.ScopeLinksRead}, CreatedAt: time.Now()}
+	revoked := modelAuth.APIKey{ID: "revoked", UserID: userID, Hash: "hash", Scopes: []string{modelAuth.ScopeLinksRead}, CreatedAt: time.Now()}
+	require.NoError(t, repo.SaveAPIKey(ctx, kept))
+	require.NoError(t, repo.SaveAPIKey(ctx, revoked))
+	require.NoError(t, repo.TouchAPIKey(ctx, kept.ID, usedAt))
+	require.NoError(t, repo.DeleteAPIKey(ctx, userID, revoked.ID))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	keys, err := reloaded.GetUserAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, kept.ID, keys[0].ID)
+	require.Equal(t, kept.Scopes, keys[0].Scopes)
+	require.NotNil(t, keys[0].LastUsedAt)
+	require.True(t, usedAt.Equal(*keys[0].LastUsedAt))
+}
+
+// TestFileRepo_ReloadUsers проверяет, что после перезапуска роли, отключение пользователей
+// и снятые URL'ы сохраняются
+func TestFileRepo_ReloadUsers(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	admin := modelAuth.User{ID: userID, Role: modelAuth.RoleAdmin, Disabled: true}
+	require.NoError(t, repo.UpdateUser(ctx, admin))
+	id, err := repo.SaveURL(ctx, "https://example.com/takedown", userID)
+	require.NoError(t, err)
+	require.NoError(t, repo.TakeDownURL(ctx, id))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	user, err := reloaded.GetUser(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, admin, *user)
+	_, err = reloaded.GetURLByID(ctx, id)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+}
+
+// TestFileRepo_ReloadWorkspaces проверяет, что после перезапуска пространства, их участники
+// и ссылки сохраняются
+func TestFileRepo_ReloadWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	createdAt := time.Now().Truncate(time.Millisecond)
+
+	repo := newTestRepo(t, filename)
+	ownerID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	editorID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	viewerID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	ws := modelWorkspace.Workspace{ID: "ws-1", Name: "campaigns", CreatedAt: createdAt}
+	require.NoError(t, repo.CreateWorkspace(ctx, ws, modelWorkspace.Member{WorkspaceID: ws.ID, UserID: ownerID, Role: modelWorkspace.RoleOwner}))
+	require.NoError(t, repo.SaveWorkspaceMember(ctx, modelWorkspace.Member{WorkspaceID: ws.ID, UserID: editorID, Role: modelWorkspace.RoleEditor}))
+	require.NoError(t, repo.SaveWorkspaceMember(ctx, modelWorkspace.Member{WorkspaceID: ws.ID, UserID: viewerID, Role: modelWorkspace.RoleViewer}))
+	require.NoError(t, repo.DeleteWorkspaceMember(ctx, ws.ID, viewerID))
+	id, err := repo.SaveURL(ctx, "https://example.com/campaign", "")
+	require.NoError(t, err)
+	deletedID, err := repo.SaveURL(ctx, "https://example.com/deleted", "")
+	require.NoError(t, err)
+	require.NoError(t, repo.AddWorkspaceURLs(ctx, ws.ID, []string{id, deletedID}))
+	modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: deletedID}
+	close(modelsCh)
+	require.NoError(t, repo.UpdateWorkspaceURLsDeletedFlag(ctx, ws.ID, modelsCh))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	got, err := reloaded.GetWorkspace(ctx, ws.ID)
+	require.NoError(t, err)
+	require.Equal(t, ws.Name, got.Name)
+	require.True(t, createdAt.Equal(got.CreatedAt))
+	members, err := reloaded.GetWorkspaceMembers(ctx, ws.ID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []modelWorkspace.Member{
+		{WorkspaceID: ws.ID, UserID: ownerID, Role: modelWorkspace.RoleOwner},
+		{WorkspaceID: ws.ID, UserID: editorID, Role: modelWorkspace.RoleEditor},
+	}, members)
+	urls, err := reloaded.GetWorkspaceURLs(ctx, ws.ID)
+	require.NoError(t, err)
+	require.Len(t, urls, 2)
+	_, err = reloaded.GetURLByID(ctx, id)
+	require.NoError(t, err)
+	_, err = reloaded.GetURLByID(ctx, deletedID)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+}
+
+func TestFileRepo_ReloadSessions(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	createdAt := time.Now().Truncate(time.Millisecond)
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	otherUserID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	for _, session := range []modelAuth.Session{
+		{ID: "session-1", UserID: userID, UserAgent: "curl/8.0", CreatedAt: createdAt, LastSeenAt: createdAt},
+		{ID: "session-2", UserID: userID, CreatedAt: createdAt.Add(time.Second), LastSeenAt: createdAt},
+		{ID: "session-3", UserID: otherUserID, CreatedAt: createdAt, LastSeenAt: createdAt},
+		{ID: "session-4", UserID: otherUserID, CreatedAt: createdAt.Add(time.Second), LastSeenAt: createdAt},
+	} {
+		require.NoError(t, repo.CreateSession(ctx, session))
+	}
+	seenAt := createdAt.Add(time.Minute)
+	require.NoError(t, repo.TouchSession(ctx, "session-1", seenAt))
+	revokedAt := createdAt.Add(time.Hour)
+	require.NoError(t, repo.RevokeSession(ctx, otherUserID, "session-3", revokedAt))
+	require.NoError(t, repo.RevokeUserSessions(ctx, userID, revokedAt))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	sessions, err := reloaded.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, "curl/8.0", sessions[0].UserAgent)
+	require.True(t, seenAt.Equal(sessions[0].LastSeenAt))
+	for _, session := range sessions {
+		require.NotNil(t, session.RevokedAt)
+		require.True(t, revokedAt.Equal(*session.RevokedAt))
+	}
+	sessions, err = reloaded.GetUserSessions(ctx, otherUserID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.NotNil(t, sessions[0].RevokedAt)
+	require.Nil(t, sessions[1].RevokedAt)
+}
+
+// TestFileRepo_Reload проверяет, что после перезапуска восстанавливаются
+// URL'ы, их владельцы, флаги удаления и пользователи
+func TestFileRepo_Reload(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	otherUserID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	keptID, err := repo.SaveURL(ctx, "https://example.com/kept", userID)
+	require.NoError(t, err)
+	deletedID, err := repo.SaveURL(ctx, "https://example.com/deleted", userID)
+	require.NoError(t, err)
+	_, err = repo.SaveURL(ctx, "https://example.com/kept", otherUserID)
+	require.Error(t, err)
+
+	modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: deletedID}
+	close(modelsCh)
+	require.NoError(t, repo.UpdateURLsDeletedFlag(ctx, userID, modelsCh))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+
+	url, err := reloaded.GetURLByID(ctx, keptID)
+	require.NoError(t, err)
+	require.Equal(t, "https://example.com/kept", url)
+
+	_, err = reloaded.GetURLByID(ctx, deletedID)
+	require.ErrorIs(t, err, repoCommon.ErrURLDeleted)
+
+	userURLs, err := reloaded.GetUserURLs(ctx, otherUserID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	require.Equal(t, keptID, userURLs[0].ShortURL)
+
+	stats, err := reloaded.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.URLs)
+	require.Equal(t, 2, stats.Users)
+
+	// новые записи продолжают нумерацию
+	require.Equal(t, repo.lineLastUUID, reloaded.lineLastUUID)
+}
+
+// TestFileRepo_ResaveURL проверяет, что повторное сокращение пишет в файл только новую связь с пользователем
+func TestFileRepo_ResaveURL(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	countLines := func() int {
+		data, err := os.ReadFile(filename)
+		require.NoError(t, err)
+		return bytes.Count(data, []byte("\n"))
+	}
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	otherUserID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	id, err := repo.SaveURL(ctx, "https://example.com", userID)
+	require.NoError(t, err)
+	lines := countLines()
+
+	_, err = repo.SaveURL(ctx, "https://example.com", userID)
+	require.Error(t, err)
+	require.Equal(t, lines, countLines())
+
+	_, err = repo.SaveURL(ctx, "https://example.com", otherUserID)
+	require.Error(t, err)
+	require.Equal(t, lines+1, countLines())
+	data, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, 1, bytes.Count(data, []byte("https://example.com")))
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	for _, user := range []string{userID, otherUserID} {
+		userURLs, err := reloaded.GetUserURLs(ctx, user)
+		require.NoError(t, err)
+		require.Len(t, userURLs, 1)
+		require.Equal(t, id, userURLs[0].ShortURL)
+	}
+}
+
+// TestFileRepo_ReloadAfterTx проверяет, что в файл попадают только зафиксированные транзакции
+func TestFileRepo_ReloadAfterTx(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "storage.json")
+	errRollback := errors.New("rollback")
+
+	repo := newTestRepo(t, filename)
+	userID, err := repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	var committedID string
+	err = repo.WithinTx(ctx, func(ctx context.Context) error {
+		committedID, err = repo.SaveURL(ctx, "https://example.com/committed", userID)
+		return err
+	})
+	require.NoError(t, err)
+	linesCommitted := repo.lineLastUUID
+
+	err = repo.WithinTx(ctx, func(ctx context.Context) error {
+		_, err := repo.SaveURL(ctx, "https://example.com/rolledback", userID)
+		require.NoError(t, err)
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+	require.Equal(t, linesCommitted, repo.lineLastUUID)
+	require.NoError(t, repo.Close())
+
+	reloaded := newTestRepo(t, filename)
+	userURLs, err := reloaded.GetUserURLs(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, 1)
+	require.Equal(t, committedID, userURLs[0].ShortURL)
+}
+
+func newTestRepo(t *testing.T, filename string) *fileRepo {
+	repo, err := NewFileRepo(filename)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		repo.Close()
+	})
+	return repo
+}
@@ -0,0 +1,110 @@
+package filerepo
+
+import (
+	"context"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+)
+
+// CreateSession сохранит новый сеанс пользователя
+func (s *fileRepo) CreateSession(ctx context.Context, session modelAuth.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return err
+	}
+
+	createdAt := session.CreatedAt
+	lastSeenAt := session.LastSeenAt
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:     session.UserID,
+		SessionID:  session.ID,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  &createdAt,
+		LastSeenAt: &lastSeenAt,
+		RevokedAt:  session.RevokedAt,
+	})
+}
+
+// GetSession вернёт сеанс по его ID
+func (s *fileRepo) GetSession(ctx context.Context, id string) (*modelAuth.Session, error) {
+	return s.repo.GetSession(ctx, id)
+}
+
+// GetUserSessions вернёт сеансы пользователя, в том числе отозванные, в порядке начала
+func (s *fileRepo) GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error) {
+	return s.repo.GetUserSessions(ctx, userID)
+}
+
+// TouchSession запишет время последнего запроса в сеансе
+func (s *fileRepo) TouchSession(ctx context.Context, id string, seenAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.TouchSession(ctx, id, seenAt); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		SessionID:  id,
+		LastSeenAt: &seenAt,
+	})
+}
+
+// RevokeSession отзовёт сеанс пользователя; время отзыва уже отозванного сеанса не меняется
+func (s *fileRepo) RevokeSession(ctx context.Context, userID string, id string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.RevokeSession(ctx, userID, id, revokedAt); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:    userID,
+		SessionID: id,
+		RevokedAt: &revokedAt,
+	})
+}
+
+// RevokeUserSessions отзовёт все действующие сеансы пользователя
+func (s *fileRepo) RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.RevokeUserSessions(ctx, userID, revokedAt); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:    userID,
+		RevokedAt: &revokedAt,
+	})
+}
+
+// applySessionRecord применит к памяти запись о сеансе: начало, запрос или отзыв
+func (s *fileRepo) applySessionRecord(record *recordShorURL) {
+	ctx := context.Background()
+	switch {
+	case record.CreatedAt != nil:
+		session := modelAuth.Session{
+			ID:        record.SessionID,
+			UserID:    record.UserID,
+			UserAgent: record.UserAgent,
+			CreatedAt: *record.CreatedAt,
+			RevokedAt: record.RevokedAt,
+		}
+		if record.LastSeenAt != nil {
+			session.LastSeenAt = *record.LastSeenAt
+		}
+		s.repo.CreateSession(ctx, session)
+	case record.SessionID == "":
+		s.repo.RevokeUserSessions(ctx, record.UserID, *record.RevokedAt)
+	case record.RevokedAt != nil:
+		s.repo.RevokeSession(ctx, record.UserID, record.SessionID, *record.RevokedAt)
+	case record.LastSeenAt != nil:
+		s.repo.TouchSession(ctx, record.SessionID, *record.LastSeenAt)
+	}
+}
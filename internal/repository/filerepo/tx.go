@@ -0,0 +1,51 @@
+package filerepo
+
+import "context"
+
+// txKey ключ контекста с транзакцией хранилища repo
+type txKey struct {
+	repo *fileRepo
+}
+
+// fileTx записи журнала, накопленные транзакцией
+type fileTx struct {
+	records []recordShorURL
+}
+
+// WithinTx выполнит fn в транзакции: изменения в памяти откатываются при ошибке,
+// а записи журнала попадают в файл одной порцией только при успешном завершении fn.
+// Вызов внутри уже начатой транзакции присоединяется к ней
+func (s *fileRepo) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.tx(ctx) != nil {
+		return fn(ctx)
+	}
+
+	return s.repo.WithinTx(ctx, func(ctx context.Context) error {
+		tx := &fileTx{}
+		if err := fn(context.WithValue(ctx, txKey{repo: s}, tx)); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		// при ошибке записи изменения в памяти тоже откатятся
+		return s.saveToFile(tx.records...)
+	})
+}
+
+// tx вернёт текущую транзакцию или nil
+func (s *fileRepo) tx(ctx context.Context) *fileTx {
+	tx, _ := ctx.Value(txKey{repo: s}).(*fileTx)
+	return tx
+}
+
+// writeRecords допишет записи в файл или, внутри транзакции, отложит их до её завершения
+func (s *fileRepo) writeRecords(ctx context.Context, records ...recordShorURL) error {
+	if tx := s.tx(ctx); tx != nil {
+		tx.records = append(tx.records, records...)
+		return nil
+	}
+
+	return s.saveToFile(records...)
+}
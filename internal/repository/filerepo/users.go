@@ -0,0 +1,49 @@
+package filerepo
+
+import (
+	"context"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+)
+
+// GetUser вернёт роль и состояние пользователя
+func (s *fileRepo) GetUser(ctx context.Context, userID string) (*modelAuth.User, error) {
+	return s.repo.GetUser(ctx, userID)
+}
+
+// ListUsers вернёт не больше limit пользователей, следующих за afterID, в порядке возрастания ID
+func (s *fileRepo) ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error) {
+	return s.repo.ListUsers(ctx, afterID, limit)
+}
+
+// UpdateUser сохранит роль и состояние пользователя
+func (s *fileRepo) UpdateUser(ctx context.Context, user modelAuth.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return err
+	}
+
+	disabled := user.Disabled
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:   user.ID,
+		Role:     user.Role,
+		Disabled: &disabled,
+	})
+}
+
+// TakeDownURL пометит URL удалённым независимо от его владельцев
+func (s *fileRepo) TakeDownURL(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.TakeDownURL(ctx, id); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		ShortURL:    id,
+		DeletedFlag: true,
+	})
+}
@@ -0,0 +1,158 @@
+package filerepo
+
+import (
+	"context"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+)
+
+// CreateWorkspace создаст рабочее пространство вместе с его владельцем
+func (s *fileRepo) CreateWorkspace(ctx context.Context, ws modelWorkspace.Workspace, owner modelWorkspace.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.CreateWorkspace(ctx, ws, owner); err != nil {
+		return err
+	}
+
+	createdAt := ws.CreatedAt
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:        owner.UserID,
+		WorkspaceID:   ws.ID,
+		WorkspaceName: ws.Name,
+		MemberRole:    owner.Role,
+		CreatedAt:     &createdAt,
+	})
+}
+
+// GetWorkspace вернёт рабочее пространство по его ID
+func (s *fileRepo) GetWorkspace(ctx context.Context, id string) (*modelWorkspace.Workspace, error) {
+	return s.repo.GetWorkspace(ctx, id)
+}
+
+// GetUserWorkspaces вернёт пространства пользователя в порядке их создания
+func (s *fileRepo) GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error) {
+	return s.repo.GetUserWorkspaces(ctx, userID)
+}
+
+// GetWorkspaceMember вернёт участника пространства
+func (s *fileRepo) GetWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*modelWorkspace.Member, error) {
+	return s.repo.GetWorkspaceMember(ctx, workspaceID, userID)
+}
+
+// GetWorkspaceMembers вернёт участников пространства в порядке возрастания ID пользователей
+func (s *fileRepo) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]modelWorkspace.Member, error) {
+	return s.repo.GetWorkspaceMembers(ctx, workspaceID)
+}
+
+// GetWorkspaceOwners вернёт ID владельцев пространства в порядке возрастания
+func (s *fileRepo) GetWorkspaceOwners(ctx context.Context, workspaceID string) ([]string, error) {
+	return s.repo.GetWorkspaceOwners(ctx, workspaceID)
+}
+
+// SaveWorkspaceMember добавит участника пространства или сменит его роль
+func (s *fileRepo) SaveWorkspaceMember(ctx context.Context, member modelWorkspace.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.SaveWorkspaceMember(ctx, member); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:      member.UserID,
+		WorkspaceID: member.WorkspaceID,
+		MemberRole:  member.Role,
+	})
+}
+
+// DeleteWorkspaceMember удалит участника пространства
+func (s *fileRepo) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.repo.DeleteWorkspaceMember(ctx, workspaceID, userID); err != nil {
+		return err
+	}
+
+	return s.writeRecords(ctx, recordShorURL{
+		UserID:      userID,
+		WorkspaceID: workspaceID,
+		DeletedFlag: true,
+	})
+}
+
+// AddWorkspaceURLs свяжет сохранённые URL'ы с рабочим пространством;
+// в файл пишутся только новые связи
+func (s *fileRepo) AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	linked := s.repo.LinkWorkspaceURLs(ctx, workspaceID, ids)
+	records := make([]recordShorURL, 0, len(linked))
+	for _, id := range linked {
+		records = append(records, recordShorURL{
+			ShortURL:    id,
+			WorkspaceID: workspaceID,
+		})
+	}
+
+	return s.writeRecords(ctx, records...)
+}
+
+// GetWorkspaceURLs вернёт все URL'ы рабочего пространства
+func (s *fileRepo) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error) {
+	return s.repo.GetWorkspaceURLs(ctx, workspaceID)
+}
+
+// UpdateWorkspaceURLsDeletedFlag пометит указанные URL'ы рабочего пространства удалёнными
+func (s *fileRepo) UpdateWorkspaceURLsDeletedFlag(ctx context.Context,
+	workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	ids := make([]string, 0)
+	for m := range modelsCh {
+		ids = append(ids, m.URLID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := s.repo.MarkWorkspaceURLsDeleted(ctx, workspaceID, ids)
+	records := make([]recordShorURL, 0, len(marked))
+	for _, id := range marked {
+		records = append(records, recordShorURL{
+			ShortURL:    id,
+			WorkspaceID: workspaceID,
+			DeletedFlag: true,
+		})
+	}
+
+	return s.writeRecords(ctx, records...)
+}
+
+// applyWorkspaceRecord применит к памяти запись о пространстве: создание, смену участника или его удаление,
+// связь со ссылкой или её удаление
+func (s *fileRepo) applyWorkspaceRecord(record *recordShorURL) {
+	ctx := context.Background()
+	member := modelWorkspace.Member{
+		WorkspaceID: record.WorkspaceID,
+		UserID:      record.UserID,
+		Role:        record.MemberRole,
+	}
+	switch {
+	case record.ShortURL != "" && record.DeletedFlag:
+		s.repo.MarkWorkspaceURLsDeleted(ctx, record.WorkspaceID, []string{record.ShortURL})
+	case record.ShortURL != "":
+		s.repo.LinkWorkspaceURLs(ctx, record.WorkspaceID, []string{record.ShortURL})
+	case record.WorkspaceName != "":
+		ws := modelWorkspace.Workspace{ID: record.WorkspaceID, Name: record.WorkspaceName}
+		if record.CreatedAt != nil {
+			ws.CreatedAt = *record.CreatedAt
+		}
+		s.repo.CreateWorkspace(ctx, ws, member)
+	case record.DeletedFlag:
+		s.repo.DeleteWorkspaceMember(ctx, record.WorkspaceID, record.UserID)
+	case record.MemberRole != "":
+		s.repo.SaveWorkspaceMember(ctx, member)
+	}
+}
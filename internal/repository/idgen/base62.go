@@ -0,0 +1,37 @@
+package idgen
+
+// base62Alphabet символы base62 в порядке возрастания ASCII-кодов,
+// поэтому ID одинаковой длины сравниваются как числа
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// encodeBase62 закодирует n в base62 без ведущих нулей
+func encodeBase62(n uint64) string {
+	return encodeWithAlphabet(n, base62Alphabet)
+}
+
+// encodeBase62Fixed закодирует n в base62, дополнив слева нулями до width символов
+func encodeBase62Fixed(n uint64, width int) string {
+	s := encodeBase62(n)
+	for len(s) < width {
+		s = "0" + s
+	}
+	return s
+}
+
+// encodeWithAlphabet закодирует n в системе счисления с основанием len(alphabet)
+func encodeWithAlphabet(n uint64, alphabet string) string {
+	base := uint64(len(alphabet))
+	if n == 0 {
+		return alphabet[:1]
+	}
+
+	buf := make([]byte, 0, 11)
+	for n > 0 {
+		buf = append(buf, alphabet[n%base])
+		n /= base
+	}
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	return string(buf)
+}
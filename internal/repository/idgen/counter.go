@@ -0,0 +1,50 @@
+package idgen
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Sequence источник возрастающих значений счётчика
+type Sequence interface {
+	// Next вернёт следующее значение
+	Next(ctx context.Context) (uint64, error)
+}
+
+// LocalSequence счётчик в памяти процесса; подходит, когда хранилище использует один экземпляр приложения
+type LocalSequence struct {
+	last atomic.Uint64
+}
+
+// NewLocalSequence создаст счётчик, первое значение которого - start
+func NewLocalSequence(start uint64) *LocalSequence {
+	s := new(LocalSequence)
+	if start > 0 {
+		s.last.Store(start - 1)
+	}
+	return s
+}
+
+// Next реализует Sequence
+func (s *LocalSequence) Next(ctx context.Context) (uint64, error) {
+	return s.last.Add(1), nil
+}
+
+// counterGenerator ID - очередное значение счётчика в base62
+type counterGenerator struct {
+	seq Sequence
+}
+
+// NewCounter создаст генератор последовательных ID
+func NewCounter(seq Sequence) Generator {
+	return &counterGenerator{seq: seq}
+}
+
+// Generate реализует Generator; занятое значение пропускается
+func (g *counterGenerator) Generate(ctx context.Context, url string, attempt int) (string, error) {
+	n, err := g.seq.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return encodeBase62(n), nil
+}
@@ -0,0 +1,23 @@
+package idgen
+
+import (
+	"context"
+
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// hashGenerator ID - усечённый хеш URL'а; один и тот же URL получает один и тот же ID
+type hashGenerator struct {
+	newHash repoCommon.HashFunc
+}
+
+// NewHash создаст генератор, получающий ID из хеша URL'а;
+// формат ID совпадает с repository.GenerateURLUniqueHash
+func NewHash(newHash repoCommon.HashFunc) Generator {
+	return &hashGenerator{newHash: newHash}
+}
+
+// Generate реализует Generator
+func (g *hashGenerator) Generate(ctx context.Context, url string, attempt int) (string, error) {
+	return repoCommon.GenerateURLID(g.newHash(), url, attempt)
+}
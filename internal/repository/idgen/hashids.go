@@ -0,0 +1,121 @@
+package idgen
+
+import (
+	"context"
+	"hash/fnv"
+	"math/bits"
+)
+
+// Множители перемешивания значений счётчика; нечётные, поэтому обратимы по модулю 2^n
+const (
+	hashidsMul1 = 0x9E3779B97F4A7C15
+	hashidsMul2 = 0xBF58476D1CE4E5B9
+)
+
+// hashidsGenerator ID - значение счётчика, запутанное в стиле Hashids:
+// значение обратимо перемешивается солью, а первый символ ID («лотерея») перемешивает алфавит,
+// поэтому соседние значения счётчика дают непохожие ID
+type hashidsGenerator struct {
+	seq      Sequence
+	salt     string
+	key      uint64
+	alphabet string
+	// width количество символов после «лотереи»
+	width int
+	// valueBits разрядность перемешиваемых значений; они помещаются в width символов
+	valueBits int
+}
+
+// NewHashids создаст генератор запутанных ID; minLength - минимальная длина ID
+func NewHashids(seq Sequence, salt string, minLength int) Generator {
+	width := minLength - 1
+	if width < 1 {
+		width = 1
+	}
+	h := fnv.New64a()
+	h.Write([]byte(salt))
+
+	return &hashidsGenerator{
+		seq:       seq,
+		salt:      salt,
+		key:       h.Sum64(),
+		alphabet:  shuffle(base62Alphabet, salt),
+		width:     width,
+		valueBits: base62Bits(width),
+	}
+}
+
+// Generate реализует Generator; занятое значение пропускается
+func (g *hashidsGenerator) Generate(ctx context.Context, url string, attempt int) (string, error) {
+	n, err := g.seq.Next(ctx)
+	if err != nil {
+		return "", err
+	}
+	return g.encode(n), nil
+}
+
+// encode закодирует n; разные n всегда дают разные ID
+func (g *hashidsGenerator) encode(n uint64) string {
+	var v uint64
+	if n < 1<<g.valueBits {
+		v = g.permute(n)
+	} else {
+		// значения, не поместившиеся в width символов, кодируются длиннее и не перемешиваются
+		v = n - 1<<g.valueBits + pow62(g.width)
+	}
+
+	lottery := g.alphabet[v%uint64(len(g.alphabet))]
+	alphabet := shuffle(g.alphabet, string(lottery)+g.salt)
+	digits := encodeWithAlphabet(v, alphabet)
+	for len(digits) < g.width {
+		digits = alphabet[:1] + digits
+	}
+	return string(lottery) + digits
+}
+
+// permute обратимо перемешает младшие valueBits бит n
+func (g *hashidsGenerator) permute(n uint64) uint64 {
+	mask := uint64(1)<<g.valueBits - 1
+	shift := g.valueBits / 2
+	x := (n ^ g.key) & mask
+	x = (x * hashidsMul1) & mask
+	x ^= x >> shift
+	x = (x * hashidsMul2) & mask
+	x ^= x >> shift
+	return x
+}
+
+// base62Bits наибольшая разрядность значений, которые помещаются в width символов base62
+func base62Bits(width int) int {
+	if width >= 11 {
+		// 62^11 больше 2^64
+		return 63
+	}
+	return bits.Len64(pow62(width)) - 1
+}
+
+// pow62 вернёт 62^n; n не больше 10
+func pow62(n int) uint64 {
+	p := uint64(1)
+	for i := 0; i < n; i++ {
+		p *= 62
+	}
+	return p
+}
+
+// shuffle детерминированно перемешает алфавит солью, как это делает Hashids
+func shuffle(alphabet string, salt string) string {
+	if salt == "" {
+		return alphabet
+	}
+
+	result := []byte(alphabet)
+	for i, v, p := len(result)-1, 0, 0; i > 0; i, v = i-1, v+1 {
+		v %= len(salt)
+		c := int(salt[v])
+		p += c
+		j := (c + v + p) % i
+		result[i], result[j] = result[j], result[i]
+	}
+	return string(result)
+}
@@ -0,0 +1,55 @@
+package idgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashids(t *testing.T) {
+	g := NewHashids(NewLocalSequence(1), "salt", 6).(*hashidsGenerator)
+
+	seen := make(map[string]uint64)
+	for n := uint64(0); n < 100000; n++ {
+		id := g.encode(n)
+		require.Len(t, id, 6)
+		requireClean(t, id)
+
+		prev, ok := seen[id]
+		require.False(t, ok, "values %d and %d have the same id %q", prev, n, id)
+		seen[id] = n
+	}
+
+	// соседние значения не похожи друг на друга
+	require.NotEqual(t, g.encode(1)[:3], g.encode(2)[:3])
+
+	// другая соль даёт другие ID
+	other := NewHashids(NewLocalSequence(1), "pepper", 6).(*hashidsGenerator)
+	require.NotEqual(t, g.encode(1), other.encode(1))
+}
+
+func TestHashids_Overflow(t *testing.T) {
+	g := NewHashids(NewLocalSequence(1), "", 2).(*hashidsGenerator)
+
+	// значения, не поместившиеся в минимальную длину, кодируются длиннее, но остаются уникальными
+	seen := make(map[string]struct{})
+	for n := uint64(0); n < 10000; n++ {
+		id := g.encode(n)
+		require.GreaterOrEqual(t, len(id), 2)
+		_, ok := seen[id]
+		require.False(t, ok, "duplicate id %q", id)
+		seen[id] = struct{}{}
+	}
+}
+
+func TestHashids_Generate(t *testing.T) {
+	g := NewHashids(NewLocalSequence(1), "salt", 8)
+
+	first, err := g.Generate(context.Background(), "https://example.com", 0)
+	require.NoError(t, err)
+	second, err := g.Generate(context.Background(), "https://example.com", 0)
+	require.NoError(t, err)
+	require.Len(t, first, 8)
+	require.NotEqual(t, first, second)
+}
@@ -0,0 +1,73 @@
+/*
+Package idgen содержит стратегии генерации ID сокращённых URL'ов.
+
+Хранилища вызывают Generator для каждого нового URL'а; если полученный ID уже занят
+другим URL'ом, генератор вызывается повторно со следующим номером попытки.
+*/
+package idgen
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+)
+
+// Generator стратегия генерации ID URL'ов
+type Generator interface {
+	// Generate вернёт ID для url; attempt - номер попытки, если предыдущий ID оказался занят
+	Generate(ctx context.Context, url string, attempt int) (string, error)
+}
+
+// Стратегии генерации ID
+const (
+	StrategyHash    = "hash"    // усечённый хеш URL'а
+	StrategyRandom  = "random"  // случайная строка base62
+	StrategyCounter = "counter" // последовательный счётчик в base62
+	StrategyTime    = "time"    // упорядоченные по времени ID
+	StrategyHashids = "hashids" // счётчик, запутанный в стиле Hashids
+)
+
+// DefaultLength длина ID по умолчанию для стратегий random и hashids
+const DefaultLength = 8
+
+// Config настройки генератора ID
+type Config struct {
+	Strategy string   // стратегия генерации; пустая строка - StrategyHash
+	Length   int      // длина ID для random, минимальная длина для hashids; 0 - DefaultLength
+	Salt     string   // соль hashids
+	Sequence Sequence // источник значений счётчика для counter и hashids
+}
+
+// New создаст генератор ID по настройкам
+func New(conf Config) (Generator, error) {
+	length := conf.Length
+	if length == 0 {
+		length = DefaultLength
+	}
+
+	switch conf.Strategy {
+	case "", StrategyHash:
+		return NewHash(sha256.New), nil
+	case StrategyRandom:
+		return NewRandom(length)
+	case StrategyCounter:
+		if conf.Sequence == nil {
+			return nil, fmt.Errorf("idgen: strategy %q requires a sequence", conf.Strategy)
+		}
+		return NewCounter(conf.Sequence), nil
+	case StrategyTime:
+		return NewTimeOrdered(), nil
+	case StrategyHashids:
+		if conf.Sequence == nil {
+			return nil, fmt.Errorf("idgen: strategy %q requires a sequence", conf.Strategy)
+		}
+		return NewHashids(conf.Sequence, conf.Salt, length), nil
+	default:
+		return nil, fmt.Errorf("idgen: unknown strategy %q", conf.Strategy)
+	}
+}
+
+// NeedsSequence сообщает, нужен ли стратегии источник значений счётчика
+func NeedsSequence(strategy string) bool {
+	return strategy == StrategyCounter || strategy == StrategyHashids
+}
@@ -0,0 +1,108 @@
+package idgen
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// requireClean проверяет, что ID состоит только из символов base62
+func requireClean(t *testing.T, id string) {
+	t.Helper()
+	require.NotEmpty(t, id)
+	for _, c := range id {
+		require.True(t, strings.ContainsRune(base62Alphabet, c), "unexpected symbol %q in id %q", c, id)
+	}
+}
+
+func TestNew(t *testing.T) {
+	seq := NewLocalSequence(1)
+	for _, strategy := range []string{"", StrategyHash, StrategyRandom, StrategyCounter, StrategyTime, StrategyHashids} {
+		g, err := New(Config{Strategy: strategy, Sequence: seq})
+		require.NoError(t, err, strategy)
+		_, err = g.Generate(context.Background(), "https://example.com", 0)
+		require.NoError(t, err, strategy)
+	}
+
+	_, err := New(Config{Strategy: "unknown"})
+	require.Error(t, err)
+	_, err = New(Config{Strategy: StrategyCounter})
+	require.Error(t, err)
+	_, err = New(Config{Strategy: StrategyRandom, Length: -1})
+	require.Error(t, err)
+}
+
+func TestHash(t *testing.T) {
+	ctx := context.Background()
+	g := NewHash(sha256.New)
+
+	id, err := g.Generate(ctx, "https://example.com", 0)
+	require.NoError(t, err)
+	legacy, err := repoCommon.GenerateURLUniqueHash(sha256.New(), "https://example.com")
+	require.NoError(t, err)
+	require.Equal(t, legacy, id)
+
+	next, err := g.Generate(ctx, "https://example.com", 1)
+	require.NoError(t, err)
+	require.NotEqual(t, id, next)
+
+	// ID без дополнения и символов '-' и '_'
+	for i := 0; i < 100; i++ {
+		id, err = g.Generate(ctx, fmt.Sprintf("https://example.com/%d", i), i%3)
+		require.NoError(t, err)
+		require.Regexp(t, "^[0-9A-Za-z]+$", id)
+		require.Len(t, id, 7)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	g, err := NewRandom(6)
+	require.NoError(t, err)
+
+	seen := make(map[string]struct{})
+	for i := 0; i < 1000; i++ {
+		id, err := g.Generate(context.Background(), "https://example.com", 0)
+		require.NoError(t, err)
+		require.Len(t, id, 6)
+		requireClean(t, id)
+		seen[id] = struct{}{}
+	}
+	require.Greater(t, len(seen), 990)
+}
+
+func TestCounter(t *testing.T) {
+	g := NewCounter(NewLocalSequence(61))
+
+	ids := make([]string, 0, 3)
+	for i := 0; i < 3; i++ {
+		id, err := g.Generate(context.Background(), "https://example.com", i)
+		require.NoError(t, err)
+		requireClean(t, id)
+		ids = append(ids, id)
+	}
+	require.Equal(t, []string{"z", "10", "11"}, ids)
+}
+
+func TestTimeOrdered(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+	g := &timeGenerator{now: func() time.Time { return now }}
+
+	ids := make([]string, 0, 100)
+	for i := 0; i < 100; i++ {
+		id, err := g.Generate(context.Background(), "https://example.com", 0)
+		require.NoError(t, err)
+		require.Len(t, id, timeIDWidth)
+		requireClean(t, id)
+		ids = append(ids, id)
+		now = now.Add(time.Millisecond)
+	}
+	// ID сортируются в порядке создания
+	require.True(t, sort.StringsAreSorted(ids))
+}
@@ -0,0 +1,43 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+)
+
+// randomGenerator ID - случайная строка base62 заданной длины
+type randomGenerator struct {
+	length int
+}
+
+// NewRandom создаст генератор случайных ID base62 длины length
+func NewRandom(length int) (Generator, error) {
+	if length <= 0 {
+		return nil, errors.New("idgen: random id length must be positive")
+	}
+	return &randomGenerator{length: length}, nil
+}
+
+// Generate реализует Generator
+func (g *randomGenerator) Generate(ctx context.Context, url string, attempt int) (string, error) {
+	id := make([]byte, 0, g.length)
+	buf := make([]byte, g.length)
+	for len(id) < g.length {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			// отбрасываем старшие значения, чтобы символы были равновероятны
+			if b >= 248 {
+				continue
+			}
+			id = append(id, base62Alphabet[b%62])
+			if len(id) == g.length {
+				break
+			}
+		}
+	}
+
+	return string(id), nil
+}
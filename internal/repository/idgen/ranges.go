@@ -0,0 +1,72 @@
+package idgen
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// DefaultRangeSize количество значений счётчика, арендуемых за раз
+const DefaultRangeSize = 100
+
+// RangeStore общий для экземпляров приложения источник диапазонов значений счётчика
+type RangeStore interface {
+	// LeaseIDRange выдаст свободный диапазон [start, end) не длиннее size
+	LeaseIDRange(ctx context.Context, size uint64) (start uint64, end uint64, err error)
+	// ReleaseIDRange вернёт неиспользованный диапазон [start, end) для повторной выдачи
+	ReleaseIDRange(ctx context.Context, start uint64, end uint64) error
+}
+
+// RangeSequence счётчик, который арендует диапазоны значений у RangeStore и выдаёт их локально,
+// поэтому экземпляры приложения с общим хранилищем не выдают одинаковых значений
+type RangeSequence struct {
+	store RangeStore
+	size  uint64
+
+	mu sync.Mutex
+	// текущий диапазон [next, end)
+	next uint64
+	end  uint64
+}
+
+// NewRangeSequence создаст счётчик, арендующий по size значений; 0 - DefaultRangeSize
+func NewRangeSequence(store RangeStore, size uint64) *RangeSequence {
+	if size == 0 {
+		size = DefaultRangeSize
+	}
+	return &RangeSequence{store: store, size: size}
+}
+
+// Next реализует Sequence; новый диапазон арендуется, когда текущий исчерпан
+func (s *RangeSequence) Next(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == s.end {
+		start, end, err := s.store.LeaseIDRange(ctx, s.size)
+		if err != nil {
+			return 0, err
+		}
+		if start >= end {
+			return 0, errors.New("idgen: leased empty id range")
+		}
+		s.next, s.end = start, end
+	}
+
+	v := s.next
+	s.next++
+	return v, nil
+}
+
+// Close вернёт неиспользованную часть текущего диапазона; после Close счётчик арендует новый диапазон
+func (s *RangeSequence) Close(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.next == s.end {
+		return nil
+	}
+	err := s.store.ReleaseIDRange(ctx, s.next, s.end)
+	s.next, s.end = 0, 0
+	return err
+}
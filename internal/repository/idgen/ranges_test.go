@@ -0,0 +1,97 @@
+package idgen
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangeStoreMock хранилище диапазонов в памяти
+type rangeStoreMock struct {
+	mu       sync.Mutex
+	next     uint64
+	released [][2]uint64
+	leases   int
+}
+
+func (m *rangeStoreMock) LeaseIDRange(ctx context.Context, size uint64) (uint64, uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.leases++
+	if len(m.released) > 0 {
+		r := m.released[0]
+		m.released = m.released[1:]
+		return r[0], r[1], nil
+	}
+	start := m.next
+	m.next += size
+	return start, m.next, nil
+}
+
+func (m *rangeStoreMock) ReleaseIDRange(ctx context.Context, start uint64, end uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.released = append(m.released, [2]uint64{start, end})
+	return nil
+}
+
+func TestRangeSequence(t *testing.T) {
+	ctx := context.Background()
+	store := &rangeStoreMock{next: 1}
+	seq := NewRangeSequence(store, 10)
+
+	for want := uint64(1); want <= 15; want++ {
+		v, err := seq.Next(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, v)
+	}
+	// аренда идёт блоками, а не на каждое значение
+	require.Equal(t, 2, store.leases)
+
+	// неиспользованный остаток возвращается и достаётся следующему счётчику
+	require.NoError(t, seq.Close(ctx))
+	require.Equal(t, [][2]uint64{{16, 21}}, store.released)
+	require.NoError(t, seq.Close(ctx))
+
+	other := NewRangeSequence(store, 10)
+	v, err := other.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(16), v)
+}
+
+func TestRangeSequence_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	store := &rangeStoreMock{next: 1}
+	sequences := []*RangeSequence{NewRangeSequence(store, 7), NewRangeSequence(store, 7)}
+
+	var mu sync.Mutex
+	values := make([]uint64, 0, 4000)
+	wg := sync.WaitGroup{}
+	for _, seq := range sequences {
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(seq *RangeSequence) {
+				defer wg.Done()
+				for j := 0; j < 500; j++ {
+					v, err := seq.Next(ctx)
+					assert.NoError(t, err)
+					mu.Lock()
+					values = append(values, v)
+					mu.Unlock()
+				}
+			}(seq)
+		}
+	}
+	wg.Wait()
+
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for i := 1; i < len(values); i++ {
+		require.NotEqual(t, values[i-1], values[i], "value %d was issued twice", values[i])
+	}
+}
@@ -0,0 +1,42 @@
+package idgen
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+)
+
+// timeEpoch начало отсчёта упорядоченных по времени ID
+var timeEpoch = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Разрядность упорядоченного по времени ID: миллисекунды с timeEpoch и случайная часть
+const (
+	timeBits   = 42
+	randomBits = 20
+	// timeIDWidth длина ID; 62 бита помещаются в 11 символов base62
+	timeIDWidth = 11
+)
+
+// timeGenerator ID начинается с времени создания, поэтому ID сортируются в порядке создания
+type timeGenerator struct {
+	now func() time.Time
+}
+
+// NewTimeOrdered создаст генератор упорядоченных по времени ID
+func NewTimeOrdered() Generator {
+	return &timeGenerator{now: time.Now}
+}
+
+// Generate реализует Generator
+func (g *timeGenerator) Generate(ctx context.Context, url string, attempt int) (string, error) {
+	ms := uint64(g.now().Sub(timeEpoch).Milliseconds()) & (1<<timeBits - 1)
+
+	var buf [4]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	random := uint64(binary.BigEndian.Uint32(buf[:])) & (1<<randomBits - 1)
+
+	return encodeBase62Fixed(ms<<randomBits|random, timeIDWidth), nil
+}
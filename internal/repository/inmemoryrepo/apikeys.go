@@ -0,0 +1,95 @@
+package inmemoryrepo
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// SaveAPIKey сохранит API-ключ
+func (s *InMemoryRepo) SaveAPIKey(ctx context.Context, key modelAuth.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.apiKeys[key.ID] = cloneAPIKey(key)
+
+	return nil
+}
+
+// GetAPIKey вернёт API-ключ по его ID
+func (s *InMemoryRepo) GetAPIKey(ctx context.Context, id string) (*modelAuth.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	key = cloneAPIKey(key)
+
+	return &key, nil
+}
+
+// GetUserAPIKeys вернёт API-ключи пользователя в порядке создания
+func (s *InMemoryRepo) GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]modelAuth.APIKey, 0)
+	for _, key := range s.apiKeys {
+		if key.UserID == userID {
+			keys = append(keys, cloneAPIKey(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// DeleteAPIKey удалит API-ключ пользователя
+func (s *InMemoryRepo) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok || key.UserID != userID {
+		return repoCommon.ErrNotFoundKey
+	}
+	delete(s.apiKeys, id)
+
+	return nil
+}
+
+// TouchAPIKey запишет время последнего использования API-ключа
+func (s *InMemoryRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, ok := s.apiKeys[id]
+	if !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	key.LastUsedAt = &usedAt
+	s.apiKeys[id] = key
+
+	return nil
+}
+
+// cloneAPIKey скопирует API-ключ, чтобы изменения копии не затрагивали хранилище
+func cloneAPIKey(key modelAuth.APIKey) modelAuth.APIKey {
+	key.Scopes = slices.Clone(key.Scopes)
+	if key.LastUsedAt != nil {
+		usedAt := *key.LastUsedAt
+		key.LastUsedAt = &usedAt
+	}
+	return key
+}
@@ -0,0 +1,76 @@
+package inmemoryrepo
+
+import (
+	"context"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// SaveRefreshToken сохранит токен обновления
+func (s *InMemoryRepo) SaveRefreshToken(ctx context.Context, token modelAuth.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshTokens[token.Hash] = token
+
+	// истёкшие токены удаляются, когда их количество удваивается
+	if len(s.refreshTokens) > 2*s.refreshTokensSwept {
+		now := time.Now()
+		for hash, t := range s.refreshTokens {
+			if t.ExpiresAt.Before(now) {
+				delete(s.refreshTokens, hash)
+			}
+		}
+		s.refreshTokensSwept = len(s.refreshTokens)
+	}
+
+	return nil
+}
+
+// ConsumeRefreshToken удалит токен обновления и вернёт его; токен можно использовать только один раз
+func (s *InMemoryRepo) ConsumeRefreshToken(ctx context.Context, hash string) (*modelAuth.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.refreshTokens[hash]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	delete(s.refreshTokens, hash)
+
+	return &token, nil
+}
+
+// CreateAccount сохранит учётную запись пользователя
+func (s *InMemoryRepo) CreateAccount(ctx context.Context, account modelAuth.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.accounts[account.UserID]; ok {
+		return repoCommon.ErrAccountExists
+	}
+	if _, ok := s.accountsByEmail[account.Email]; ok {
+		return repoCommon.ErrAccountExists
+	}
+
+	s.accounts[account.UserID] = account
+	s.accountsByEmail[account.Email] = account.UserID
+
+	return nil
+}
+
+// GetAccountByEmail вернёт учётную запись по адресу почты
+func (s *InMemoryRepo) GetAccountByEmail(ctx context.Context, email string) (*modelAuth.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userID, ok := s.accountsByEmail[email]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	account := s.accounts[userID]
+
+	return &account, nil
+}
@@ -0,0 +1,475 @@
+package inmemoryrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// GetUsersPage вернёт не больше limit пользователей, следующих за afterID,
+// в порядке возрастания ID
+func (s *InMemoryRepo) GetUsersPage(ctx context.Context, afterID string, limit int) ([]snapshot.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshotUsersPage(afterID, limit), nil
+}
+
+// GetURLsPage вернёт не больше limit URL'ов, следующих за afterID,
+// в порядке возрастания ID
+func (s *InMemoryRepo) GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.urlsPage(afterID, limit), nil
+}
+
+// GetAccountsPage вернёт не больше limit учётных записей, следующих за учётной записью
+// пользователя afterUserID, в порядке возрастания ID пользователя
+func (s *InMemoryRepo) GetAccountsPage(ctx context.Context, afterUserID string, limit int) ([]snapshot.Account, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.accountsPage(afterUserID, limit), nil
+}
+
+// GetIdentitiesPage вернёт не больше limit удостоверений, следующих за afterKey,
+// в порядке возрастания snapshot.Identity.Key
+func (s *InMemoryRepo) GetIdentitiesPage(ctx context.Context, afterKey string, limit int) ([]snapshot.Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.identitiesPage(afterKey, limit), nil
+}
+
+// GetAPIKeysPage вернёт не больше limit API-ключей, следующих за afterID,
+// в порядке возрастания ID
+func (s *InMemoryRepo) GetAPIKeysPage(ctx context.Context, afterID string, limit int) ([]snapshot.APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.apiKeysPage(afterID, limit), nil
+}
+
+// GetWorkspacesPage вернёт не больше limit рабочих пространств, следующих за afterID,
+// в порядке возрастания ID
+func (s *InMemoryRepo) GetWorkspacesPage(ctx context.Context, afterID string, limit int) ([]snapshot.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.workspacesPage(afterID, limit), nil
+}
+
+// Export выгрузит согласованный снимок всех данных в порядке возрастания ID
+func (s *InMemoryRepo) Export(ctx context.Context, w snapshot.Writer) error {
+	// копируем данные под блокировкой, чтобы не задерживать изменения на время выгрузки
+	s.mu.RLock()
+	users := s.snapshotUsersPage("", 0)
+	urls := s.urlsPage("", 0)
+	accounts := s.accountsPage("", 0)
+	identities := s.identitiesPage("", 0)
+	apiKeys := s.apiKeysPage("", 0)
+	workspaces := s.workspacesPage("", 0)
+	s.mu.RUnlock()
+
+	for _, u := range users {
+		if err := w.WriteUser(u); err != nil {
+			return err
+		}
+	}
+	for _, u := range urls {
+		if err := w.WriteURL(u); err != nil {
+			return err
+		}
+	}
+	for _, a := range accounts {
+		if err := w.WriteAccount(a); err != nil {
+			return err
+		}
+	}
+	for _, i := range identities {
+		if err := w.WriteIdentity(i); err != nil {
+			return err
+		}
+	}
+	for _, k := range apiKeys {
+		if err := w.WriteAPIKey(k); err != nil {
+			return err
+		}
+	}
+	for _, ws := range workspaces {
+		if err := w.WriteWorkspace(ws); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *InMemoryRepo) usersPage(afterID string, limit int) []string {
+	ids := make([]string, 0, len(s.users))
+	for id := range s.users {
+		ids = append(ids, id)
+	}
+
+	return pageOf(ids, afterID, limit)
+}
+
+func (s *InMemoryRepo) snapshotUsersPage(afterID string, limit int) []snapshot.User {
+	page := s.usersPage(afterID, limit)
+	response := make([]snapshot.User, 0, len(page))
+	for _, id := range page {
+		user := s.user(id)
+		response = append(response, snapshot.User{ID: id, Role: user.Role, Disabled: user.Disabled})
+	}
+
+	return response
+}
+
+func (s *InMemoryRepo) urlsPage(afterID string, limit int) []snapshot.URL {
+	ids := make([]string, 0, len(s.storage))
+	for id := range s.storage {
+		ids = append(ids, id)
+	}
+
+	page := pageOf(ids, afterID, limit)
+	response := make([]snapshot.URL, 0, len(page))
+	for _, id := range page {
+		data := s.storage[id]
+		userIDs := make([]string, 0, len(data.users))
+		for userID := range data.users {
+			userIDs = append(userIDs, userID)
+		}
+		sort.Strings(userIDs)
+
+		response = append(response, snapshot.URL{
+			ID:          id,
+			OriginalURL: data.url,
+			Deleted:     data.deleted,
+			UserIDs:     userIDs,
+		})
+	}
+
+	return response
+}
+
+func (s *InMemoryRepo) accountsPage(afterUserID string, limit int) []snapshot.Account {
+	ids := make([]string, 0, len(s.accounts))
+	for id := range s.accounts {
+		ids = append(ids, id)
+	}
+
+	page := pageOf(ids, afterUserID, limit)
+	response := make([]snapshot.Account, 0, len(page))
+	for _, id := range page {
+		account := s.accounts[id]
+		response = append(response, snapshot.Account{
+			UserID:       account.UserID,
+			Email:        account.Email,
+			PasswordHash: account.PasswordHash,
+			CreatedAt:    account.CreatedAt,
+		})
+	}
+
+	return response
+}
+
+func (s *InMemoryRepo) identitiesPage(afterKey string, limit int) []snapshot.Identity {
+	byKey := make(map[string]snapshot.Identity, len(s.identities))
+	keys := make([]string, 0, len(s.identities))
+	for _, identity := range s.identities {
+		i := snapshot.Identity{
+			Issuer:    identity.Issuer,
+			Subject:   identity.Subject,
+			UserID:    identity.UserID,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		}
+		byKey[i.Key()] = i
+		keys = append(keys, i.Key())
+	}
+
+	page := pageOf(keys, afterKey, limit)
+	response := make([]snapshot.Identity, 0, len(page))
+	for _, key := range page {
+		response = append(response, byKey[key])
+	}
+
+	return response
+}
+
+func (s *InMemoryRepo) apiKeysPage(afterID string, limit int) []snapshot.APIKey {
+	ids := make([]string, 0, len(s.apiKeys))
+	for id := range s.apiKeys {
+		ids = append(ids, id)
+	}
+
+	page := pageOf(ids, afterID, limit)
+	response := make([]snapshot.APIKey, 0, len(page))
+	for _, id := range page {
+		key := cloneAPIKey(s.apiKeys[id])
+		response = append(response, snapshot.APIKey{
+			ID:         key.ID,
+			UserID:     key.UserID,
+			Hash:       key.Hash,
+			Name:       key.Name,
+			Scopes:     key.Scopes,
+			CreatedAt:  key.CreatedAt,
+			LastUsedAt: key.LastUsedAt,
+		})
+	}
+
+	return response
+}
+
+func (s *InMemoryRepo) workspacesPage(afterID string, limit int) []snapshot.Workspace {
+	ids := make([]string, 0, len(s.workspaces))
+	for id := range s.workspaces {
+		ids = append(ids, id)
+	}
+
+	page := pageOf(ids, afterID, limit)
+	urlIDs := make(map[string][]string, len(page))
+	for _, id := range page {
+		urlIDs[id] = []string{}
+	}
+	for urlID, data := range s.storage {
+		for wsID := range data.workspaces {
+			if ids, ok := urlIDs[wsID]; ok {
+				urlIDs[wsID] = append(ids, urlID)
+			}
+		}
+	}
+
+	response := make([]snapshot.Workspace, 0, len(page))
+	for _, id := range page {
+		ws := s.workspaces[id]
+		members := make([]snapshot.Member, 0, len(s.workspaceMembers[id]))
+		for userID, role := range s.workspaceMembers[id] {
+			members = append(members, snapshot.Member{UserID: userID, Role: role})
+		}
+		sort.Slice(members, func(i, j int) bool {
+			return members[i].UserID < members[j].UserID
+		})
+		sort.Strings(urlIDs[id])
+
+		response = append(response, snapshot.Workspace{
+			ID:        ws.ID,
+			Name:      ws.Name,
+			CreatedAt: ws.CreatedAt,
+			Members:   members,
+			URLIDs:    urlIDs[id],
+		})
+	}
+
+	return response
+}
+
+// ImportUsers сохранит пользователей с заранее известными ID;
+// у существующих пользователей заменит роль и состояние, если они известны
+func (s *InMemoryRepo) ImportUsers(ctx context.Context, users []snapshot.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range users {
+		s.users[u.ID] = struct{}{}
+		if u.Role != "" {
+			s.setUser(modelAuth.User{ID: u.ID, Role: u.Role, Disabled: u.Disabled})
+		}
+	}
+
+	return nil
+}
+
+// ImportURLs сохранит URL'ы с заранее известными ID;
+// у существующих URL'ов дополнит владельцев и флаг удаления.
+// Если хотя бы один URL противоречит сохранённым, ничего не сохраняется
+func (s *InMemoryRepo) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u := range urls {
+		if data, ok := s.storage[u.ID]; ok && data.url != u.OriginalURL {
+			return fmt.Errorf("%w: id %s", repoCommon.ErrImportConflict, u.ID)
+		}
+		if id, ok := s.index[u.OriginalURL]; ok && id != u.ID {
+			return fmt.Errorf("%w: url %s", repoCommon.ErrImportConflict, u.OriginalURL)
+		}
+	}
+
+	for _, u := range urls {
+		data, ok := s.storage[u.ID]
+		if !ok {
+			s.addURL(nil, u.ID, u.OriginalURL, "")
+			data = s.storage[u.ID]
+		}
+		for _, userID := range u.UserIDs {
+			s.addOwner(nil, data, userID)
+		}
+		data.deleted = data.deleted || u.Deleted
+	}
+
+	return nil
+}
+
+// ImportAccounts сохранит учётные записи вместе с их пользователями;
+// уже сохранённые учётные записи пропускаются.
+// Если почта или пользователь хотя бы одной учётной записи заняты другой, ничего не сохраняется
+func (s *InMemoryRepo) ImportAccounts(ctx context.Context, accounts []snapshot.Account) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, a := range accounts {
+		if existing, ok := s.accounts[a.UserID]; ok && existing.Email != a.Email {
+			return fmt.Errorf("%w: account of user %s", repoCommon.ErrImportConflict, a.UserID)
+		}
+		if userID, ok := s.accountsByEmail[a.Email]; ok && userID != a.UserID {
+			return fmt.Errorf("%w: email %s", repoCommon.ErrImportConflict, a.Email)
+		}
+	}
+
+	for _, a := range accounts {
+		s.addUser(nil, a.UserID)
+		if _, ok := s.accounts[a.UserID]; ok {
+			continue
+		}
+		s.accounts[a.UserID] = modelAuth.Account{
+			UserID:       a.UserID,
+			Email:        a.Email,
+			PasswordHash: a.PasswordHash,
+			CreatedAt:    a.CreatedAt,
+		}
+		s.accountsByEmail[a.Email] = a.UserID
+	}
+
+	return nil
+}
+
+// ImportIdentities сохранит удостоверения вместе с их пользователями;
+// уже сохранённые удостоверения пропускаются.
+// Если хотя бы одно удостоверение привязано к другому пользователю, ничего не сохраняется
+func (s *InMemoryRepo) ImportIdentities(ctx context.Context, identities []snapshot.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, i := range identities {
+		key := identityKey{issuer: i.Issuer, subject: i.Subject}
+		if existing, ok := s.identities[key]; ok && existing.UserID != i.UserID {
+			return fmt.Errorf("%w: identity %s", repoCommon.ErrImportConflict, i.Key())
+		}
+	}
+
+	for _, i := range identities {
+		s.addUser(nil, i.UserID)
+		key := identityKey{issuer: i.Issuer, subject: i.Subject}
+		if _, ok := s.identities[key]; ok {
+			continue
+		}
+		s.identities[key] = modelAuth.Identity{
+			Issuer:    i.Issuer,
+			Subject:   i.Subject,
+			UserID:    i.UserID,
+			Email:     i.Email,
+			CreatedAt: i.CreatedAt,
+		}
+	}
+
+	return nil
+}
+
+// ImportAPIKeys сохранит API-ключи вместе с их пользователями;
+// уже сохранённые ключи пропускаются.
+// Если хотя бы один ключ принадлежит другому пользователю, ничего не сохраняется
+func (s *InMemoryRepo) ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range keys {
+		if existing, ok := s.apiKeys[k.ID]; ok && existing.UserID != k.UserID {
+			return fmt.Errorf("%w: api key %s", repoCommon.ErrImportConflict, k.ID)
+		}
+	}
+
+	for _, k := range keys {
+		s.addUser(nil, k.UserID)
+		if _, ok := s.apiKeys[k.ID]; ok {
+			continue
+		}
+		s.apiKeys[k.ID] = cloneAPIKey(modelAuth.APIKey{
+			ID:         k.ID,
+			UserID:     k.UserID,
+			Hash:       k.Hash,
+			Name:       k.Name,
+			Scopes:     k.Scopes,
+			CreatedAt:  k.CreatedAt,
+			LastUsedAt: k.LastUsedAt,
+		})
+	}
+
+	return nil
+}
+
+// ImportWorkspaces сохранит рабочие пространства; у существующих пространств
+// заменит роли перечисленных участников и дополнит ссылки.
+// Если хотя бы одна ссылка не найдена, ничего не сохраняется
+func (s *InMemoryRepo) ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ws := range workspaces {
+		for _, urlID := range ws.URLIDs {
+			if _, ok := s.storage[urlID]; !ok {
+				return fmt.Errorf("%w: url %s of workspace %s not found", repoCommon.ErrImportConflict, urlID, ws.ID)
+			}
+		}
+	}
+
+	for _, ws := range workspaces {
+		if _, ok := s.workspaces[ws.ID]; !ok {
+			s.workspaces[ws.ID] = modelWorkspace.Workspace{ID: ws.ID, Name: ws.Name, CreatedAt: ws.CreatedAt}
+			s.workspaceMembers[ws.ID] = make(map[string]string, len(ws.Members))
+		}
+		for _, m := range ws.Members {
+			s.addUser(nil, m.UserID)
+			s.workspaceMembers[ws.ID][m.UserID] = m.Role
+		}
+		for _, urlID := range ws.URLIDs {
+			s.storage[urlID].workspaces[ws.ID] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// MarkURLsDeleted пометит URL'ы удалёнными вне зависимости от владельцев
+func (s *InMemoryRepo) MarkURLsDeleted(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if data, ok := s.storage[id]; ok {
+			data.deleted = true
+		}
+	}
+}
+
+// pageOf отсортирует ids и вернёт не больше limit элементов, следующих за afterID
+func pageOf(ids []string, afterID string, limit int) []string {
+	sort.Strings(ids)
+	start := sort.SearchStrings(ids, afterID)
+	if start < len(ids) && ids[start] == afterID {
+		start++
+	}
+
+	end := len(ids)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	return ids[start:end]
+}
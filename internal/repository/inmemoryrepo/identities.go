@@ -0,0 +1,41 @@
+package inmemoryrepo
+
+import (
+	"context"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// identityKey ключ удостоверения
+type identityKey struct {
+	issuer  string
+	subject string
+}
+
+// CreateIdentity привяжет удостоверение издателя к пользователю
+func (s *InMemoryRepo) CreateIdentity(ctx context.Context, identity modelAuth.Identity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := identityKey{issuer: identity.Issuer, subject: identity.Subject}
+	if _, ok := s.identities[key]; ok {
+		return repoCommon.ErrIdentityExists
+	}
+	s.identities[key] = identity
+
+	return nil
+}
+
+// GetIdentity вернёт удостоверение по издателю и ID пользователя у издателя
+func (s *InMemoryRepo) GetIdentity(ctx context.Context, issuer string, subject string) (*modelAuth.Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	identity, ok := s.identities[identityKey{issuer: issuer, subject: subject}]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return &identity, nil
+}
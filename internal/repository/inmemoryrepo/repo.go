@@ -8,83 +8,285 @@ import (
 	"crypto/sha256"
 	"errors"
 	"math/rand"
+	"sync"
 	"time"
 
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
 	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
 	"github.com/google/uuid"
 )
 
 // данные url'а
 type urlDataItem struct {
-	url   string              // оригинальный URL
-	users map[string]struct{} // пользователи, которые когда-либо формировали этот URL;
+	url     string              // оригинальный URL
+	deleted bool                // флаг удаления
+	users   map[string]struct{} // пользователи, которые когда-либо формировали этот URL;
+	// рабочие пространства, которым принадлежит URL
+	workspaces map[string]struct{}
 }
 
 // InMemoryRepo хранилище коротких адресов в памяти
 type InMemoryRepo struct {
+	mu sync.RWMutex
+	// txMu выполняет транзакции по одной
+	txMu sync.Mutex
 	// хранилище адресов и их id'шников; ключ - id, значение - информация об URL'е
-	storage map[string]urlDataItem
-	r       *rand.Rand
+	storage map[string]*urlDataItem
+	// индекс оригинальных URL'ов; ключ - URL, значение - id
+	index map[string]string
+	// зарегистрированные пользователи
+	users map[string]struct{}
+	r     *rand.Rand
+	// генератор ID URL'ов
+	idGen idgen.Generator
+	// токены обновления; ключ - хеш токена
+	refreshTokens map[string]modelAuth.RefreshToken
+	// refreshTokensSwept количество токенов обновления после последнего удаления истёкших
+	refreshTokensSwept int
+	// учётные записи; ключ - ID пользователя
+	accounts map[string]modelAuth.Account
+	// индекс учётных записей; ключ - адрес почты, значение - ID пользователя
+	accountsByEmail map[string]string
+	// API-ключи; ключ - ID API-ключа
+	apiKeys map[string]modelAuth.APIKey
+	// сеансы пользователей; ключ - ID сеанса
+	sessions map[string]modelAuth.Session
+	// удостоверения OpenID Connect; ключ - издатель и ID пользователя у издателя
+	identities map[identityKey]modelAuth.Identity
+	// роли и состояние пользователей, отличные от умолчаний; ключ - ID пользователя
+	userAccess map[string]modelAuth.User
+	// рабочие пространства; ключ - ID пространства
+	workspaces map[string]modelWorkspace.Workspace
+	// роли участников пространств; ключ - ID пространства, затем ID пользователя
+	workspaceMembers map[string]map[string]string
 }
 
 // NewInMemoryRepo инициализирует inmermory хранилище
 func NewInMemoryRepo() *InMemoryRepo {
 	r := rand.New(rand.NewSource(time.Now().UnixMilli()))
-	s := make(map[string]urlDataItem)
 	return &InMemoryRepo{
-		storage: s,
+		storage: make(map[string]*urlDataItem),
+		index:   make(map[string]string),
+		users:   make(map[string]struct{}),
 		r:       r,
+		idGen:   idgen.NewHash(sha256.New),
+
+		refreshTokens: make(map[string]modelAuth.RefreshToken),
+
+		accounts:        make(map[string]modelAuth.Account),
+		accountsByEmail: make(map[string]string),
+		apiKeys:         make(map[string]modelAuth.APIKey),
+		sessions:        make(map[string]modelAuth.Session),
+		identities:      make(map[identityKey]modelAuth.Identity),
+		userAccess:      make(map[string]modelAuth.User),
+
+		workspaces:       make(map[string]modelWorkspace.Workspace),
+		workspaceMembers: make(map[string]map[string]string),
 	}
 }
 
-// UpdateURLsDeletedFlag пометит URL'ы удалёнными
+// TakeDownURL пометит URL удалённым независимо от его владельцев
+func (s *InMemoryRepo) TakeDownURL(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, ok := s.storage[id]
+	if !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	data.deleted = true
+
+	return nil
+}
+
+// SetIDGenerator заменит генератор ID URL'ов
+func (s *InMemoryRepo) SetIDGenerator(g idgen.Generator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.idGen = g
+}
+
+// UpdateURLsDeletedFlag пометит URL'ы пользователя удалёнными
 func (s *InMemoryRepo) UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
-	return errors.New("not implemented")
+	ids := make([]string, 0)
+	for m := range modelsCh {
+		ids = append(ids, m.URLID)
+	}
+
+	s.MarkUserURLsDeleted(ctx, userID, ids)
+	return nil
 }
 
-// SaveURL сохранит url и вернёт его id'шник
-func (s *InMemoryRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
-	h := sha256.New()
-	hash, err := repoCommon.GenerateURLUniqueHash(h, url)
-	if err != nil {
-		return "", err
+// MarkUserURLsDeleted пометит удалёнными URL'ы, принадлежащие пользователю,
+// и вернёт ID фактически помеченных URL'ов
+func (s *InMemoryRepo) MarkUserURLsDeleted(ctx context.Context, userID string, ids []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := make([]string, 0, len(ids))
+	for _, id := range ids {
+		data, ok := s.storage[id]
+		if !ok || data.deleted {
+			continue
+		}
+		if _, ok := data.users[userID]; !ok {
+			continue
+		}
+
+		data.deleted = true
+		marked = append(marked, id)
 	}
+	s.undoLog(ctx).add(func() {
+		for _, id := range marked {
+			if data, ok := s.storage[id]; ok {
+				data.deleted = false
+			}
+		}
+	})
+
+	return marked
+}
+
+// SaveURL сохранит url и вернёт его id'шник;
+// если ID занят другим URL'ом, подбирает следующий
+func (s *InMemoryRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	undo := s.undoLog(ctx)
 	// если уже существует
-	if _, ok := s.storage[hash]; ok {
-		err = repoCommon.NewURLAlreadyExistsError(hash, url)
-		return "", err
+	if id, ok := s.index[url]; ok {
+		s.addOwner(undo, s.storage[id], userID)
+		return id, repoCommon.NewURLAlreadyExistsError(id, url)
 	}
 
-	data := urlDataItem{
-		url:   url,
-		users: map[string]struct{}{},
+	for attempt := 0; attempt < repoCommon.MaxURLIDAttempts; attempt++ {
+		hash, err := s.idGen.Generate(ctx, url, attempt)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := s.storage[hash]; ok {
+			continue
+		}
+
+		s.addURL(undo, hash, url, userID)
+		return hash, nil
+	}
+
+	return "", repoCommon.ErrURLIDCollision
+}
+
+// AddURL сохранит url с заранее известным id'шником;
+// если URL с таким id'шником уже есть, добавит ему владельца
+func (s *InMemoryRepo) AddURL(id string, url string, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if data, ok := s.storage[id]; ok {
+		s.addOwner(nil, data, userID)
+		return
 	}
+
+	s.addURL(nil, id, url, userID)
+}
+
+// AddOwner добавит владельца URL'у с заранее известным id'шником; неизвестный id'шник пропускается
+func (s *InMemoryRepo) AddOwner(id string, userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if data, ok := s.storage[id]; ok {
+		s.addOwner(nil, data, userID)
+	}
+}
+
+// IsOwner проверит, что пользователь уже сокращал URL
+func (s *InMemoryRepo) IsOwner(url string, userID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.index[url]
+	if !ok {
+		return false
+	}
+	_, ok = s.storage[id].users[userID]
+	return ok
+}
+
+// AddUser зарегистрирует пользователя с заранее известным ID
+func (s *InMemoryRepo) AddUser(userID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[userID] = struct{}{}
+}
+
+// addURL сохранит URL; undo - журнал отката транзакции, может быть nil
+func (s *InMemoryRepo) addURL(undo *undoLog, id string, url string, userID string) {
+	data := &urlDataItem{
+		url:        url,
+		users:      map[string]struct{}{},
+		workspaces: map[string]struct{}{},
+	}
+	s.storage[id] = data
+	s.index[url] = id
+	undo.add(func() {
+		delete(s.storage, id)
+		delete(s.index, url)
+	})
+
+	s.addOwner(undo, data, userID)
+}
+
+// addOwner добавит URL'у владельца; undo - журнал отката транзакции, может быть nil
+func (s *InMemoryRepo) addOwner(undo *undoLog, data *urlDataItem, userID string) {
+	if userID == "" {
+		return
+	}
+
 	if _, ok := data.users[userID]; !ok {
-		if userID != "" {
-			data.users[userID] = struct{}{}
-		}
+		data.users[userID] = struct{}{}
+		undo.add(func() { delete(data.users, userID) })
+	}
+	s.addUser(undo, userID)
+}
+
+// addUser зарегистрирует пользователя; undo - журнал отката транзакции, может быть nil
+func (s *InMemoryRepo) addUser(undo *undoLog, userID string) {
+	if _, ok := s.users[userID]; ok {
+		return
 	}
 
-	s.storage[hash] = data
-	return hash, nil
+	s.users[userID] = struct{}{}
+	undo.add(func() { delete(s.users, userID) })
 }
 
 // GetURLByID вернёт URL по ID
 func (s *InMemoryRepo) GetURLByID(ctx context.Context, id string) (string, error) {
-	res, ok := s.storage[id]
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
+	res, ok := s.storage[id]
 	if !ok {
 		return "", repoCommon.ErrNotFoundKey
 	}
+	if res.deleted {
+		return "", repoCommon.ErrURLDeleted
+	}
 
 	return res.url, nil
 }
 
 // GetUserURLs вернёт все URL'ы пользователя
 func (s *InMemoryRepo) GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	response := make([]model.GetUserURLsItemResponse, 0)
 	for urlID, data := range s.storage {
 		if _, ok := data.users[userID]; !ok {
@@ -105,29 +307,30 @@ func (s *InMemoryRepo) Ping(ctx context.Context) error {
 	return nil
 }
 
-// SaveURLsBatch сохранит множество URL'ов пачкой
+// SaveURLsBatch сохранит множество URL'ов пачкой в одной транзакции
 func (s *InMemoryRepo) SaveURLsBatch(ctx context.Context,
 	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
 	response := make([]model.CreateShortenURLBatchItemResponse, 0, len(request))
-	for _, v := range request {
-		hash, err := s.SaveURL(ctx, v.OriginalURL, userID)
-		if err != nil {
-			var errAlreadyExists *repoCommon.URLAlreadyExistsError
-			if errors.As(err, &errAlreadyExists) {
-				response = append(response, model.CreateShortenURLBatchItemResponse{
-					CorrelationID: v.CorrelationID,
-					ShortURL:      errAlreadyExists.ID,
-				})
-
-				continue
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		for _, v := range request {
+			hash, err := s.SaveURL(ctx, v.OriginalURL, userID)
+			if err != nil {
+				var errAlreadyExists *repoCommon.URLAlreadyExistsError
+				if !errors.As(err, &errAlreadyExists) {
+					return err
+				}
+				hash = errAlreadyExists.ID
 			}
-			return nil, err
-		}
 
-		response = append(response, model.CreateShortenURLBatchItemResponse{
-			CorrelationID: v.CorrelationID,
-			ShortURL:      hash,
-		})
+			response = append(response, model.CreateShortenURLBatchItemResponse{
+				CorrelationID: v.CorrelationID,
+				ShortURL:      hash,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return response, nil
@@ -135,8 +338,13 @@ func (s *InMemoryRepo) SaveURLsBatch(ctx context.Context,
 
 // GetNewUserID вернёт новый уникальны ID
 func (s *InMemoryRepo) GetNewUserID(ctx context.Context) (string, error) {
-	id := uuid.New()
-	return id.String(), nil
+	id := uuid.New().String()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.addUser(s.undoLog(ctx), id)
+	return id, nil
 }
 
 // Close релизует Closer
@@ -146,25 +354,34 @@ func (s *InMemoryRepo) Close() error {
 
 // Clear удалит все данные из хранилища
 func (s *InMemoryRepo) Clear() error {
-	for k := range s.storage {
-		delete(s.storage, k)
-	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.storage = make(map[string]*urlDataItem)
+	s.index = make(map[string]string)
+	s.users = make(map[string]struct{})
+	s.refreshTokens = make(map[string]modelAuth.RefreshToken)
+	s.refreshTokensSwept = 0
+	s.accounts = make(map[string]modelAuth.Account)
+	s.accountsByEmail = make(map[string]string)
+	s.apiKeys = make(map[string]modelAuth.APIKey)
+	s.sessions = make(map[string]modelAuth.Session)
+	s.identities = make(map[identityKey]modelAuth.Identity)
+	s.userAccess = make(map[string]modelAuth.User)
+	s.workspaces = make(map[string]modelWorkspace.Workspace)
+	s.workspaceMembers = make(map[string]map[string]string)
 
 	return nil
 }
 
 // GetStats возвращает статистику
 func (s *InMemoryRepo) GetStats(ctx context.Context) (*modelStats.StatsResponse, error) {
-	users := make(map[string]struct{})
-	for _, v := range s.storage {
-		for k := range v.users {
-			users[k] = struct{}{}
-		}
-	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
 	response := new(modelStats.StatsResponse)
 	response.URLs = len(s.storage)
-	response.Users = len(users)
+	response.Users = len(s.users)
 
 	return response, nil
 }
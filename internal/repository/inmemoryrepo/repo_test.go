@@ -0,0 +1,69 @@
+package inmemoryrepo
+
+import (
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/conformance"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance проверяет хранилище общим набором сценариев
+func TestConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) conformance.Repo {
+		return NewInMemoryRepo()
+	})
+}
+
+// TestSnapshotConformance проверяет выгрузку и загрузку данных
+func TestSnapshotConformance(t *testing.T) {
+	conformance.RunSnapshot(t, func(t *testing.T) conformance.SnapshotRepo {
+		return NewInMemoryRepo()
+	})
+}
+
+// TestTxConformance проверяет транзакции
+func TestTxConformance(t *testing.T) {
+	conformance.RunTx(t, func(t *testing.T) conformance.TxRepo {
+		return NewInMemoryRepo()
+	})
+}
+
+// TestAuthConformance проверяет хранение данных аутентификации
+func TestAuthConformance(t *testing.T) {
+	conformance.RunAuth(t, func(t *testing.T) conformance.AuthRepo {
+		return NewInMemoryRepo()
+	})
+}
+
+// TestWorkspaceConformance проверяет хранение рабочих пространств
+func TestWorkspaceConformance(t *testing.T) {
+	conformance.RunWorkspaces(t, func(t *testing.T) conformance.WorkspaceRepo {
+		return NewInMemoryRepo()
+	})
+}
+
+// TestCollisionConformance проверяет подбор ID при коллизиях хешей
+func TestCollisionConformance(t *testing.T) {
+	conformance.RunCollisions(t, func(t *testing.T) conformance.CollisionRepo {
+		return NewInMemoryRepo()
+	})
+}
+
+// TestConformance_IDGenerators проверяет хранилище с каждой стратегией генерации ID
+func TestConformance_IDGenerators(t *testing.T) {
+	strategies := []string{idgen.StrategyRandom, idgen.StrategyCounter, idgen.StrategyTime, idgen.StrategyHashids}
+	for _, strategy := range strategies {
+		strategy := strategy
+		t.Run(strategy, func(t *testing.T) {
+			conformance.Run(t, func(t *testing.T) conformance.Repo {
+				g, err := idgen.New(idgen.Config{Strategy: strategy, Sequence: idgen.NewLocalSequence(1)})
+				require.NoError(t, err)
+
+				repo := NewInMemoryRepo()
+				repo.SetIDGenerator(g)
+				return repo
+			})
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package inmemoryrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// CreateSession сохранит новый сеанс пользователя
+func (s *InMemoryRepo) CreateSession(ctx context.Context, session modelAuth.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = cloneSession(session)
+
+	return nil
+}
+
+// GetSession вернёт сеанс по его ID
+func (s *InMemoryRepo) GetSession(ctx context.Context, id string) (*modelAuth.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	session = cloneSession(session)
+
+	return &session, nil
+}
+
+// GetUserSessions вернёт сеансы пользователя, в том числе отозванные, в порядке начала
+func (s *InMemoryRepo) GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := make([]modelAuth.Session, 0)
+	for _, session := range s.sessions {
+		if session.UserID == userID {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID < sessions[j].ID
+		}
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+// TouchSession запишет время последнего запроса в сеансе
+func (s *InMemoryRepo) TouchSession(ctx context.Context, id string, seenAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	session.LastSeenAt = seenAt
+	s.sessions[id] = session
+
+	return nil
+}
+
+// RevokeSession отзовёт сеанс пользователя; время отзыва уже отозванного сеанса не меняется
+func (s *InMemoryRepo) RevokeSession(ctx context.Context, userID string, id string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.UserID != userID {
+		return repoCommon.ErrNotFoundKey
+	}
+	if session.RevokedAt == nil {
+		session.RevokedAt = &revokedAt
+		s.sessions[id] = session
+	}
+
+	return nil
+}
+
+// RevokeUserSessions отзовёт все действующие сеансы пользователя
+func (s *InMemoryRepo) RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = &revokedAt
+			s.sessions[id] = session
+		}
+	}
+
+	return nil
+}
+
+// cloneSession скопирует сеанс, чтобы изменения копии не затрагивали хранилище
+func cloneSession(session modelAuth.Session) modelAuth.Session {
+	if session.RevokedAt != nil {
+		revokedAt := *session.RevokedAt
+		session.RevokedAt = &revokedAt
+	}
+	return session
+}
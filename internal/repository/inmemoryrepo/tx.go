@@ -0,0 +1,65 @@
+package inmemoryrepo
+
+import "context"
+
+// Транзакции эмулируются журналом отката: изменения применяются сразу
+// и видны остальным вызовам, а при ошибке отменяются в обратном порядке.
+// Транзакции выполняются строго по одной.
+
+// txKey ключ контекста с журналом отката транзакции хранилища repo
+type txKey struct {
+	repo *InMemoryRepo
+}
+
+// undoLog журнал отката транзакции
+type undoLog struct {
+	ops []func()
+}
+
+// add запомнит операцию отката; вне транзакции ничего не делает
+func (l *undoLog) add(op func()) {
+	if l == nil {
+		return
+	}
+	l.ops = append(l.ops, op)
+}
+
+// WithinTx выполнит fn в транзакции: если fn вернула ошибку или запаниковала,
+// изменения, сделанные с переданным в fn контекстом, будут отменены.
+// Вызов внутри уже начатой транзакции присоединяется к ней
+func (s *InMemoryRepo) WithinTx(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if s.undoLog(ctx) != nil {
+		return fn(ctx)
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	log := &undoLog{}
+	defer func() {
+		if p := recover(); p != nil {
+			s.rollback(log)
+			panic(p)
+		}
+		if err != nil {
+			s.rollback(log)
+		}
+	}()
+
+	return fn(context.WithValue(ctx, txKey{repo: s}, log))
+}
+
+// undoLog вернёт журнал отката текущей транзакции или nil
+func (s *InMemoryRepo) undoLog(ctx context.Context) *undoLog {
+	log, _ := ctx.Value(txKey{repo: s}).(*undoLog)
+	return log
+}
+
+func (s *InMemoryRepo) rollback(log *undoLog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(log.ops) - 1; i >= 0; i-- {
+		log.ops[i]()
+	}
+}
@@ -0,0 +1,65 @@
+package inmemoryrepo
+
+import (
+	"context"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// GetUser вернёт роль и состояние пользователя
+func (s *InMemoryRepo) GetUser(ctx context.Context, userID string) (*modelAuth.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.users[userID]; !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	user := s.user(userID)
+
+	return &user, nil
+}
+
+// ListUsers вернёт не больше limit пользователей, следующих за afterID, в порядке возрастания ID
+func (s *InMemoryRepo) ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := s.usersPage(afterID, limit)
+	users := make([]modelAuth.User, 0, len(ids))
+	for _, id := range ids {
+		users = append(users, s.user(id))
+	}
+
+	return users, nil
+}
+
+// UpdateUser сохранит роль и состояние пользователя
+func (s *InMemoryRepo) UpdateUser(ctx context.Context, user modelAuth.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.ID]; !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	s.setUser(user)
+
+	return nil
+}
+
+// setUser сохранит роль и состояние пользователя; умолчания не хранятся
+func (s *InMemoryRepo) setUser(user modelAuth.User) {
+	if user.Role == modelAuth.RoleUser && !user.Disabled {
+		delete(s.userAccess, user.ID)
+		return
+	}
+	s.userAccess[user.ID] = user
+}
+
+// user вернёт роль и состояние существующего пользователя
+func (s *InMemoryRepo) user(userID string) modelAuth.User {
+	if user, ok := s.userAccess[userID]; ok {
+		return user
+	}
+	return modelAuth.User{ID: userID, Role: modelAuth.RoleUser}
+}
@@ -0,0 +1,222 @@
+package inmemoryrepo
+
+import (
+	"context"
+	"sort"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// CreateWorkspace создаст рабочее пространство вместе с его владельцем
+func (s *InMemoryRepo) CreateWorkspace(ctx context.Context, ws modelWorkspace.Workspace, owner modelWorkspace.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.workspaces[ws.ID] = ws
+	s.workspaceMembers[ws.ID] = map[string]string{owner.UserID: owner.Role}
+
+	return nil
+}
+
+// GetWorkspace вернёт рабочее пространство по его ID
+func (s *InMemoryRepo) GetWorkspace(ctx context.Context, id string) (*modelWorkspace.Workspace, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ws, ok := s.workspaces[id]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return &ws, nil
+}
+
+// GetUserWorkspaces вернёт пространства пользователя в порядке их создания
+func (s *InMemoryRepo) GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	memberships := make([]modelWorkspace.Membership, 0)
+	for id, members := range s.workspaceMembers {
+		if role, ok := members[userID]; ok {
+			memberships = append(memberships, modelWorkspace.Membership{Workspace: s.workspaces[id], Role: role})
+		}
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i].Workspace, memberships[j].Workspace
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID < b.ID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return memberships, nil
+}
+
+// GetWorkspaceMember вернёт участника пространства
+func (s *InMemoryRepo) GetWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*modelWorkspace.Member, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	role, ok := s.workspaceMembers[workspaceID][userID]
+	if !ok {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return &modelWorkspace.Member{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+// GetWorkspaceMembers вернёт участников пространства в порядке возрастания ID пользователей
+func (s *InMemoryRepo) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]modelWorkspace.Member, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := make([]modelWorkspace.Member, 0, len(s.workspaceMembers[workspaceID]))
+	for userID, role := range s.workspaceMembers[workspaceID] {
+		members = append(members, modelWorkspace.Member{WorkspaceID: workspaceID, UserID: userID, Role: role})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].UserID < members[j].UserID
+	})
+
+	return members, nil
+}
+
+// GetWorkspaceOwners вернёт ID владельцев пространства в порядке возрастания
+func (s *InMemoryRepo) GetWorkspaceOwners(ctx context.Context, workspaceID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	owners := make([]string, 0)
+	for userID, role := range s.workspaceMembers[workspaceID] {
+		if role == modelWorkspace.RoleOwner {
+			owners = append(owners, userID)
+		}
+	}
+	sort.Strings(owners)
+
+	return owners, nil
+}
+
+// SaveWorkspaceMember добавит участника пространства или сменит его роль
+func (s *InMemoryRepo) SaveWorkspaceMember(ctx context.Context, member modelWorkspace.Member) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members, ok := s.workspaceMembers[member.WorkspaceID]
+	if !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	members[member.UserID] = member.Role
+
+	return nil
+}
+
+// DeleteWorkspaceMember удалит участника пространства
+func (s *InMemoryRepo) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	members := s.workspaceMembers[workspaceID]
+	if _, ok := members[userID]; !ok {
+		return repoCommon.ErrNotFoundKey
+	}
+	delete(members, userID)
+
+	return nil
+}
+
+// AddWorkspaceURLs свяжет сохранённые URL'ы с рабочим пространством; неизвестные ID пропускаются
+func (s *InMemoryRepo) AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error {
+	s.LinkWorkspaceURLs(ctx, workspaceID, ids)
+	return nil
+}
+
+// LinkWorkspaceURLs свяжет сохранённые URL'ы с рабочим пространством
+// и вернёт ID URL'ов, которые не были с ним связаны
+func (s *InMemoryRepo) LinkWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	undo := s.undoLog(ctx)
+	linked := make([]string, 0, len(ids))
+	for _, id := range ids {
+		data, ok := s.storage[id]
+		if !ok {
+			continue
+		}
+		if _, ok := data.workspaces[workspaceID]; ok {
+			continue
+		}
+
+		data.workspaces[workspaceID] = struct{}{}
+		undo.add(func() { delete(data.workspaces, workspaceID) })
+		linked = append(linked, id)
+	}
+
+	return linked
+}
+
+// GetWorkspaceURLs вернёт все URL'ы рабочего пространства
+func (s *InMemoryRepo) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	response := make([]model.GetUserURLsItemResponse, 0)
+	for urlID, data := range s.storage {
+		if _, ok := data.workspaces[workspaceID]; !ok {
+			continue
+		}
+
+		response = append(response, model.GetUserURLsItemResponse{
+			OriginalURL: data.url,
+			ShortURL:    urlID,
+		})
+	}
+
+	return response, nil
+}
+
+// UpdateWorkspaceURLsDeletedFlag пометит URL'ы рабочего пространства удалёнными
+func (s *InMemoryRepo) UpdateWorkspaceURLsDeletedFlag(ctx context.Context,
+	workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	ids := make([]string, 0)
+	for m := range modelsCh {
+		ids = append(ids, m.URLID)
+	}
+
+	s.MarkWorkspaceURLsDeleted(ctx, workspaceID, ids)
+	return nil
+}
+
+// MarkWorkspaceURLsDeleted пометит удалёнными URL'ы, принадлежащие рабочему пространству,
+// и вернёт ID фактически помеченных URL'ов
+func (s *InMemoryRepo) MarkWorkspaceURLsDeleted(ctx context.Context, workspaceID string, ids []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := make([]string, 0, len(ids))
+	for _, id := range ids {
+		data, ok := s.storage[id]
+		if !ok || data.deleted {
+			continue
+		}
+		if _, ok := data.workspaces[workspaceID]; !ok {
+			continue
+		}
+
+		data.deleted = true
+		marked = append(marked, id)
+	}
+	s.undoLog(ctx).add(func() {
+		for _, id := range marked {
+			if data, ok := s.storage[id]; ok {
+				data.deleted = false
+			}
+		}
+	})
+
+	return marked
+}
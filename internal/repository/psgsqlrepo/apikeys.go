@@ -0,0 +1,91 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// SaveAPIKey сохранит API-ключ
+func (s *psgsqlRepo) SaveAPIKey(ctx context.Context, key modelAuth.APIKey) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO api_keys (id, user_id, key_hash, name, scopes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, key.ID, key.UserID, key.Hash, key.Name, key.Scopes, key.CreatedAt)
+
+	return err
+}
+
+// GetAPIKey вернёт API-ключ по его ID
+func (s *psgsqlRepo) GetAPIKey(ctx context.Context, id string) (*modelAuth.APIKey, error) {
+	key := &modelAuth.APIKey{ID: id}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT user_id, key_hash, name, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE id = $1
+	`, id).Scan(&key.UserID, &key.Hash, &key.Name, &key.Scopes, &key.CreatedAt, &key.LastUsedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// GetUserAPIKeys вернёт API-ключи пользователя в порядке создания
+func (s *psgsqlRepo) GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT id, key_hash, name, scopes, created_at, last_used_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]modelAuth.APIKey, 0)
+	for rows.Next() {
+		key := modelAuth.APIKey{UserID: userID}
+		err = rows.Scan(&key.ID, &key.Hash, &key.Name, &key.Scopes, &key.CreatedAt, &key.LastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// DeleteAPIKey удалит API-ключ пользователя
+func (s *psgsqlRepo) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	tag, err := s.querier(ctx).Exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// TouchAPIKey запишет время последнего использования API-ключа
+func (s *psgsqlRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	tag, err := s.querier(ctx).Exec(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, usedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
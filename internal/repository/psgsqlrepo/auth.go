@@ -4,22 +4,88 @@ import (
 	"context"
 	"errors"
 
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgconn"
 )
 
 // GetNewUserID создаст нового пользователя ивернёт его ID
 func (s *psgsqlRepo) GetNewUserID(ctx context.Context) (string, error) {
 	id := uuid.New()
-	_, err := s.conn.ExecContext(ctx, "INSERT INTO users (id) VALUES($1)", id)
+	_, err := s.querier(ctx).Exec(ctx, "INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING", id.String())
 	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgerrcode.UniqueViolation == pgErr.Code {
-			return id.String(), nil
-		}
-
 		return "", err
 	}
 	return id.String(), nil
 }
+
+// SaveRefreshToken сохранит токен обновления и удалит истёкшие токены пользователя
+func (s *psgsqlRepo) SaveRefreshToken(ctx context.Context, token modelAuth.RefreshToken) error {
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.querier(ctx)
+		_, err := q.Exec(ctx, `DELETE FROM refresh_tokens WHERE user_id = $1 AND expires_at < now()`, token.UserID)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.Exec(ctx, `
+			INSERT INTO refresh_tokens (token_hash, user_id, session_id, expires_at)
+			VALUES ($1, $2, $3, $4)
+		`, token.Hash, token.UserID, token.SessionID, token.ExpiresAt)
+		return err
+	})
+}
+
+// ConsumeRefreshToken удалит токен обновления и вернёт его; токен можно использовать только один раз
+func (s *psgsqlRepo) ConsumeRefreshToken(ctx context.Context, hash string) (*modelAuth.RefreshToken, error) {
+	token := &modelAuth.RefreshToken{Hash: hash}
+	err := s.querier(ctx).QueryRow(ctx, `
+		DELETE FROM refresh_tokens
+		WHERE token_hash = $1
+		RETURNING user_id, session_id, expires_at
+	`, hash).Scan(&token.UserID, &token.SessionID, &token.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// CreateAccount сохранит учётную запись пользователя
+func (s *psgsqlRepo) CreateAccount(ctx context.Context, account modelAuth.Account) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO accounts (user_id, email, password_hash, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, account.UserID, account.Email, account.PasswordHash, account.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return repoCommon.ErrAccountExists
+	}
+
+	return err
+}
+
+// GetAccountByEmail вернёт учётную запись по адресу почты
+func (s *psgsqlRepo) GetAccountByEmail(ctx context.Context, email string) (*modelAuth.Account, error) {
+	account := &modelAuth.Account{Email: email}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT user_id, password_hash, created_at
+		FROM accounts
+		WHERE email = $1
+	`, email).Scan(&account.UserID, &account.PasswordHash, &account.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
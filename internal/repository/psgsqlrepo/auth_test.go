@@ -18,6 +18,7 @@ func (ts *PostgresTestSuite) Test_psgsqlRepo_GetNewUserID() {
 		FROM users
 		WHERE id = $1
 	`
-	err = ts.psgsqlRepo.conn.QueryRowContext(ctx, query, userID).Err()
+	var id string
+	err = ts.psgsqlRepo.pool.QueryRow(ctx, query, userID).Scan(&id)
 	require.NoError(ts.T(), err)
 }
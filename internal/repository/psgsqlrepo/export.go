@@ -0,0 +1,582 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// exportPageSize размер страницы при выгрузке снимка
+const exportPageSize = 1000
+
+// Постраничная выборка идёт в побайтовом порядке ID (COLLATE "C"),
+// чтобы он совпадал с порядком остальных хранилищ.
+
+// GetUsersPage вернёт не больше limit пользователей, следующих за afterID,
+// в порядке возрастания ID
+func (s *psgsqlRepo) GetUsersPage(ctx context.Context, afterID string, limit int) ([]snapshot.User, error) {
+	return getUsersPage(ctx, s.querier(ctx), afterID, limit)
+}
+
+// GetURLsPage вернёт не больше limit URL'ов, следующих за afterID,
+// в порядке возрастания ID
+func (s *psgsqlRepo) GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error) {
+	return getURLsPage(ctx, s.querier(ctx), afterID, limit)
+}
+
+// GetAccountsPage вернёт не больше limit учётных записей, следующих за учётной записью
+// пользователя afterUserID, в порядке возрастания ID пользователя
+func (s *psgsqlRepo) GetAccountsPage(ctx context.Context, afterUserID string, limit int) ([]snapshot.Account, error) {
+	return getAccountsPage(ctx, s.querier(ctx), afterUserID, limit)
+}
+
+// GetIdentitiesPage вернёт не больше limit удостоверений, следующих за afterKey,
+// в порядке возрастания snapshot.Identity.Key
+func (s *psgsqlRepo) GetIdentitiesPage(ctx context.Context, afterKey string, limit int) ([]snapshot.Identity, error) {
+	return getIdentitiesPage(ctx, s.querier(ctx), afterKey, limit)
+}
+
+// GetAPIKeysPage вернёт не больше limit API-ключей, следующих за afterID,
+// в порядке возрастания ID
+func (s *psgsqlRepo) GetAPIKeysPage(ctx context.Context, afterID string, limit int) ([]snapshot.APIKey, error) {
+	return getAPIKeysPage(ctx, s.querier(ctx), afterID, limit)
+}
+
+// GetWorkspacesPage вернёт не больше limit рабочих пространств, следующих за afterID,
+// в порядке возрастания ID
+func (s *psgsqlRepo) GetWorkspacesPage(ctx context.Context, afterID string, limit int) ([]snapshot.Workspace, error) {
+	return getWorkspacesPage(ctx, s.querier(ctx), afterID, limit)
+}
+
+// Export выгрузит согласованный снимок всех данных в порядке возрастания ID;
+// выгрузка идёт в одной транзакции REPEATABLE READ, поэтому не блокирует запись
+func (s *psgsqlRepo) Export(ctx context.Context, w snapshot.Writer) error {
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = exportPages(ctx, tx, getUsersPage, func(u snapshot.User) string { return u.ID }, w.WriteUser)
+	if err != nil {
+		return err
+	}
+	err = exportPages(ctx, tx, getURLsPage, func(u snapshot.URL) string { return u.ID }, w.WriteURL)
+	if err != nil {
+		return err
+	}
+	err = exportPages(ctx, tx, getAccountsPage, func(a snapshot.Account) string { return a.UserID }, w.WriteAccount)
+	if err != nil {
+		return err
+	}
+	err = exportPages(ctx, tx, getIdentitiesPage, snapshot.Identity.Key, w.WriteIdentity)
+	if err != nil {
+		return err
+	}
+	err = exportPages(ctx, tx, getAPIKeysPage, func(k snapshot.APIKey) string { return k.ID }, w.WriteAPIKey)
+	if err != nil {
+		return err
+	}
+	err = exportPages(ctx, tx, getWorkspacesPage, func(ws snapshot.Workspace) string { return ws.ID }, w.WriteWorkspace)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// exportPages выгрузит страницами все записи одного вида
+func exportPages[T any](ctx context.Context, q querier,
+	fetch func(ctx context.Context, q querier, afterID string, limit int) ([]T, error),
+	key func(T) string,
+	write func(T) error) error {
+	afterID := ""
+	for {
+		page, err := fetch(ctx, q, afterID, exportPageSize)
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			return nil
+		}
+		for _, item := range page {
+			if err = write(item); err != nil {
+				return err
+			}
+		}
+		afterID = key(page[len(page)-1])
+	}
+}
+
+func getUsersPage(ctx context.Context, q querier, afterID string, limit int) ([]snapshot.User, error) {
+	type userModel struct {
+		ID       string `db:"id"`
+		Role     string `db:"role"`
+		Disabled bool   `db:"disabled"`
+	}
+	rows, err := q.Query(ctx, `
+	SELECT id, role, disabled FROM users
+	WHERE id COLLATE "C" > $1
+	ORDER BY id COLLATE "C"
+	LIMIT NULLIF($2, 0)
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	models, err := pgx.CollectRows(rows, pgx.RowToStructByName[userModel])
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]snapshot.User, 0, len(models))
+	for _, m := range models {
+		users = append(users, snapshot.User(m))
+	}
+
+	return users, nil
+}
+
+func getURLsPage(ctx context.Context, q querier, afterID string, limit int) ([]snapshot.URL, error) {
+	type urlModel struct {
+		ID        string `db:"id"`
+		URL       string `db:"url"`
+		IsDeleted bool   `db:"deleted_flag"`
+	}
+	rows, err := q.Query(ctx, `
+	SELECT id, url, deleted_flag FROM shorten_url
+	WHERE id COLLATE "C" > $1
+	ORDER BY id COLLATE "C"
+	LIMIT NULLIF($2, 0)
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	models, err := pgx.CollectRows(rows, pgx.RowToStructByName[urlModel])
+	if err != nil {
+		return nil, err
+	}
+	if len(models) == 0 {
+		return []snapshot.URL{}, nil
+	}
+
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	type ownerModel struct {
+		URLID  string `db:"url_id"`
+		UserID string `db:"user_id"`
+	}
+	rows, err = q.Query(ctx, `
+	SELECT url_id, user_id FROM users_shorten_url
+	WHERE url_id = ANY($1)
+	ORDER BY user_id COLLATE "C"
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	owners, err := pgx.CollectRows(rows, pgx.RowToStructByName[ownerModel])
+	if err != nil {
+		return nil, err
+	}
+	usersByURL := make(map[string][]string, len(models))
+	for _, o := range owners {
+		usersByURL[o.URLID] = append(usersByURL[o.URLID], o.UserID)
+	}
+
+	response := make([]snapshot.URL, 0, len(models))
+	for _, m := range models {
+		userIDs := usersByURL[m.ID]
+		if userIDs == nil {
+			userIDs = []string{}
+		}
+
+		response = append(response, snapshot.URL{
+			ID:          m.ID,
+			OriginalURL: m.URL,
+			Deleted:     m.IsDeleted,
+			UserIDs:     userIDs,
+		})
+	}
+
+	return response, nil
+}
+
+func getAccountsPage(ctx context.Context, q querier, afterUserID string, limit int) ([]snapshot.Account, error) {
+	rows, err := q.Query(ctx, `
+	SELECT user_id, email, password_hash, created_at FROM accounts
+	WHERE user_id COLLATE "C" > $1
+	ORDER BY user_id COLLATE "C"
+	LIMIT NULLIF($2, 0)
+	`, afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (snapshot.Account, error) {
+		var a snapshot.Account
+		err := row.Scan(&a.UserID, &a.Email, &a.PasswordHash, &a.CreatedAt)
+		a.CreatedAt = a.CreatedAt.UTC()
+		return a, err
+	})
+}
+
+func getIdentitiesPage(ctx context.Context, q querier, afterKey string, limit int) ([]snapshot.Identity, error) {
+	rows, err := q.Query(ctx, `
+	SELECT issuer, subject, user_id, email, created_at FROM identities
+	WHERE (issuer || '#' || subject) COLLATE "C" > $1
+	ORDER BY (issuer || '#' || subject) COLLATE "C"
+	LIMIT NULLIF($2, 0)
+	`, afterKey, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (snapshot.Identity, error) {
+		var i snapshot.Identity
+		err := row.Scan(&i.Issuer, &i.Subject, &i.UserID, &i.Email, &i.CreatedAt)
+		i.CreatedAt = i.CreatedAt.UTC()
+		return i, err
+	})
+}
+
+func getAPIKeysPage(ctx context.Context, q querier, afterID string, limit int) ([]snapshot.APIKey, error) {
+	rows, err := q.Query(ctx, `
+	SELECT id, user_id, key_hash, name, scopes, created_at, last_used_at FROM api_keys
+	WHERE id COLLATE "C" > $1
+	ORDER BY id COLLATE "C"
+	LIMIT NULLIF($2, 0)
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (snapshot.APIKey, error) {
+		var k snapshot.APIKey
+		err := row.Scan(&k.ID, &k.UserID, &k.Hash, &k.Name, &k.Scopes, &k.CreatedAt, &k.LastUsedAt)
+		k.CreatedAt = k.CreatedAt.UTC()
+		if k.LastUsedAt != nil {
+			usedAt := k.LastUsedAt.UTC()
+			k.LastUsedAt = &usedAt
+		}
+		return k, err
+	})
+}
+
+func getWorkspacesPage(ctx context.Context, q querier, afterID string, limit int) ([]snapshot.Workspace, error) {
+	rows, err := q.Query(ctx, `
+	SELECT id, name, created_at FROM workspaces
+	WHERE id COLLATE "C" > $1
+	ORDER BY id COLLATE "C"
+	LIMIT NULLIF($2, 0)
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	workspaces, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (snapshot.Workspace, error) {
+		ws := snapshot.Workspace{Members: []snapshot.Member{}, URLIDs: []string{}}
+		err := row.Scan(&ws.ID, &ws.Name, &ws.CreatedAt)
+		ws.CreatedAt = ws.CreatedAt.UTC()
+		return ws, err
+	})
+	if err != nil || len(workspaces) == 0 {
+		return workspaces, err
+	}
+
+	ids := make([]string, 0, len(workspaces))
+	byID := make(map[string]*snapshot.Workspace, len(workspaces))
+	for i := range workspaces {
+		ids = append(ids, workspaces[i].ID)
+		byID[workspaces[i].ID] = &workspaces[i]
+	}
+
+	rows, err = q.Query(ctx, `
+	SELECT workspace_id, user_id, role FROM workspace_members
+	WHERE workspace_id = ANY($1)
+	ORDER BY user_id COLLATE "C"
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	var workspaceID string
+	var m snapshot.Member
+	_, err = pgx.ForEachRow(rows, []any{&workspaceID, &m.UserID, &m.Role}, func() error {
+		ws := byID[workspaceID]
+		ws.Members = append(ws.Members, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err = q.Query(ctx, `
+	SELECT workspace_id, url_id FROM workspace_shorten_url
+	WHERE workspace_id = ANY($1)
+	ORDER BY url_id COLLATE "C"
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	var urlID string
+	_, err = pgx.ForEachRow(rows, []any{&workspaceID, &urlID}, func() error {
+		ws := byID[workspaceID]
+		ws.URLIDs = append(ws.URLIDs, urlID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return workspaces, nil
+}
+
+// ImportUsers сохранит пользователей с заранее известными ID;
+// у существующих пользователей заменит роль и состояние, если они известны
+func (s *psgsqlRepo) ImportUsers(ctx context.Context, users []snapshot.User) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, u := range users {
+		if u.Role == "" {
+			_, err = tx.Exec(ctx, `INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING`, u.ID)
+		} else {
+			_, err = tx.Exec(ctx, `
+			INSERT INTO users (id, role, disabled) VALUES($1, $2, $3)
+			ON CONFLICT (id) DO UPDATE SET role = EXCLUDED.role, disabled = EXCLUDED.disabled
+			`, u.ID, u.Role, u.Disabled)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ImportURLs сохранит URL'ы с заранее известными ID;
+// у существующих URL'ов дополнит владельцев и флаг удаления.
+// Если хотя бы один URL противоречит сохранённым, ничего не сохраняется
+func (s *psgsqlRepo) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]string, 0, len(urls))
+	for _, u := range urls {
+		// строка не вернётся, если ID уже занят другим URL'ом
+		var id string
+		err = tx.QueryRow(ctx, `
+		INSERT INTO shorten_url (id, url, deleted_flag) VALUES($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE
+		SET deleted_flag = shorten_url.deleted_flag OR EXCLUDED.deleted_flag
+		WHERE shorten_url.url = EXCLUDED.url
+		RETURNING id
+		`, u.ID, u.OriginalURL, u.Deleted).Scan(&id)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			// URL сохранён под другим ID
+			if errors.As(err, &pgErr) && pgerrcode.UniqueViolation == pgErr.Code {
+				return fmt.Errorf("%w: url %s", repoCommon.ErrImportConflict, u.OriginalURL)
+			}
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w: id %s", repoCommon.ErrImportConflict, u.ID)
+			}
+			return err
+		}
+
+		for _, userID := range u.UserIDs {
+			_, err = tx.Exec(ctx, `INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING`, userID)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `INSERT INTO users_shorten_url (user_id, url_id) VALUES($1, $2)
+			ON CONFLICT DO NOTHING`, userID, u.ID)
+			if err != nil {
+				return err
+			}
+		}
+		ids = append(ids, u.ID)
+	}
+	if len(ids) > 0 {
+		if err = s.notifyChanges(ctx, tx, ChangeOpRestore, "", ids); err != nil {
+			return err
+		}
+	}
+	if err = tx.Commit(ctx); err != nil {
+		return err
+	}
+	s.writes.mark("", ids...)
+
+	return nil
+}
+
+// ImportAccounts сохранит учётные записи вместе с их пользователями;
+// уже сохранённые учётные записи пропускаются.
+// Если почта или пользователь хотя бы одной учётной записи заняты другой, ничего не сохраняется
+func (s *psgsqlRepo) ImportAccounts(ctx context.Context, accounts []snapshot.Account) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, a := range accounts {
+		_, err = tx.Exec(ctx, `INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING`, a.UserID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+		INSERT INTO accounts (user_id, email, password_hash, created_at) VALUES($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+		`, a.UserID, a.Email, a.PasswordHash, a.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		var same bool
+		err = tx.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM accounts WHERE user_id = $1 AND email = $2)`,
+			a.UserID, a.Email).Scan(&same)
+		if err != nil {
+			return err
+		}
+		if !same {
+			return fmt.Errorf("%w: account of user %s", repoCommon.ErrImportConflict, a.UserID)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ImportIdentities сохранит удостоверения вместе с их пользователями;
+// уже сохранённые удостоверения пропускаются.
+// Если хотя бы одно удостоверение привязано к другому пользователю, ничего не сохраняется
+func (s *psgsqlRepo) ImportIdentities(ctx context.Context, identities []snapshot.Identity) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, i := range identities {
+		_, err = tx.Exec(ctx, `INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING`, i.UserID)
+		if err != nil {
+			return err
+		}
+		// строка не вернётся, если удостоверение привязано к другому пользователю
+		var userID string
+		err = tx.QueryRow(ctx, `
+		INSERT INTO identities (issuer, subject, user_id, email, created_at) VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT (issuer, subject) DO UPDATE
+		SET user_id = identities.user_id
+		WHERE identities.user_id = EXCLUDED.user_id
+		RETURNING user_id
+		`, i.Issuer, i.Subject, i.UserID, i.Email, i.CreatedAt).Scan(&userID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: identity %s", repoCommon.ErrImportConflict, i.Key())
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ImportAPIKeys сохранит API-ключи вместе с их пользователями; уже сохранённые ключи пропускаются.
+// Если хотя бы один ключ принадлежит другому пользователю, ничего не сохраняется
+func (s *psgsqlRepo) ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, k := range keys {
+		_, err = tx.Exec(ctx, `INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING`, k.UserID)
+		if err != nil {
+			return err
+		}
+		// строка не вернётся, если ключ принадлежит другому пользователю
+		var id string
+		err = tx.QueryRow(ctx, `
+		INSERT INTO api_keys (id, user_id, key_hash, name, scopes, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, COALESCE($5::text[], '{}'), $6, $7)
+		ON CONFLICT (id) DO UPDATE
+		SET user_id = api_keys.user_id
+		WHERE api_keys.user_id = EXCLUDED.user_id
+		RETURNING id
+		`, k.ID, k.UserID, k.Hash, k.Name, k.Scopes, k.CreatedAt, k.LastUsedAt).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: api key %s", repoCommon.ErrImportConflict, k.ID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ImportWorkspaces сохранит рабочие пространства; у существующих пространств
+// заменит роли перечисленных участников и дополнит ссылки.
+// Если хотя бы одна ссылка не найдена, ничего не сохраняется
+func (s *psgsqlRepo) ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, ws := range workspaces {
+		var found int
+		err = tx.QueryRow(ctx, `SELECT count(*) FROM shorten_url WHERE id = ANY($1)`, ws.URLIDs).Scan(&found)
+		if err != nil {
+			return err
+		}
+		if found != len(ws.URLIDs) {
+			return fmt.Errorf("%w: url of workspace %s not found", repoCommon.ErrImportConflict, ws.ID)
+		}
+
+		_, err = tx.Exec(ctx, `
+		INSERT INTO workspaces (id, name, created_at) VALUES($1, $2, $3)
+		ON CONFLICT DO NOTHING
+		`, ws.ID, ws.Name, ws.CreatedAt)
+		if err != nil {
+			return err
+		}
+		for _, m := range ws.Members {
+			_, err = tx.Exec(ctx, `INSERT INTO users (id) VALUES($1) ON CONFLICT DO NOTHING`, m.UserID)
+			if err != nil {
+				return err
+			}
+			_, err = tx.Exec(ctx, `
+			INSERT INTO workspace_members (workspace_id, user_id, role) VALUES($1, $2, $3)
+			ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			`, ws.ID, m.UserID, m.Role)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(ctx, `
+		INSERT INTO workspace_shorten_url (workspace_id, url_id)
+		SELECT $1, url_id FROM unnest($2::text[]) AS url_id
+		ON CONFLICT DO NOTHING
+		`, ws.ID, ws.URLIDs)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,45 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// CreateIdentity привяжет удостоверение издателя к пользователю
+func (s *psgsqlRepo) CreateIdentity(ctx context.Context, identity modelAuth.Identity) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO identities (issuer, subject, user_id, email, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, identity.Issuer, identity.Subject, identity.UserID, identity.Email, identity.CreatedAt)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return repoCommon.ErrIdentityExists
+	}
+
+	return err
+}
+
+// GetIdentity вернёт удостоверение по издателю и ID пользователя у издателя
+func (s *psgsqlRepo) GetIdentity(ctx context.Context, issuer string, subject string) (*modelAuth.Identity, error) {
+	identity := &modelAuth.Identity{Issuer: issuer, Subject: subject}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT user_id, email, created_at
+		FROM identities
+		WHERE issuer = $1 AND subject = $2
+	`, issuer, subject).Scan(&identity.UserID, &identity.Email, &identity.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
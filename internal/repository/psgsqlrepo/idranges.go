@@ -0,0 +1,90 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// idCounterName имя счётчика ID URL'ов в таблице id_counter
+const idCounterName = "url_id"
+
+// LeaseIDRange выдаст диапазон значений счётчика ID [start, end) не длиннее size.
+// Сначала выдаются диапазоны, возвращённые остановленными экземплярами приложения; от длинного
+// возвращённого диапазона отрезается size значений, а остаток ждёт следующей аренды.
+// Затем счётчик сдвигается на size; при первой аренде он начинается после количества сохранённых URL'ов.
+//
+// Аренда идёт в собственной транзакции и не присоединяется к транзакции из ctx:
+// откат сохранения URL'а не должен возвращать диапазон, который уже выдан экземпляру,
+// а блокировка счётчика не должна держаться до конца чужой транзакции
+func (s *psgsqlRepo) LeaseIDRange(ctx context.Context, size uint64) (start uint64, end uint64, err error) {
+	err = s.withinOwnTx(ctx, func(q pgx.Tx) error {
+		// возвращённый диапазон, не занятый параллельной арендой
+		err := q.QueryRow(ctx, `
+		DELETE FROM id_counter_free_ranges
+		WHERE (name, start_value) = (
+			SELECT name, start_value FROM id_counter_free_ranges
+			WHERE name = $1
+			ORDER BY start_value
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING start_value, end_value`, idCounterName).Scan(&start, &end)
+		if err == nil {
+			if end-start <= size {
+				return nil
+			}
+			rest := start + size
+			_, err = q.Exec(ctx, `
+			INSERT INTO id_counter_free_ranges (name, start_value, end_value)
+			VALUES ($1, $2, $3)`, idCounterName, rest, end)
+			end = rest
+			return err
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+
+		return q.QueryRow(ctx, `
+		INSERT INTO id_counter (name, next_value)
+		VALUES ($1, (SELECT count(*) FROM shorten_url) + 1 + $2)
+		ON CONFLICT (name) DO UPDATE SET next_value = id_counter.next_value + $2
+		RETURNING next_value - $2, next_value`, idCounterName, size).Scan(&start, &end)
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return start, end, nil
+}
+
+// ReleaseIDRange вернёт неиспользованный диапазон [start, end) для повторной выдачи;
+// как и аренда, возврат не присоединяется к транзакции из ctx
+func (s *psgsqlRepo) ReleaseIDRange(ctx context.Context, start uint64, end uint64) error {
+	if start >= end {
+		return nil
+	}
+
+	_, err := s.pool.Exec(ctx, `
+	INSERT INTO id_counter_free_ranges (name, start_value, end_value)
+	VALUES ($1, $2, $3)
+	ON CONFLICT DO NOTHING`, idCounterName, start, end)
+
+	return err
+}
+
+// withinOwnTx выполнит fn в новой транзакции пула, даже если ctx уже несёт транзакцию хранилища
+func (s *psgsqlRepo) withinOwnTx(ctx context.Context, fn func(tx pgx.Tx) error) error {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
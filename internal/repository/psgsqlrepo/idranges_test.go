@@ -0,0 +1,125 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/stretchr/testify/assert"
+)
+
+// Test_psgsqlRepo_LeaseIDRange проверяет аренду и возврат диапазонов
+func (ts *PostgresTestSuite) Test_psgsqlRepo_LeaseIDRange() {
+	ctx := context.Background()
+
+	// счётчик начинается после сохранённых URL'ов
+	userID, err := ts.GetNewUserID(ctx)
+	ts.Require().NoError(err)
+	_, err = ts.SaveURL(ctx, "https://example.com/counter", userID)
+	ts.Require().NoError(err)
+
+	start, end, err := ts.LeaseIDRange(ctx, 10)
+	ts.Require().NoError(err)
+	ts.Equal(uint64(2), start)
+	ts.Equal(uint64(12), end)
+
+	start, end, err = ts.LeaseIDRange(ctx, 10)
+	ts.Require().NoError(err)
+	ts.Equal(uint64(12), start)
+	ts.Equal(uint64(22), end)
+
+	// возвращённый диапазон выдаётся повторно
+	ts.Require().NoError(ts.ReleaseIDRange(ctx, 15, 22))
+	start, end, err = ts.LeaseIDRange(ctx, 10)
+	ts.Require().NoError(err)
+	ts.Equal(uint64(15), start)
+	ts.Equal(uint64(22), end)
+
+	// длинный возвращённый диапазон выдаётся частями не длиннее size
+	start, end, err = ts.LeaseIDRange(ctx, 30)
+	ts.Require().NoError(err)
+	ts.Equal(uint64(22), start)
+	ts.Equal(uint64(52), end)
+	ts.Require().NoError(ts.ReleaseIDRange(ctx, 27, 52))
+	for _, want := range [][2]uint64{{27, 37}, {37, 47}, {47, 52}, {52, 62}} {
+		start, end, err = ts.LeaseIDRange(ctx, 10)
+		ts.Require().NoError(err)
+		ts.Equal(want[0], start)
+		ts.Equal(want[1], end)
+	}
+}
+
+// Test_psgsqlRepo_RangeSequence_Concurrent проверяет, что два экземпляра счётчика
+// с общей БД не выдают одинаковых значений
+func (ts *PostgresTestSuite) Test_psgsqlRepo_RangeSequence_Concurrent() {
+	ctx := context.Background()
+	first := idgen.NewRangeSequence(&ts.psgsqlRepo, 7)
+	second := idgen.NewRangeSequence(&ts.psgsqlRepo, 7)
+
+	var mu sync.Mutex
+	values := make([]uint64, 0, 1200)
+	run := func(sequences ...*idgen.RangeSequence) {
+		wg := sync.WaitGroup{}
+		for _, seq := range sequences {
+			for i := 0; i < 4; i++ {
+				wg.Add(1)
+				go func(seq *idgen.RangeSequence) {
+					defer wg.Done()
+					for j := 0; j < 100; j++ {
+						v, err := seq.Next(ctx)
+						assert.NoError(ts.T(), err)
+						mu.Lock()
+						values = append(values, v)
+						mu.Unlock()
+					}
+				}(seq)
+			}
+		}
+		wg.Wait()
+	}
+
+	run(first, second)
+	// остаток остановленного экземпляра достаётся другому
+	ts.Require().NoError(first.Close(ctx))
+	run(second)
+	ts.Require().NoError(second.Close(ctx))
+
+	ts.Require().Len(values, 1200)
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	for i := 1; i < len(values); i++ {
+		ts.Require().NotEqual(values[i-1], values[i], "value %d was issued twice", values[i])
+	}
+}
+
+// Test_psgsqlRepo_LeaseIDRange_RolledBackTx проверяет, что откат транзакции сохранения
+// не возвращает арендованный в ней диапазон другим экземплярам
+func (ts *PostgresTestSuite) Test_psgsqlRepo_LeaseIDRange_RolledBackTx() {
+	ctx := context.Background()
+	first := idgen.NewRangeSequence(&ts.psgsqlRepo, 10)
+	second := idgen.NewRangeSequence(&ts.psgsqlRepo, 10)
+
+	var leased uint64
+	errRollback := errors.New("rollback")
+	err := ts.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		leased, err = first.Next(ctx)
+		ts.Require().NoError(err)
+		return errRollback
+	})
+	ts.Require().ErrorIs(err, errRollback)
+
+	values := map[uint64]struct{}{}
+	for i := 0; i < 10; i++ {
+		v, err := first.Next(ctx)
+		ts.Require().NoError(err)
+		values[v] = struct{}{}
+	}
+	values[leased] = struct{}{}
+	for i := 0; i < 10; i++ {
+		v, err := second.Next(ctx)
+		ts.Require().NoError(err)
+		ts.Require().NotContains(values, v, "value %d was issued twice", v)
+	}
+}
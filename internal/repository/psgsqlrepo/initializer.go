@@ -2,24 +2,108 @@ package psgsqlrepo
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// NewSQLxConnection создаёт экземпляр sqlx.DB и накатывает миграции
-func NewSQLxConnection(ctx context.Context, databaseDsn string) (*sqlx.DB, error) {
-	_, cancel := context.WithTimeout(ctx, time.Second*100)
-	defer cancel()
-	db, err := sqlx.Connect("pgx", databaseDsn)
+// PoolConfig настройки пула соединений с БД; нулевые значения оставляют настройки pgx
+// или строки подключения
+type PoolConfig struct {
+	MaxConns               int           // максимальное количество соединений
+	MinConns               int           // минимальное количество открытых соединений
+	MaxConnLifetime        time.Duration // время жизни соединения
+	ConnectTimeout         time.Duration // таймаут установки соединения
+	StatementTimeout       time.Duration // таймаут выполнения запроса
+	StatementCacheCapacity int           // размер кеша подготовленных запросов; 0 - запросы не подготавливаются
+	SkipMigrations         bool          // не накатывать миграции; схемой управляет команда db migrate
+}
+
+// NewPool создаёт пул соединений с БД и накатывает миграции, если они не отключены
+func NewPool(ctx context.Context, databaseDsn string, conf PoolConfig) (*pgxpool.Pool, error) {
+	pool, err := newPool(ctx, databaseDsn, conf)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := migrate(db); err != nil {
+	pingCtx := ctx
+	if conf.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		pingCtx, cancel = context.WithTimeout(ctx, conf.ConnectTimeout)
+		defer cancel()
+	}
+	if err = pool.Ping(pingCtx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	if conf.SkipMigrations {
+		return pool, nil
+	}
+	if err = RunMigrations(ctx, pool, MigrationUp, new(migrationLogger)); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// NewReplicaPool создаёт пул соединений с репликой БД; соединения открываются по мере надобности,
+// поэтому недоступная при запуске реплика не мешает старту, а её доступность проверяет хранилище
+func NewReplicaPool(ctx context.Context, databaseDsn string, conf PoolConfig) (*pgxpool.Pool, error) {
+	return newPool(ctx, databaseDsn, conf)
+}
+
+func newPool(ctx context.Context, databaseDsn string, conf PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := newPoolConfig(databaseDsn, conf)
+	if err != nil {
 		return nil, err
 	}
 
-	return db, nil
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
+
+func newPoolConfig(databaseDsn string, conf PoolConfig) (*pgxpool.Config, error) {
+	poolConfig, err := pgxpool.ParseConfig(databaseDsn)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse database dsn: %w", err)
+	}
+
+	if conf.MaxConns < 0 || conf.MinConns < 0 {
+		return nil, fmt.Errorf("database pool connections count can not be negative")
+	}
+	if conf.MaxConns > 0 {
+		poolConfig.MaxConns = int32(conf.MaxConns)
+	}
+	if conf.MinConns > 0 {
+		poolConfig.MinConns = int32(conf.MinConns)
+	}
+	if poolConfig.MinConns > poolConfig.MaxConns {
+		return nil, fmt.Errorf("database pool min connections %d is greater than max connections %d",
+			poolConfig.MinConns, poolConfig.MaxConns)
+	}
+	if conf.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = conf.MaxConnLifetime
+	}
+
+	connConfig := poolConfig.ConnConfig
+	if conf.ConnectTimeout > 0 {
+		connConfig.ConnectTimeout = conf.ConnectTimeout
+	}
+	if conf.StatementTimeout > 0 {
+		connConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(conf.StatementTimeout.Milliseconds(), 10)
+	}
+	if conf.StatementCacheCapacity > 0 {
+		connConfig.DefaultQueryExecMode = pgx.QueryExecModeCacheStatement
+		connConfig.StatementCacheCapacity = conf.StatementCacheCapacity
+	} else {
+		// без подготовленных запросов, например при работе через PgBouncer
+		connConfig.DefaultQueryExecMode = pgx.QueryExecModeExec
+		connConfig.StatementCacheCapacity = 0
+	}
+
+	return poolConfig, nil
 }
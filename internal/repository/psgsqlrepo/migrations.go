@@ -1,38 +1,67 @@
 package psgsqlrepo
 
 import (
+	"context"
 	"embed"
 	"fmt"
 
 	"github.com/KartoonYoko/go-url-shortener/internal/logger"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 )
 
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
+// migrationsDir директория миграций во встроенной файловой системе
+const migrationsDir = "migrations"
+
+// Команды управления миграциями
+const (
+	MigrationUp      = "up"      // накатить все новые миграции
+	MigrationDown    = "down"    // откатить последнюю миграцию
+	MigrationStatus  = "status"  // вывести состояние каждой миграции
+	MigrationVersion = "version" // вывести текущую версию схемы
+)
+
+// IsMigrationCommand проверит, поддерживается ли команда управления миграциями
+func IsMigrationCommand(command string) bool {
+	switch command {
+	case MigrationUp, MigrationDown, MigrationStatus, MigrationVersion:
+		return true
+	}
+	return false
+}
+
+// migrationLogger выводит сообщения goose в лог приложения
 type migrationLogger struct{}
 
 func (l *migrationLogger) Fatalf(format string, v ...interface{}) {
-	logger.Log.Sugar().Errorf(format, v)
+	logger.Log.Sugar().Errorf(format, v...)
 }
 
 func (l *migrationLogger) Printf(format string, v ...interface{}) {
-	logger.Log.Sugar().Infof(format, v)
+	logger.Log.Sugar().Infof(format, v...)
 }
 
-func migrate(db *sqlx.DB) error {
+// RunMigrations выполнит команду управления встроенными миграциями;
+// сообщения goose, в том числе состояние и версия схемы, выводятся в out
+func RunMigrations(ctx context.Context, pool *pgxpool.Pool, command string, out goose.Logger) error {
+	if !IsMigrationCommand(command) {
+		return fmt.Errorf("unknown migration command %q", command)
+	}
+
+	// goose работает через database/sql, поэтому открываем обёртку над пулом
+	db := stdlib.OpenDBFromPool(pool)
+	defer db.Close()
+
 	goose.SetBaseFS(embedMigrations)
-	goose.SetLogger(new(migrationLogger))
+	goose.SetLogger(out)
 
 	if err := goose.SetDialect("postgres"); err != nil {
 		return fmt.Errorf("postgres migrate set dialect postgres: %w", err)
 	}
 
-	if err := goose.Up(db.DB, "migrations"); err != nil {
-		return err
-	}
-
-	return nil
+	return goose.RunContext(ctx, command, db, migrationsDir)
 }
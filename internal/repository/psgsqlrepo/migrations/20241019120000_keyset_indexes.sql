@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX IF NOT EXISTS shorten_url_id_c_idx ON shorten_url (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS users_id_c_idx ON users (id COLLATE "C");
+CREATE INDEX IF NOT EXISTS users_shorten_url_url_id_idx ON users_shorten_url (url_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS users_shorten_url_url_id_idx;
+DROP INDEX IF EXISTS users_id_c_idx;
+DROP INDEX IF EXISTS shorten_url_id_c_idx;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS id_counter (
+    name VARCHAR PRIMARY KEY,
+    next_value BIGINT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS id_counter_free_ranges (
+    name VARCHAR NOT NULL,
+    start_value BIGINT NOT NULL,
+    end_value BIGINT NOT NULL,
+    PRIMARY KEY (name, start_value)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS id_counter_free_ranges;
+DROP TABLE IF EXISTS id_counter;
+-- +goose StatementEnd
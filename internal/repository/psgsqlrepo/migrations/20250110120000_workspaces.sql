@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS workspaces (
+    id VARCHAR PRIMARY KEY,
+    name VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id VARCHAR NOT NULL,
+    user_id VARCHAR NOT NULL,
+    role VARCHAR NOT NULL,
+
+    PRIMARY KEY(workspace_id, user_id),
+
+    CONSTRAINT fk_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id),
+
+    CONSTRAINT fk_user_id
+    FOREIGN KEY (user_id)
+    REFERENCES users (id)
+);
+
+CREATE INDEX IF NOT EXISTS workspace_members_user_id_idx ON workspace_members (user_id);
+
+CREATE TABLE IF NOT EXISTS workspace_shorten_url (
+    workspace_id VARCHAR NOT NULL,
+    url_id VARCHAR NOT NULL,
+
+    PRIMARY KEY(workspace_id, url_id),
+
+    CONSTRAINT fk_workspace_id
+    FOREIGN KEY (workspace_id)
+    REFERENCES workspaces (id),
+
+    CONSTRAINT fk_url_id
+    FOREIGN KEY (url_id)
+    REFERENCES shorten_url (id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS workspace_shorten_url;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
+-- +goose StatementEnd
@@ -0,0 +1,49 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/stretchr/testify/require"
+)
+
+// migrationOutput собирает сообщения goose
+type migrationOutput struct {
+	lines []string
+}
+
+func (o *migrationOutput) Fatalf(format string, v ...interface{}) {
+	o.Printf(format, v...)
+}
+
+func (o *migrationOutput) Printf(format string, v ...interface{}) {
+	o.lines = append(o.lines, fmt.Sprintf(format, v...))
+}
+
+// Test_RunMigrations проверяет откат и повторное применение миграций
+func (ts *PostgresTestSuite) Test_RunMigrations() {
+	ctx := context.Background()
+
+	out := new(migrationOutput)
+	require.NoError(ts.T(), RunMigrations(ctx, ts.pool, MigrationVersion, out))
+	version := strings.Join(out.lines, "\n")
+	require.Contains(ts.T(), version, "version")
+
+	require.NoError(ts.T(), RunMigrations(ctx, ts.pool, MigrationDown, new(migrationOutput)))
+	out = new(migrationOutput)
+	require.NoError(ts.T(), RunMigrations(ctx, ts.pool, MigrationStatus, out))
+	require.Contains(ts.T(), strings.Join(out.lines, "\n"), "Pending")
+
+	// схема восстанавливается и снова пригодна для работы
+	require.NoError(ts.T(), RunMigrations(ctx, ts.pool, MigrationUp, new(migrationOutput)))
+	out = new(migrationOutput)
+	require.NoError(ts.T(), RunMigrations(ctx, ts.pool, MigrationVersion, out))
+	require.Equal(ts.T(), version, strings.Join(out.lines, "\n"))
+	userID, err := ts.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	_, err = ts.SaveURL(ctx, "https://example.com/migrated", userID)
+	require.NoError(ts.T(), err)
+
+	require.Error(ts.T(), RunMigrations(ctx, ts.pool, "redo", new(migrationOutput)))
+}
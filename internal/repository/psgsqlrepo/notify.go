@@ -0,0 +1,211 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+)
+
+// changesChannel канал уведомлений об изменениях URL'ов
+const changesChannel = "shortener_url_changes"
+
+// maxChangePayloadSize наибольший размер уведомления в байтах; Postgres ограничивает его 8000 байт
+const maxChangePayloadSize = 7000
+
+// Пауза перед повторным подключением слушателя; удваивается после каждой неудачи
+const (
+	minListenBackoff = 500 * time.Millisecond
+	maxListenBackoff = 30 * time.Second
+)
+
+// Операции над URL'ами, о которых рассылаются уведомления
+const (
+	ChangeOpUpdate  = "update"  // URL создан
+	ChangeOpDelete  = "delete"  // URL помечен удалённым
+	ChangeOpRestore = "restore" // URL загружен из резервной копии или другого хранилища
+	ChangeOpOwner   = "owner"   // у URL'а появился владелец; сам URL не изменился
+)
+
+// ChangeEvent уведомление об изменении URL'ов
+type ChangeEvent struct {
+	Source string   `json:"source"` // экземпляр хранилища, изменивший URL'ы
+	Op     string   `json:"op"`
+	UserID string   `json:"user_id,omitempty"` // пользователь, чьи URL'ы изменились; может быть пустым
+	IDs    []string `json:"ids"`
+}
+
+// ChangeHandler получатель изменений URL'ов, сделанных другими экземплярами приложения
+type ChangeHandler interface {
+	// Invalidate сбросит локальное состояние указанных URL'ов
+	Invalidate(ids ...string)
+	// InvalidateAll сбросит всё локальное состояние, когда уведомления могли быть пропущены
+	InvalidateAll()
+}
+
+// errAlreadyListening сообщает о повторной подписке на изменения
+var errAlreadyListening = errors.New("psgsql repo: already listening for changes")
+
+// notifyChanges разошлёт уведомление об изменении пользователем userID URL'ов через q;
+// внутри транзакции уведомление доставляется только при её фиксации
+func (s *psgsqlRepo) notifyChanges(ctx context.Context, q querier, op string, userID string, ids []string) error {
+	payloads, err := changePayloads(ChangeEvent{Source: s.instanceID, Op: op, UserID: userID, IDs: ids})
+	if err != nil {
+		return err
+	}
+
+	for _, payload := range payloads {
+		_, err = q.Exec(ctx, "SELECT pg_notify($1, $2)", changesChannel, payload)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// changePayloads разобьёт событие на уведомления допустимого размера
+func changePayloads(event ChangeEvent) ([]string, error) {
+	payloads := make([]string, 0)
+	ids := event.IDs
+	for len(ids) > 0 {
+		// подбираем наибольшую порцию ID, которая помещается в уведомление
+		n := len(ids)
+		for {
+			chunk := event
+			chunk.IDs = ids[:n]
+			data, err := json.Marshal(chunk)
+			if err != nil {
+				return nil, err
+			}
+			if len(data) <= maxChangePayloadSize {
+				payloads = append(payloads, string(data))
+				break
+			}
+			if n == 1 {
+				return nil, errors.New("psgsql repo: url id is too long for change notification")
+			}
+			n /= 2
+		}
+		ids = ids[n:]
+	}
+
+	return payloads, nil
+}
+
+// ListenChanges подпишет h на изменения URL'ов, сделанные другими экземплярами приложения.
+// Подписка оформляется до возврата, поэтому состояние, построенное после вызова, не пропустит изменений.
+// Подписка работает до Close на отдельном соединении; при его потере соединение восстанавливается,
+// а локальное состояние сбрасывается целиком
+func (s *psgsqlRepo) ListenChanges(h ChangeHandler) error {
+	if s.stopListen != nil {
+		return errAlreadyListening
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	conn, err := s.subscribe(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	s.stopListen = cancel
+	s.listenDone = make(chan struct{})
+	go s.listen(ctx, conn, h)
+
+	return nil
+}
+
+// listen обрабатывает уведомления, переподключаясь при ошибках
+func (s *psgsqlRepo) listen(ctx context.Context, conn *pgx.Conn, h ChangeHandler) {
+	defer close(s.listenDone)
+
+	for {
+		err := s.receive(ctx, conn, h)
+
+		backoff := minListenBackoff
+		for {
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Log.Warn("database change listener disconnected",
+				zap.Error(err),
+				zap.Duration("retry_in", backoff))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+			if backoff > maxListenBackoff {
+				backoff = maxListenBackoff
+			}
+
+			conn, err = s.subscribe(ctx)
+			if err == nil {
+				break
+			}
+		}
+
+		// пока подписки не было, уведомления могли быть пропущены
+		h.InvalidateAll()
+	}
+}
+
+// subscribe подключится отдельным соединением и подпишется на уведомления
+func (s *psgsqlRepo) subscribe(ctx context.Context) (*pgx.Conn, error) {
+	connConfig := s.pool.Config().ConnConfig.Copy()
+	connConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{changesChannel}.Sanitize())
+	if err != nil {
+		conn.Close(context.Background())
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// receive будет обрабатывать уведомления до ошибки, после чего закроет соединение
+func (s *psgsqlRepo) receive(ctx context.Context, conn *pgx.Conn, h ChangeHandler) error {
+	defer conn.Close(context.Background())
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		s.handleChange(n.Payload, h)
+	}
+}
+
+// handleChange передаст h изменения из уведомления, пропуская собственные;
+// восстановление идёт мимо кеша, поэтому о нём сообщается и своему экземпляру.
+// Изменённые URL'ы и их пользователь читаются из основной БД и на этом экземпляре,
+// поэтому пользователь видит свои записи, даже если следующий запрос попал на другой экземпляр
+func (s *psgsqlRepo) handleChange(payload string, h ChangeHandler) {
+	var event ChangeEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		logger.Log.Error("can not parse database change notification", zap.Error(err))
+		return
+	}
+	if len(event.IDs) == 0 || (event.Source == s.instanceID && event.Op != ChangeOpRestore) {
+		return
+	}
+
+	// реплики могут ещё не знать об изменении
+	if event.Op == ChangeOpOwner {
+		s.writes.mark(event.UserID)
+		return
+	}
+	s.writes.mark(event.UserID, event.IDs...)
+	h.Invalidate(event.IDs...)
+}
@@ -0,0 +1,133 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/stretchr/testify/require"
+)
+
+// changeHandlerMock передаёт полученные изменения в каналы
+type changeHandlerMock struct {
+	ids    chan []string
+	resets chan struct{}
+}
+
+func newChangeHandlerMock() *changeHandlerMock {
+	return &changeHandlerMock{
+		ids:    make(chan []string, 10),
+		resets: make(chan struct{}, 10),
+	}
+}
+
+func (m *changeHandlerMock) Invalidate(ids ...string) {
+	m.ids <- ids
+}
+
+func (m *changeHandlerMock) InvalidateAll() {
+	m.resets <- struct{}{}
+}
+
+func Test_changePayloads(t *testing.T) {
+	ids := make([]string, 0, 2000)
+	for i := 0; i < 2000; i++ {
+		ids = append(ids, fmt.Sprintf("id%06d", i))
+	}
+
+	payloads, err := changePayloads(ChangeEvent{Source: "src", Op: ChangeOpDelete, IDs: ids})
+	require.NoError(t, err)
+	require.Greater(t, len(payloads), 1)
+
+	// порции укладываются в ограничение и вместе содержат все ID по порядку
+	got := make([]string, 0, len(ids))
+	for _, p := range payloads {
+		require.LessOrEqual(t, len(p), maxChangePayloadSize)
+
+		var event ChangeEvent
+		require.NoError(t, json.Unmarshal([]byte(p), &event))
+		require.Equal(t, "src", event.Source)
+		require.Equal(t, ChangeOpDelete, event.Op)
+		got = append(got, event.IDs...)
+	}
+	require.Equal(t, ids, got)
+
+	_, err = changePayloads(ChangeEvent{Op: ChangeOpDelete, IDs: []string{strings.Repeat("a", maxChangePayloadSize)}})
+	require.Error(t, err)
+}
+
+func Test_psgsqlRepo_handleChange(t *testing.T) {
+	s := &psgsqlRepo{instanceID: "self", writes: newRecentWrites(time.Minute)}
+	h := newChangeHandlerMock()
+	payload := func(source, op, userID string, ids ...string) string {
+		data, err := json.Marshal(ChangeEvent{Source: source, Op: op, UserID: userID, IDs: ids})
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	s.handleChange(payload("other", ChangeOpDelete, "user-1", "a", "b"), h)
+	require.Equal(t, []string{"a", "b"}, <-h.ids)
+	// изменённые URL'ы и их пользователь читаются с основного сервера
+	require.True(t, s.writes.urlWritten("a"))
+	require.True(t, s.writes.userWrote("user-1"))
+
+	// новый владелец не меняет сам URL
+	s.handleChange(payload("other", ChangeOpOwner, "user-2", "e"), h)
+	require.True(t, s.writes.userWrote("user-2"))
+	require.False(t, s.writes.urlWritten("e"))
+	require.Empty(t, h.ids)
+
+	// уведомление прежней версии без пользователя
+	s.handleChange(`{"source":"other","op":"update","ids":["f"]}`, h)
+	require.Equal(t, []string{"f"}, <-h.ids)
+
+	// собственные изменения кеш уже учёл, кроме восстановления
+	s.handleChange(payload("self", ChangeOpDelete, "", "c"), h)
+	s.handleChange(payload("self", ChangeOpRestore, "", "d"), h)
+	require.Equal(t, []string{"d"}, <-h.ids)
+
+	s.handleChange("not json", h)
+	require.Empty(t, h.ids)
+}
+
+// Test_psgsqlRepo_ListenChanges проверяет доставку изменений другому экземпляру
+func (ts *PostgresTestSuite) Test_psgsqlRepo_ListenChanges() {
+	ctx := context.Background()
+	other, err := NewPsgsqlRepo(ctx, ts.pool)
+	ts.Require().NoError(err)
+	h := newChangeHandlerMock()
+	ts.Require().NoError(other.ListenChanges(h))
+	// основной пул общий с остальными тестами, поэтому останавливаем только подписку
+	defer func() {
+		other.stopListen()
+		<-other.listenDone
+	}()
+	ts.Require().ErrorIs(other.ListenChanges(h), errAlreadyListening)
+
+	receive := func(ch <-chan []string) []string {
+		select {
+		case ids := <-ch:
+			return ids
+		case <-time.After(5 * time.Second):
+			ts.FailNow("change notification was not received")
+			return nil
+		}
+	}
+	userID, err := ts.GetNewUserID(ctx)
+	ts.Require().NoError(err)
+	id, err := ts.SaveURL(ctx, "https://example.com/listen", userID)
+	ts.Require().NoError(err)
+	ts.Equal([]string{id}, receive(h.ids))
+
+	modelsCh := make(chan model.UpdateURLDeletedFlag, 1)
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: id}
+	close(modelsCh)
+	ts.Require().NoError(ts.UpdateURLsDeletedFlag(ctx, userID, modelsCh))
+	ts.Equal([]string{id}, receive(h.ids))
+	// первая подписка оформлена до возврата и не сбрасывает состояние
+	ts.Empty(h.resets)
+}
@@ -4,5 +4,5 @@ import "context"
 
 // Ping реализует Pinger
 func (s *psgsqlRepo) Ping(ctx context.Context) error {
-	return s.conn.PingContext(ctx)
+	return s.pool.Ping(ctx)
 }
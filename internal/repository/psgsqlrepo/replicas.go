@@ -0,0 +1,253 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Значения по умолчанию для ReplicaConfig
+const (
+	defaultReplicaStickiness   = 5 * time.Second
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = time.Second
+)
+
+// ReplicaConfig настройки чтения с реплик; нулевые значения заменяются значениями по умолчанию
+type ReplicaConfig struct {
+	// Stickiness время после записи, в течение которого чтения данных пользователя
+	// и записанных URL'ов идут в основную БД
+	Stickiness time.Duration
+	// HealthCheckInterval период проверки доступности реплик
+	HealthCheckInterval time.Duration
+	// HealthCheckTimeout таймаут проверки доступности одной реплики
+	HealthCheckTimeout time.Duration
+}
+
+func (c ReplicaConfig) withDefaults() ReplicaConfig {
+	if c.Stickiness <= 0 {
+		c.Stickiness = defaultReplicaStickiness
+	}
+	if c.HealthCheckInterval <= 0 {
+		c.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if c.HealthCheckTimeout <= 0 {
+		c.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+	return c
+}
+
+// replicaConn соединение с репликой
+type replicaConn interface {
+	querier
+	Ping(ctx context.Context) error
+	Close()
+}
+
+// replica реплика и её состояние
+type replica struct {
+	conn    replicaConn
+	healthy atomic.Bool
+}
+
+// replicaSet реплики для чтения; запросы распределяются по доступным репликам по очереди
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+	conf     ReplicaConfig
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newReplicaSet(conns []replicaConn, conf ReplicaConfig) *replicaSet {
+	rs := &replicaSet{
+		replicas: make([]*replica, 0, len(conns)),
+		conf:     conf.withDefaults(),
+	}
+	for _, conn := range conns {
+		rs.replicas = append(rs.replicas, &replica{conn: conn})
+	}
+	return rs
+}
+
+// start проверяет реплики и запускает их периодическую проверку
+func (rs *replicaSet) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	rs.cancel = cancel
+	rs.done = make(chan struct{})
+
+	rs.checkHealth(ctx)
+	go func() {
+		defer close(rs.done)
+
+		ticker := time.NewTicker(rs.conf.HealthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				rs.checkHealth(ctx)
+			}
+		}
+	}()
+}
+
+// checkHealth проверяет доступность всех реплик
+func (rs *replicaSet) checkHealth(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i, r := range rs.replicas {
+		wg.Add(1)
+		go func(i int, r *replica) {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, rs.conf.HealthCheckTimeout)
+			defer cancel()
+			err := r.conn.Ping(pingCtx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			healthy := err == nil
+			if r.healthy.Swap(healthy) != healthy {
+				logger.Log.Info("database replica health changed",
+					zap.Int("replica", i),
+					zap.Bool("healthy", healthy),
+					zap.Error(err))
+			}
+		}(i, r)
+	}
+	wg.Wait()
+}
+
+// pick вернёт следующую доступную реплику или nil, если доступных нет;
+// очередь идёт только по доступным репликам, чтобы нагрузка делилась между ними поровну
+func (rs *replicaSet) pick() *replica {
+	healthy := 0
+	for _, r := range rs.replicas {
+		if r.healthy.Load() {
+			healthy++
+		}
+	}
+	if healthy == 0 {
+		return nil
+	}
+
+	k := int(rs.next.Add(1) % uint64(healthy))
+	for _, r := range rs.replicas {
+		if !r.healthy.Load() {
+			continue
+		}
+		if k == 0 {
+			return r
+		}
+		k--
+	}
+
+	// доступность изменилась во время выбора
+	return nil
+}
+
+// close останавливает проверки и закрывает соединения с репликами
+func (rs *replicaSet) close() {
+	if rs.cancel != nil {
+		rs.cancel()
+		<-rs.done
+	}
+	for _, r := range rs.replicas {
+		r.conn.Close()
+	}
+}
+
+// recentWrites запоминает недавние записи пользователей и URL'ов,
+// чтобы читать их из основной БД, пока изменения не дошли до реплик.
+// Записи других экземпляров приложения приходят в уведомлениях об изменениях
+// (см. handleChange), поэтому балансировщику не нужно закреплять пользователя за экземпляром
+type recentWrites struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	users     map[string]time.Time // ID пользователя -> время последней записи
+	urls      map[string]time.Time // ID URL'а -> время последней записи
+	lastPrune time.Time
+}
+
+func newRecentWrites(window time.Duration) *recentWrites {
+	return &recentWrites{
+		window: window,
+		now:    time.Now,
+		users:  make(map[string]time.Time),
+		urls:   make(map[string]time.Time),
+	}
+}
+
+// mark запомнит запись пользователем userID URL'ов urlIDs
+func (w *recentWrites) mark(userID string, urlIDs ...string) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	if userID != "" {
+		w.users[userID] = now
+	}
+	for _, id := range urlIDs {
+		w.urls[id] = now
+	}
+
+	// устаревшие записи удаляем не чаще раза в окно
+	if now.Sub(w.lastPrune) >= w.window {
+		w.prune(now)
+	}
+}
+
+// userWrote сообщит, писал ли пользователь недавно
+func (w *recentWrites) userWrote(userID string) bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.isRecent(w.users[userID])
+}
+
+// urlWritten сообщит, изменялся ли URL недавно
+func (w *recentWrites) urlWritten(id string) bool {
+	if w == nil {
+		return false
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.isRecent(w.urls[id])
+}
+
+func (w *recentWrites) isRecent(at time.Time) bool {
+	return !at.IsZero() && w.now().Sub(at) < w.window
+}
+
+func (w *recentWrites) prune(now time.Time) {
+	for id, at := range w.users {
+		if now.Sub(at) >= w.window {
+			delete(w.users, id)
+		}
+	}
+	for id, at := range w.urls {
+		if now.Sub(at) >= w.window {
+			delete(w.urls, id)
+		}
+	}
+	w.lastPrune = now
+}
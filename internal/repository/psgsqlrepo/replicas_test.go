@@ -0,0 +1,141 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// replicaConnMock реплика, доступность которой задаётся в тесте
+type replicaConnMock struct {
+	pingErr error
+	closed  bool
+}
+
+func (m *replicaConnMock) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("not implemented")
+}
+
+func (m *replicaConnMock) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *replicaConnMock) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return nil
+}
+
+func (m *replicaConnMock) CopyFrom(ctx context.Context,
+	tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	return 0, errors.New("not implemented")
+}
+
+func (m *replicaConnMock) Ping(ctx context.Context) error {
+	return m.pingErr
+}
+
+func (m *replicaConnMock) Close() {
+	m.closed = true
+}
+
+// txMock транзакция, в которой выполняется чтение
+type txMock struct {
+	pgx.Tx
+}
+
+func TestReplicaSet_Pick(t *testing.T) {
+	first, second, third := &replicaConnMock{}, &replicaConnMock{}, &replicaConnMock{pingErr: errors.New("down")}
+	rs := newReplicaSet([]replicaConn{first, second, third}, ReplicaConfig{})
+	rs.checkHealth(context.Background())
+
+	picked := map[replicaConn]int{}
+	for i := 0; i < 10; i++ {
+		picked[rs.pick().conn]++
+	}
+	require.Equal(t, map[replicaConn]int{first: 5, second: 5}, picked)
+
+	// все реплики недоступны
+	first.pingErr = errors.New("down")
+	second.pingErr = errors.New("down")
+	rs.checkHealth(context.Background())
+	require.Nil(t, rs.pick())
+
+	// реплика восстановилась
+	third.pingErr = nil
+	rs.checkHealth(context.Background())
+	require.Equal(t, replicaConn(third), rs.pick().conn)
+
+	rs.close()
+	require.True(t, first.closed)
+	require.True(t, third.closed)
+}
+
+func TestRecentWrites(t *testing.T) {
+	w := newRecentWrites(time.Second)
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	w.mark("user", "url")
+	require.True(t, w.userWrote("user"))
+	require.True(t, w.urlWritten("url"))
+	require.False(t, w.userWrote("other"))
+	require.False(t, w.urlWritten("other"))
+
+	now = now.Add(2 * time.Second)
+	require.False(t, w.userWrote("user"))
+	require.False(t, w.urlWritten("url"))
+
+	// устаревшие записи удаляются при следующей записи
+	w.mark("other")
+	require.Len(t, w.users, 1)
+	require.Empty(t, w.urls)
+
+	// без реплик недавние записи не отслеживаются
+	var disabled *recentWrites
+	disabled.mark("user", "url")
+	require.False(t, disabled.userWrote("user"))
+}
+
+func TestPsgsqlRepo_Read(t *testing.T) {
+	ctx := context.Background()
+	conn := &replicaConnMock{}
+	repo := &psgsqlRepo{
+		replicas: newReplicaSet([]replicaConn{conn}, ReplicaConfig{}),
+	}
+	repo.replicas.checkHealth(ctx)
+	onReplica := func(q querier) bool { return q == querier(conn) }
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		sticky      bool
+		replicaErr  error
+		wantReplica []bool // на каком соединении выполнялась каждая попытка
+	}{
+		{name: "replica", ctx: ctx, wantReplica: []bool{true}},
+		{name: "sticky", ctx: ctx, sticky: true, wantReplica: []bool{false}},
+		{name: "transaction", ctx: context.WithValue(ctx, txKey{repo: repo}, &txMock{}), wantReplica: []bool{false}},
+		{name: "not found on replica", ctx: ctx, replicaErr: repoCommon.ErrNotFoundKey, wantReplica: []bool{true, false}},
+		{name: "replica error", ctx: ctx, replicaErr: errors.New("broken"), wantReplica: []bool{true, false}},
+		{name: "replica marked unhealthy", ctx: ctx, wantReplica: []bool{false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := make([]bool, 0)
+			err := repo.read(tt.ctx, tt.sticky, func(q querier) error {
+				attempts = append(attempts, onReplica(q))
+				if onReplica(q) {
+					return tt.replicaErr
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.wantReplica, attempts)
+		})
+	}
+}
@@ -2,24 +2,111 @@ package psgsqlrepo
 
 import (
 	"context"
+	"crypto/sha256"
+	"errors"
 
-	"github.com/jmoiron/sqlx"
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
 )
 
+// querier общий интерфейс пула соединений и транзакции
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
 type psgsqlRepo struct {
-	conn *sqlx.DB
+	pool *pgxpool.Pool
+	// ID экземпляра хранилища в уведомлениях об изменениях
+	instanceID string
+	// генератор ID URL'ов
+	idGen idgen.Generator
+	// реплики для чтения; nil, если реплик нет
+	replicas *replicaSet
+	// недавние записи, чтения которых идут в основную БД; nil, если реплик нет
+	writes *recentWrites
+
+	// остановка подписки на изменения; nil, если подписки нет
+	stopListen context.CancelFunc
+	listenDone chan struct{}
 }
 
 // NewPsgsqlRepo инициализирует хранилище для работы с БД
-func NewPsgsqlRepo(ctx context.Context, db *sqlx.DB) (*psgsqlRepo, error) {
+func NewPsgsqlRepo(ctx context.Context, pool *pgxpool.Pool) (*psgsqlRepo, error) {
+	return NewPsgsqlRepoWithReplicas(ctx, pool, nil, ReplicaConfig{})
+}
+
+// NewPsgsqlRepoWithReplicas инициализирует хранилище, читающее URL'ы и статистику с реплик;
+// при недоступности реплик чтения идут в основную БД pool
+func NewPsgsqlRepoWithReplicas(ctx context.Context,
+	pool *pgxpool.Pool,
+	replicas []*pgxpool.Pool,
+	conf ReplicaConfig) (*psgsqlRepo, error) {
 	repo := &psgsqlRepo{
-		conn: db,
+		pool:       pool,
+		instanceID: uuid.New().String(),
+		idGen:      idgen.NewHash(sha256.New),
 	}
+	if len(replicas) == 0 {
+		return repo, nil
+	}
+
+	conns := make([]replicaConn, 0, len(replicas))
+	for _, r := range replicas {
+		conns = append(conns, r)
+	}
+	repo.replicas = newReplicaSet(conns, conf)
+	repo.writes = newRecentWrites(repo.replicas.conf.Stickiness)
+	repo.replicas.start()
 
 	return repo, nil
 }
 
 // Close релизует Closer
 func (s *psgsqlRepo) Close() error {
-	return s.conn.Close()
+	if s.stopListen != nil {
+		s.stopListen()
+		<-s.listenDone
+	}
+	if s.replicas != nil {
+		s.replicas.close()
+	}
+	s.pool.Close()
+	return nil
+}
+
+// read выполнит чтение на реплике, если она доступна, чтение не попадает в транзакцию
+// и не должно видеть недавних записей (sticky). Если реплика не нашла данные или вернула ошибку,
+// чтение повторяется в основной БД: данные могли ещё не дойти до реплики
+func (s *psgsqlRepo) read(ctx context.Context, sticky bool, fn func(q querier) error) error {
+	if s.replicas == nil || sticky || s.inTx(ctx) {
+		return fn(s.querier(ctx))
+	}
+
+	r := s.replicas.pick()
+	if r == nil {
+		return fn(s.pool)
+	}
+
+	err := fn(r.conn)
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return err
+	case !errors.Is(err, repoCommon.ErrNotFoundKey):
+		// до следующей проверки реплика не используется
+		r.healthy.Store(false)
+		logger.Log.Warn("database replica read failed, falling back to primary", zap.Error(err))
+	}
+
+	return fn(s.pool)
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/conformance"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/testcontainers/testcontainers-go"
@@ -17,24 +19,85 @@ type PostgresTestSuite struct {
 	suite.Suite
 	psgsqlRepo
 
-	tc *tcpostgres.PostgresContainer
+	tc  *tcpostgres.PostgresContainer
+	dsn string
 }
 
 func (s *psgsqlRepo) cleanTables(ctx context.Context) error {
-	query := `DELETE FROM users_shorten_url`
-	_, err := s.conn.ExecContext(ctx, query)
+	query := `DELETE FROM refresh_tokens`
+	_, err := s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM sessions`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM identities`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM accounts`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM workspace_shorten_url`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM workspace_members`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM workspaces`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM api_keys`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM users_shorten_url`
+	_, err = s.pool.Exec(ctx, query)
 	if err != nil {
 		return err
 	}
 
 	query = `DELETE FROM users`
-	_, err = s.conn.ExecContext(ctx, query)
+	_, err = s.pool.Exec(ctx, query)
 	if err != nil {
 		return err
 	}
 
 	query = `DELETE FROM shorten_url`
-	_, err = s.conn.ExecContext(ctx, query)
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM id_counter_free_ranges`
+	_, err = s.pool.Exec(ctx, query)
+	if err != nil {
+		return err
+	}
+
+	query = `DELETE FROM id_counter`
+	_, err = s.pool.Exec(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -64,9 +127,10 @@ func (ts *PostgresTestSuite) SetupSuite() {
 
 	dbConnectionString, err := getDBConnectionString(ctx, pgc, "shortenerdb", "123")
 	require.NoError(ts.T(), err)
-	db, err := NewSQLxConnection(ctx, dbConnectionString)
+	ts.dsn = dbConnectionString
+	pool, err := NewPool(ctx, dbConnectionString, PoolConfig{})
 	require.NoError(ts.T(), err)
-	repository, err := NewPsgsqlRepo(ctx, db)
+	repository, err := NewPsgsqlRepo(ctx, pool)
 	require.NoError(ts.T(), err)
 	ts.psgsqlRepo = *repository
 
@@ -115,3 +179,71 @@ func getDBConnectionString(
 		"host=%s user=postgres password=%s dbname=%s port=%d sslmode=disable",
 		host, password, dbname, portDigit), nil
 }
+
+// Test_psgsqlRepo_Conformance проверяет хранилище общим набором сценариев
+func (ts *PostgresTestSuite) Test_psgsqlRepo_Conformance() {
+	conformance.Run(ts.T(), func(t *testing.T) conformance.Repo {
+		require.NoError(t, ts.cleanTables(context.Background()))
+		return &ts.psgsqlRepo
+	})
+}
+
+// Test_psgsqlRepo_SnapshotConformance проверяет выгрузку и загрузку данных
+func (ts *PostgresTestSuite) Test_psgsqlRepo_SnapshotConformance() {
+	conformance.RunSnapshot(ts.T(), func(t *testing.T) conformance.SnapshotRepo {
+		require.NoError(t, ts.cleanTables(context.Background()))
+		return &ts.psgsqlRepo
+	})
+}
+
+// Test_psgsqlRepo_TxConformance проверяет транзакции
+func (ts *PostgresTestSuite) Test_psgsqlRepo_TxConformance() {
+	conformance.RunTx(ts.T(), func(t *testing.T) conformance.TxRepo {
+		require.NoError(t, ts.cleanTables(context.Background()))
+		return &ts.psgsqlRepo
+	})
+}
+
+// Test_psgsqlRepo_AuthConformance проверяет хранение данных аутентификации
+func (ts *PostgresTestSuite) Test_psgsqlRepo_AuthConformance() {
+	conformance.RunAuth(ts.T(), func(t *testing.T) conformance.AuthRepo {
+		require.NoError(t, ts.cleanTables(context.Background()))
+		return &ts.psgsqlRepo
+	})
+}
+
+// Test_psgsqlRepo_WorkspaceConformance проверяет хранение рабочих пространств
+func (ts *PostgresTestSuite) Test_psgsqlRepo_WorkspaceConformance() {
+	conformance.RunWorkspaces(ts.T(), func(t *testing.T) conformance.WorkspaceRepo {
+		require.NoError(t, ts.cleanTables(context.Background()))
+		return &ts.psgsqlRepo
+	})
+}
+
+// Test_psgsqlRepo_CollisionConformance проверяет подбор ID при коллизиях хешей
+func (ts *PostgresTestSuite) Test_psgsqlRepo_CollisionConformance() {
+	conformance.RunCollisions(ts.T(), func(t *testing.T) conformance.CollisionRepo {
+		require.NoError(t, ts.cleanTables(context.Background()))
+		// хеш подменяется в копии, чтобы не затронуть остальные тесты
+		repository := ts.psgsqlRepo
+		return &repository
+	})
+}
+
+// Test_psgsqlRepo_ReplicaConformance проверяет хранилище с репликой;
+// репликой служит та же БД, поэтому проверяется маршрутизация, а не задержка репликации
+func (ts *PostgresTestSuite) Test_psgsqlRepo_ReplicaConformance() {
+	ctx := context.Background()
+	replica, err := NewReplicaPool(ctx, ts.dsn, PoolConfig{})
+	require.NoError(ts.T(), err)
+	repository, err := NewPsgsqlRepoWithReplicas(ctx, ts.pool, []*pgxpool.Pool{replica}, ReplicaConfig{})
+	require.NoError(ts.T(), err)
+	// основной пул общий с остальными тестами, поэтому закрываем только реплики
+	defer repository.replicas.close()
+	require.NotNil(ts.T(), repository.replicas.pick())
+
+	conformance.Run(ts.T(), func(t *testing.T) conformance.Repo {
+		require.NoError(t, ts.cleanTables(ctx))
+		return repository
+	})
+}
@@ -0,0 +1,104 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateSession сохранит новый сеанс пользователя
+func (s *psgsqlRepo) CreateSession(ctx context.Context, session modelAuth.Session) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO sessions (id, user_id, user_agent, created_at, last_seen_at, revoked_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, session.ID, session.UserID, session.UserAgent, session.CreatedAt, session.LastSeenAt, session.RevokedAt)
+
+	return err
+}
+
+// GetSession вернёт сеанс по его ID
+func (s *psgsqlRepo) GetSession(ctx context.Context, id string) (*modelAuth.Session, error) {
+	session := &modelAuth.Session{ID: id}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT user_id, user_agent, created_at, last_seen_at, revoked_at
+		FROM sessions
+		WHERE id = $1
+	`, id).Scan(&session.UserID, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// GetUserSessions вернёт сеансы пользователя, в том числе отозванные, в порядке начала
+func (s *psgsqlRepo) GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT id, user_agent, created_at, last_seen_at, revoked_at
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY created_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]modelAuth.Session, 0)
+	for rows.Next() {
+		session := modelAuth.Session{UserID: userID}
+		err = rows.Scan(&session.ID, &session.UserAgent, &session.CreatedAt, &session.LastSeenAt, &session.RevokedAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// TouchSession запишет время последнего запроса в сеансе
+func (s *psgsqlRepo) TouchSession(ctx context.Context, id string, seenAt time.Time) error {
+	tag, err := s.querier(ctx).Exec(ctx, `UPDATE sessions SET last_seen_at = $2 WHERE id = $1`, id, seenAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// RevokeSession отзовёт сеанс пользователя; время отзыва уже отозванного сеанса не меняется
+func (s *psgsqlRepo) RevokeSession(ctx context.Context, userID string, id string, revokedAt time.Time) error {
+	tag, err := s.querier(ctx).Exec(ctx, `
+		UPDATE sessions SET revoked_at = COALESCE(revoked_at, $3)
+		WHERE id = $1 AND user_id = $2
+	`, id, userID, revokedAt)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// RevokeUserSessions отзовёт все действующие сеансы пользователя
+func (s *psgsqlRepo) RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		UPDATE sessions SET revoked_at = $2
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID, revokedAt)
+
+	return err
+}
@@ -2,42 +2,54 @@ package psgsqlrepo
 
 import (
 	"context"
+	"errors"
 
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 	reoppsitory "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
 )
 
-// GetURLByID вернёт URL по его ID
+// GetURLByID вернёт URL по его ID; недавно изменённые URL'ы читаются из основной БД
 func (s *psgsqlRepo) GetURLByID(ctx context.Context, id string) (string, error) {
-	type queryResult struct {
-		URL       string `db:"url"`
-		IsDeleted bool   `db:"deleted_flag"`
-	}
-	var res queryResult
-	err := s.conn.GetContext(ctx, &res, "SELECT url, deleted_flag FROM shorten_url WHERE id=$1", id)
+	var url string
+	var isDeleted bool
+	err := s.read(ctx, s.writes.urlWritten(id), func(q querier) error {
+		err := q.QueryRow(ctx, "SELECT url, deleted_flag FROM shorten_url WHERE id=$1", id).Scan(&url, &isDeleted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return reoppsitory.ErrNotFoundKey
+		}
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
-	if res.IsDeleted {
+	if isDeleted {
 		return "", reoppsitory.ErrURLDeleted
 	}
 
-	return res.URL, nil
+	return url, nil
 }
 
-// GetUserURLs вернёт все когда-либо сокращенные URL'ы пользователем
+// GetUserURLs вернёт все когда-либо сокращенные URL'ы пользователем;
+// сразу после записи пользователя они читаются из основной БД
 func (s *psgsqlRepo) GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error) {
 	type GetModel struct {
 		URLID string `db:"url_id"`
 		URL   string `db:"url"`
 	}
-	models := []GetModel{}
-	err := s.conn.Select(&models, `
-	SELECT url_id, url FROM users_shorten_url 
-	LEFT JOIN shorten_url ON shorten_url.id=users_shorten_url.url_id
-	WHERE user_id=$1
-	`, userID)
-
+	var models []GetModel
+	err := s.read(ctx, s.writes.userWrote(userID), func(q querier) error {
+		rows, err := q.Query(ctx, `
+		SELECT url_id, url FROM users_shorten_url 
+		LEFT JOIN shorten_url ON shorten_url.id=users_shorten_url.url_id
+		WHERE user_id=$1
+		`, userID)
+		if err != nil {
+			return err
+		}
+		models, err = pgx.CollectRows(rows, pgx.RowToStructByName[GetModel])
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
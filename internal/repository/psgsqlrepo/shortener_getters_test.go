@@ -3,6 +3,7 @@ package psgsqlrepo
 import (
 	"context"
 
+	reoppsitory "github.com/KartoonYoko/go-url-shortener/internal/repository"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,6 +23,14 @@ func (ts *PostgresTestSuite) Test_psgsqlRepo_GetURLByID() {
 	require.Equal(ts.T(), someURL, checkableURL)
 }
 
+// Test_psgsqlRepo_GetURLByID_NotFound проверяет ошибку при запросе несуществующего URL'a
+func (ts *PostgresTestSuite) Test_psgsqlRepo_GetURLByID_NotFound() {
+	ctx := context.Background()
+
+	_, err := ts.psgsqlRepo.GetURLByID(ctx, "notexists")
+	require.ErrorIs(ts.T(), err, reoppsitory.ErrNotFoundKey)
+}
+
 // Test_psgsqlRepo_GetUserURLs проверяет SQL запрос на получение URL'ов конкретным пользователем
 func (ts *PostgresTestSuite) Test_psgsqlRepo_GetUserURLs() {
 	ctx := context.Background()
@@ -2,279 +2,314 @@ package psgsqlrepo
 
 import (
 	"context"
-	"crypto/sha256"
 	"errors"
-	"strconv"
+	"fmt"
+	"sort"
 
-	"github.com/KartoonYoko/go-url-shortener/internal/logger"
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
-	"github.com/jackc/pgerrcode"
-	"github.com/jackc/pgx/v5/pgconn"
-	"github.com/jmoiron/sqlx"
-	"go.uber.org/zap"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/jackc/pgx/v5"
 )
 
-// сохранит url и вернёт его id'шник
+// SetIDGenerator заменит генератор ID URL'ов
+func (s *psgsqlRepo) SetIDGenerator(g idgen.Generator) {
+	s.idGen = g
+}
+
+// сохранит url и вернёт его id'шник; URL и его владелец сохраняются в одной транзакции,
+// без userID URL сохраняется без владельца. Если ID занят другим URL'ом, подбирает следующий
 func (s *psgsqlRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
-	// сгенерируем уникальный ID для URL'a
-	h := sha256.New()
-	var err error
-	hash, err := repoCommon.GenerateURLUniqueHash(h, url)
+	var hash string
+	exists := false
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		hash, exists, err = s.insertURL(ctx, url)
+		if err != nil {
+			return err
+		}
+
+		return s.insertUserIDAndHash(ctx, userID, hash)
+	})
 	if err != nil {
 		return "", err
 	}
+	s.writes.mark(userID, hash)
+	if exists {
+		return hash, repoCommon.NewURLAlreadyExistsError(hash, url)
+	}
 
-	_, err = s.conn.ExecContext(ctx, "INSERT INTO shorten_url (url, id) VALUES($1, $2)", url, hash)
-	if err != nil {
-		var pgErr *pgconn.PgError
-		// если вставка не удалась по причине, что уже существует такой URL в БД,
-		// то делаем ещё один запрос для определения существующего ID
-		if errors.As(err, &pgErr) && pgerrcode.UniqueViolation == pgErr.Code {
-			row := s.conn.QueryRowContext(ctx, "SELECT id FROM shorten_url WHERE url=$1", url)
-			err = row.Err()
-			if err != nil {
-				return "", err
-			}
-			err = row.Scan(&hash)
-			if err != nil {
-				return "", err
-			}
-
-			err = s.insertUserIDAndHash(ctx, userID, hash)
-			if err != nil {
-				return "", err
-			}
+	return hash, nil
+}
 
-			err = repoCommon.NewURLAlreadyExistsError(hash, url)
-			return hash, err
+// insertURL сохранит URL, подбирая свободный ID; exists сообщает, что URL уже был сохранён
+func (s *psgsqlRepo) insertURL(ctx context.Context, url string) (id string, exists bool, err error) {
+	q := s.querier(ctx)
+	for attempt := 0; attempt < repoCommon.MaxURLIDAttempts; attempt++ {
+		id, err = s.idGen.Generate(ctx, url, attempt)
+		if err != nil {
+			return "", false, err
 		}
 
-		return "", err
-	}
+		// ошибка уникальности прервала бы транзакцию, поэтому конфликт пропускаем
+		tag, err := q.Exec(ctx, "INSERT INTO shorten_url (url, id) VALUES($1, $2) ON CONFLICT DO NOTHING", url, id)
+		if err != nil {
+			return "", false, err
+		}
+		if tag.RowsAffected() == 1 {
+			return id, false, s.notifyChanges(ctx, q, ChangeOpUpdate, "", []string{id})
+		}
 
-	err = s.insertUserIDAndHash(ctx, userID, hash)
-	if err != nil {
-		return "", err
+		// URL уже существует - определим его ID
+		err = q.QueryRow(ctx, "SELECT id FROM shorten_url WHERE url=$1", url).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// ID занят другим URL'ом
+			continue
+		}
+		if err != nil {
+			return "", false, err
+		}
+		return id, true, nil
 	}
 
-	return hash, nil
+	return "", false, repoCommon.ErrURLIDCollision
 }
 
-// SaveURLsBatch выполняет множественную вставку
+// copyBatchThreshold количество уникальных URL'ов в пачке,
+// начиная с которого они загружаются через COPY
+const copyBatchThreshold = 500
+
+// SaveURLsBatch выполняет множественную вставку в одной транзакции;
+// ответ возвращается в порядке запроса
 func (s *psgsqlRepo) SaveURLsBatch(ctx context.Context,
 	batch []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
-	// проверим какие URL'ы существуют;
-	// все существующие URL'ы добавим в словарь,
-	// где ключ - URL, значение - ID;
-	existsURLs, err := s.getMapedExistsURLs(ctx, batch)
-	if err != nil {
-		return nil, err
+	if len(batch) == 0 {
+		return []model.CreateShortenURLBatchItemResponse{}, nil
 	}
 
-	// запомним несуществующие URL'ы
-	notExistsURLs := make([]string, 0, len(batch))
+	// уникальные URL'ы пачки
+	urls := make([]string, 0, len(batch))
+	seen := make(map[string]struct{}, len(batch))
 	for _, v := range batch {
-		if _, ok := existsURLs[v.OriginalURL]; ok {
+		if _, ok := seen[v.OriginalURL]; ok {
 			continue
 		}
-
-		notExistsURLs = append(notExistsURLs, v.OriginalURL)
+		seen[v.OriginalURL] = struct{}{}
+		urls = append(urls, v.OriginalURL)
 	}
 
-	// добавим в БД несуществующие
-	arrOfmapToInsert := []map[string]interface{}{}
-	h := sha256.New()
-	for _, url := range notExistsURLs {
-		// если уже существует - добавлять не нужно
-		if _, ok := existsURLs[url]; ok {
-			continue
-		}
-
-		id, err := repoCommon.GenerateURLUniqueHash(h, url)
+	var idsByURL map[string]string
+	err := s.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		q := s.querier(ctx)
+		idsByURL, err = s.insertBatchURLs(ctx, q, urls)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		arrOfmapToInsert = append(arrOfmapToInsert, map[string]interface{}{
-			"id":  id,
-			"url": url,
-		})
-
-		// запомним сгенерированный url для ответа и чтобы больше не генерировать ID
-		existsURLs[url] = id
-	}
-	if len(arrOfmapToInsert) > 0 {
-		_, err = s.conn.NamedExec(`INSERT INTO shorten_url (id, url) VALUES(:id, :url)`, arrOfmapToInsert)
-		if err != nil {
-			return nil, err
+		ids := make([]string, 0, len(urls))
+		for _, url := range urls {
+			ids = append(ids, idsByURL[url])
 		}
-	}
-
-	// сохраним информацию о пользователе
-	allURLsIDsMap := make(map[string]struct{})
-	for _, urlHash := range existsURLs {
-		allURLsIDsMap[urlHash] = struct{}{}
-	}
-	for _, mapItem := range arrOfmapToInsert {
-		urlHashInterface, ok := mapItem["id"]
-		if !ok {
-			continue
+		if userID == "" {
+			return nil
 		}
-		urlHash, ok := urlHashInterface.(string)
-		if !ok {
-			continue
+		tag, err := q.Exec(ctx, `INSERT INTO users_shorten_url (user_id, url_id)
+		SELECT $1, url_id FROM unnest($2::text[]) AS url_id
+		ON CONFLICT DO NOTHING`, userID, ids)
+		if err != nil {
+			return err
 		}
-		allURLsIDsMap[urlHash] = struct{}{}
-	}
-	allURLsIDsArr := make([]string, 0, len(allURLsIDsMap))
-	for k := range allURLsIDsMap {
-		allURLsIDsArr = append(allURLsIDsArr, k)
-	}
-	err = s.insertUserIDAndHashes(ctx, userID, allURLsIDsArr)
-	if err != nil {
-		return nil, err
-	}
-
-	// соберём ответ
-	response := make([]model.CreateShortenURLBatchItemResponse, 0, len(existsURLs))
-	for url, id := range existsURLs {
-		// найдём все CorrelationID с данным URL'ом
-		сorrelationIDs := []string{}
-		for _, requestItem := range batch {
-			if requestItem.OriginalURL == url {
-				сorrelationIDs = append(сorrelationIDs, requestItem.CorrelationID)
+		if tag.RowsAffected() > 0 {
+			if err = s.notifyChanges(ctx, q, ChangeOpOwner, userID, ids); err != nil {
+				return err
 			}
 		}
 
-		// соберём ответы для каждого сorrelationID
-		for _, сorrelationID := range сorrelationIDs {
-			response = append(response, model.CreateShortenURLBatchItemResponse{
-				ShortURL:      id,
-				CorrelationID: сorrelationID,
-			})
-		}
+		s.writes.mark(userID, ids...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// если количество ответов не совпало с количеством запросов - выдаём ошибку
-	if len(batch) != len(response) {
-		message := "batch request to insert url's returns wrong responses count"
-		responseCountZapStr := zap.String("response count", strconv.Itoa(len(response)))
-		requestCountZapStr := zap.String("request count", strconv.Itoa(len(batch)))
-		logger.Log.Error(
-			message,
-			requestCountZapStr,
-			responseCountZapStr)
-
-		return nil, errors.New(message)
+	response := make([]model.CreateShortenURLBatchItemResponse, 0, len(batch))
+	for _, v := range batch {
+		response = append(response, model.CreateShortenURLBatchItemResponse{
+			CorrelationID: v.CorrelationID,
+			ShortURL:      idsByURL[v.OriginalURL],
+		})
 	}
 
 	return response, nil
 }
 
-// insertUserIDAndHash вставляет запись о пользователе и URL'е в таблицу, если записи нет
-func (s *psgsqlRepo) insertUserIDAndHash(ctx context.Context, userID string, hash string) error {
-	_, err := s.conn.ExecContext(ctx, "INSERT INTO users_shorten_url (user_id, url_id) VALUES($1, $2)", userID, hash)
-	if err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgerrcode.UniqueViolation == pgErr.Code {
-			// если уже существует, то добавлять не нужно
-			return nil
+// batchURL URL пачки и сгенерированный для него ID
+type batchURL struct {
+	id  string
+	url string
+}
+
+// insertBatchURLs сохранит URL'ы, подбирая свободные ID, и вернёт словарь,
+// где ключ - URL, значение - его ID; вызывается внутри транзакции
+func (s *psgsqlRepo) insertBatchURLs(ctx context.Context, q querier, urls []string) (map[string]string, error) {
+	idsByURL := make(map[string]string, len(urls))
+	pending := urls
+	for attempt := 0; len(pending) > 0; attempt++ {
+		if attempt == repoCommon.MaxURLIDAttempts {
+			return nil, fmt.Errorf("can not save url %s: %w", pending[0], repoCommon.ErrURLIDCollision)
 		}
 
-		return err
+		items := make([]batchURL, 0, len(pending))
+		for _, url := range pending {
+			id, err := s.idGen.Generate(ctx, url, attempt)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, batchURL{id: id, url: url})
+		}
+		// вставка в одном порядке не даёт параллельным пачкам взаимно блокироваться
+		sort.Slice(items, func(i, j int) bool { return items[i].id < items[j].id })
+
+		var inserted []string
+		var err error
+		if len(items) < copyBatchThreshold {
+			inserted, err = insertURLs(ctx, q, items)
+		} else {
+			inserted, err = copyURLs(ctx, q, items)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(inserted) > 0 {
+			err = s.notifyChanges(ctx, q, ChangeOpUpdate, "", inserted)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// URL'ы, сохранённые ранее или параллельным запросом, могут иметь другие ID
+		found, err := getIDsByURLs(ctx, q, pending)
+		if err != nil {
+			return nil, err
+		}
+		// URL'ы, ID которых заняты другими URL'ами, получат следующую попытку
+		next := make([]string, 0)
+		for _, url := range pending {
+			id, ok := found[url]
+			if !ok {
+				next = append(next, url)
+				continue
+			}
+			idsByURL[url] = id
+		}
+		pending = next
 	}
 
-	return nil
+	return idsByURL, nil
 }
 
-// insertUserIDAndHashes вставляет записи о пользователе и URL'ах в таблицу, если записей нет
-func (s *psgsqlRepo) insertUserIDAndHashes(ctx context.Context, userID string, hashes []string) error {
-	// получение только тех записей, которые ещё не существуют в БД
-	rows, err := s.conn.QueryContext(ctx, `SELECT url_id FROM users_shorten_url WHERE user_id=$1`, userID)
-	if err != nil {
-		return err
+// insertURLs вставляет URL'ы одним запросом, пропуская существующие;
+// вернёт ID вставленных URL'ов
+func insertURLs(ctx context.Context, q querier, items []batchURL) ([]string, error) {
+	ids := make([]string, 0, len(items))
+	urls := make([]string, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.id)
+		urls = append(urls, item.url)
 	}
-	defer rows.Close()
 
-	existsUserHashes := make(map[string]struct{})
-	for rows.Next() {
-		var urlID string
-		err := rows.Scan(&urlID)
-		if err != nil {
-			return err
-		}
-		existsUserHashes[urlID] = struct{}{}
+	rows, err := q.Query(ctx, `INSERT INTO shorten_url (id, url)
+	SELECT * FROM unnest($1::text[], $2::text[])
+	ON CONFLICT DO NOTHING
+	RETURNING id`, ids, urls)
+	if err != nil {
+		return nil, err
 	}
-	if err := rows.Err(); err != nil {
-		return err
+
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
+
+// copyURLs загружает URL'ы через COPY во временную таблицу
+// и переносит из неё, пропуская существующие; вызывается внутри транзакции.
+// Вернёт ID вставленных URL'ов
+func copyURLs(ctx context.Context, q querier, items []batchURL) ([]string, error) {
+	_, err := q.Exec(ctx, `CREATE TEMPORARY TABLE batch_shorten_url (
+		id VARCHAR,
+		url VARCHAR
+	) ON COMMIT DROP`)
+	if err != nil {
+		return nil, err
 	}
 
-	type insertUserURLModel struct {
-		UserID string `db:"user_id"`
-		URLID  string `db:"url_id"`
+	_, err = q.CopyFrom(ctx,
+		pgx.Identifier{"batch_shorten_url"},
+		[]string{"id", "url"},
+		pgx.CopyFromSlice(len(items), func(i int) ([]interface{}, error) {
+			return []interface{}{items[i].id, items[i].url}, nil
+		}))
+	if err != nil {
+		return nil, err
 	}
-	hashesToInsert := make([]insertUserURLModel, 0, len(hashes))
-	for _, urlID := range hashes {
-		if _, ok := existsUserHashes[urlID]; ok {
-			continue
-		}
 
-		hashesToInsert = append(hashesToInsert, insertUserURLModel{
-			UserID: userID,
-			URLID:  urlID,
-		})
+	rows, err := q.Query(ctx, `INSERT INTO shorten_url (id, url)
+	SELECT id, url FROM batch_shorten_url ORDER BY id
+	ON CONFLICT DO NOTHING
+	RETURNING id`)
+	if err != nil {
+		return nil, err
 	}
-	if len(hashesToInsert) == 0 {
-		return nil
+	inserted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, err
 	}
 
-	_, err = s.conn.NamedExec(`INSERT INTO users_shorten_url (user_id, url_id) 
-		VALUES (:user_id, :url_id)`, hashesToInsert)
-
+	// таблица удаляется сразу: транзакция может сохранить ещё одну пачку
+	_, err = q.Exec(ctx, `DROP TABLE batch_shorten_url`)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return inserted, nil
 }
 
-// getMapedExistsURLs вернёт существующие URL'ы в виде словаря,
+// getIDsByURLs вернёт сохранённые URL'ы в виде словаря,
 // где ключ - URL, значение - ID этого URL'а
-func (s *psgsqlRepo) getMapedExistsURLs(ctx context.Context,
-	batch []model.CreateShortenURLBatchItemRequest) (map[string]string, error) {
-	// подготовим запрос для нахождения всех URL'ов
-	requestURLs := make([]string, 0, len(batch))
-	for _, v := range batch {
-		requestURLs = append(requestURLs, v.OriginalURL)
-	}
-	query, args, err := sqlx.In(`SELECT id, url FROM shorten_url WHERE url IN (?)`, requestURLs)
-	if err != nil {
-		return nil, err
-	}
-	query = s.conn.Rebind(query)
-	rows, err := s.conn.QueryContext(ctx, query, args...)
+func getIDsByURLs(ctx context.Context, q querier, urls []string) (map[string]string, error) {
+	rows, err := q.Query(ctx, `SELECT id, url FROM shorten_url WHERE url = ANY($1)`, urls)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	// все существующие URL'ы добавим в словарь,
-	// где ключ - URL, значение - ID
-	existsURLs := map[string]string{}
+	idsByURL := make(map[string]string, len(urls))
 	for rows.Next() {
 		var id, url string
-		err = rows.Scan(&id, &url)
-		if err != nil {
+		if err = rows.Scan(&id, &url); err != nil {
 			return nil, err
 		}
-
-		existsURLs[url] = id
+		idsByURL[url] = id
 	}
-	err = rows.Err()
-	if err != nil {
+	if err = rows.Err(); err != nil {
 		return nil, err
 	}
 
-	return existsURLs, nil
+	return idsByURL, nil
+}
+
+// insertUserIDAndHash вставляет запись о пользователе и URL'е в таблицу, если записи нет,
+// и рассылает уведомление о новом владельце; без пользователя ничего не делает
+func (s *psgsqlRepo) insertUserIDAndHash(ctx context.Context, userID string, hash string) error {
+	if userID == "" {
+		return nil
+	}
+
+	q := s.querier(ctx)
+	tag, err := q.Exec(ctx, `INSERT INTO users_shorten_url (user_id, url_id) VALUES($1, $2)
+	ON CONFLICT DO NOTHING`, userID, hash)
+	if err != nil || tag.RowsAffected() == 0 {
+		return err
+	}
+
+	return s.notifyChanges(ctx, q, ChangeOpOwner, userID, []string{hash})
 }
@@ -62,3 +62,45 @@ func (ts *PostgresTestSuite) Test_psgsqlRepo_SaveURLsBatch() {
 		}
 	}
 }
+
+// Test_psgsqlRepo_SaveURLsBatch_Copy тестирует вставку большой пачки через COPY
+func (ts *PostgresTestSuite) Test_psgsqlRepo_SaveURLsBatch_Copy() {
+	ctx := context.Background()
+
+	userID, err := ts.psgsqlRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	existingID, err := ts.psgsqlRepo.SaveURL(ctx, "https://copy.example.com/0", userID)
+	require.NoError(ts.T(), err)
+
+	batchLength := copyBatchThreshold + 10
+	batch := make([]model.CreateShortenURLBatchItemRequest, 0, batchLength+1)
+	for i := 0; i < batchLength; i++ {
+		batch = append(batch, model.CreateShortenURLBatchItemRequest{
+			CorrelationID: fmt.Sprintf("%d", i),
+			OriginalURL:   fmt.Sprintf("https://copy.example.com/%d", i),
+		})
+	}
+	batch = append(batch, model.CreateShortenURLBatchItemRequest{
+		CorrelationID: "repeated",
+		OriginalURL:   "https://copy.example.com/1",
+	})
+
+	otherUserID, err := ts.psgsqlRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	response, err := ts.psgsqlRepo.SaveURLsBatch(ctx, batch, otherUserID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), response, len(batch))
+
+	for i, b := range batch {
+		require.Equal(ts.T(), b.CorrelationID, response[i].CorrelationID)
+		gotURL, err := ts.psgsqlRepo.GetURLByID(ctx, response[i].ShortURL)
+		require.NoError(ts.T(), err)
+		require.Equal(ts.T(), b.OriginalURL, gotURL)
+	}
+	require.Equal(ts.T(), existingID, response[0].ShortURL)
+	require.Equal(ts.T(), response[1].ShortURL, response[len(response)-1].ShortURL)
+
+	userURLs, err := ts.psgsqlRepo.GetUserURLs(ctx, otherUserID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), userURLs, batchLength)
+}
@@ -4,44 +4,76 @@ import (
 	"context"
 
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5"
 )
 
 // UpdateURLsDeletedFlag обновит поле deleted_flag согласно модели modelsCh
+// и разошлёт уведомление об удалении
 func (s *psgsqlRepo) UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
-	type argType struct {
-		UserID string   `db:"user_id"`
-		URLIDs []string `db:"url_ids"`
-	}
-	arg := argType{
-		UserID: userID,
-		URLIDs: make([]string, 0),
-	}
+	urlIDs := make([]string, 0)
 	for model := range modelsCh {
-		arg.URLIDs = append(arg.URLIDs, model.URLID)
+		urlIDs = append(urlIDs, model.URLID)
+	}
+	if len(urlIDs) == 0 {
+		return nil
 	}
 
 	query := `
 	UPDATE shorten_url AS su
 	SET deleted_flag = true
 		FROM users_shorten_url AS usu
-	WHERE usu.user_id=:user_id AND
-	su.id IN (:url_ids)`
+	WHERE usu.url_id=su.id AND
+	usu.user_id=$1 AND
+	su.id = ANY($2)
+	RETURNING su.id`
 
-	query, args, err := sqlx.Named(query, arg)
-	if err != nil {
-		return err
+	s.writes.mark(userID, urlIDs...)
+	return s.markURLsDeleted(ctx, query, userID, userID, urlIDs)
+}
+
+// UpdateWorkspaceURLsDeletedFlag пометит удалёнными URL'ы рабочего пространства согласно модели modelsCh
+// и разошлёт уведомление об удалении
+func (s *psgsqlRepo) UpdateWorkspaceURLsDeletedFlag(ctx context.Context,
+	workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	urlIDs := make([]string, 0)
+	for model := range modelsCh {
+		urlIDs = append(urlIDs, model.URLID)
 	}
-	query, args, err = sqlx.In(query, args...)
-	if err != nil {
-		return err
+	if len(urlIDs) == 0 {
+		return nil
 	}
 
-	query = s.conn.Rebind(query)
-	_, err = s.conn.ExecContext(ctx, query, args...)
-	if err != nil {
-		return err
-	}
+	query := `
+	UPDATE shorten_url AS su
+	SET deleted_flag = true
+		FROM workspace_shorten_url AS wsu
+	WHERE wsu.url_id=su.id AND
+	wsu.workspace_id=$1 AND
+	su.id = ANY($2)
+	RETURNING su.id`
+
+	s.writes.mark("", urlIDs...)
+	return s.markURLsDeleted(ctx, query, workspaceID, "", urlIDs)
+}
+
+// markURLsDeleted выполнит запрос query, помечающий удалёнными URL'ы urlIDs владельца ownerID,
+// и разошлёт уведомление об удалении пользователем userID
+func (s *psgsqlRepo) markURLsDeleted(ctx context.Context, query string,
+	ownerID string, userID string, urlIDs []string) error {
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.querier(ctx)
+		rows, err := q.Query(ctx, query, ownerID, urlIDs)
+		if err != nil {
+			return err
+		}
+		deleted, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		if err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
 
-	return nil
+		return s.notifyChanges(ctx, q, ChangeOpDelete, userID, deleted)
+	})
 }
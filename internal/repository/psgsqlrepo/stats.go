@@ -7,26 +7,33 @@ import (
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
 )
 
-// GetStats возвращает статистику
+// GetStats возвращает статистику; читается с реплики, если она есть
 func (s *psgsqlRepo) GetStats(ctx context.Context) (*model.StatsResponse, error) {
-	var count int
-	var query string
-	var err error
 	response := new(model.StatsResponse)
+	err := s.read(ctx, false, func(q querier) error {
+		var count int
+		var query string
+		var err error
 
-	query = `SELECT COUNT(*) FROM shorten_url`
-	err = s.conn.QueryRowContext(ctx, query).Scan(&count)
-	if err != nil {
-		return nil, fmt.Errorf("can not count shorten_url: %w", err)
-	}
-	response.URLs = count
+		query = `SELECT COUNT(*) FROM shorten_url`
+		err = q.QueryRow(ctx, query).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("can not count shorten_url: %w", err)
+		}
+		response.URLs = count
+
+		query = `SELECT COUNT(*) FROM users`
+		err = q.QueryRow(ctx, query).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("can not count users: %w", err)
+		}
+		response.Users = count
 
-	query = `SELECT COUNT(*) FROM users`
-	err = s.conn.QueryRowContext(ctx, query).Scan(&count)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("can not count users: %w", err)
+		return nil, err
 	}
-	response.Users = count
 
 	return response, nil
 }
@@ -0,0 +1,48 @@
+package psgsqlrepo
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// txKey ключ контекста с транзакцией хранилища repo
+type txKey struct {
+	repo *psgsqlRepo
+}
+
+// WithinTx выполнит fn в транзакции БД: запросы хранилища с переданным в fn контекстом
+// идут в этой транзакции; она фиксируется, если fn завершилась без ошибки.
+// Вызов внутри уже начатой транзакции присоединяется к ней
+func (s *psgsqlRepo) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err = fn(context.WithValue(ctx, txKey{repo: s}, tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// inTx сообщит, выполняется ли вызов внутри транзакции
+func (s *psgsqlRepo) inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{repo: s}).(pgx.Tx)
+	return ok
+}
+
+// querier вернёт текущую транзакцию или, вне транзакции, пул соединений
+func (s *psgsqlRepo) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{repo: s}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return s.pool
+}
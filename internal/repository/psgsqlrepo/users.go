@@ -0,0 +1,83 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// GetUser вернёт роль и состояние пользователя
+func (s *psgsqlRepo) GetUser(ctx context.Context, userID string) (*modelAuth.User, error) {
+	user := &modelAuth.User{ID: userID}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT role, disabled FROM users WHERE id = $1
+	`, userID).Scan(&user.Role, &user.Disabled)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListUsers вернёт не больше limit пользователей, следующих за afterID, в порядке возрастания ID
+func (s *psgsqlRepo) ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT id, role, disabled FROM users
+		WHERE id COLLATE "C" > $1
+		ORDER BY id COLLATE "C"
+		LIMIT NULLIF($2, 0)
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]modelAuth.User, 0)
+	for rows.Next() {
+		var user modelAuth.User
+		if err = rows.Scan(&user.ID, &user.Role, &user.Disabled); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// UpdateUser сохранит роль и состояние пользователя
+func (s *psgsqlRepo) UpdateUser(ctx context.Context, user modelAuth.User) error {
+	tag, err := s.querier(ctx).Exec(ctx, `
+		UPDATE users SET role = $2, disabled = $3 WHERE id = $1
+	`, user.ID, user.Role, user.Disabled)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// TakeDownURL пометит URL удалённым независимо от его владельцев
+// и разошлёт уведомление об удалении
+func (s *psgsqlRepo) TakeDownURL(ctx context.Context, id string) error {
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.querier(ctx)
+		tag, err := q.Exec(ctx, `UPDATE shorten_url SET deleted_flag = true WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return repoCommon.ErrNotFoundKey
+		}
+
+		return s.notifyChanges(ctx, q, ChangeOpDelete, "", []string{id})
+	})
+}
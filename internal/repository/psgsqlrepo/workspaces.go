@@ -0,0 +1,202 @@
+package psgsqlrepo
+
+import (
+	"context"
+	"errors"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// CreateWorkspace создаст рабочее пространство вместе с его владельцем
+func (s *psgsqlRepo) CreateWorkspace(ctx context.Context, ws modelWorkspace.Workspace, owner modelWorkspace.Member) error {
+	return s.WithinTx(ctx, func(ctx context.Context) error {
+		q := s.querier(ctx)
+		_, err := q.Exec(ctx, `
+			INSERT INTO workspaces (id, name, created_at) VALUES ($1, $2, $3)
+		`, ws.ID, ws.Name, ws.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		_, err = q.Exec(ctx, `
+			INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		`, ws.ID, owner.UserID, owner.Role)
+		return err
+	})
+}
+
+// GetWorkspace вернёт рабочее пространство по его ID
+func (s *psgsqlRepo) GetWorkspace(ctx context.Context, id string) (*modelWorkspace.Workspace, error) {
+	ws := &modelWorkspace.Workspace{ID: id}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT name, created_at FROM workspaces WHERE id = $1
+	`, id).Scan(&ws.Name, &ws.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// GetUserWorkspaces вернёт пространства пользователя в порядке их создания
+func (s *psgsqlRepo) GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT w.id, w.name, w.created_at, m.role
+		FROM workspace_members m
+		JOIN workspaces w ON w.id = m.workspace_id
+		WHERE m.user_id = $1
+		ORDER BY w.created_at, w.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	memberships := make([]modelWorkspace.Membership, 0)
+	for rows.Next() {
+		var m modelWorkspace.Membership
+		if err = rows.Scan(&m.Workspace.ID, &m.Workspace.Name, &m.Workspace.CreatedAt, &m.Role); err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+// GetWorkspaceMember вернёт участника пространства
+func (s *psgsqlRepo) GetWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*modelWorkspace.Member, error) {
+	member := &modelWorkspace.Member{WorkspaceID: workspaceID, UserID: userID}
+	err := s.querier(ctx).QueryRow(ctx, `
+		SELECT role FROM workspace_members WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceID, userID).Scan(&member.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// GetWorkspaceMembers вернёт участников пространства в порядке возрастания ID пользователей
+func (s *psgsqlRepo) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]modelWorkspace.Member, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT user_id, role FROM workspace_members
+		WHERE workspace_id = $1
+		ORDER BY user_id COLLATE "C"
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]modelWorkspace.Member, 0)
+	for rows.Next() {
+		member := modelWorkspace.Member{WorkspaceID: workspaceID}
+		if err = rows.Scan(&member.UserID, &member.Role); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// GetWorkspaceOwners вернёт ID владельцев пространства в порядке возрастания;
+// внутри транзакции строки владельцев блокируются до её завершения
+func (s *psgsqlRepo) GetWorkspaceOwners(ctx context.Context, workspaceID string) ([]string, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT user_id FROM workspace_members
+		WHERE workspace_id = $1 AND role = $2
+		ORDER BY user_id COLLATE "C"
+		FOR UPDATE
+	`, workspaceID, modelWorkspace.RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	owners := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err = rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		owners = append(owners, userID)
+	}
+
+	return owners, rows.Err()
+}
+
+// SaveWorkspaceMember добавит участника пространства или сменит его роль
+func (s *psgsqlRepo) SaveWorkspaceMember(ctx context.Context, member modelWorkspace.Member) error {
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO workspace_members (workspace_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+	`, member.WorkspaceID, member.UserID, member.Role)
+
+	return err
+}
+
+// DeleteWorkspaceMember удалит участника пространства
+func (s *psgsqlRepo) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	tag, err := s.querier(ctx).Exec(ctx, `
+		DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2
+	`, workspaceID, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// AddWorkspaceURLs свяжет сохранённые URL'ы с рабочим пространством
+func (s *psgsqlRepo) AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := s.querier(ctx).Exec(ctx, `
+		INSERT INTO workspace_shorten_url (workspace_id, url_id)
+		SELECT $1, url_id FROM unnest($2::text[]) AS url_id
+		ON CONFLICT DO NOTHING
+	`, workspaceID, ids)
+
+	return err
+}
+
+// GetWorkspaceURLs вернёт все URL'ы рабочего пространства
+func (s *psgsqlRepo) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error) {
+	rows, err := s.querier(ctx).Query(ctx, `
+		SELECT su.id, su.url
+		FROM workspace_shorten_url wsu
+		JOIN shorten_url su ON su.id = wsu.url_id
+		WHERE wsu.workspace_id = $1
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	response := make([]model.GetUserURLsItemResponse, 0)
+	for rows.Next() {
+		var item model.GetUserURLsItemResponse
+		if err = rows.Scan(&item.ShortURL, &item.OriginalURL); err != nil {
+			return nil, err
+		}
+		response = append(response, item)
+	}
+
+	return response, rows.Err()
+}
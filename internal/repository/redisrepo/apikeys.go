@@ -0,0 +1,171 @@
+package redisrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// apiKeyScopesSeparator разделитель прав в поле scopes
+const apiKeyScopesSeparator = " "
+
+// deleteAPIKeyScript удаляет API-ключ, если он принадлежит пользователю.
+//
+// KEYS[1] - хеш API-ключа, KEYS[2] - множество API-ключей пользователя;
+// ARGV[1] - ID пользователя, ARGV[2] - ID API-ключа.
+// Возвращает 0, если ключ не найден.
+var deleteAPIKeyScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'user_id') ~= ARGV[1] then
+	return 0
+end
+
+redis.call('DEL', KEYS[1])
+redis.call('SREM', KEYS[2], ARGV[2])
+return 1
+`)
+
+// touchAPIKeyScript записывает время использования существующего API-ключа.
+//
+// KEYS[1] - хеш API-ключа; ARGV[1] - время в миллисекундах.
+// Возвращает 0, если ключ не найден.
+var touchAPIKeyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], 'last_used_at', ARGV[1])
+return 1
+`)
+
+// SaveAPIKey сохранит API-ключ
+func (s *redisRepo) SaveAPIKey(ctx context.Context, key modelAuth.APIKey) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keyAPIKey(key.ID),
+			"user_id", key.UserID,
+			"hash", key.Hash,
+			"name", key.Name,
+			"scopes", strings.Join(key.Scopes, apiKeyScopesSeparator),
+			"created_at", key.CreatedAt.UnixMilli(),
+		)
+		pipe.SAdd(ctx, keyUserAPIKeys(key.UserID), key.ID)
+		return nil
+	})
+
+	return err
+}
+
+// GetAPIKey вернёт API-ключ по его ID
+func (s *redisRepo) GetAPIKey(ctx context.Context, id string) (*modelAuth.APIKey, error) {
+	values, err := s.client.HGetAll(ctx, keyAPIKey(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return parseAPIKey(id, values)
+}
+
+// GetUserAPIKeys вернёт API-ключи пользователя в порядке создания
+func (s *redisRepo) GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error) {
+	ids, err := s.client.SMembers(ctx, keyUserAPIKeys(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, keyAPIKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]modelAuth.APIKey, 0, len(ids))
+	for i, id := range ids {
+		values := cmds[i].Val()
+		if len(values) == 0 {
+			continue
+		}
+		key, err := parseAPIKey(id, values)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].ID < keys[j].ID
+		}
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys, nil
+}
+
+// DeleteAPIKey удалит API-ключ пользователя
+func (s *redisRepo) DeleteAPIKey(ctx context.Context, userID string, id string) error {
+	deleted, err := deleteAPIKeyScript.Run(ctx, s.client,
+		[]string{keyAPIKey(id), keyUserAPIKeys(userID)},
+		userID, id,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// TouchAPIKey запишет время последнего использования API-ключа
+func (s *redisRepo) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	touched, err := touchAPIKeyScript.Run(ctx, s.client, []string{keyAPIKey(id)}, usedAt.UnixMilli()).Int()
+	if err != nil {
+		return err
+	}
+	if touched == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// parseAPIKey соберёт API-ключ из полей его хеша
+func parseAPIKey(id string, values map[string]string) (*modelAuth.APIKey, error) {
+	createdAt, err := strconv.ParseInt(values["created_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse api key creation time: %w", err)
+	}
+
+	key := &modelAuth.APIKey{
+		ID:        id,
+		UserID:    values["user_id"],
+		Hash:      values["hash"],
+		Name:      values["name"],
+		Scopes:    strings.Fields(values["scopes"]),
+		CreatedAt: time.UnixMilli(createdAt),
+	}
+	if v, ok := values["last_used_at"]; ok {
+		lastUsedAt, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse api key last use time: %w", err)
+		}
+		usedAt := time.UnixMilli(lastUsedAt)
+		key.LastUsedAt = &usedAt
+	}
+
+	return key, nil
+}
@@ -0,0 +1,145 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetNewUserID создаст нового пользователя и вернёт его ID
+func (s *redisRepo) GetNewUserID(ctx context.Context) (string, error) {
+	id := uuid.New().String()
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, keyUsers, redis.Z{Member: id})
+		pipe.Incr(ctx, keyStatsUsers)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// SaveRefreshToken сохранит токен обновления; Redis удалит его по истечении
+func (s *redisRepo) SaveRefreshToken(ctx context.Context, token modelAuth.RefreshToken) error {
+	key := keyRefreshToken(token.Hash)
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, key,
+			"user_id", token.UserID,
+			"session_id", token.SessionID,
+			"expires_at", token.ExpiresAt.UnixMilli(),
+		)
+		pipe.PExpireAt(ctx, key, token.ExpiresAt)
+		return nil
+	})
+
+	return err
+}
+
+// ConsumeRefreshToken удалит токен обновления и вернёт его; токен можно использовать только один раз
+func (s *redisRepo) ConsumeRefreshToken(ctx context.Context, hash string) (*modelAuth.RefreshToken, error) {
+	key := keyRefreshToken(hash)
+
+	var fields *redis.MapStringStringCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		fields = pipe.HGetAll(ctx, key)
+		pipe.Del(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := fields.Val()
+	if len(values) == 0 {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	expiresAt, err := strconv.ParseInt(values["expires_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse refresh token expiration: %w", err)
+	}
+
+	return &modelAuth.RefreshToken{
+		Hash:      hash,
+		UserID:    values["user_id"],
+		SessionID: values["session_id"],
+		ExpiresAt: time.UnixMilli(expiresAt),
+	}, nil
+}
+
+// createAccountScript атомарно сохраняет учётную запись и её адрес почты в индексе.
+//
+// KEYS[1] - индекс учётных записей, KEYS[2] - хеш учётной записи;
+// ARGV[1] - ID пользователя, ARGV[2] - адрес почты, ARGV[3] - хеш пароля,
+// ARGV[4] - время регистрации в миллисекундах.
+// Возвращает 0, если почта занята или учётная запись уже есть.
+var createAccountScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[2]) == 1 or redis.call('EXISTS', KEYS[2]) == 1 then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], ARGV[2], ARGV[1])
+redis.call('HSET', KEYS[2], 'email', ARGV[2], 'password_hash', ARGV[3], 'created_at', ARGV[4])
+return 1
+`)
+
+// CreateAccount сохранит учётную запись пользователя
+func (s *redisRepo) CreateAccount(ctx context.Context, account modelAuth.Account) error {
+	created, err := createAccountScript.Run(ctx, s.client,
+		[]string{keyAccountsByEmail, keyAccount(account.UserID)},
+		account.UserID, account.Email, account.PasswordHash, account.CreatedAt.UnixMilli(),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if created == 0 {
+		return repoCommon.ErrAccountExists
+	}
+
+	return nil
+}
+
+// GetAccountByEmail вернёт учётную запись по адресу почты
+func (s *redisRepo) GetAccountByEmail(ctx context.Context, email string) (*modelAuth.Account, error) {
+	userID, err := s.client.HGet(ctx, keyAccountsByEmail, email).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := s.client.HGetAll(ctx, keyAccount(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return parseAccount(userID, values)
+}
+
+// parseAccount соберёт учётную запись из полей её хеша
+func parseAccount(userID string, values map[string]string) (*modelAuth.Account, error) {
+	createdAt, err := strconv.ParseInt(values["created_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse account creation time: %w", err)
+	}
+
+	return &modelAuth.Account{
+		UserID:       userID,
+		Email:        values["email"],
+		PasswordHash: values["password_hash"],
+		CreatedAt:    time.UnixMilli(createdAt),
+	}, nil
+}
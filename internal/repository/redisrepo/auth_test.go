@@ -0,0 +1,18 @@
+package redisrepo
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_redisRepo_GetNewUserID проверяет создание нового пользователя
+func (ts *RedisTestSuite) Test_redisRepo_GetNewUserID() {
+	ctx := context.Background()
+
+	userID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+
+	_, err = ts.redisRepo.client.ZScore(ctx, keyUsers, userID).Result()
+	require.NoError(ts.T(), err)
+}
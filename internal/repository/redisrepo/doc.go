@@ -0,0 +1,4 @@
+/*
+Package redisrepo это реализация хранилища в Redis.
+*/
+package redisrepo
@@ -0,0 +1,871 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// scanCount подсказка Redis о количестве ключей, просматриваемых за один вызов SCAN
+const scanCount = 1000
+
+// importUsersScript добавляет пользователей, увеличивает счётчик на число новых
+// и сохраняет известные роли и состояния.
+//
+// KEYS[1] - множество ID пользователей, KEYS[2] - счётчик пользователей,
+// KEYS[3..n] - хеши пользователей;
+// ARGV - тройки ID пользователя, роль (пустая строка - роль неизвестна), флаг отключения.
+var importUsersScript = redis.NewScript(`
+local added = 0
+for i = 3, #KEYS do
+	local n = (i - 3) * 3
+	added = added + redis.call('ZADD', KEYS[1], 0, ARGV[n + 1])
+	if ARGV[n + 2] ~= '' then
+		redis.call('HSET', KEYS[i], 'role', ARGV[n + 2], 'disabled', ARGV[n + 3])
+	end
+end
+redis.call('INCRBY', KEYS[2], added)
+return added
+`)
+
+// importURLScript сохраняет URL с заранее известным ID либо дополняет существующий.
+//
+// KEYS[1] - хеш URL'а, KEYS[2] - индекс URL'ов, KEYS[3] - множество ID URL'ов,
+// KEYS[4] - счётчик URL'ов, KEYS[5] - множество пользователей URL'а,
+// KEYS[6] - множество ID пользователей, KEYS[7] - счётчик пользователей,
+// KEYS[8..n] - множества URL'ов владельцев;
+// ARGV[1] - ID, ARGV[2] - URL, ARGV[3] - флаг удаления, ARGV[4..n-4] - ID владельцев.
+// Возвращает 1, если URL противоречит сохранённым данным.
+var importURLScript = redis.NewScript(`
+local current = redis.call('HGET', KEYS[1], 'url')
+if current and current ~= ARGV[2] then
+	return 1
+end
+local indexed = redis.call('HGET', KEYS[2], ARGV[2])
+if indexed and indexed ~= ARGV[1] then
+	return 1
+end
+
+if not current then
+	redis.call('HSET', KEYS[1], 'url', ARGV[2], 'deleted', '0')
+	redis.call('HSET', KEYS[2], ARGV[2], ARGV[1])
+	redis.call('ZADD', KEYS[3], 0, ARGV[1])
+	redis.call('INCR', KEYS[4])
+end
+if ARGV[3] == '1' then
+	redis.call('HSET', KEYS[1], 'deleted', '1')
+end
+
+for i = 8, #KEYS do
+	local userID = ARGV[i - 4]
+	redis.call('SADD', KEYS[i], ARGV[1])
+	redis.call('SADD', KEYS[5], userID)
+	redis.call('INCRBY', KEYS[7], redis.call('ZADD', KEYS[6], 0, userID))
+end
+return 0
+`)
+
+// importAccountScript сохраняет учётную запись вместе с пользователем;
+// уже сохранённая учётная запись не меняется.
+//
+// KEYS[1] - индекс учётных записей, KEYS[2] - хеш учётной записи,
+// KEYS[3] - множество ID пользователей, KEYS[4] - счётчик пользователей;
+// ARGV[1] - ID пользователя, ARGV[2] - адрес почты, ARGV[3] - хеш пароля,
+// ARGV[4] - время регистрации в миллисекундах.
+// Возвращает 1, если у пользователя другая почта или почта занята другим пользователем.
+var importAccountScript = redis.NewScript(`
+local email = redis.call('HGET', KEYS[2], 'email')
+if email and email ~= ARGV[2] then
+	return 1
+end
+local owner = redis.call('HGET', KEYS[1], ARGV[2])
+if owner and owner ~= ARGV[1] then
+	return 1
+end
+
+redis.call('INCRBY', KEYS[4], redis.call('ZADD', KEYS[3], 0, ARGV[1]))
+if not email then
+	redis.call('HSET', KEYS[1], ARGV[2], ARGV[1])
+	redis.call('HSET', KEYS[2], 'email', ARGV[2], 'password_hash', ARGV[3], 'created_at', ARGV[4])
+end
+return 0
+`)
+
+// importIdentityScript сохраняет удостоверение вместе с пользователем;
+// уже сохранённое удостоверение не меняется.
+//
+// KEYS[1] - хеш удостоверения, KEYS[2] - множество ID пользователей, KEYS[3] - счётчик пользователей;
+// ARGV[1] - ID пользователя, ARGV[2] - адрес почты, ARGV[3] - время первого входа в миллисекундах.
+// Возвращает 1, если удостоверение привязано к другому пользователю.
+var importIdentityScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'user_id')
+if owner and owner ~= ARGV[1] then
+	return 1
+end
+
+redis.call('INCRBY', KEYS[3], redis.call('ZADD', KEYS[2], 0, ARGV[1]))
+if not owner then
+	redis.call('HSET', KEYS[1], 'user_id', ARGV[1], 'email', ARGV[2], 'created_at', ARGV[3])
+end
+return 0
+`)
+
+// importAPIKeyScript сохраняет API-ключ вместе с пользователем; уже сохранённый ключ не меняется.
+//
+// KEYS[1] - хеш API-ключа, KEYS[2] - множество API-ключей пользователя,
+// KEYS[3] - множество ID пользователей, KEYS[4] - счётчик пользователей;
+// ARGV[1] - ID ключа, ARGV[2] - ID пользователя, ARGV[3] - хеш, ARGV[4] - название,
+// ARGV[5] - права, ARGV[6] - время создания, ARGV[7] - время последнего использования
+// в миллисекундах или пустая строка.
+// Возвращает 1, если ключ принадлежит другому пользователю.
+var importAPIKeyScript = redis.NewScript(`
+local owner = redis.call('HGET', KEYS[1], 'user_id')
+if owner and owner ~= ARGV[2] then
+	return 1
+end
+
+redis.call('INCRBY', KEYS[4], redis.call('ZADD', KEYS[3], 0, ARGV[2]))
+if not owner then
+	redis.call('HSET', KEYS[1], 'user_id', ARGV[2], 'hash', ARGV[3], 'name', ARGV[4],
+		'scopes', ARGV[5], 'created_at', ARGV[6])
+	if ARGV[7] ~= '' then
+		redis.call('HSET', KEYS[1], 'last_used_at', ARGV[7])
+	end
+	redis.call('SADD', KEYS[2], ARGV[1])
+end
+return 0
+`)
+
+// importWorkspaceScript сохраняет рабочее пространство либо дополняет существующее:
+// роли перечисленных участников заменяются, ссылки добавляются.
+//
+// KEYS[1] - хеш пространства, KEYS[2] - хеш участников, KEYS[3] - множество ссылок пространства,
+// KEYS[4] - множество ID пользователей, KEYS[5] - счётчик пользователей,
+// KEYS[6..5+m] - множества пространств участников, KEYS[6+m..n] - хеши ссылок;
+// ARGV[1] - ID пространства, ARGV[2] - название, ARGV[3] - время создания в миллисекундах,
+// ARGV[4] - количество участников m, далее m пар ID пользователя и роль, далее ID ссылок.
+// Возвращает 1, если хотя бы одна ссылка не найдена.
+var importWorkspaceScript = redis.NewScript(`
+local m = tonumber(ARGV[4])
+for i = 6 + m, #KEYS do
+	if redis.call('EXISTS', KEYS[i]) == 0 then
+		return 1
+	end
+end
+
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	redis.call('HSET', KEYS[1], 'name', ARGV[2], 'created_at', ARGV[3])
+end
+for i = 1, m do
+	local userID = ARGV[3 + 2 * i]
+	redis.call('HSET', KEYS[2], userID, ARGV[4 + 2 * i])
+	redis.call('SADD', KEYS[5 + i], ARGV[1])
+	redis.call('INCRBY', KEYS[5], redis.call('ZADD', KEYS[4], 0, userID))
+end
+for i = 5 + 2 * m, #ARGV do
+	redis.call('SADD', KEYS[3], ARGV[i])
+end
+return 0
+`)
+
+// exportScript атомарно читает все данные хранилища.
+//
+// KEYS[1] - множество ID пользователей, KEYS[2] - множество ID URL'ов, KEYS[3] - индекс учётных записей;
+// ARGV[1..9] - префиксы ключей хешей URL'ов, множеств пользователей URL'а, хешей пользователей,
+// учётных записей, удостоверений, API-ключей, пространств, участников и ссылок пространств.
+// Возвращает списки пользователей {ID, {роль, флаг отключения}}, URL'ов {ID, URL, флаг удаления, {владельцы}},
+// учётных записей {ID пользователя, поля хеша}, удостоверений и API-ключей {окончание ключа, поля хеша}
+// и пространств {ID, поля хеша, поля хеша участников, {ссылки}}.
+var exportScript = redis.NewScript(`
+local function suffixes(prefix)
+	local result = {}
+	for _, key in ipairs(redis.call('KEYS', prefix .. '*')) do
+		result[#result + 1] = string.sub(key, #prefix + 1)
+	end
+	return result
+end
+
+local users = {}
+for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	users[#users + 1] = {id, redis.call('HMGET', ARGV[3] .. id, 'role', 'disabled')}
+end
+local urls = {}
+for _, id in ipairs(redis.call('ZRANGE', KEYS[2], 0, -1)) do
+	local data = redis.call('HMGET', ARGV[1] .. id, 'url', 'deleted')
+	if data[1] then
+		urls[#urls + 1] = {id, data[1], data[2] or '0', redis.call('SMEMBERS', ARGV[2] .. id)}
+	end
+end
+local accounts = {}
+for _, userID in ipairs(redis.call('HVALS', KEYS[3])) do
+	accounts[#accounts + 1] = {userID, redis.call('HGETALL', ARGV[4] .. userID)}
+end
+local identities = {}
+for _, key in ipairs(suffixes(ARGV[5])) do
+	identities[#identities + 1] = {key, redis.call('HGETALL', ARGV[5] .. key)}
+end
+local apiKeys = {}
+for _, id in ipairs(suffixes(ARGV[6])) do
+	apiKeys[#apiKeys + 1] = {id, redis.call('HGETALL', ARGV[6] .. id)}
+end
+local workspaces = {}
+for _, id in ipairs(suffixes(ARGV[7])) do
+	workspaces[#workspaces + 1] = {id, redis.call('HGETALL', ARGV[7] .. id),
+		redis.call('HGETALL', ARGV[8] .. id), redis.call('SMEMBERS', ARGV[9] .. id)}
+end
+return {users, urls, accounts, identities, apiKeys, workspaces}
+`)
+
+// GetUsersPage вернёт не больше limit пользователей, следующих за afterID,
+// в порядке возрастания ID
+func (s *redisRepo) GetUsersPage(ctx context.Context, afterID string, limit int) ([]snapshot.User, error) {
+	users, err := s.ListUsers(ctx, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]snapshot.User, 0, len(users))
+	for _, u := range users {
+		response = append(response, snapshot.User{ID: u.ID, Role: u.Role, Disabled: u.Disabled})
+	}
+
+	return response, nil
+}
+
+// GetURLsPage вернёт не больше limit URL'ов, следующих за afterID,
+// в порядке возрастания ID
+func (s *redisRepo) GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error) {
+	ids, err := s.client.ZRangeByLex(ctx, keyURLIDs, lexRange(afterID, limit)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	dataCmds := make([]*redis.SliceCmd, 0, len(ids))
+	usersCmds := make([]*redis.StringSliceCmd, 0, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			dataCmds = append(dataCmds, pipe.HMGet(ctx, keyURL(id), "url", "deleted"))
+			usersCmds = append(usersCmds, pipe.SMembers(ctx, keyURLUsers(id)))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	response := make([]snapshot.URL, 0, len(ids))
+	for i, id := range ids {
+		data, err := dataCmds[i].Result()
+		if err != nil {
+			return nil, err
+		}
+		url, ok := data[0].(string)
+		if !ok {
+			continue
+		}
+		deleted, _ := data[1].(string)
+
+		userIDs, err := usersCmds[i].Result()
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(userIDs)
+
+		response = append(response, snapshot.URL{
+			ID:          id,
+			OriginalURL: url,
+			Deleted:     deleted == "1",
+			UserIDs:     userIDs,
+		})
+	}
+
+	return response, nil
+}
+
+// GetAccountsPage вернёт не больше limit учётных записей, следующих за учётной записью
+// пользователя afterUserID, в порядке возрастания ID пользователя.
+// Учётные записи перечисляются по индексу почты, поэтому каждый вызов читает весь индекс
+func (s *redisRepo) GetAccountsPage(ctx context.Context, afterUserID string, limit int) ([]snapshot.Account, error) {
+	userIDs, err := s.client.HVals(ctx, keyAccountsByEmail).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	page := pageOf(userIDs, afterUserID, limit)
+	cmds := make([]*redis.MapStringStringCmd, len(page))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, userID := range page {
+			cmds[i] = pipe.HGetAll(ctx, keyAccount(userID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]snapshot.Account, 0, len(page))
+	for i, userID := range page {
+		if len(cmds[i].Val()) == 0 {
+			continue
+		}
+		account, err := parseAccount(userID, cmds[i].Val())
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, snapshotAccount(account))
+	}
+
+	return response, nil
+}
+
+// GetIdentitiesPage вернёт не больше limit удостоверений, следующих за afterKey,
+// в порядке возрастания snapshot.Identity.Key.
+// Удостоверения перечисляются через SCAN, поэтому каждый вызов просматривает все ключи хранилища
+func (s *redisRepo) GetIdentitiesPage(ctx context.Context, afterKey string, limit int) ([]snapshot.Identity, error) {
+	keys, err := s.scanSuffixes(ctx, keyIdentityPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	page := pageOf(keys, afterKey, limit)
+	cmds := make([]*redis.MapStringStringCmd, len(page))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range page {
+			cmds[i] = pipe.HGetAll(ctx, keyIdentityPrefix+key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]snapshot.Identity, 0, len(page))
+	for i, key := range page {
+		if len(cmds[i].Val()) == 0 {
+			continue
+		}
+		identity, err := parseIdentityKey(key, cmds[i].Val())
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, identity)
+	}
+
+	return response, nil
+}
+
+// GetAPIKeysPage вернёт не больше limit API-ключей, следующих за afterID,
+// в порядке возрастания ID.
+// Ключи перечисляются через SCAN, поэтому каждый вызов просматривает все ключи хранилища
+func (s *redisRepo) GetAPIKeysPage(ctx context.Context, afterID string, limit int) ([]snapshot.APIKey, error) {
+	ids, err := s.scanSuffixes(ctx, keyAPIKey(""))
+	if err != nil {
+		return nil, err
+	}
+
+	page := pageOf(ids, afterID, limit)
+	cmds := make([]*redis.MapStringStringCmd, len(page))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range page {
+			cmds[i] = pipe.HGetAll(ctx, keyAPIKey(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]snapshot.APIKey, 0, len(page))
+	for i, id := range page {
+		if len(cmds[i].Val()) == 0 {
+			continue
+		}
+		key, err := parseAPIKey(id, cmds[i].Val())
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, snapshotAPIKey(key))
+	}
+
+	return response, nil
+}
+
+// GetWorkspacesPage вернёт не больше limit рабочих пространств, следующих за afterID,
+// в порядке возрастания ID.
+// Пространства перечисляются через SCAN, поэтому каждый вызов просматривает все ключи хранилища
+func (s *redisRepo) GetWorkspacesPage(ctx context.Context, afterID string, limit int) ([]snapshot.Workspace, error) {
+	ids, err := s.scanSuffixes(ctx, keyWorkspace(""))
+	if err != nil {
+		return nil, err
+	}
+
+	page := pageOf(ids, afterID, limit)
+	wsCmds := make([]*redis.MapStringStringCmd, len(page))
+	membersCmds := make([]*redis.MapStringStringCmd, len(page))
+	urlsCmds := make([]*redis.StringSliceCmd, len(page))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range page {
+			wsCmds[i] = pipe.HGetAll(ctx, keyWorkspace(id))
+			membersCmds[i] = pipe.HGetAll(ctx, keyWorkspaceMembers(id))
+			urlsCmds[i] = pipe.SMembers(ctx, keyWorkspaceURLs(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]snapshot.Workspace, 0, len(page))
+	for i, id := range page {
+		if len(wsCmds[i].Val()) == 0 {
+			continue
+		}
+		ws, err := snapshotWorkspace(id, wsCmds[i].Val(), membersCmds[i].Val(), urlsCmds[i].Val())
+		if err != nil {
+			return nil, err
+		}
+		response = append(response, ws)
+	}
+
+	return response, nil
+}
+
+// Export выгрузит согласованный снимок всех данных в порядке возрастания ID.
+// Данные читаются одним скриптом, который на время чтения блокирует Redis
+func (s *redisRepo) Export(ctx context.Context, w snapshot.Writer) error {
+	res, err := exportScript.Run(ctx, s.client,
+		[]string{keyUsers, keyURLIDs, keyAccountsByEmail},
+		keyURL(""), keyURLUsersPrefix, keyUser(""), keyAccount(""), keyIdentityPrefix, keyAPIKey(""),
+		keyWorkspace(""), keyWorkspaceMembers(""), keyWorkspaceURLs(""),
+	).Slice()
+	if err != nil {
+		return err
+	}
+	if len(res) != 6 {
+		return fmt.Errorf("redis repo: unexpected export script result length: %d", len(res))
+	}
+
+	for _, v := range exportedItems(res[0]) {
+		if len(v) != 2 {
+			return fmt.Errorf("redis repo: unexpected exported user: %v", v)
+		}
+		id, _ := v[0].(string)
+		fields, _ := v[1].([]interface{})
+		if len(fields) != 2 {
+			return fmt.Errorf("redis repo: unexpected exported user: %v", v)
+		}
+		user := parseUser(id, fields)
+		if err = w.WriteUser(snapshot.User{ID: id, Role: user.Role, Disabled: user.Disabled}); err != nil {
+			return err
+		}
+	}
+
+	for _, v := range exportedItems(res[1]) {
+		if len(v) != 4 {
+			return fmt.Errorf("redis repo: unexpected exported url: %v", v)
+		}
+		id, _ := v[0].(string)
+		url, _ := v[1].(string)
+		deleted, _ := v[2].(string)
+
+		err = w.WriteURL(snapshot.URL{
+			ID:          id,
+			OriginalURL: url,
+			Deleted:     deleted == "1",
+			UserIDs:     sortedStrings(v[3]),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	accounts := make([]snapshot.Account, 0)
+	for _, v := range exportedItems(res[2]) {
+		userID, values, err := exportedHash(v)
+		if err != nil {
+			return err
+		}
+		account, err := parseAccount(userID, values)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, snapshotAccount(account))
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].UserID < accounts[j].UserID })
+	for _, a := range accounts {
+		if err = w.WriteAccount(a); err != nil {
+			return err
+		}
+	}
+
+	identities := make([]snapshot.Identity, 0)
+	for _, v := range exportedItems(res[3]) {
+		key, values, err := exportedHash(v)
+		if err != nil {
+			return err
+		}
+		identity, err := parseIdentityKey(key, values)
+		if err != nil {
+			return err
+		}
+		identities = append(identities, identity)
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].Key() < identities[j].Key() })
+	for _, i := range identities {
+		if err = w.WriteIdentity(i); err != nil {
+			return err
+		}
+	}
+
+	apiKeys := make([]snapshot.APIKey, 0)
+	for _, v := range exportedItems(res[4]) {
+		id, values, err := exportedHash(v)
+		if err != nil {
+			return err
+		}
+		key, err := parseAPIKey(id, values)
+		if err != nil {
+			return err
+		}
+		apiKeys = append(apiKeys, snapshotAPIKey(key))
+	}
+	sort.Slice(apiKeys, func(i, j int) bool { return apiKeys[i].ID < apiKeys[j].ID })
+	for _, k := range apiKeys {
+		if err = w.WriteAPIKey(k); err != nil {
+			return err
+		}
+	}
+
+	workspaces := make([]snapshot.Workspace, 0)
+	for _, v := range exportedItems(res[5]) {
+		if len(v) != 4 {
+			return fmt.Errorf("redis repo: unexpected exported workspace: %v", v)
+		}
+		id, _ := v[0].(string)
+		ws, err := snapshotWorkspace(id, hashFields(v[1]), hashFields(v[2]), sortedStrings(v[3]))
+		if err != nil {
+			return err
+		}
+		workspaces = append(workspaces, ws)
+	}
+	sort.Slice(workspaces, func(i, j int) bool { return workspaces[i].ID < workspaces[j].ID })
+	for _, ws := range workspaces {
+		if err = w.WriteWorkspace(ws); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ImportUsers сохранит пользователей с заранее известными ID;
+// у существующих пользователей заменит роль и состояние, если они известны
+func (s *redisRepo) ImportUsers(ctx context.Context, users []snapshot.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	keys := []string{keyUsers, keyStatsUsers}
+	args := make([]interface{}, 0, 3*len(users))
+	for _, u := range users {
+		keys = append(keys, keyUser(u.ID))
+		args = append(args, u.ID, u.Role, boolToFlag(u.Disabled))
+	}
+
+	return importUsersScript.Run(ctx, s.client, keys, args...).Err()
+}
+
+// ImportURLs сохранит URL'ы с заранее известными ID;
+// у существующих URL'ов дополнит владельцев и флаг удаления
+func (s *redisRepo) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	for _, u := range urls {
+		keys := []string{
+			keyURL(u.ID), keyURLsIndex, keyURLIDs, keyStatsURLs,
+			keyURLUsers(u.ID), keyUsers, keyStatsUsers,
+		}
+		args := []interface{}{u.ID, u.OriginalURL, boolToFlag(u.Deleted)}
+		for _, userID := range u.UserIDs {
+			keys = append(keys, keyUserURLs(userID))
+			args = append(args, userID)
+		}
+
+		code, err := importURLScript.Run(ctx, s.client, keys, args...).Int()
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%w: id %s", repoCommon.ErrImportConflict, u.ID)
+		}
+	}
+
+	return nil
+}
+
+// ImportAccounts сохранит учётные записи вместе с их пользователями;
+// уже сохранённые учётные записи пропускаются
+func (s *redisRepo) ImportAccounts(ctx context.Context, accounts []snapshot.Account) error {
+	for _, a := range accounts {
+		code, err := importAccountScript.Run(ctx, s.client,
+			[]string{keyAccountsByEmail, keyAccount(a.UserID), keyUsers, keyStatsUsers},
+			a.UserID, a.Email, a.PasswordHash, a.CreatedAt.UnixMilli(),
+		).Int()
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%w: account of user %s", repoCommon.ErrImportConflict, a.UserID)
+		}
+	}
+
+	return nil
+}
+
+// ImportIdentities сохранит удостоверения вместе с их пользователями;
+// уже сохранённые удостоверения пропускаются
+func (s *redisRepo) ImportIdentities(ctx context.Context, identities []snapshot.Identity) error {
+	for _, i := range identities {
+		code, err := importIdentityScript.Run(ctx, s.client,
+			[]string{keyIdentity(i.Issuer, i.Subject), keyUsers, keyStatsUsers},
+			i.UserID, i.Email, i.CreatedAt.UnixMilli(),
+		).Int()
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%w: identity %s", repoCommon.ErrImportConflict, i.Key())
+		}
+	}
+
+	return nil
+}
+
+// ImportAPIKeys сохранит API-ключи вместе с их пользователями; уже сохранённые ключи пропускаются
+func (s *redisRepo) ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error {
+	for _, k := range keys {
+		lastUsedAt := ""
+		if k.LastUsedAt != nil {
+			lastUsedAt = strconv.FormatInt(k.LastUsedAt.UnixMilli(), 10)
+		}
+
+		code, err := importAPIKeyScript.Run(ctx, s.client,
+			[]string{keyAPIKey(k.ID), keyUserAPIKeys(k.UserID), keyUsers, keyStatsUsers},
+			k.ID, k.UserID, k.Hash, k.Name, strings.Join(k.Scopes, apiKeyScopesSeparator),
+			k.CreatedAt.UnixMilli(), lastUsedAt,
+		).Int()
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%w: api key %s", repoCommon.ErrImportConflict, k.ID)
+		}
+	}
+
+	return nil
+}
+
+// ImportWorkspaces сохранит рабочие пространства; у существующих пространств
+// заменит роли перечисленных участников и дополнит ссылки
+func (s *redisRepo) ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error {
+	for _, ws := range workspaces {
+		keys := []string{
+			keyWorkspace(ws.ID), keyWorkspaceMembers(ws.ID), keyWorkspaceURLs(ws.ID), keyUsers, keyStatsUsers,
+		}
+		args := []interface{}{ws.ID, ws.Name, ws.CreatedAt.UnixMilli(), len(ws.Members)}
+		for _, m := range ws.Members {
+			keys = append(keys, keyUserWorkspaces(m.UserID))
+			args = append(args, m.UserID, m.Role)
+		}
+		for _, urlID := range ws.URLIDs {
+			keys = append(keys, keyURL(urlID))
+			args = append(args, urlID)
+		}
+
+		code, err := importWorkspaceScript.Run(ctx, s.client, keys, args...).Int()
+		if err != nil {
+			return err
+		}
+		if code != 0 {
+			return fmt.Errorf("%w: url of workspace %s not found", repoCommon.ErrImportConflict, ws.ID)
+		}
+	}
+
+	return nil
+}
+
+// scanSuffixes вернёт окончания всех ключей с префиксом prefix
+func (s *redisRepo) scanSuffixes(ctx context.Context, prefix string) ([]string, error) {
+	// SCAN может вернуть один ключ несколько раз
+	seen := make(map[string]struct{})
+	iter := s.client.Scan(ctx, 0, prefix+"*", scanCount).Iterator()
+	for iter.Next(ctx) {
+		seen[strings.TrimPrefix(iter.Val(), prefix)] = struct{}{}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+
+	suffixes := make([]string, 0, len(seen))
+	for suffix := range seen {
+		suffixes = append(suffixes, suffix)
+	}
+	return suffixes, nil
+}
+
+// exportedItems список записей из результата скрипта выгрузки
+func exportedItems(v interface{}) [][]interface{} {
+	list, _ := v.([]interface{})
+	items := make([][]interface{}, 0, len(list))
+	for _, item := range list {
+		fields, _ := item.([]interface{})
+		items = append(items, fields)
+	}
+	return items
+}
+
+// exportedHash разберёт запись вида {ключ, поля HGETALL} из результата скрипта выгрузки
+func exportedHash(item []interface{}) (string, map[string]string, error) {
+	if len(item) != 2 {
+		return "", nil, fmt.Errorf("redis repo: unexpected exported item: %v", item)
+	}
+	key, _ := item[0].(string)
+	return key, hashFields(item[1]), nil
+}
+
+// hashFields поля хеша из ответа HGETALL внутри скрипта
+func hashFields(v interface{}) map[string]string {
+	fields, _ := v.([]interface{})
+	values := make(map[string]string, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		values[field] = value
+	}
+	return values
+}
+
+// sortedStrings упорядоченный список строк из результата скрипта
+func sortedStrings(v interface{}) []string {
+	list, _ := v.([]interface{})
+	result := make([]string, 0, len(list))
+	for _, item := range list {
+		s, _ := item.(string)
+		result = append(result, s)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// parseIdentityKey соберёт удостоверение снимка из окончания ключа и полей его хеша
+func parseIdentityKey(key string, values map[string]string) (snapshot.Identity, error) {
+	issuer, subject, ok := strings.Cut(key, "#")
+	if !ok {
+		return snapshot.Identity{}, fmt.Errorf("redis repo: unexpected identity key %q", key)
+	}
+	identity, err := parseIdentity(issuer, subject, values)
+	if err != nil {
+		return snapshot.Identity{}, err
+	}
+
+	return snapshot.Identity{
+		Issuer:    identity.Issuer,
+		Subject:   identity.Subject,
+		UserID:    identity.UserID,
+		Email:     identity.Email,
+		CreatedAt: identity.CreatedAt.UTC(),
+	}, nil
+}
+
+// snapshotAccount учётная запись снимка
+func snapshotAccount(a *modelAuth.Account) snapshot.Account {
+	return snapshot.Account{
+		UserID:       a.UserID,
+		Email:        a.Email,
+		PasswordHash: a.PasswordHash,
+		CreatedAt:    a.CreatedAt.UTC(),
+	}
+}
+
+// snapshotAPIKey API-ключ снимка
+func snapshotAPIKey(k *modelAuth.APIKey) snapshot.APIKey {
+	key := snapshot.APIKey{
+		ID:        k.ID,
+		UserID:    k.UserID,
+		Hash:      k.Hash,
+		Name:      k.Name,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.UTC(),
+	}
+	if k.LastUsedAt != nil {
+		usedAt := k.LastUsedAt.UTC()
+		key.LastUsedAt = &usedAt
+	}
+	return key
+}
+
+// snapshotWorkspace соберёт пространство снимка из хеша пространства, хеша участников и ID ссылок
+func snapshotWorkspace(id string, values map[string]string, roles map[string]string, urlIDs []string) (snapshot.Workspace, error) {
+	ws, err := parseWorkspace(id, values)
+	if err != nil {
+		return snapshot.Workspace{}, err
+	}
+
+	members := make([]snapshot.Member, 0, len(roles))
+	for userID, role := range roles {
+		members = append(members, snapshot.Member{UserID: userID, Role: role})
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	sort.Strings(urlIDs)
+
+	return snapshot.Workspace{
+		ID:        ws.ID,
+		Name:      ws.Name,
+		CreatedAt: ws.CreatedAt.UTC(),
+		Members:   members,
+		URLIDs:    urlIDs,
+	}, nil
+}
+
+// lexRange диапазон для ZRANGEBYLEX, начинающийся после afterID
+func lexRange(afterID string, limit int) *redis.ZRangeBy {
+	opt := &redis.ZRangeBy{
+		Min:   "-",
+		Max:   "+",
+		Count: int64(limit),
+	}
+	if afterID != "" {
+		opt.Min = "(" + afterID
+	}
+	return opt
+}
+
+// pageOf отсортирует ids и вернёт не больше limit элементов, следующих за afterID
+func pageOf(ids []string, afterID string, limit int) []string {
+	sort.Strings(ids)
+	start := sort.SearchStrings(ids, afterID)
+	if start < len(ids) && ids[start] == afterID {
+		start++
+	}
+
+	end := len(ids)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	return ids[start:end]
+}
+
+// boolToFlag представление флага в хеше URL'а
+func boolToFlag(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,71 @@
+package redisrepo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// createIdentityScript атомарно сохраняет удостоверение, если его ещё нет.
+//
+// KEYS[1] - хеш удостоверения; ARGV[1] - ID пользователя, ARGV[2] - адрес почты,
+// ARGV[3] - время первого входа в миллисекундах.
+// Возвращает 0, если удостоверение уже привязано к пользователю.
+var createIdentityScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], 'user_id', ARGV[1], 'email', ARGV[2], 'created_at', ARGV[3])
+return 1
+`)
+
+// CreateIdentity привяжет удостоверение издателя к пользователю
+func (s *redisRepo) CreateIdentity(ctx context.Context, identity modelAuth.Identity) error {
+	created, err := createIdentityScript.Run(ctx, s.client,
+		[]string{keyIdentity(identity.Issuer, identity.Subject)},
+		identity.UserID, identity.Email, identity.CreatedAt.UnixMilli(),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if created == 0 {
+		return repoCommon.ErrIdentityExists
+	}
+
+	return nil
+}
+
+// GetIdentity вернёт удостоверение по издателю и ID пользователя у издателя
+func (s *redisRepo) GetIdentity(ctx context.Context, issuer string, subject string) (*modelAuth.Identity, error) {
+	values, err := s.client.HGetAll(ctx, keyIdentity(issuer, subject)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return parseIdentity(issuer, subject, values)
+}
+
+// parseIdentity соберёт удостоверение из полей его хеша
+func parseIdentity(issuer string, subject string, values map[string]string) (*modelAuth.Identity, error) {
+	createdAt, err := strconv.ParseInt(values["created_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse identity creation time: %w", err)
+	}
+
+	return &modelAuth.Identity{
+		Issuer:    issuer,
+		Subject:   subject,
+		UserID:    values["user_id"],
+		Email:     values["email"],
+		CreatedAt: time.UnixMilli(createdAt),
+	}, nil
+}
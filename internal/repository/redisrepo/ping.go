@@ -0,0 +1,8 @@
+package redisrepo
+
+import "context"
+
+// Ping реализует Pinger
+func (s *redisRepo) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
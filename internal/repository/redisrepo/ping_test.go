@@ -0,0 +1,15 @@
+package redisrepo
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_redisRepo_Ping тестирует пинг
+func (ts *RedisTestSuite) Test_redisRepo_Ping() {
+	ctx := context.Background()
+
+	err := ts.redisRepo.Ping(ctx)
+	require.NoError(ts.T(), err)
+}
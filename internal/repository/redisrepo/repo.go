@@ -0,0 +1,140 @@
+package redisrepo
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix префикс всех ключей хранилища
+const keyPrefix = "shortener:"
+
+// ключи хранилища
+const (
+	keyURLsIndex  = keyPrefix + "urls_index"  // хеш: оригинальный URL -> ID
+	keyURLIDs     = keyPrefix + "url_ids"     // упорядоченное множество ID URL'ов
+	keyUsers      = keyPrefix + "users"       // упорядоченное множество ID пользователей
+	keyStatsURLs  = keyPrefix + "stats:urls"  // счётчик сокращённых URL'ов
+	keyStatsUsers = keyPrefix + "stats:users" // счётчик пользователей
+
+	keyAccountsByEmail = keyPrefix + "accounts_by_email" // хеш: адрес почты -> ID пользователя
+)
+
+// keyURL ключ хеша с данными URL'а: поля url и deleted
+func keyURL(id string) string {
+	return keyPrefix + "url:" + id
+}
+
+// keyURLUsersPrefix префикс ключей множеств пользователей URL'а
+const keyURLUsersPrefix = keyPrefix + "url_users:"
+
+// keyURLUsers ключ множества ID пользователей, сокращавших URL
+func keyURLUsers(id string) string {
+	return keyURLUsersPrefix + id
+}
+
+// keyUserURLs ключ множества ID URL'ов пользователя
+func keyUserURLs(userID string) string {
+	return keyPrefix + "user_urls:" + userID
+}
+
+// keyRefreshToken ключ хеша с данными токена обновления: поля user_id и expires_at
+func keyRefreshToken(hash string) string {
+	return keyPrefix + "refresh_token:" + hash
+}
+
+// keyAccount ключ хеша с учётной записью пользователя: поля email, password_hash и created_at
+func keyAccount(userID string) string {
+	return keyPrefix + "account:" + userID
+}
+
+// keyIdentityPrefix префикс ключей хешей удостоверений
+const keyIdentityPrefix = keyPrefix + "identity:"
+
+// keyIdentity ключ хеша с удостоверением OpenID Connect: поля user_id, email и created_at.
+// Издатель не может содержать фрагмент, поэтому '#' однозначно отделяет его от ID пользователя у издателя
+func keyIdentity(issuer string, subject string) string {
+	return keyIdentityPrefix + issuer + "#" + subject
+}
+
+// keyAPIKey ключ хеша с API-ключом: поля user_id, hash, name, scopes, created_at и last_used_at
+func keyAPIKey(id string) string {
+	return keyPrefix + "api_key:" + id
+}
+
+// keyUserAPIKeys ключ множества ID API-ключей пользователя
+func keyUserAPIKeys(userID string) string {
+	return keyPrefix + "user_api_keys:" + userID
+}
+
+// keyUser ключ хеша с ролью и состоянием пользователя: поля role и disabled;
+// пока роль не менялась, хеша нет
+func keyUser(userID string) string {
+	return keyPrefix + "user:" + userID
+}
+
+// keyWorkspace ключ хеша с рабочим пространством: поля name и created_at
+func keyWorkspace(id string) string {
+	return keyPrefix + "workspace:" + id
+}
+
+// keyWorkspaceMembers ключ хеша участников пространства: ID пользователя -> роль
+func keyWorkspaceMembers(id string) string {
+	return keyPrefix + "workspace_members:" + id
+}
+
+// keyWorkspaceURLs ключ множества ID URL'ов рабочего пространства
+func keyWorkspaceURLs(id string) string {
+	return keyPrefix + "workspace_urls:" + id
+}
+
+// keyUserWorkspaces ключ множества ID пространств, в которых состоит пользователь
+func keyUserWorkspaces(userID string) string {
+	return keyPrefix + "user_workspaces:" + userID
+}
+
+// keySession ключ хеша с сеансом: поля user_id, user_agent, created_at, last_seen_at и revoked_at
+func keySession(id string) string {
+	return keyPrefix + "session:" + id
+}
+
+// keyUserSessions ключ множества ID сеансов пользователя
+func keyUserSessions(userID string) string {
+	return keyPrefix + "user_sessions:" + userID
+}
+
+type redisRepo struct {
+	client *redis.Client
+	// генератор ID URL'ов
+	idGen idgen.Generator
+}
+
+// NewRedisRepo инициализирует хранилище для работы с Redis;
+// redisURL - строка подключения вида redis://<user>:<password>@<host>:<port>/<db>
+func NewRedisRepo(ctx context.Context, redisURL string) (*redisRepo, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse redis url: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err = client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("can not connect to redis: %w", err)
+	}
+
+	repo := &redisRepo{
+		client: client,
+		idGen:  idgen.NewHash(sha256.New),
+	}
+
+	return repo, nil
+}
+
+// Close релизует Closer
+func (s *redisRepo) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,128 @@
+package redisrepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/conformance"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type RedisTestSuite struct {
+	suite.Suite
+	redisRepo
+
+	mr *miniredis.Miniredis
+}
+
+// SetupSuite запускает in-process Redis и инициализирует RedisTestSuite
+func (ts *RedisTestSuite) SetupSuite() {
+	ctx := context.Background()
+
+	mr, err := miniredis.Run()
+	require.NoError(ts.T(), err)
+	ts.mr = mr
+
+	repository, err := NewRedisRepo(ctx, "redis://"+mr.Addr())
+	require.NoError(ts.T(), err)
+	ts.redisRepo = *repository
+}
+
+// TearDownSuite останавливает Redis
+func (ts *RedisTestSuite) TearDownSuite() {
+	require.NoError(ts.T(), ts.redisRepo.Close())
+	ts.mr.Close()
+}
+
+// SetupTest очищает хранилище
+func (ts *RedisTestSuite) SetupTest() {
+	ts.mr.FlushAll()
+}
+
+// TestRedisRepository входная точка для тестирования
+func TestRedisRepository(t *testing.T) {
+	suite.Run(t, new(RedisTestSuite))
+}
+
+// Test_NewRedisRepo_WrongURL проверяет ошибку при неверной строке подключения
+func Test_NewRedisRepo_WrongURL(t *testing.T) {
+	_, err := NewRedisRepo(context.Background(), "not a redis url")
+	require.Error(t, err)
+}
+
+// TestConformance проверяет хранилище общим набором сценариев
+func TestConformance(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	conformance.Run(t, func(t *testing.T) conformance.Repo {
+		mr.FlushAll()
+		repository, err := NewRedisRepo(context.Background(), "redis://"+mr.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repository.Close()
+		})
+		return repository
+	})
+}
+
+// TestSnapshotConformance проверяет выгрузку и загрузку данных
+func TestSnapshotConformance(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	conformance.RunSnapshot(t, func(t *testing.T) conformance.SnapshotRepo {
+		mr.FlushAll()
+		repository, err := NewRedisRepo(context.Background(), "redis://"+mr.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repository.Close()
+		})
+		return repository
+	})
+}
+
+// TestAuthConformance проверяет хранение данных аутентификации
+func TestAuthConformance(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	conformance.RunAuth(t, func(t *testing.T) conformance.AuthRepo {
+		mr.FlushAll()
+		repository, err := NewRedisRepo(context.Background(), "redis://"+mr.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repository.Close()
+		})
+		return repository
+	})
+}
+
+// TestWorkspaceConformance проверяет хранение рабочих пространств
+func TestWorkspaceConformance(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	conformance.RunWorkspaces(t, func(t *testing.T) conformance.WorkspaceRepo {
+		mr.FlushAll()
+		repository, err := NewRedisRepo(context.Background(), "redis://"+mr.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repository.Close()
+		})
+		return repository
+	})
+}
+
+// TestCollisionConformance проверяет подбор ID при коллизиях хешей
+func TestCollisionConformance(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	conformance.RunCollisions(t, func(t *testing.T) conformance.CollisionRepo {
+		mr.FlushAll()
+		repository, err := NewRedisRepo(context.Background(), "redis://"+mr.Addr())
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			repository.Close()
+		})
+		return repository
+	})
+}
@@ -0,0 +1,189 @@
+package redisrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// touchSessionScript записывает время запроса в существующем сеансе.
+//
+// KEYS[1] - хеш сеанса; ARGV[1] - время в миллисекундах.
+// Возвращает 0, если сеанс не найден.
+var touchSessionScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], 'last_seen_at', ARGV[1])
+return 1
+`)
+
+// revokeSessionScript отзывает сеанс, если он принадлежит пользователю;
+// время отзыва уже отозванного сеанса не меняется.
+//
+// KEYS[1] - хеш сеанса; ARGV[1] - ID пользователя, ARGV[2] - время отзыва в миллисекундах.
+// Возвращает 0, если сеанс не найден.
+var revokeSessionScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'user_id') ~= ARGV[1] then
+	return 0
+end
+
+redis.call('HSETNX', KEYS[1], 'revoked_at', ARGV[2])
+return 1
+`)
+
+// CreateSession сохранит новый сеанс пользователя
+func (s *redisRepo) CreateSession(ctx context.Context, session modelAuth.Session) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		key := keySession(session.ID)
+		pipe.HSet(ctx, key,
+			"user_id", session.UserID,
+			"user_agent", session.UserAgent,
+			"created_at", session.CreatedAt.UnixMilli(),
+			"last_seen_at", session.LastSeenAt.UnixMilli(),
+		)
+		if session.RevokedAt != nil {
+			pipe.HSet(ctx, key, "revoked_at", session.RevokedAt.UnixMilli())
+		}
+		pipe.SAdd(ctx, keyUserSessions(session.UserID), session.ID)
+		return nil
+	})
+
+	return err
+}
+
+// GetSession вернёт сеанс по его ID
+func (s *redisRepo) GetSession(ctx context.Context, id string) (*modelAuth.Session, error) {
+	values, err := s.client.HGetAll(ctx, keySession(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return parseSession(id, values)
+}
+
+// GetUserSessions вернёт сеансы пользователя, в том числе отозванные, в порядке начала
+func (s *redisRepo) GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error) {
+	ids, err := s.client.SMembers(ctx, keyUserSessions(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.MapStringStringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HGetAll(ctx, keySession(id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]modelAuth.Session, 0, len(ids))
+	for i, id := range ids {
+		values := cmds[i].Val()
+		if len(values) == 0 {
+			continue
+		}
+		session, err := parseSession(id, values)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].CreatedAt.Equal(sessions[j].CreatedAt) {
+			return sessions[i].ID < sessions[j].ID
+		}
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+
+	return sessions, nil
+}
+
+// TouchSession запишет время последнего запроса в сеансе
+func (s *redisRepo) TouchSession(ctx context.Context, id string, seenAt time.Time) error {
+	touched, err := touchSessionScript.Run(ctx, s.client, []string{keySession(id)}, seenAt.UnixMilli()).Int()
+	if err != nil {
+		return err
+	}
+	if touched == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// RevokeSession отзовёт сеанс пользователя; время отзыва уже отозванного сеанса не меняется
+func (s *redisRepo) RevokeSession(ctx context.Context, userID string, id string, revokedAt time.Time) error {
+	revoked, err := revokeSessionScript.Run(ctx, s.client,
+		[]string{keySession(id)},
+		userID, revokedAt.UnixMilli(),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if revoked == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// RevokeUserSessions отзовёт все действующие сеансы пользователя
+func (s *redisRepo) RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error {
+	ids, err := s.client.SMembers(ctx, keyUserSessions(userID)).Result()
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			revokeSessionScript.Eval(ctx, pipe, []string{keySession(id)}, userID, revokedAt.UnixMilli())
+		}
+		return nil
+	})
+
+	return err
+}
+
+// parseSession соберёт сеанс из полей его хеша
+func parseSession(id string, values map[string]string) (*modelAuth.Session, error) {
+	createdAt, err := strconv.ParseInt(values["created_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse session creation time: %w", err)
+	}
+	lastSeenAt, err := strconv.ParseInt(values["last_seen_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse session last seen time: %w", err)
+	}
+
+	session := &modelAuth.Session{
+		ID:         id,
+		UserID:     values["user_id"],
+		UserAgent:  values["user_agent"],
+		CreatedAt:  time.UnixMilli(createdAt),
+		LastSeenAt: time.UnixMilli(lastSeenAt),
+	}
+	if v, ok := values["revoked_at"]; ok {
+		revokedAt, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("can not parse session revocation time: %w", err)
+		}
+		t := time.UnixMilli(revokedAt)
+		session.RevokedAt = &t
+	}
+
+	return session, nil
+}
@@ -0,0 +1,75 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetURLByID вернёт URL по его ID
+func (s *redisRepo) GetURLByID(ctx context.Context, id string) (string, error) {
+	res, err := s.client.HMGet(ctx, keyURL(id), "url", "deleted").Result()
+	if err != nil {
+		return "", err
+	}
+
+	url, ok := res[0].(string)
+	if !ok {
+		return "", repoCommon.ErrNotFoundKey
+	}
+	if deleted, _ := res[1].(string); deleted == "1" {
+		return "", repoCommon.ErrURLDeleted
+	}
+
+	return url, nil
+}
+
+// GetUserURLs вернёт все когда-либо сокращенные URL'ы пользователем
+func (s *redisRepo) GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error) {
+	return s.getURLs(ctx, keyUserURLs(userID))
+}
+
+// GetWorkspaceURLs вернёт все URL'ы рабочего пространства
+func (s *redisRepo) GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error) {
+	return s.getURLs(ctx, keyWorkspaceURLs(workspaceID))
+}
+
+// getURLs вернёт URL'ы, ID которых входят во множество ownerKey
+func (s *redisRepo) getURLs(ctx context.Context, ownerKey string) ([]model.GetUserURLsItemResponse, error) {
+	ids, err := s.client.SMembers(ctx, ownerKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.StringCmd, 0, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, id := range ids {
+			cmds = append(cmds, pipe.HGet(ctx, keyURL(id), "url"))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	response := make([]model.GetUserURLsItemResponse, 0, len(ids))
+	for i, cmd := range cmds {
+		url, err := cmd.Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			return nil, err
+		}
+
+		response = append(response, model.GetUserURLsItemResponse{
+			ShortURL:    ids[i],
+			OriginalURL: url,
+		})
+	}
+
+	return response, nil
+}
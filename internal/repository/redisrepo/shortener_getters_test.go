@@ -0,0 +1,47 @@
+package redisrepo
+
+import (
+	"context"
+
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_redisRepo_GetURLByID_NotFound проверяет получение несуществующего URL'а
+func (ts *RedisTestSuite) Test_redisRepo_GetURLByID_NotFound() {
+	ctx := context.Background()
+
+	_, err := ts.redisRepo.GetURLByID(ctx, "notexists")
+	require.ErrorIs(ts.T(), err, repoCommon.ErrNotFoundKey)
+}
+
+// Test_redisRepo_GetUserURLs проверяет получение URL'ов конкретного пользователя
+func (ts *RedisTestSuite) Test_redisRepo_GetUserURLs() {
+	ctx := context.Background()
+
+	urls := []string{
+		"https://someurl.example.com",
+		"https://someurl.example1.com",
+		"https://someurl.example2.com",
+	}
+	userID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	otherUserID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+
+	m := map[string]string{}
+	for _, u := range urls {
+		urlID, err := ts.redisRepo.SaveURL(ctx, u, userID)
+		require.NoError(ts.T(), err)
+		m[u] = urlID
+	}
+	_, err = ts.redisRepo.SaveURL(ctx, "https://other.example.com", otherUserID)
+	require.NoError(ts.T(), err)
+
+	res, err := ts.redisRepo.GetUserURLs(ctx, userID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), res, len(urls))
+	for _, v := range res {
+		require.Equal(ts.T(), m[v.OriginalURL], v.ShortURL)
+	}
+}
@@ -0,0 +1,114 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/KartoonYoko/go-url-shortener/internal/repository/idgen"
+	"github.com/redis/go-redis/v9"
+)
+
+// результаты скрипта сохранения URL'а
+const (
+	saveResultCreated int64 = iota // URL сохранён
+	saveResultExists               // URL уже существует
+	saveResultIDTaken              // ID занят другим URL'ом
+)
+
+// saveURLScript атомарно сохраняет URL и связывает его с пользователем.
+//
+// KEYS[1] - хеш URL'а, KEYS[2] - индекс URL'ов, KEYS[3] - множество URL'ов пользователя,
+// KEYS[4] - счётчик URL'ов, KEYS[5] - множество ID URL'ов;
+// ARGV[1] - ID, ARGV[2] - URL, ARGV[3] - ID пользователя,
+// ARGV[4] - префикс ключей множеств пользователей URL'а.
+// Возвращает код результата и ID URL'а.
+var saveURLScript = redis.NewScript(`
+local existing = redis.call('HGET', KEYS[2], ARGV[2])
+if existing then
+	if ARGV[3] ~= '' then
+		redis.call('SADD', KEYS[3], existing)
+		redis.call('SADD', ARGV[4] .. existing, ARGV[3])
+	end
+	return {1, existing}
+end
+
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return {2, ARGV[1]}
+end
+
+redis.call('HSET', KEYS[1], 'url', ARGV[2], 'deleted', '0')
+redis.call('HSET', KEYS[2], ARGV[2], ARGV[1])
+redis.call('ZADD', KEYS[5], 0, ARGV[1])
+if ARGV[3] ~= '' then
+	redis.call('SADD', KEYS[3], ARGV[1])
+	redis.call('SADD', ARGV[4] .. ARGV[1], ARGV[3])
+end
+redis.call('INCR', KEYS[4])
+return {0, ARGV[1]}
+`)
+
+// SetIDGenerator заменит генератор ID URL'ов
+func (s *redisRepo) SetIDGenerator(g idgen.Generator) {
+	s.idGen = g
+}
+
+// SaveURL сохранит url и вернёт его id'шник;
+// если ID занят другим URL'ом, подбирает следующий
+func (s *redisRepo) SaveURL(ctx context.Context, url string, userID string) (string, error) {
+	for attempt := 0; attempt < repoCommon.MaxURLIDAttempts; attempt++ {
+		hash, err := s.idGen.Generate(ctx, url, attempt)
+		if err != nil {
+			return "", err
+		}
+
+		keys := []string{keyURL(hash), keyURLsIndex, keyUserURLs(userID), keyStatsURLs, keyURLIDs}
+		res, err := saveURLScript.Run(ctx, s.client, keys, hash, url, userID, keyURLUsersPrefix).Slice()
+		if err != nil {
+			return "", err
+		}
+		if len(res) != 2 {
+			return "", fmt.Errorf("redis repo: unexpected save script result: %v", res)
+		}
+		code, _ := res[0].(int64)
+		id, _ := res[1].(string)
+
+		switch code {
+		case saveResultCreated:
+			return id, nil
+		case saveResultExists:
+			return id, repoCommon.NewURLAlreadyExistsError(id, url)
+		case saveResultIDTaken:
+			continue
+		default:
+			return "", fmt.Errorf("redis repo: unexpected save script code: %d", code)
+		}
+	}
+
+	return "", repoCommon.ErrURLIDCollision
+}
+
+// SaveURLsBatch сохранит множество URL'ов; ответ соответствует порядку запроса
+func (s *redisRepo) SaveURLsBatch(ctx context.Context,
+	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
+	response := make([]model.CreateShortenURLBatchItemResponse, 0, len(request))
+	for _, v := range request {
+		id, err := s.SaveURL(ctx, v.OriginalURL, userID)
+		if err != nil {
+			var errAlreadyExists *repoCommon.URLAlreadyExistsError
+			if !errors.As(err, &errAlreadyExists) {
+				return nil, err
+			}
+			id = errAlreadyExists.ID
+		}
+
+		response = append(response, model.CreateShortenURLBatchItemResponse{
+			CorrelationID: v.CorrelationID,
+			ShortURL:      id,
+		})
+	}
+
+	return response, nil
+}
@@ -0,0 +1,81 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_redisRepo_SaveURL проверяет сохранение URL'а
+func (ts *RedisTestSuite) Test_redisRepo_SaveURL() {
+	ctx := context.Background()
+
+	someURL := "https://someurl.example.com"
+	userID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	urlID, err := ts.redisRepo.SaveURL(ctx, someURL, userID)
+	require.NoError(ts.T(), err)
+
+	gotURL, err := ts.redisRepo.GetURLByID(ctx, urlID)
+	require.NoError(ts.T(), err)
+	require.Equal(ts.T(), someURL, gotURL)
+}
+
+// Test_redisRepo_SaveURL_AlreadyExists проверяет повторное сохранение URL'а другим пользователем
+func (ts *RedisTestSuite) Test_redisRepo_SaveURL_AlreadyExists() {
+	ctx := context.Background()
+
+	someURL := "https://someurl.example.com"
+	firstUserID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	secondUserID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+
+	urlID, err := ts.redisRepo.SaveURL(ctx, someURL, firstUserID)
+	require.NoError(ts.T(), err)
+
+	_, err = ts.redisRepo.SaveURL(ctx, someURL, secondUserID)
+	var errAlreadyExists *repoCommon.URLAlreadyExistsError
+	require.True(ts.T(), errors.As(err, &errAlreadyExists))
+	require.Equal(ts.T(), urlID, errAlreadyExists.ID)
+
+	// повторно сокративший пользователь тоже видит URL в своём списке
+	userURLs, err := ts.redisRepo.GetUserURLs(ctx, secondUserID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), userURLs, 1)
+	require.Equal(ts.T(), urlID, userURLs[0].ShortURL)
+}
+
+// Test_redisRepo_SaveURLsBatch проверяет сохранение множества URL'ов
+func (ts *RedisTestSuite) Test_redisRepo_SaveURLsBatch() {
+	ctx := context.Background()
+
+	batchLength := 10
+	batch := make([]model.CreateShortenURLBatchItemRequest, 0, batchLength)
+	for i := 0; i < batchLength; i++ {
+		batch = append(batch, model.CreateShortenURLBatchItemRequest{
+			CorrelationID: fmt.Sprintf("%d", i+1),
+			OriginalURL:   fmt.Sprintf("https://someurl%d.example.com", i+1),
+		})
+	}
+	// повторяющийся URL должен получить тот же ID
+	batch = append(batch, model.CreateShortenURLBatchItemRequest{
+		CorrelationID: "repeated",
+		OriginalURL:   batch[0].OriginalURL,
+	})
+
+	userID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	response, err := ts.redisRepo.SaveURLsBatch(ctx, batch, userID)
+	require.NoError(ts.T(), err)
+	require.Len(ts.T(), response, len(batch))
+
+	for i, b := range batch {
+		require.Equal(ts.T(), b.CorrelationID, response[i].CorrelationID)
+	}
+	require.Equal(ts.T(), response[0].ShortURL, response[len(response)-1].ShortURL)
+}
@@ -0,0 +1,73 @@
+package redisrepo
+
+import (
+	"context"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// deleteURLsScript помечает удалёнными только те URL'ы, которые принадлежат владельцу.
+//
+// KEYS[1] - множество URL'ов пользователя или рабочего пространства, KEYS[2..n] - хеши URL'ов;
+// ARGV[1..n-1] - ID URL'ов в том же порядке.
+var deleteURLsScript = redis.NewScript(`
+for i, id in ipairs(ARGV) do
+	if redis.call('SISMEMBER', KEYS[1], id) == 1 then
+		redis.call('HSET', KEYS[i + 1], 'deleted', '1')
+	end
+end
+return 0
+`)
+
+// UpdateURLsDeletedFlag пометит удалёнными URL'ы пользователя согласно модели modelsCh
+func (s *redisRepo) UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	return s.markURLsDeleted(ctx, keyUserURLs(userID), modelsCh)
+}
+
+// UpdateWorkspaceURLsDeletedFlag пометит удалёнными URL'ы рабочего пространства согласно модели modelsCh
+func (s *redisRepo) UpdateWorkspaceURLsDeletedFlag(ctx context.Context,
+	workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	return s.markURLsDeleted(ctx, keyWorkspaceURLs(workspaceID), modelsCh)
+}
+
+// markURLsDeleted пометит удалёнными URL'ы из modelsCh, входящие во множество ownerKey
+func (s *redisRepo) markURLsDeleted(ctx context.Context, ownerKey string, modelsCh <-chan model.UpdateURLDeletedFlag) error {
+	keys := []string{ownerKey}
+	args := make([]interface{}, 0)
+	for m := range modelsCh {
+		keys = append(keys, keyURL(m.URLID))
+		args = append(args, m.URLID)
+	}
+	if len(args) == 0 {
+		return nil
+	}
+
+	return deleteURLsScript.Run(ctx, s.client, keys, args...).Err()
+}
+
+// takeDownURLScript помечает удалённым существующий URL.
+//
+// KEYS[1] - хеш URL'а. Возвращает 0, если URL не найден.
+var takeDownURLScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+
+redis.call('HSET', KEYS[1], 'deleted', '1')
+return 1
+`)
+
+// TakeDownURL пометит URL удалённым независимо от его владельцев
+func (s *redisRepo) TakeDownURL(ctx context.Context, id string) error {
+	found, err := takeDownURLScript.Run(ctx, s.client, []string{keyURL(id)}).Int()
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
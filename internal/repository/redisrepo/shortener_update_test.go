@@ -0,0 +1,38 @@
+package redisrepo
+
+import (
+	"context"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/stretchr/testify/require"
+)
+
+// Test_redisRepo_UpdateURLsDeletedFlag проверяет, что пользователь может удалить только свои URL'ы
+func (ts *RedisTestSuite) Test_redisRepo_UpdateURLsDeletedFlag() {
+	ctx := context.Background()
+
+	userID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	otherUserID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+
+	ownURLID, err := ts.redisRepo.SaveURL(ctx, "https://own.example.com", userID)
+	require.NoError(ts.T(), err)
+	otherURLID, err := ts.redisRepo.SaveURL(ctx, "https://other.example.com", otherUserID)
+	require.NoError(ts.T(), err)
+
+	modelsCh := make(chan model.UpdateURLDeletedFlag, 2)
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: ownURLID}
+	modelsCh <- model.UpdateURLDeletedFlag{URLID: otherURLID}
+	close(modelsCh)
+
+	err = ts.redisRepo.UpdateURLsDeletedFlag(ctx, userID, modelsCh)
+	require.NoError(ts.T(), err)
+
+	_, err = ts.redisRepo.GetURLByID(ctx, ownURLID)
+	require.ErrorIs(ts.T(), err, repoCommon.ErrURLDeleted)
+
+	_, err = ts.redisRepo.GetURLByID(ctx, otherURLID)
+	require.NoError(ts.T(), err)
+}
@@ -0,0 +1,38 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetStats возвращает статистику
+func (s *redisRepo) GetStats(ctx context.Context) (*model.StatsResponse, error) {
+	var err error
+	response := new(model.StatsResponse)
+
+	response.URLs, err = s.getCounter(ctx, keyStatsURLs)
+	if err != nil {
+		return nil, fmt.Errorf("can not get urls counter: %w", err)
+	}
+
+	response.Users, err = s.getCounter(ctx, keyStatsUsers)
+	if err != nil {
+		return nil, fmt.Errorf("can not get users counter: %w", err)
+	}
+
+	return response, nil
+}
+
+// getCounter вернёт значение счётчика; несуществующий счётчик равен нулю
+func (s *redisRepo) getCounter(ctx context.Context, key string) (int, error) {
+	count, err := s.client.Get(ctx, key).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+
+	return count, err
+}
@@ -0,0 +1,25 @@
+package redisrepo
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_redisRepo_GetStats проверяет счётчики статистики
+func (ts *RedisTestSuite) Test_redisRepo_GetStats() {
+	ctx := context.Background()
+
+	userID, err := ts.redisRepo.GetNewUserID(ctx)
+	require.NoError(ts.T(), err)
+	_, err = ts.redisRepo.SaveURL(ctx, "https://someurl.example.com", userID)
+	require.NoError(ts.T(), err)
+	// повторное сокращение не увеличивает счётчик
+	_, err = ts.redisRepo.SaveURL(ctx, "https://someurl.example.com", userID)
+	require.Error(ts.T(), err)
+
+	r, err := ts.redisRepo.GetStats(ctx)
+	require.NoError(ts.T(), err)
+	require.Equal(ts.T(), 1, r.URLs)
+	require.Equal(ts.T(), 1, r.Users)
+}
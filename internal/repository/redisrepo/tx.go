@@ -0,0 +1,9 @@
+package redisrepo
+
+import "context"
+
+// WithinTx выполнит fn; каждая операция хранилища атомарна сама по себе (Lua-скрипты),
+// но отката изменений нескольких операций Redis не поддерживает
+func (s *redisRepo) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,98 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// updateUserScript сохраняет роль и состояние существующего пользователя.
+//
+// KEYS[1] - множество пользователей, KEYS[2] - хеш пользователя;
+// ARGV[1] - ID пользователя, ARGV[2] - роль, ARGV[3] - флаг отключения.
+// Возвращает 0, если пользователь не найден.
+var updateUserScript = redis.NewScript(`
+if not redis.call('ZSCORE', KEYS[1], ARGV[1]) then
+	return 0
+end
+
+redis.call('HSET', KEYS[2], 'role', ARGV[2], 'disabled', ARGV[3])
+return 1
+`)
+
+// GetUser вернёт роль и состояние пользователя
+func (s *redisRepo) GetUser(ctx context.Context, userID string) (*modelAuth.User, error) {
+	var score *redis.FloatCmd
+	var fields *redis.SliceCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		score = pipe.ZScore(ctx, keyUsers, userID)
+		fields = pipe.HMGet(ctx, keyUser(userID), "role", "disabled")
+		return nil
+	})
+	if errors.Is(score.Err(), redis.Nil) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user := parseUser(userID, fields.Val())
+	return &user, nil
+}
+
+// ListUsers вернёт не больше limit пользователей, следующих за afterID, в порядке возрастания ID
+func (s *redisRepo) ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error) {
+	ids, err := s.client.ZRangeByLex(ctx, keyUsers, lexRange(afterID, limit)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	cmds := make([]*redis.SliceCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.HMGet(ctx, keyUser(id), "role", "disabled")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]modelAuth.User, 0, len(ids))
+	for i, id := range ids {
+		users = append(users, parseUser(id, cmds[i].Val()))
+	}
+
+	return users, nil
+}
+
+// UpdateUser сохранит роль и состояние пользователя
+func (s *redisRepo) UpdateUser(ctx context.Context, user modelAuth.User) error {
+	found, err := updateUserScript.Run(ctx, s.client,
+		[]string{keyUsers, keyUser(user.ID)},
+		user.ID, user.Role, boolToFlag(user.Disabled),
+	).Int()
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// parseUser соберёт пользователя из полей role и disabled его хеша
+func parseUser(userID string, fields []interface{}) modelAuth.User {
+	user := modelAuth.User{ID: userID, Role: modelAuth.RoleUser}
+	if role, ok := fields[0].(string); ok && role != "" {
+		user.Role = role
+	}
+	if disabled, ok := fields[1].(string); ok {
+		user.Disabled = disabled == boolToFlag(true)
+	}
+	return user
+}
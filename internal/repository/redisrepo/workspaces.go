@@ -0,0 +1,224 @@
+package redisrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/redis/go-redis/v9"
+)
+
+// saveWorkspaceMemberScript добавляет участника существующего пространства или меняет его роль.
+//
+// KEYS[1] - хеш пространства, KEYS[2] - хеш участников пространства,
+// KEYS[3] - множество пространств пользователя;
+// ARGV[1] - ID пользователя, ARGV[2] - роль, ARGV[3] - ID пространства.
+// Возвращает 0, если пространство не найдено.
+var saveWorkspaceMemberScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+
+redis.call('HSET', KEYS[2], ARGV[1], ARGV[2])
+redis.call('SADD', KEYS[3], ARGV[3])
+return 1
+`)
+
+// deleteWorkspaceMemberScript удаляет участника пространства.
+//
+// KEYS[1] - хеш участников пространства, KEYS[2] - множество пространств пользователя;
+// ARGV[1] - ID пользователя, ARGV[2] - ID пространства.
+// Возвращает 0, если участник не найден.
+var deleteWorkspaceMemberScript = redis.NewScript(`
+if redis.call('HDEL', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+
+redis.call('SREM', KEYS[2], ARGV[2])
+return 1
+`)
+
+// CreateWorkspace создаст рабочее пространство вместе с его владельцем
+func (s *redisRepo) CreateWorkspace(ctx context.Context, ws modelWorkspace.Workspace, owner modelWorkspace.Member) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, keyWorkspace(ws.ID),
+			"name", ws.Name,
+			"created_at", ws.CreatedAt.UnixMilli(),
+		)
+		pipe.HSet(ctx, keyWorkspaceMembers(ws.ID), owner.UserID, owner.Role)
+		pipe.SAdd(ctx, keyUserWorkspaces(owner.UserID), ws.ID)
+		return nil
+	})
+
+	return err
+}
+
+// GetWorkspace вернёт рабочее пространство по его ID
+func (s *redisRepo) GetWorkspace(ctx context.Context, id string) (*modelWorkspace.Workspace, error) {
+	values, err := s.client.HGetAll(ctx, keyWorkspace(id)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+
+	return parseWorkspace(id, values)
+}
+
+// GetUserWorkspaces вернёт пространства пользователя в порядке их создания
+func (s *redisRepo) GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error) {
+	ids, err := s.client.SMembers(ctx, keyUserWorkspaces(userID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	wsCmds := make([]*redis.MapStringStringCmd, len(ids))
+	roleCmds := make([]*redis.StringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			wsCmds[i] = pipe.HGetAll(ctx, keyWorkspace(id))
+			roleCmds[i] = pipe.HGet(ctx, keyWorkspaceMembers(id), userID)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	memberships := make([]modelWorkspace.Membership, 0, len(ids))
+	for i, id := range ids {
+		values := wsCmds[i].Val()
+		role := roleCmds[i].Val()
+		if len(values) == 0 || role == "" {
+			continue
+		}
+		ws, err := parseWorkspace(id, values)
+		if err != nil {
+			return nil, err
+		}
+		memberships = append(memberships, modelWorkspace.Membership{Workspace: *ws, Role: role})
+	}
+	sort.Slice(memberships, func(i, j int) bool {
+		a, b := memberships[i].Workspace, memberships[j].Workspace
+		if a.CreatedAt.Equal(b.CreatedAt) {
+			return a.ID < b.ID
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return memberships, nil
+}
+
+// GetWorkspaceMember вернёт участника пространства
+func (s *redisRepo) GetWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*modelWorkspace.Member, error) {
+	role, err := s.client.HGet(ctx, keyWorkspaceMembers(workspaceID), userID).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, repoCommon.ErrNotFoundKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &modelWorkspace.Member{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+// GetWorkspaceMembers вернёт участников пространства в порядке возрастания ID пользователей
+func (s *redisRepo) GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]modelWorkspace.Member, error) {
+	roles, err := s.client.HGetAll(ctx, keyWorkspaceMembers(workspaceID)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]modelWorkspace.Member, 0, len(roles))
+	for userID, role := range roles {
+		members = append(members, modelWorkspace.Member{WorkspaceID: workspaceID, UserID: userID, Role: role})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].UserID < members[j].UserID
+	})
+
+	return members, nil
+}
+
+// GetWorkspaceOwners вернёт ID владельцев пространства в порядке возрастания
+func (s *redisRepo) GetWorkspaceOwners(ctx context.Context, workspaceID string) ([]string, error) {
+	members, err := s.GetWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make([]string, 0)
+	for _, m := range members {
+		if m.Role == modelWorkspace.RoleOwner {
+			owners = append(owners, m.UserID)
+		}
+	}
+
+	return owners, nil
+}
+
+// SaveWorkspaceMember добавит участника пространства или сменит его роль
+func (s *redisRepo) SaveWorkspaceMember(ctx context.Context, member modelWorkspace.Member) error {
+	saved, err := saveWorkspaceMemberScript.Run(ctx, s.client,
+		[]string{keyWorkspace(member.WorkspaceID), keyWorkspaceMembers(member.WorkspaceID), keyUserWorkspaces(member.UserID)},
+		member.UserID, member.Role, member.WorkspaceID,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if saved == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// DeleteWorkspaceMember удалит участника пространства
+func (s *redisRepo) DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error {
+	deleted, err := deleteWorkspaceMemberScript.Run(ctx, s.client,
+		[]string{keyWorkspaceMembers(workspaceID), keyUserWorkspaces(userID)},
+		userID, workspaceID,
+	).Int()
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return repoCommon.ErrNotFoundKey
+	}
+
+	return nil
+}
+
+// parseWorkspace соберёт рабочее пространство из полей его хеша
+func parseWorkspace(id string, values map[string]string) (*modelWorkspace.Workspace, error) {
+	createdAt, err := strconv.ParseInt(values["created_at"], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("can not parse workspace creation time: %w", err)
+	}
+
+	return &modelWorkspace.Workspace{
+		ID:        id,
+		Name:      values["name"],
+		CreatedAt: time.UnixMilli(createdAt),
+	}, nil
+}
+
+// AddWorkspaceURLs свяжет сохранённые URL'ы с рабочим пространством
+func (s *redisRepo) AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, id)
+	}
+
+	return s.client.SAdd(ctx, keyWorkspaceURLs(workspaceID), members...).Err()
+}
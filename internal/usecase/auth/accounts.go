@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// DefaultPasswordHashCost стоимость хеширования паролей bcrypt по умолчанию
+const DefaultPasswordHashCost = 12
+
+// ограничения длины пароля; bcrypt учитывает только первые 72 байта
+const (
+	minPasswordLength = 8
+	maxPasswordBytes  = 72
+)
+
+// Ошибки регистрации и входа
+var (
+	ErrInvalidEmail       = errors.New("email is invalid")
+	ErrInvalidPassword    = errors.New("password must be from 8 characters to 72 bytes long")
+	ErrAccountExists      = errors.New("email is taken or user is already registered")
+	ErrInvalidCredentials = errors.New("email or password is incorrect")
+)
+
+// SetPasswordHashCost задаст стоимость хеширования новых паролей bcrypt
+func (uc *authUseCase) SetPasswordHashCost(cost int) error {
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return fmt.Errorf("password hash cost must be from %d to %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+	uc.passwordHashCost = cost
+	return nil
+}
+
+// Register заведёт учётную запись для пользователя userID; ссылки, сокращённые им анонимно,
+// остаются за ним и становятся доступны после входа с любого устройства
+func (uc *authUseCase) Register(ctx context.Context, userID string, email string, password string) error {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return err
+	}
+	if utf8.RuneCountInString(password) < minPasswordLength || len(password) > maxPasswordBytes {
+		return ErrInvalidPassword
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), uc.passwordHashCost)
+	if err != nil {
+		return err
+	}
+
+	err = uc.repository.CreateAccount(ctx, modelAuth.Account{
+		UserID:       userID,
+		Email:        email,
+		PasswordHash: string(hash),
+		CreatedAt:    uc.now(),
+	})
+	if errors.Is(err, repoCommon.ErrAccountExists) {
+		return ErrAccountExists
+	}
+
+	return err
+}
+
+// Login проверит почту и пароль и вернёт ID пользователя учётной записи
+func (uc *authUseCase) Login(ctx context.Context, email string, password string) (string, error) {
+	email, err := normalizeEmail(email)
+	if err != nil {
+		return "", ErrInvalidCredentials
+	}
+
+	account, err := uc.repository.GetAccountByEmail(ctx, email)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		// пароль всё равно сверяется, чтобы по времени ответа нельзя было узнать, занята ли почта
+		bcrypt.CompareHashAndPassword(uc.dummyPasswordHash(), []byte(password))
+		return "", ErrInvalidCredentials
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(account.PasswordHash), []byte(password))
+	if err != nil {
+		return "", ErrInvalidCredentials
+	}
+
+	return account.UserID, nil
+}
+
+// dummyPasswordHash вернёт хеш, с которым сверяется пароль при входе с неизвестной почтой
+func (uc *authUseCase) dummyPasswordHash() []byte {
+	uc.dummyHashOnce.Do(func() {
+		// ошибка невозможна: пароль короче 72 байт, а стоимость проверена при настройке
+		uc.dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), uc.passwordHashCost)
+	})
+	return uc.dummyHash
+}
+
+// normalizeEmail проверит адрес почты и приведёт его к нижнему регистру
+func normalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+
+	return email, nil
+}
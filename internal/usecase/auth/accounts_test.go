@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestAccountUseCase(t *testing.T) *authUseCase {
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+	require.NoError(t, uc.SetPasswordHashCost(bcrypt.MinCost))
+	return uc
+}
+
+func TestAuthUseCase_RegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestAccountUseCase(t)
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, uc.Register(ctx, userID, " User@Example.com ", "secret-password"))
+
+	// вход возвращает пользователя, которым была сделана регистрация
+	gotUserID, err := uc.Login(ctx, "user@example.com", "secret-password")
+	require.NoError(t, err)
+	require.Equal(t, userID, gotUserID)
+
+	gotUserID, err = uc.Login(ctx, "USER@example.com", "secret-password")
+	require.NoError(t, err)
+	require.Equal(t, userID, gotUserID)
+}
+
+func TestAuthUseCase_RegisterErrors(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestAccountUseCase(t)
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, uc.Register(ctx, userID, "user@example.com", "secret-password"))
+	otherUserID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		userID   string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{name: "invalid email", userID: otherUserID, email: "user", password: "secret-password", wantErr: ErrInvalidEmail},
+		{name: "display name", userID: otherUserID, email: "User <other@example.com>", password: "secret-password", wantErr: ErrInvalidEmail},
+		{name: "short password", userID: otherUserID, email: "other@example.com", password: "short", wantErr: ErrInvalidPassword},
+		{name: "long password", userID: otherUserID, email: "other@example.com", password: string(make([]byte, 73)), wantErr: ErrInvalidPassword},
+		{name: "email taken", userID: otherUserID, email: "USER@example.com", password: "secret-password", wantErr: ErrAccountExists},
+		{name: "already registered", userID: userID, email: "other@example.com", password: "secret-password", wantErr: ErrAccountExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := uc.Register(ctx, tt.userID, tt.email, tt.password)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestAuthUseCase_LoginInvalid(t *testing.T) {
+	ctx := context.Background()
+	uc := newTestAccountUseCase(t)
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	require.NoError(t, uc.Register(ctx, userID, "user@example.com", "secret-password"))
+
+	for name, credentials := range map[string][2]string{
+		"wrong password": {"user@example.com", "wrong-password"},
+		"unknown email":  {"unknown@example.com", "secret-password"},
+		"invalid email":  {"user", "secret-password"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := uc.Login(ctx, credentials[0], credentials[1])
+			require.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestAuthUseCase_SetPasswordHashCost(t *testing.T) {
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+	require.Error(t, uc.SetPasswordHashCost(bcrypt.MinCost-1))
+	require.Error(t, uc.SetPasswordHashCost(bcrypt.MaxCost+1))
+	require.NoError(t, uc.SetPasswordHashCost(bcrypt.MinCost))
+}
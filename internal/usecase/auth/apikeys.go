@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// размеры частей API-ключа в байтах; ключ имеет вид <ID>.<секрет>
+const (
+	apiKeyIDSize     = 8
+	apiKeySecretSize = 32
+)
+
+// maxAPIKeyNameLength максимальная длина названия API-ключа
+const maxAPIKeyNameLength = 100
+
+// apiKeyTouchInterval время последнего использования API-ключа обновляется не чаще этого интервала
+const apiKeyTouchInterval = time.Minute
+
+// Ошибки API-ключей
+var (
+	ErrInvalidAPIKey     = errors.New("api key is invalid")
+	ErrAPIKeyNotFound    = errors.New("api key not found")
+	ErrInvalidAPIKeyName = errors.New("api key name must be at most 100 characters long")
+	ErrInvalidScopes     = errors.New("scopes must be a non-empty subset of links:write, links:read, stats:read")
+)
+
+// CreateAPIKey создаст пользователю API-ключ с правами scopes и вернёт его;
+// ключ показывается только один раз, в хранилище попадает только хеш секретной части
+func (uc *authUseCase) CreateAPIKey(ctx context.Context,
+	userID string, name string, scopes []string) (string, *modelAuth.APIKey, error) {
+	if utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		return "", nil, ErrInvalidAPIKeyName
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id, err := randomBytes(apiKeyIDSize)
+	if err != nil {
+		return "", nil, err
+	}
+	secret, err := randomBytes(apiKeySecretSize)
+	if err != nil {
+		return "", nil, err
+	}
+	key := &modelAuth.APIKey{
+		ID:        hex.EncodeToString(id),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: uc.now(),
+	}
+	encodedSecret := base64.RawURLEncoding.EncodeToString(secret)
+	key.Hash = hashToken(encodedSecret)
+
+	if err = uc.repository.SaveAPIKey(ctx, *key); err != nil {
+		return "", nil, err
+	}
+
+	return key.ID + "." + encodedSecret, key, nil
+}
+
+// GetUserAPIKeys вернёт API-ключи пользователя
+func (uc *authUseCase) GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error) {
+	return uc.repository.GetUserAPIKeys(ctx, userID)
+}
+
+// RevokeAPIKey отзовёт API-ключ пользователя
+func (uc *authUseCase) RevokeAPIKey(ctx context.Context, userID string, id string) error {
+	err := uc.repository.DeleteAPIKey(ctx, userID, id)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return ErrAPIKeyNotFound
+	}
+
+	return err
+}
+
+// AuthenticateAPIKey проверит API-ключ и вернёт его данные; секрет сравнивается за постоянное время
+func (uc *authUseCase) AuthenticateAPIKey(ctx context.Context, apiKey string) (*modelAuth.APIKey, error) {
+	id, secret, ok := strings.Cut(apiKey, ".")
+	if !ok || id == "" || secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := uc.repository.GetAPIKey(ctx, id)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(key.Hash)) != 1 {
+		return nil, ErrInvalidAPIKey
+	}
+
+	now := uc.now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err = uc.repository.TouchAPIKey(ctx, key.ID, now); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+
+	return key, nil
+}
+
+// normalizeScopes проверит права и уберёт повторы
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, ErrInvalidScopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(modelAuth.Scopes, scope) {
+			return nil, ErrInvalidScopes
+		}
+	}
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// randomBytes вернёт size случайных байт
+func randomBytes(size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthUseCase_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	apiKey, key, err := uc.CreateAPIKey(ctx, userID, "ci",
+		[]string{modelAuth.ScopeLinksWrite, modelAuth.ScopeLinksRead, modelAuth.ScopeLinksWrite})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(apiKey, key.ID+"."))
+	require.NotContains(t, key.Hash, strings.TrimPrefix(apiKey, key.ID+"."))
+	require.Equal(t, []string{modelAuth.ScopeLinksRead, modelAuth.ScopeLinksWrite}, key.Scopes)
+
+	// первое использование запоминается
+	got, err := uc.AuthenticateAPIKey(ctx, apiKey)
+	require.NoError(t, err)
+	require.Equal(t, userID, got.UserID)
+	require.True(t, got.HasScope(modelAuth.ScopeLinksWrite))
+	require.False(t, got.HasScope(modelAuth.ScopeStatsRead))
+	require.NotNil(t, got.LastUsedAt)
+	require.True(t, now.Equal(*got.LastUsedAt))
+
+	// время использования обновляется не чаще apiKeyTouchInterval
+	firstUse := now
+	now = now.Add(apiKeyTouchInterval / 2)
+	got, err = uc.AuthenticateAPIKey(ctx, apiKey)
+	require.NoError(t, err)
+	require.True(t, firstUse.Equal(*got.LastUsedAt))
+	now = now.Add(apiKeyTouchInterval)
+	got, err = uc.AuthenticateAPIKey(ctx, apiKey)
+	require.NoError(t, err)
+	require.True(t, now.Equal(*got.LastUsedAt))
+
+	keys, err := uc.GetUserAPIKeys(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, key.ID, keys[0].ID)
+
+	// отозвать ключ может только владелец
+	otherUserID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	require.ErrorIs(t, uc.RevokeAPIKey(ctx, otherUserID, key.ID), ErrAPIKeyNotFound)
+	require.NoError(t, uc.RevokeAPIKey(ctx, userID, key.ID))
+	_, err = uc.AuthenticateAPIKey(ctx, apiKey)
+	require.ErrorIs(t, err, ErrInvalidAPIKey)
+}
+
+func TestAuthUseCase_AuthenticateAPIKeyInvalid(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	_, key, err := uc.CreateAPIKey(ctx, userID, "", []string{modelAuth.ScopeLinksRead})
+	require.NoError(t, err)
+
+	for name, apiKey := range map[string]string{
+		"empty":          "",
+		"without secret": key.ID,
+		"unknown id":     "unknown.secret",
+		"wrong secret":   key.ID + ".secret",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := uc.AuthenticateAPIKey(ctx, apiKey)
+			require.ErrorIs(t, err, ErrInvalidAPIKey)
+		})
+	}
+}
+
+func TestAuthUseCase_CreateAPIKeyInvalid(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+
+	tests := []struct {
+		name    string
+		keyName string
+		scopes  []string
+		wantErr error
+	}{
+		{name: "no scopes", wantErr: ErrInvalidScopes},
+		{name: "unknown scope", scopes: []string{"links:admin"}, wantErr: ErrInvalidScopes},
+		{
+			name:    "long name",
+			keyName: strings.Repeat("a", maxAPIKeyNameLength+1),
+			scopes:  []string{modelAuth.ScopeLinksRead},
+			wantErr: ErrInvalidAPIKeyName,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := uc.CreateAPIKey(ctx, "user", tt.keyName, tt.scopes)
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
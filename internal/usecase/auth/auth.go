@@ -3,25 +3,165 @@ Package auth это usecase для работы с аутентификацие
 */
 package auth
 
-import "context"
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// DefaultRefreshTokenTTL время жизни токена обновления по умолчанию
+const DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+
+// refreshTokenSize размер токена обновления в байтах
+const refreshTokenSize = 32
+
+// ErrInvalidRefreshToken токен обновления неизвестен, уже использован или истёк
+var ErrInvalidRefreshToken = errors.New("refresh token is invalid")
 
 // AuthRepo интерфейс для хранилища
 type AuthRepo interface {
 	GetNewUserID(ctx context.Context) (string, error)
+	SaveRefreshToken(ctx context.Context, token modelAuth.RefreshToken) error
+	ConsumeRefreshToken(ctx context.Context, hash string) (*modelAuth.RefreshToken, error)
+	CreateAccount(ctx context.Context, account modelAuth.Account) error
+	GetAccountByEmail(ctx context.Context, email string) (*modelAuth.Account, error)
+	CreateIdentity(ctx context.Context, identity modelAuth.Identity) error
+	GetIdentity(ctx context.Context, issuer string, subject string) (*modelAuth.Identity, error)
+	SaveAPIKey(ctx context.Context, key modelAuth.APIKey) error
+	GetAPIKey(ctx context.Context, id string) (*modelAuth.APIKey, error)
+	GetUserAPIKeys(ctx context.Context, userID string) ([]modelAuth.APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID string, id string) error
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+	CreateSession(ctx context.Context, session modelAuth.Session) error
+	GetSession(ctx context.Context, id string) (*modelAuth.Session, error)
+	GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error)
+	TouchSession(ctx context.Context, id string, seenAt time.Time) error
+	RevokeSession(ctx context.Context, userID string, id string, revokedAt time.Time) error
+	RevokeUserSessions(ctx context.Context, userID string, revokedAt time.Time) error
+	GetUser(ctx context.Context, userID string) (*modelAuth.User, error)
+	ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error)
+	UpdateUser(ctx context.Context, user modelAuth.User) error
 }
 
 type authUseCase struct {
 	repository AuthRepo
+	refreshTTL time.Duration
+	now        func() time.Time
+
+	passwordHashCost int
+	dummyHashOnce    sync.Once
+	dummyHash        []byte
+
+	usersMu sync.Mutex
+	users   map[string]cachedUser
+
+	sessionsMu sync.Mutex
+	sessions   map[string]cachedSession
 }
 
 // NewAuthUseCase конструктор authUseCase
 func NewAuthUseCase(r AuthRepo) *authUseCase {
 	return &authUseCase{
 		repository: r,
+		refreshTTL: DefaultRefreshTokenTTL,
+		now:        time.Now,
+
+		passwordHashCost: DefaultPasswordHashCost,
+
+		users:    make(map[string]cachedUser),
+		sessions: make(map[string]cachedSession),
 	}
 }
 
+// SetRefreshTokenTTL задаст время жизни выдаваемых токенов обновления
+func (uc *authUseCase) SetRefreshTokenTTL(ttl time.Duration) {
+	uc.refreshTTL = ttl
+}
+
 // GetNewUserID вернёт уникальный ID пользователя
 func (uc *authUseCase) GetNewUserID(ctx context.Context) (string, error) {
 	return uc.repository.GetNewUserID(ctx)
 }
+
+// issueRefreshToken выдаст токен обновления в сеансе sessionID; в хранилище попадает только хеш токена
+func (uc *authUseCase) issueRefreshToken(ctx context.Context,
+	userID string, sessionID string) (*modelAuth.IssuedRefreshToken, error) {
+	b := make([]byte, refreshTokenSize)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	expiresAt := uc.now().Add(uc.refreshTTL)
+	err := uc.repository.SaveRefreshToken(ctx, modelAuth.RefreshToken{
+		Hash:      hashToken(token),
+		UserID:    userID,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &modelAuth.IssuedRefreshToken{
+		Token:     token,
+		UserID:    userID,
+		SessionID: sessionID,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// Refresh обменяет токен обновления на новый в том же сеансе; каждый токен обновления
+// принимается только один раз, а токены отозванного сеанса не принимаются.
+// Для токена, выданного до появления сеансов, начнётся новый сеанс клиента userAgent
+func (uc *authUseCase) Refresh(ctx context.Context,
+	refreshToken string, userAgent string) (*modelAuth.IssuedRefreshToken, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	stored, err := uc.repository.ConsumeRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !stored.ExpiresAt.After(uc.now()) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if stored.SessionID == "" {
+		return uc.StartSession(ctx, stored.UserID, userAgent)
+	}
+
+	// отзыв проверяется по хранилищу, а не по кешу, чтобы отозванный сеанс нельзя было продлить
+	session, err := uc.repository.GetSession(ctx, stored.SessionID)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if session.RevokedAt != nil || session.UserID != stored.UserID {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err = uc.touchSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return uc.issueRefreshToken(ctx, stored.UserID, stored.SessionID)
+}
+
+// hashToken вернёт хеш случайного токена; соль для таких токенов не нужна
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthUseCase_Refresh(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	issued, err := uc.StartSession(ctx, userID, "curl/8.0")
+	require.NoError(t, err)
+	require.Equal(t, userID, issued.UserID)
+	require.NotEmpty(t, issued.SessionID)
+	require.WithinDuration(t, time.Now().Add(DefaultRefreshTokenTTL), issued.ExpiresAt, time.Minute)
+
+	refreshed, err := uc.Refresh(ctx, issued.Token, "curl/8.0")
+	require.NoError(t, err)
+	require.Equal(t, userID, refreshed.UserID)
+	require.Equal(t, issued.SessionID, refreshed.SessionID)
+	require.NotEqual(t, issued.Token, refreshed.Token)
+
+	// токен обновления одноразовый
+	_, err = uc.Refresh(ctx, issued.Token, "curl/8.0")
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	refreshed, err = uc.Refresh(ctx, refreshed.Token, "curl/8.0")
+	require.NoError(t, err)
+	require.Equal(t, userID, refreshed.UserID)
+}
+
+func TestAuthUseCase_RefreshInvalid(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+	uc.SetRefreshTokenTTL(time.Hour)
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	issued, err := uc.StartSession(ctx, userID, "")
+	require.NoError(t, err)
+
+	for name, token := range map[string]string{
+		"empty":   "",
+		"unknown": "unknown",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := uc.Refresh(ctx, token, "")
+			require.ErrorIs(t, err, ErrInvalidRefreshToken)
+		})
+	}
+
+	now = now.Add(2 * time.Hour)
+	_, err = uc.Refresh(ctx, issued.Token, "")
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthUseCase_RefreshWithoutSession(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := NewAuthUseCase(repo)
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	// токен, выданный до появления сеансов
+	require.NoError(t, repo.SaveRefreshToken(ctx, modelAuth.RefreshToken{
+		Hash:      hashToken("legacy"),
+		UserID:    userID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}))
+
+	refreshed, err := uc.Refresh(ctx, "legacy", "curl/8.0")
+	require.NoError(t, err)
+	require.Equal(t, userID, refreshed.UserID)
+	require.NotEmpty(t, refreshed.SessionID)
+
+	sessions, err := uc.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, refreshed.SessionID, sessions[0].ID)
+	require.Equal(t, "curl/8.0", sessions[0].UserAgent)
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// LoginIdentity вернёт ID пользователя, к которому привязано удостоверение издателя OpenID Connect.
+// При первом входе заводится новый пользователь: ссылки, сокращённые анонимно, и учётная запись
+// с той же почтой к нему не переносятся, иначе чужое удостоверение открыло бы доступ к ним
+func (uc *authUseCase) LoginIdentity(ctx context.Context, identity modelAuth.Identity) (string, error) {
+	existing, err := uc.repository.GetIdentity(ctx, identity.Issuer, identity.Subject)
+	if err == nil {
+		return existing.UserID, nil
+	}
+	if !errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return "", err
+	}
+
+	identity.UserID, err = uc.repository.GetNewUserID(ctx)
+	if err != nil {
+		return "", fmt.Errorf("get new user ID: %w", err)
+	}
+	identity.CreatedAt = uc.now()
+	err = uc.repository.CreateIdentity(ctx, identity)
+	if errors.Is(err, repoCommon.ErrIdentityExists) {
+		// удостоверение привязал параллельный первый вход
+		existing, err = uc.repository.GetIdentity(ctx, identity.Issuer, identity.Subject)
+		if err != nil {
+			return "", err
+		}
+		return existing.UserID, nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return identity.UserID, nil
+}
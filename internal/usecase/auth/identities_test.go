@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthUseCase_LoginIdentity(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+	identity := modelAuth.Identity{Issuer: "https://idp.example.com", Subject: "subject", Email: "user@example.com"}
+
+	// первый вход заводит нового пользователя, следующие возвращают его же
+	userID, err := uc.LoginIdentity(ctx, identity)
+	require.NoError(t, err)
+	require.NotEmpty(t, userID)
+	again, err := uc.LoginIdentity(ctx, identity)
+	require.NoError(t, err)
+	require.Equal(t, userID, again)
+
+	// тот же ID у другого издателя - другой пользователь
+	identity.Issuer = "https://other.example.com"
+	other, err := uc.LoginIdentity(ctx, identity)
+	require.NoError(t, err)
+	require.NotEqual(t, userID, other)
+}
+
+// racingIdentityRepo привязывает удостоверение другому пользователю перед каждой привязкой,
+// как параллельный первый вход
+type racingIdentityRepo struct {
+	*inmr.InMemoryRepo
+	winnerID string
+}
+
+func (r *racingIdentityRepo) CreateIdentity(ctx context.Context, identity modelAuth.Identity) error {
+	winner := identity
+	winner.UserID = r.winnerID
+	if err := r.InMemoryRepo.CreateIdentity(ctx, winner); err != nil {
+		return err
+	}
+	return r.InMemoryRepo.CreateIdentity(ctx, identity)
+}
+
+func TestAuthUseCase_LoginIdentityConcurrent(t *testing.T) {
+	ctx := context.Background()
+	repo := &racingIdentityRepo{InMemoryRepo: inmr.NewInMemoryRepo()}
+	var err error
+	repo.winnerID, err = repo.GetNewUserID(ctx)
+	require.NoError(t, err)
+	uc := NewAuthUseCase(repo)
+
+	// проигравший вход получает пользователя, к которому удостоверение уже привязано
+	userID, err := uc.LoginIdentity(ctx, modelAuth.Identity{Issuer: "https://idp.example.com", Subject: "subject"})
+	require.NoError(t, err)
+	require.Equal(t, repo.winnerID, userID)
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// sessionIDSize размер ID сеанса в байтах
+const sessionIDSize = 16
+
+// maxUserAgentLength User-Agent сеанса обрезается до этой длины
+const maxUserAgentLength = 256
+
+// sessionCacheTTL время, в течение которого сеанс не перечитывается из хранилища;
+// отзыв сеанса на других экземплярах сервиса вступает в силу не позже
+const sessionCacheTTL = 5 * time.Second
+
+// maxCachedSessions при превышении этого количества кеш сеансов сбрасывается
+const maxCachedSessions = 10000
+
+// sessionTouchInterval время последнего запроса в сеансе обновляется не чаще этого интервала
+const sessionTouchInterval = time.Minute
+
+// ErrSessionNotFound сеанс не найден или принадлежит другому пользователю
+var ErrSessionNotFound = errors.New("session not found")
+
+type cachedSession struct {
+	session   modelAuth.Session
+	found     bool
+	expiresAt time.Time
+}
+
+// StartSession начнёт сеанс пользователя на устройстве клиента userAgent и выдаст в нём токен обновления
+func (uc *authUseCase) StartSession(ctx context.Context,
+	userID string, userAgent string) (*modelAuth.IssuedRefreshToken, error) {
+	id, err := randomBytes(sessionIDSize)
+	if err != nil {
+		return nil, err
+	}
+	if runes := []rune(userAgent); len(runes) > maxUserAgentLength {
+		userAgent = string(runes[:maxUserAgentLength])
+	}
+
+	now := uc.now()
+	session := modelAuth.Session{
+		ID:         hex.EncodeToString(id),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+	if err = uc.repository.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return uc.issueRefreshToken(ctx, userID, session.ID)
+}
+
+// SessionActive проверит, что сеанс принадлежит пользователю и не отозван, и запишет время запроса в нём
+func (uc *authUseCase) SessionActive(ctx context.Context, userID string, sessionID string) (bool, error) {
+	session, ok, err := uc.cachedSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return false, nil
+	}
+
+	if err = uc.touchSession(ctx, &session); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetUserSessions вернёт действующие сеансы пользователя в порядке начала;
+// сеанс действует, пока не отозван и его токен обновления ещё мог не истечь
+func (uc *authUseCase) GetUserSessions(ctx context.Context, userID string) ([]modelAuth.Session, error) {
+	sessions, err := uc.repository.GetUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	active := make([]modelAuth.Session, 0, len(sessions))
+	for _, session := range sessions {
+		if session.RevokedAt == nil && session.LastSeenAt.Add(uc.refreshTTL).After(now) {
+			active = append(active, session)
+		}
+	}
+
+	return active, nil
+}
+
+// RevokeSession отзовёт сеанс пользователя: его токены доступа и обновления перестанут приниматься
+func (uc *authUseCase) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	err := uc.repository.RevokeSession(ctx, userID, sessionID, uc.now())
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	uc.forgetSessions(func(s modelAuth.Session) bool { return s.ID == sessionID })
+
+	return nil
+}
+
+// RevokeUserSessions отзовёт все сеансы пользователя на всех устройствах
+func (uc *authUseCase) RevokeUserSessions(ctx context.Context, userID string) error {
+	if err := uc.repository.RevokeUserSessions(ctx, userID, uc.now()); err != nil {
+		return err
+	}
+	uc.forgetSessions(func(s modelAuth.Session) bool { return s.UserID == userID })
+
+	return nil
+}
+
+// touchSession запишет время запроса в сеансе, если с прошлой записи прошло не меньше sessionTouchInterval
+func (uc *authUseCase) touchSession(ctx context.Context, session *modelAuth.Session) error {
+	now := uc.now()
+	if now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+	if err := uc.repository.TouchSession(ctx, session.ID, now); err != nil {
+		return err
+	}
+
+	uc.sessionsMu.Lock()
+	if cached, ok := uc.sessions[session.ID]; ok {
+		cached.session.LastSeenAt = now
+		uc.sessions[session.ID] = cached
+	}
+	uc.sessionsMu.Unlock()
+
+	return nil
+}
+
+// cachedSession вернёт сеанс из кеша или из хранилища; ok ложно, если сеанса нет
+func (uc *authUseCase) cachedSession(ctx context.Context, sessionID string) (modelAuth.Session, bool, error) {
+	now := uc.now()
+	uc.sessionsMu.Lock()
+	cached, ok := uc.sessions[sessionID]
+	uc.sessionsMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.session, cached.found, nil
+	}
+
+	stored, err := uc.repository.GetSession(ctx, sessionID)
+	if err != nil && !errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return modelAuth.Session{}, false, err
+	}
+	cached = cachedSession{expiresAt: now.Add(sessionCacheTTL)}
+	if err == nil {
+		cached.session = *stored
+		cached.found = true
+	}
+
+	uc.sessionsMu.Lock()
+	if len(uc.sessions) >= maxCachedSessions {
+		uc.sessions = make(map[string]cachedSession)
+	}
+	uc.sessions[sessionID] = cached
+	uc.sessionsMu.Unlock()
+
+	return cached.session, cached.found, nil
+}
+
+// forgetSessions удалит из кеша подходящие сеансы, чтобы их отзыв на этом экземпляре действовал сразу
+func (uc *authUseCase) forgetSessions(match func(modelAuth.Session) bool) {
+	uc.sessionsMu.Lock()
+	for id, cached := range uc.sessions {
+		if match(cached.session) {
+			delete(uc.sessions, id)
+		}
+	}
+	uc.sessionsMu.Unlock()
+}
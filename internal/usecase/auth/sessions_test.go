@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthUseCase_Sessions(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := NewAuthUseCase(repo)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	laptop, err := uc.StartSession(ctx, userID, "Mozilla/5.0")
+	require.NoError(t, err)
+	now = now.Add(time.Second)
+	phone, err := uc.StartSession(ctx, userID, strings.Repeat("a", 2*maxUserAgentLength))
+	require.NoError(t, err)
+
+	active, err := uc.SessionActive(ctx, userID, laptop.SessionID)
+	require.NoError(t, err)
+	require.True(t, active)
+	active, err = uc.SessionActive(ctx, "other", laptop.SessionID)
+	require.NoError(t, err)
+	require.False(t, active)
+	active, err = uc.SessionActive(ctx, userID, "unknown")
+	require.NoError(t, err)
+	require.False(t, active)
+
+	// время последнего запроса обновляется не чаще sessionTouchInterval
+	now = now.Add(sessionTouchInterval)
+	_, err = uc.SessionActive(ctx, userID, laptop.SessionID)
+	require.NoError(t, err)
+	sessions, err := uc.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.Equal(t, laptop.SessionID, sessions[0].ID)
+	require.Equal(t, "Mozilla/5.0", sessions[0].UserAgent)
+	require.True(t, now.Equal(sessions[0].LastSeenAt))
+	require.Equal(t, phone.SessionID, sessions[1].ID)
+	require.Len(t, sessions[1].UserAgent, maxUserAgentLength)
+
+	// отзыв действует сразу: токены сеанса больше не принимаются
+	require.ErrorIs(t, uc.RevokeSession(ctx, "other", laptop.SessionID), ErrSessionNotFound)
+	require.NoError(t, uc.RevokeSession(ctx, userID, laptop.SessionID))
+	active, err = uc.SessionActive(ctx, userID, laptop.SessionID)
+	require.NoError(t, err)
+	require.False(t, active)
+	_, err = uc.Refresh(ctx, laptop.Token, "")
+	require.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	sessions, err = uc.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	require.Equal(t, phone.SessionID, sessions[0].ID)
+
+	require.NoError(t, uc.RevokeUserSessions(ctx, userID))
+	active, err = uc.SessionActive(ctx, userID, phone.SessionID)
+	require.NoError(t, err)
+	require.False(t, active)
+	sessions, err = uc.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestAuthUseCase_SessionRevokedElsewhere(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := NewAuthUseCase(repo)
+	other := NewAuthUseCase(repo)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	issued, err := uc.StartSession(ctx, userID, "")
+	require.NoError(t, err)
+	active, err := uc.SessionActive(ctx, userID, issued.SessionID)
+	require.NoError(t, err)
+	require.True(t, active)
+
+	// отзыв на другом экземпляре действует после истечения кеша
+	require.NoError(t, other.RevokeUserSessions(ctx, userID))
+	active, err = uc.SessionActive(ctx, userID, issued.SessionID)
+	require.NoError(t, err)
+	require.True(t, active)
+
+	now = now.Add(sessionCacheTTL)
+	active, err = uc.SessionActive(ctx, userID, issued.SessionID)
+	require.NoError(t, err)
+	require.False(t, active)
+}
+
+func TestAuthUseCase_SessionsExpire(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+	uc.SetRefreshTokenTTL(time.Hour)
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+	_, err = uc.StartSession(ctx, userID, "")
+	require.NoError(t, err)
+
+	now = now.Add(time.Hour)
+	sessions, err := uc.GetUserSessions(ctx, userID)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
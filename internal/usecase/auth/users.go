@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+)
+
+// Размер страницы списка пользователей
+const (
+	DefaultUsersPageSize = 100
+	MaxUsersPageSize     = 1000
+)
+
+// userCacheTTL время, в течение которого роль и состояние пользователя не перечитываются из хранилища;
+// отключение пользователя на других экземплярах сервиса вступает в силу не позже
+const userCacheTTL = 5 * time.Second
+
+// maxCachedUsers при превышении этого количества кеш пользователей сбрасывается
+const maxCachedUsers = 10000
+
+// Ошибки управления пользователями
+var (
+	ErrUserDisabled = errors.New("user is disabled")
+	ErrUserNotFound = errors.New("user not found")
+	ErrInvalidRole  = errors.New("role is invalid")
+)
+
+type cachedUser struct {
+	user      modelAuth.User
+	expiresAt time.Time
+}
+
+// Authorize вернёт роль пользователя или ErrUserDisabled, если пользователь отключён;
+// пользователь, которого нет в хранилище, считается обычным
+func (uc *authUseCase) Authorize(ctx context.Context, userID string) (*modelAuth.User, error) {
+	user, err := uc.cachedUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	return &user, nil
+}
+
+// ListUsers вернёт не больше limit пользователей, следующих за afterID, в порядке возрастания ID
+func (uc *authUseCase) ListUsers(ctx context.Context, afterID string, limit int) ([]modelAuth.User, error) {
+	if limit <= 0 {
+		limit = DefaultUsersPageSize
+	}
+	if limit > MaxUsersPageSize {
+		limit = MaxUsersPageSize
+	}
+
+	return uc.repository.ListUsers(ctx, afterID, limit)
+}
+
+// UpdateUser изменит роль или состояние пользователя
+func (uc *authUseCase) UpdateUser(ctx context.Context,
+	userID string, request modelAuth.UpdateUserRequest) (*modelAuth.User, error) {
+	if request.Role != nil && !slices.Contains(modelAuth.Roles, *request.Role) {
+		return nil, ErrInvalidRole
+	}
+
+	user, err := uc.repository.GetUser(ctx, userID)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if request.Role != nil {
+		user.Role = *request.Role
+	}
+	if request.Disabled != nil {
+		user.Disabled = *request.Disabled
+	}
+
+	err = uc.repository.UpdateUser(ctx, *user)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	uc.forgetUser(userID)
+
+	return user, nil
+}
+
+// GrantAdmin выдаст пользователям роль администратора
+func (uc *authUseCase) GrantAdmin(ctx context.Context, userIDs []string) error {
+	role := modelAuth.RoleAdmin
+	for _, userID := range userIDs {
+		_, err := uc.UpdateUser(ctx, userID, modelAuth.UpdateUserRequest{Role: &role})
+		if err != nil {
+			return fmt.Errorf("grant admin role to user %s: %w", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// cachedUser вернёт пользователя из кеша или из хранилища
+func (uc *authUseCase) cachedUser(ctx context.Context, userID string) (modelAuth.User, error) {
+	now := uc.now()
+	uc.usersMu.Lock()
+	cached, ok := uc.users[userID]
+	uc.usersMu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.user, nil
+	}
+
+	user := modelAuth.User{ID: userID, Role: modelAuth.RoleUser}
+	stored, err := uc.repository.GetUser(ctx, userID)
+	if err != nil && !errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return modelAuth.User{}, err
+	}
+	if err == nil {
+		user = *stored
+	}
+
+	uc.usersMu.Lock()
+	if len(uc.users) >= maxCachedUsers {
+		uc.users = make(map[string]cachedUser)
+	}
+	uc.users[userID] = cachedUser{user: user, expiresAt: now.Add(userCacheTTL)}
+	uc.usersMu.Unlock()
+
+	return user, nil
+}
+
+// forgetUser удалит пользователя из кеша, чтобы изменения на этом экземпляре действовали сразу
+func (uc *authUseCase) forgetUser(userID string) {
+	uc.usersMu.Lock()
+	delete(uc.users, userID)
+	uc.usersMu.Unlock()
+}
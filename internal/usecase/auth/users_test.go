@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthUseCase_Authorize(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := NewAuthUseCase(repo)
+	now := time.Now()
+	uc.now = func() time.Time { return now }
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+
+	user, err := uc.Authorize(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.RoleUser, user.Role)
+
+	// пользователь, которого нет в хранилище, считается обычным
+	user, err = uc.Authorize(ctx, "unknown")
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.RoleUser, user.Role)
+
+	// изменения через usecase действуют сразу
+	require.NoError(t, uc.GrantAdmin(ctx, []string{userID}))
+	user, err = uc.Authorize(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.RoleAdmin, user.Role)
+
+	// изменения в обход usecase действуют после истечения кеша
+	require.NoError(t, repo.UpdateUser(ctx, modelAuth.User{ID: userID, Role: modelAuth.RoleAdmin, Disabled: true}))
+	_, err = uc.Authorize(ctx, userID)
+	require.NoError(t, err)
+
+	now = now.Add(userCacheTTL)
+	_, err = uc.Authorize(ctx, userID)
+	require.ErrorIs(t, err, ErrUserDisabled)
+}
+
+func TestAuthUseCase_UpdateUser(t *testing.T) {
+	ctx := context.Background()
+	uc := NewAuthUseCase(inmr.NewInMemoryRepo())
+
+	userID, err := uc.GetNewUserID(ctx)
+	require.NoError(t, err)
+
+	disabled := true
+	user, err := uc.UpdateUser(ctx, userID, modelAuth.UpdateUserRequest{Disabled: &disabled})
+	require.NoError(t, err)
+	require.Equal(t, modelAuth.User{ID: userID, Role: modelAuth.RoleUser, Disabled: true}, *user)
+	_, err = uc.Authorize(ctx, userID)
+	require.ErrorIs(t, err, ErrUserDisabled)
+
+	role := "root"
+	_, err = uc.UpdateUser(ctx, userID, modelAuth.UpdateUserRequest{Role: &role})
+	require.ErrorIs(t, err, ErrInvalidRole)
+
+	_, err = uc.UpdateUser(ctx, "unknown", modelAuth.UpdateUserRequest{Disabled: &disabled})
+	require.ErrorIs(t, err, ErrUserNotFound)
+	require.ErrorIs(t, uc.GrantAdmin(ctx, []string{"unknown"}), ErrUserNotFound)
+
+	users, err := uc.ListUsers(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, []modelAuth.User{*user}, users)
+}
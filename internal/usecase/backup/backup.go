@@ -0,0 +1,159 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/logger"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	"go.uber.org/zap"
+)
+
+// restoreBatchSize количество записей, загружаемых в хранилище за раз
+const restoreBatchSize = 500
+
+// BackupRepo интерфейс хранилища
+type BackupRepo interface {
+	Export(ctx context.Context, w snapshot.Writer) error
+	ImportUsers(ctx context.Context, users []snapshot.User) error
+	ImportURLs(ctx context.Context, urls []snapshot.URL) error
+	ImportAccounts(ctx context.Context, accounts []snapshot.Account) error
+	ImportIdentities(ctx context.Context, identities []snapshot.Identity) error
+	ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error
+	ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error
+}
+
+type backupUsecase struct {
+	repository BackupRepo
+	now        func() time.Time
+}
+
+// New инициализирует backupUsecase
+func New(repo BackupRepo) *backupUsecase {
+	return &backupUsecase{
+		repository: repo,
+		now:        time.Now,
+	}
+}
+
+// Backup запишет в w снимок всех данных хранилища
+func (uc *backupUsecase) Backup(ctx context.Context, w io.Writer) (*snapshot.Summary, error) {
+	sw, err := newSnapshotWriter(w, uc.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	if err = uc.repository.Export(ctx, sw); err != nil {
+		logger.Log.Error("can not export snapshot",
+			zap.String("package", "backup"),
+			zap.String("func", "Backup"),
+			zap.Error(err))
+		return nil, err
+	}
+
+	if err = sw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &sw.summary, nil
+}
+
+// Restore загрузит в хранилище снимок из r.
+//
+// Записи загружаются пачками по мере чтения, и загрузка идемпотентна:
+// если снимок окажется повреждённым, уже загруженные записи останутся в хранилище,
+// а повторное восстановление исправного снимка ничего не испортит
+func (uc *backupUsecase) Restore(ctx context.Context, r io.Reader) (*snapshot.Summary, error) {
+	sr, err := newSnapshotReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		users      []snapshot.User
+		urls       []snapshot.URL
+		accounts   []snapshot.Account
+		identities []snapshot.Identity
+		keys       []snapshot.APIKey
+		workspaces []snapshot.Workspace
+	)
+	flush := func() error {
+		// записи загружаются раньше записей, которые на них ссылаются
+		if err := importBatch(ctx, &users, uc.repository.ImportUsers); err != nil {
+			return err
+		}
+		if err := importBatch(ctx, &urls, uc.repository.ImportURLs); err != nil {
+			return err
+		}
+		if err := importBatch(ctx, &accounts, uc.repository.ImportAccounts); err != nil {
+			return err
+		}
+		if err := importBatch(ctx, &identities, uc.repository.ImportIdentities); err != nil {
+			return err
+		}
+		if err := importBatch(ctx, &keys, uc.repository.ImportAPIKeys); err != nil {
+			return err
+		}
+		return importBatch(ctx, &workspaces, uc.repository.ImportWorkspaces)
+	}
+
+	for {
+		rec, err := sr.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch rec.Type {
+		case recordUser:
+			users = append(users, *rec.User)
+		case recordURL:
+			urls = append(urls, *rec.URL)
+		case recordAccount:
+			accounts = append(accounts, *rec.Account)
+		case recordIdentity:
+			identities = append(identities, *rec.Identity)
+		case recordAPIKey:
+			keys = append(keys, *rec.APIKey)
+		case recordWorkspace:
+			workspaces = append(workspaces, *rec.Workspace)
+		}
+
+		pending := len(users) + len(urls) + len(accounts) + len(identities) + len(keys) + len(workspaces)
+		if pending >= restoreBatchSize {
+			if err = flush(); err != nil {
+				logger.Log.Error("can not import snapshot",
+					zap.String("package", "backup"),
+					zap.String("func", "Restore"),
+					zap.Error(err))
+				return nil, err
+			}
+		}
+	}
+
+	if err = flush(); err != nil {
+		logger.Log.Error("can not import snapshot",
+			zap.String("package", "backup"),
+			zap.String("func", "Restore"),
+			zap.Error(err))
+		return nil, err
+	}
+
+	return &sr.summary, nil
+}
+
+// importBatch загрузит накопленные записи и очистит пачку
+func importBatch[T any](ctx context.Context, items *[]T, store func(ctx context.Context, items []T) error) error {
+	if len(*items) == 0 {
+		return nil
+	}
+	if err := store(ctx, *items); err != nil {
+		return err
+	}
+	*items = (*items)[:0]
+	return nil
+}
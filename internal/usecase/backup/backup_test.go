@@ -0,0 +1,192 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func newFilledRepo(t *testing.T) *inmr.InMemoryRepo {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	for i := 0; i < 3; i++ {
+		userID, err := repo.GetNewUserID(ctx)
+		require.NoError(t, err)
+		for j := 0; j < 400; j++ {
+			_, err = repo.SaveURL(ctx, fmt.Sprintf("https://example.com/%d/%d", i, j), userID)
+			require.NoError(t, err)
+		}
+
+		ch := make(chan model.UpdateURLDeletedFlag, 1)
+		id, err := repo.SaveURL(ctx, fmt.Sprintf("https://example.com/%d/deleted", i), userID)
+		require.NoError(t, err)
+		ch <- model.UpdateURLDeletedFlag{URLID: id}
+		close(ch)
+		require.NoError(t, repo.UpdateURLsDeletedFlag(ctx, userID, ch))
+	}
+
+	users, err := repo.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	urls, err := repo.GetURLsPage(ctx, "", 2)
+	require.NoError(t, err)
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	users[0].Role = modelAuth.RoleAdmin
+	users[1].Disabled = true
+	require.NoError(t, repo.ImportUsers(ctx, users[:2]))
+	require.NoError(t, repo.ImportAccounts(ctx, []snapshot.Account{
+		{UserID: users[0].ID, Email: "admin@example.com", PasswordHash: "hash", CreatedAt: createdAt},
+	}))
+	require.NoError(t, repo.ImportIdentities(ctx, []snapshot.Identity{
+		{Issuer: "https://issuer.example.com", Subject: "sub-1", UserID: users[1].ID, CreatedAt: createdAt},
+	}))
+	require.NoError(t, repo.ImportAPIKeys(ctx, []snapshot.APIKey{{
+		ID: "key-1", UserID: users[0].ID, Hash: "key-hash", Name: "ci",
+		Scopes: []string{modelAuth.ScopeLinksWrite}, CreatedAt: createdAt,
+	}}))
+	require.NoError(t, repo.ImportWorkspaces(ctx, []snapshot.Workspace{{
+		ID: "ws-1", Name: "team", CreatedAt: createdAt,
+		Members: []snapshot.Member{
+			{UserID: users[0].ID, Role: modelWorkspace.RoleOwner},
+			{UserID: users[2].ID, Role: modelWorkspace.RoleEditor},
+		},
+		URLIDs: []string{urls[0].ID, urls[1].ID},
+	}}))
+	return repo
+}
+
+func TestBackup_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := newFilledRepo(t)
+
+	buf := new(bytes.Buffer)
+	summary, err := New(source).Backup(ctx, buf)
+	require.NoError(t, err)
+	require.Equal(t, snapshot.Summary{
+		Users: 3, URLs: 1203, Accounts: 1, Identities: 1, APIKeys: 1, Workspaces: 1,
+	}, *summary)
+
+	target := inmr.NewInMemoryRepo()
+	restored, err := New(target).Restore(ctx, bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, summary, restored)
+
+	expected, err := source.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	got, err := target.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+
+	expectedUsers, err := source.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	gotUsers, err := target.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedUsers, gotUsers)
+
+	expectedAccounts, err := source.GetAccountsPage(ctx, "", 0)
+	require.NoError(t, err)
+	gotAccounts, err := target.GetAccountsPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedAccounts, gotAccounts)
+
+	expectedIdentities, err := source.GetIdentitiesPage(ctx, "", 0)
+	require.NoError(t, err)
+	gotIdentities, err := target.GetIdentitiesPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedIdentities, gotIdentities)
+
+	expectedKeys, err := source.GetAPIKeysPage(ctx, "", 0)
+	require.NoError(t, err)
+	gotKeys, err := target.GetAPIKeysPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedKeys, gotKeys)
+
+	expectedWorkspaces, err := source.GetWorkspacesPage(ctx, "", 0)
+	require.NoError(t, err)
+	gotWorkspaces, err := target.GetWorkspacesPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expectedWorkspaces, gotWorkspaces)
+
+	// повторное восстановление ничего не меняет
+	_, err = New(target).Restore(ctx, bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	got, err = target.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, expected, got)
+}
+
+func TestBackup_RestoreV1(t *testing.T) {
+	ctx := context.Background()
+	data := compress(t, []byte(`{"type":"header","format":"go-url-shortener-snapshot","version":1}
+{"type":"user","user_id":"user-1"}
+{"type":"url","url":{"id":"abc","original_url":"https://example.com","is_deleted":false,"user_ids":["user-1"]}}
+{"type":"footer","users":1,"urls":1}
+`))
+
+	target := inmr.NewInMemoryRepo()
+	summary, err := New(target).Restore(ctx, bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, snapshot.Summary{Users: 1, URLs: 1}, *summary)
+
+	users, err := target.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, []snapshot.User{{ID: "user-1", Role: modelAuth.RoleUser}}, users)
+	require.True(t, target.IsOwner("https://example.com", "user-1"))
+}
+
+func TestBackup_RestoreInvalid(t *testing.T) {
+	ctx := context.Background()
+
+	buf := new(bytes.Buffer)
+	_, err := New(newFilledRepo(t)).Backup(ctx, buf)
+	require.NoError(t, err)
+	raw := decompress(t, buf.Bytes())
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "Not gzip", data: []byte("plain text")},
+		{name: "Unknown format", data: compress(t, []byte(`{"type":"header","format":"other","version":1}`+"\n"))},
+		{name: "Unsupported version", data: compress(t, bytes.Replace(raw, []byte(`"version":2`), []byte(`"version":3`), 1))},
+		{name: "Truncated", data: compress(t, raw[:len(raw)/2])},
+		{name: "Without footer", data: compress(t, raw[:bytes.LastIndex(raw[:len(raw)-1], []byte("\n"))+1])},
+		{name: "Wrong count", data: compress(t, bytes.Replace(raw, []byte(`"users":3`), []byte(`"users":4`), 1))},
+		{name: "Wrong workspaces count", data: compress(t, bytes.Replace(raw, []byte(`"workspaces":1`), []byte(`"workspaces":2`), 1))},
+		{name: "Workspace without owner", data: compress(t, bytes.Replace(raw, []byte(`"role":"owner"`), []byte(`"role":"viewer"`), 1))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(inmr.NewInMemoryRepo()).Restore(ctx, bytes.NewReader(tt.data))
+			require.ErrorIs(t, err, ErrInvalidSnapshot)
+		})
+	}
+}
+
+func compress(t *testing.T, data []byte) []byte {
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	_, err := gz.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func decompress(t *testing.T, data []byte) []byte {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	_, err = buf.ReadFrom(gz)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
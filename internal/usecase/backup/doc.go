@@ -0,0 +1,12 @@
+/*
+Package backup это usecase для резервного копирования и восстановления данных.
+
+Снимок - это сжатый gzip'ом поток JSON-записей, по одной на строку:
+заголовок, пользователи с ролями, URL'ы с владельцами и флагами удаления, учётные записи,
+удостоверения, API-ключи, рабочие пространства с участниками и ссылками и завершающая запись
+с количеством записей каждого вида. Сеансы и токены обновления в снимок не попадают.
+Снимки первой версии, где есть только пользователи и URL'ы, по-прежнему восстанавливаются.
+Формат не зависит от хранилища, поэтому снимок, снятый с одного хранилища,
+можно восстановить в любое другое.
+*/
+package backup
@@ -0,0 +1,276 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+)
+
+// Параметры формата снимка
+const (
+	formatName    = "go-url-shortener-snapshot"
+	formatVersion = 2
+	// formatVersionV1 первая версия: только пользователи без ролей и URL'ы
+	formatVersionV1 = 1
+)
+
+// Типы записей снимка
+const (
+	recordHeader    = "header"
+	recordUser      = "user"
+	recordURL       = "url"
+	recordAccount   = "account"
+	recordIdentity  = "identity"
+	recordAPIKey    = "api_key"
+	recordWorkspace = "workspace"
+	recordFooter    = "footer"
+)
+
+// ErrInvalidSnapshot снимок повреждён, обрезан или имеет неизвестный формат
+var ErrInvalidSnapshot = errors.New("backup: invalid snapshot")
+
+// record строка снимка
+type record struct {
+	Type       string              `json:"type"`
+	Format     string              `json:"format,omitempty"`
+	Version    int                 `json:"version,omitempty"`
+	CreatedAt  *time.Time          `json:"created_at,omitempty"`
+	UserID     string              `json:"user_id,omitempty"` // пользователь в снимке первой версии
+	User       *snapshot.User      `json:"user,omitempty"`
+	URL        *snapshot.URL       `json:"url,omitempty"`
+	Account    *snapshot.Account   `json:"account,omitempty"`
+	Identity   *snapshot.Identity  `json:"identity,omitempty"`
+	APIKey     *snapshot.APIKey    `json:"api_key,omitempty"`
+	Workspace  *snapshot.Workspace `json:"workspace,omitempty"`
+	Users      *int                `json:"users,omitempty"`
+	URLs       *int                `json:"urls,omitempty"`
+	Accounts   *int                `json:"accounts,omitempty"`
+	Identities *int                `json:"identities,omitempty"`
+	APIKeys    *int                `json:"api_keys,omitempty"`
+	Workspaces *int                `json:"workspaces,omitempty"`
+}
+
+// snapshotWriter пишет снимок; реализует snapshot.Writer
+type snapshotWriter struct {
+	gz      *gzip.Writer
+	enc     *json.Encoder
+	summary snapshot.Summary
+}
+
+func newSnapshotWriter(w io.Writer, createdAt time.Time) (*snapshotWriter, error) {
+	gz := gzip.NewWriter(w)
+	sw := &snapshotWriter{
+		gz:  gz,
+		enc: json.NewEncoder(gz),
+	}
+
+	err := sw.enc.Encode(record{
+		Type:      recordHeader,
+		Format:    formatName,
+		Version:   formatVersion,
+		CreatedAt: &createdAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sw, nil
+}
+
+// WriteUser реализует snapshot.Writer
+func (w *snapshotWriter) WriteUser(user snapshot.User) error {
+	w.summary.Users++
+	return w.enc.Encode(record{Type: recordUser, User: &user})
+}
+
+// WriteURL реализует snapshot.Writer
+func (w *snapshotWriter) WriteURL(url snapshot.URL) error {
+	w.summary.URLs++
+	return w.enc.Encode(record{Type: recordURL, URL: &url})
+}
+
+// WriteAccount реализует snapshot.Writer
+func (w *snapshotWriter) WriteAccount(account snapshot.Account) error {
+	w.summary.Accounts++
+	return w.enc.Encode(record{Type: recordAccount, Account: &account})
+}
+
+// WriteIdentity реализует snapshot.Writer
+func (w *snapshotWriter) WriteIdentity(identity snapshot.Identity) error {
+	w.summary.Identities++
+	return w.enc.Encode(record{Type: recordIdentity, Identity: &identity})
+}
+
+// WriteAPIKey реализует snapshot.Writer
+func (w *snapshotWriter) WriteAPIKey(key snapshot.APIKey) error {
+	w.summary.APIKeys++
+	return w.enc.Encode(record{Type: recordAPIKey, APIKey: &key})
+}
+
+// WriteWorkspace реализует snapshot.Writer
+func (w *snapshotWriter) WriteWorkspace(ws snapshot.Workspace) error {
+	w.summary.Workspaces++
+	return w.enc.Encode(record{Type: recordWorkspace, Workspace: &ws})
+}
+
+// Close допишет завершающую запись и закроет gzip-поток
+func (w *snapshotWriter) Close() error {
+	err := w.enc.Encode(record{
+		Type:       recordFooter,
+		Users:      &w.summary.Users,
+		URLs:       &w.summary.URLs,
+		Accounts:   &w.summary.Accounts,
+		Identities: &w.summary.Identities,
+		APIKeys:    &w.summary.APIKeys,
+		Workspaces: &w.summary.Workspaces,
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.gz.Close()
+}
+
+// snapshotReader читает снимок
+type snapshotReader struct {
+	gz      *gzip.Reader
+	dec     *json.Decoder
+	version int
+	summary snapshot.Summary
+	done    bool
+}
+
+func newSnapshotReader(r io.Reader) (*snapshotReader, error) {
+	gz, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+
+	sr := &snapshotReader{
+		gz:  gz,
+		dec: json.NewDecoder(gz),
+	}
+
+	var header record
+	if err = sr.dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("%w: can not read header: %s", ErrInvalidSnapshot, err)
+	}
+	if header.Type != recordHeader || header.Format != formatName {
+		return nil, fmt.Errorf("%w: unknown format", ErrInvalidSnapshot)
+	}
+	if header.Version != formatVersion && header.Version != formatVersionV1 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidSnapshot, header.Version)
+	}
+	sr.version = header.Version
+
+	return sr, nil
+}
+
+// next вернёт очередную запись с данными;
+// io.EOF - снимок прочитан полностью и количество записей совпало с завершающей записью
+func (r *snapshotReader) next() (*record, error) {
+	if r.done {
+		return nil, io.EOF
+	}
+
+	rec := new(record)
+	if err := r.dec.Decode(rec); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: unexpected end of snapshot", ErrInvalidSnapshot)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSnapshot, err)
+	}
+
+	switch rec.Type {
+	case recordUser:
+		if rec.User == nil && rec.UserID != "" {
+			rec.User = &snapshot.User{ID: rec.UserID}
+		}
+		if rec.User == nil || rec.User.ID == "" {
+			return nil, fmt.Errorf("%w: empty user id", ErrInvalidSnapshot)
+		}
+		r.summary.Users++
+	case recordURL:
+		if rec.URL == nil || rec.URL.ID == "" || rec.URL.OriginalURL == "" {
+			return nil, fmt.Errorf("%w: incomplete url record", ErrInvalidSnapshot)
+		}
+		r.summary.URLs++
+	case recordAccount:
+		if rec.Account == nil || rec.Account.UserID == "" || rec.Account.Email == "" {
+			return nil, fmt.Errorf("%w: incomplete account record", ErrInvalidSnapshot)
+		}
+		r.summary.Accounts++
+	case recordIdentity:
+		if rec.Identity == nil || rec.Identity.Issuer == "" ||
+			rec.Identity.Subject == "" || rec.Identity.UserID == "" {
+			return nil, fmt.Errorf("%w: incomplete identity record", ErrInvalidSnapshot)
+		}
+		r.summary.Identities++
+	case recordAPIKey:
+		if rec.APIKey == nil || rec.APIKey.ID == "" || rec.APIKey.UserID == "" || rec.APIKey.Hash == "" {
+			return nil, fmt.Errorf("%w: incomplete api key record", ErrInvalidSnapshot)
+		}
+		r.summary.APIKeys++
+	case recordWorkspace:
+		if err := validateWorkspace(rec.Workspace); err != nil {
+			return nil, err
+		}
+		r.summary.Workspaces++
+	case recordFooter:
+		if !r.footerMatches(rec) {
+			return nil, fmt.Errorf("%w: records count does not match footer", ErrInvalidSnapshot)
+		}
+		r.done = true
+		return nil, io.EOF
+	default:
+		return nil, fmt.Errorf("%w: unknown record type %q", ErrInvalidSnapshot, rec.Type)
+	}
+
+	return rec, nil
+}
+
+// validateWorkspace проверит, что у пространства есть ID и владелец:
+// пространство без владельца никто не сможет администрировать
+func validateWorkspace(ws *snapshot.Workspace) error {
+	if ws == nil || ws.ID == "" {
+		return fmt.Errorf("%w: incomplete workspace record", ErrInvalidSnapshot)
+	}
+
+	hasOwner := false
+	for _, m := range ws.Members {
+		if m.UserID == "" || m.Role == "" {
+			return fmt.Errorf("%w: incomplete member of workspace %s", ErrInvalidSnapshot, ws.ID)
+		}
+		hasOwner = hasOwner || m.Role == modelWorkspace.RoleOwner
+	}
+	if !hasOwner {
+		return fmt.Errorf("%w: workspace %s has no owner", ErrInvalidSnapshot, ws.ID)
+	}
+	return nil
+}
+
+// footerMatches сверит количество прочитанных записей с завершающей записью
+func (r *snapshotReader) footerMatches(footer *record) bool {
+	expected := []*int{footer.Users, footer.URLs,
+		footer.Accounts, footer.Identities, footer.APIKeys, footer.Workspaces}
+	got := []int{r.summary.Users, r.summary.URLs,
+		r.summary.Accounts, r.summary.Identities, r.summary.APIKeys, r.summary.Workspaces}
+	// в снимке первой версии есть только пользователи и URL'ы
+	if r.version == formatVersionV1 {
+		expected, got = expected[:2], got[:2]
+	}
+
+	for i := range expected {
+		if expected[i] == nil || *expected[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,11 @@
+/*
+Package migration это usecase для переноса данных из одного хранилища в другое.
+
+Перенос идёт страницами в порядке возрастания ID: сначала пользователи с ролями, затем URL'ы
+вместе с владельцами и флагами удаления, учётные записи, удостоверения, API-ключи
+и рабочие пространства с участниками и ссылками. Сеансы и токены обновления
+не переносятся: после переноса пользователи входят заново. После каждой страницы прогресс сохраняется
+в файл состояния, поэтому прерванный перенос продолжается с места остановки.
+Загрузка в хранилище идемпотентна, так что повторно перенесённая страница ничего не портит.
+*/
+package migration
@@ -0,0 +1,248 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelStats "github.com/KartoonYoko/go-url-shortener/internal/model/stats"
+)
+
+// defaultBatchSize размер страницы по умолчанию
+const defaultBatchSize = 500
+
+// ErrVerificationFailed данные в хранилище-приёмнике не совпали с исходными
+var ErrVerificationFailed = errors.New("migration: verification failed")
+
+// ErrStateMismatch файл состояния относится к переносу между другими хранилищами
+var ErrStateMismatch = errors.New("migration: state file belongs to another migration")
+
+// SourceRepo хранилище, из которого переносятся данные
+type SourceRepo interface {
+	GetUsersPage(ctx context.Context, afterID string, limit int) ([]snapshot.User, error)
+	GetURLsPage(ctx context.Context, afterID string, limit int) ([]snapshot.URL, error)
+	GetAccountsPage(ctx context.Context, afterUserID string, limit int) ([]snapshot.Account, error)
+	GetIdentitiesPage(ctx context.Context, afterKey string, limit int) ([]snapshot.Identity, error)
+	GetAPIKeysPage(ctx context.Context, afterID string, limit int) ([]snapshot.APIKey, error)
+	GetWorkspacesPage(ctx context.Context, afterID string, limit int) ([]snapshot.Workspace, error)
+	GetStats(ctx context.Context) (*modelStats.StatsResponse, error)
+}
+
+// TargetRepo хранилище, в которое переносятся данные
+type TargetRepo interface {
+	SourceRepo
+	ImportUsers(ctx context.Context, users []snapshot.User) error
+	ImportURLs(ctx context.Context, urls []snapshot.URL) error
+	ImportAccounts(ctx context.Context, accounts []snapshot.Account) error
+	ImportIdentities(ctx context.Context, identities []snapshot.Identity) error
+	ImportAPIKeys(ctx context.Context, keys []snapshot.APIKey) error
+	ImportWorkspaces(ctx context.Context, workspaces []snapshot.Workspace) error
+}
+
+// Этапы переноса
+const (
+	StageUsers      = "users"      // перенос пользователей
+	StageURLs       = "urls"       // перенос URL'ов
+	StageAccounts   = "accounts"   // перенос учётных записей
+	StageIdentities = "identities" // перенос удостоверений
+	StageAPIKeys    = "api_keys"   // перенос API-ключей
+	StageWorkspaces = "workspaces" // перенос рабочих пространств
+	StageVerify     = "verify"     // проверка результата
+	StageDone       = "done"       // перенос завершён
+)
+
+// stages этапы переноса данных в порядке зависимостей
+var stages = []string{StageUsers, StageURLs, StageAccounts, StageIdentities, StageAPIKeys, StageWorkspaces}
+
+// Counts количество записей каждого вида
+type Counts struct {
+	Users      int `json:"users"`      // пользователей
+	URLs       int `json:"urls"`       // URL'ов
+	Accounts   int `json:"accounts"`   // учётных записей
+	Identities int `json:"identities"` // удостоверений
+	APIKeys    int `json:"api_keys"`   // API-ключей
+	Workspaces int `json:"workspaces"` // рабочих пространств
+}
+
+// Progress прогресс переноса
+type Progress struct {
+	Stage      string // текущий этап
+	Counts            // перенесено записей
+	TotalUsers int    // пользователей в исходном хранилище на момент старта
+	TotalURLs  int    // URL'ов в исходном хранилище на момент старта
+}
+
+// Report итог переноса
+type Report struct {
+	Progress
+	Resumed  bool          // перенос продолжен по файлу состояния
+	Verified *VerifyReport // результат проверки; nil, если проверка не выполнялась
+}
+
+// Options настройки переноса
+type Options struct {
+	// ID переноса, обычно строится из адресов хранилищ;
+	// по нему проверяется, что файл состояния относится к этому переносу
+	ID string
+	// Путь к файлу состояния; пустая строка - перенос не продолжается после сбоя
+	StateFile string
+	// Размер страницы
+	BatchSize int
+	// Проверить результат после переноса
+	Verify bool
+	// Вызывается после каждой перенесённой страницы и в конце каждого этапа
+	OnProgress func(Progress)
+}
+
+type migrationUsecase struct {
+	source SourceRepo
+	target TargetRepo
+	opts   Options
+}
+
+// New инициализирует migrationUsecase
+func New(source SourceRepo, target TargetRepo, opts Options) *migrationUsecase {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.OnProgress == nil {
+		opts.OnProgress = func(Progress) {}
+	}
+
+	return &migrationUsecase{
+		source: source,
+		target: target,
+		opts:   opts,
+	}
+}
+
+// Run переносит данные и, если требуется, проверяет результат
+func (uc *migrationUsecase) Run(ctx context.Context) (*Report, error) {
+	st, resumed, err := loadState(uc.opts.StateFile, uc.opts.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := uc.source.GetStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("can not get source stats: %w", err)
+	}
+
+	report := &Report{Resumed: resumed}
+	report.TotalUsers = stats.Users
+	report.TotalURLs = stats.URLs
+	report.Counts = st.Counts
+
+	for st.Stage != StageDone {
+		if err = uc.migrateStage(ctx, st, report); err != nil {
+			return report, err
+		}
+	}
+
+	if uc.opts.Verify {
+		report.Stage = StageVerify
+		uc.opts.OnProgress(report.Progress)
+
+		report.Verified, err = verify(ctx, uc.source, uc.target, uc.opts.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		if report.Verified.MismatchCount > 0 {
+			return report, fmt.Errorf("%w: %d mismatches", ErrVerificationFailed, report.Verified.MismatchCount)
+		}
+	}
+
+	report.Stage = StageDone
+	uc.opts.OnProgress(report.Progress)
+	return report, nil
+}
+
+// migrateStage переносит данные текущего этапа и переводит состояние на следующий этап
+func (uc *migrationUsecase) migrateStage(ctx context.Context, st *state, report *Report) error {
+	switch st.Stage {
+	case StageUsers:
+		return migratePages(ctx, uc, st, report, "users",
+			uc.source.GetUsersPage, uc.target.ImportUsers,
+			func(u snapshot.User) string { return u.ID },
+			func(c *Counts) *int { return &c.Users })
+	case StageURLs:
+		return migratePages(ctx, uc, st, report, "urls",
+			uc.source.GetURLsPage, uc.target.ImportURLs,
+			func(u snapshot.URL) string { return u.ID },
+			func(c *Counts) *int { return &c.URLs })
+	case StageAccounts:
+		return migratePages(ctx, uc, st, report, "accounts",
+			uc.source.GetAccountsPage, uc.target.ImportAccounts,
+			func(a snapshot.Account) string { return a.UserID },
+			func(c *Counts) *int { return &c.Accounts })
+	case StageIdentities:
+		return migratePages(ctx, uc, st, report, "identities",
+			uc.source.GetIdentitiesPage, uc.target.ImportIdentities,
+			snapshot.Identity.Key,
+			func(c *Counts) *int { return &c.Identities })
+	case StageAPIKeys:
+		return migratePages(ctx, uc, st, report, "api keys",
+			uc.source.GetAPIKeysPage, uc.target.ImportAPIKeys,
+			func(k snapshot.APIKey) string { return k.ID },
+			func(c *Counts) *int { return &c.APIKeys })
+	case StageWorkspaces:
+		return migratePages(ctx, uc, st, report, "workspaces",
+			uc.source.GetWorkspacesPage, uc.target.ImportWorkspaces,
+			func(ws snapshot.Workspace) string { return ws.ID },
+			func(c *Counts) *int { return &c.Workspaces })
+	default:
+		return fmt.Errorf("unknown migration stage %q", st.Stage)
+	}
+}
+
+// migratePages переносит страницами записи одного вида начиная с курсора состояния;
+// counter выбирает счётчик этих записей
+func migratePages[T any](
+	ctx context.Context,
+	uc *migrationUsecase,
+	st *state,
+	report *Report,
+	name string,
+	fetch func(ctx context.Context, afterID string, limit int) ([]T, error),
+	store func(ctx context.Context, items []T) error,
+	key func(T) string,
+	counter func(*Counts) *int) error {
+	report.Stage = st.Stage
+	for {
+		page, err := fetch(ctx, st.Cursor, uc.opts.BatchSize)
+		if err != nil {
+			return fmt.Errorf("can not read %s after %q: %w", name, st.Cursor, err)
+		}
+		if len(page) == 0 {
+			break
+		}
+
+		if err = store(ctx, page); err != nil {
+			return fmt.Errorf("can not import %s after %q: %w", name, st.Cursor, err)
+		}
+
+		st.Cursor = key(page[len(page)-1])
+		*counter(&st.Counts) += len(page)
+		if err = st.save(uc.opts.StateFile); err != nil {
+			return err
+		}
+
+		report.Counts = st.Counts
+		uc.opts.OnProgress(report.Progress)
+	}
+
+	st.Stage = nextStage(st.Stage)
+	st.Cursor = ""
+	return st.save(uc.opts.StateFile)
+}
+
+// nextStage вернёт этап, следующий за stage
+func nextStage(stage string) string {
+	for i, s := range stages[:len(stages)-1] {
+		if s == stage {
+			return stages[i+1]
+		}
+	}
+	return StageDone
+}
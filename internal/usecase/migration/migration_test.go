@@ -0,0 +1,300 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+// failingTarget приёмник, который перестаёт принимать URL'ы после failAfter страниц
+type failingTarget struct {
+	*inmr.InMemoryRepo
+	failAfter int
+	imported  int
+}
+
+func (t *failingTarget) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	if t.imported >= t.failAfter {
+		return errors.New("target is unavailable")
+	}
+	t.imported++
+	return t.InMemoryRepo.ImportURLs(ctx, urls)
+}
+
+// lossyTarget приёмник, который теряет владельцев URL'ов
+type lossyTarget struct {
+	*inmr.InMemoryRepo
+}
+
+func (t *lossyTarget) ImportURLs(ctx context.Context, urls []snapshot.URL) error {
+	stripped := make([]snapshot.URL, 0, len(urls))
+	for _, u := range urls {
+		u.UserIDs = nil
+		stripped = append(stripped, u)
+	}
+	return t.InMemoryRepo.ImportURLs(ctx, stripped)
+}
+
+// rolelessTarget приёмник, который теряет роли и состояние пользователей
+type rolelessTarget struct {
+	*inmr.InMemoryRepo
+}
+
+func (t *rolelessTarget) ImportUsers(ctx context.Context, users []snapshot.User) error {
+	stripped := make([]snapshot.User, 0, len(users))
+	for _, u := range users {
+		stripped = append(stripped, snapshot.User{ID: u.ID})
+	}
+	return t.InMemoryRepo.ImportUsers(ctx, stripped)
+}
+
+func newSource(t *testing.T, users int, urlsPerUser int) *inmr.InMemoryRepo {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	for i := 0; i < users; i++ {
+		userID, err := repo.GetNewUserID(ctx)
+		require.NoError(t, err)
+
+		ids := make([]string, 0, urlsPerUser)
+		for j := 0; j < urlsPerUser; j++ {
+			id, err := repo.SaveURL(ctx, fmt.Sprintf("https://example.com/%d/%d", i, j), userID)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+
+		// общий для всех пользователей URL
+		_, err = repo.SaveURL(ctx, "https://example.com/shared", userID)
+		if err != nil {
+			var errAlreadyExists *repoCommon.URLAlreadyExistsError
+			require.ErrorAs(t, err, &errAlreadyExists)
+		}
+
+		// первый URL каждого пользователя удалён
+		repo.MarkUserURLsDeleted(context.Background(), userID, ids[:1])
+	}
+	return repo
+}
+
+func TestMigration_Run(t *testing.T) {
+	ctx := context.Background()
+	source := newSource(t, 5, 4)
+	target := inmr.NewInMemoryRepo()
+
+	progress := make([]Progress, 0)
+	uc := New(source, target, Options{
+		ID:         "test",
+		StateFile:  filepath.Join(t.TempDir(), "state.json"),
+		BatchSize:  3,
+		Verify:     true,
+		OnProgress: func(p Progress) { progress = append(progress, p) },
+	})
+	report, err := uc.Run(ctx)
+	require.NoError(t, err)
+	require.False(t, report.Resumed)
+	require.Equal(t, StageDone, report.Stage)
+	require.Equal(t, 5, report.Users)
+	require.Equal(t, 21, report.URLs)
+	require.Equal(t, report.TotalUsers, report.Users)
+	require.Equal(t, report.TotalURLs, report.URLs)
+	require.NotNil(t, report.Verified)
+	require.Equal(t, 0, report.Verified.MismatchCount)
+	require.NotEmpty(t, progress)
+	require.Equal(t, StageDone, progress[len(progress)-1].Stage)
+
+	sourceURLs, err := source.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	targetURLs, err := target.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, sourceURLs, targetURLs)
+
+	sourceStats, err := source.GetStats(ctx)
+	require.NoError(t, err)
+	targetStats, err := target.GetStats(ctx)
+	require.NoError(t, err)
+	require.Equal(t, sourceStats, targetStats)
+}
+
+func TestMigration_AuthAndWorkspaces(t *testing.T) {
+	ctx := context.Background()
+	source := newSource(t, 2, 2)
+	target := inmr.NewInMemoryRepo()
+
+	users, err := source.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	urls, err := source.GetURLsPage(ctx, "", 0)
+	require.NoError(t, err)
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	usedAt := createdAt.Add(time.Hour)
+
+	users[0].Role = modelAuth.RoleAdmin
+	users[1].Disabled = true
+	require.NoError(t, source.ImportUsers(ctx, users))
+	require.NoError(t, source.ImportAccounts(ctx, []snapshot.Account{
+		{UserID: users[0].ID, Email: "admin@example.com", PasswordHash: "hash", CreatedAt: createdAt},
+	}))
+	require.NoError(t, source.ImportIdentities(ctx, []snapshot.Identity{
+		{Issuer: "https://issuer.example.com", Subject: "sub-1", UserID: users[1].ID, CreatedAt: createdAt},
+	}))
+	require.NoError(t, source.ImportAPIKeys(ctx, []snapshot.APIKey{{
+		ID: "key-1", UserID: users[0].ID, Hash: "key-hash", Name: "ci",
+		Scopes: []string{modelAuth.ScopeLinksRead}, CreatedAt: createdAt, LastUsedAt: &usedAt,
+	}}))
+	require.NoError(t, source.ImportWorkspaces(ctx, []snapshot.Workspace{{
+		ID: "ws-1", Name: "team", CreatedAt: createdAt,
+		Members: []snapshot.Member{
+			{UserID: users[0].ID, Role: modelWorkspace.RoleOwner},
+			{UserID: users[1].ID, Role: modelWorkspace.RoleViewer},
+		},
+		URLIDs: []string{urls[0].ID},
+	}}))
+
+	report, err := New(source, target, Options{ID: "test", BatchSize: 1, Verify: true}).Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, Counts{Users: 2, URLs: 5, Accounts: 1, Identities: 1, APIKeys: 1, Workspaces: 1}, report.Counts)
+	require.Equal(t, report.Counts, report.Verified.Counts)
+
+	var expected, got collectingWriter
+	require.NoError(t, source.Export(ctx, &expected))
+	require.NoError(t, target.Export(ctx, &got))
+	require.Equal(t, expected, got)
+
+	// потерянные роли находятся проверкой
+	lossy := &rolelessTarget{InMemoryRepo: inmr.NewInMemoryRepo()}
+	report, err = New(source, lossy, Options{ID: "test", Verify: true}).Run(ctx)
+	require.ErrorIs(t, err, ErrVerificationFailed)
+	require.Equal(t, 2, report.Verified.MismatchCount)
+}
+
+func TestMigration_Resume(t *testing.T) {
+	ctx := context.Background()
+	source := newSource(t, 3, 5)
+	target := &failingTarget{InMemoryRepo: inmr.NewInMemoryRepo(), failAfter: 2}
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+	opts := Options{ID: "test", StateFile: stateFile, BatchSize: 4, Verify: true}
+
+	report, err := New(source, target, opts).Run(ctx)
+	require.Error(t, err)
+	require.Equal(t, StageURLs, report.Stage)
+	require.Equal(t, 8, report.URLs)
+
+	// после восстановления приёмника перенос продолжается с места остановки
+	target.failAfter = 100
+	report, err = New(source, target, opts).Run(ctx)
+	require.NoError(t, err)
+	require.True(t, report.Resumed)
+	require.Equal(t, 16, report.URLs)
+	require.Equal(t, 4, target.imported)
+
+	// завершённый перенос повторно только проверяется
+	report, err = New(source, target, opts).Run(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 4, target.imported)
+	require.Equal(t, 16, report.Verified.URLs)
+}
+
+func TestMigration_StateMismatch(t *testing.T) {
+	ctx := context.Background()
+	stateFile := filepath.Join(t.TempDir(), "state.json")
+
+	_, err := New(newSource(t, 1, 1), inmr.NewInMemoryRepo(), Options{ID: "first", StateFile: stateFile}).Run(ctx)
+	require.NoError(t, err)
+
+	_, err = New(newSource(t, 1, 1), inmr.NewInMemoryRepo(), Options{ID: "second", StateFile: stateFile}).Run(ctx)
+	require.ErrorIs(t, err, ErrStateMismatch)
+}
+
+func TestMigration_VerificationFailed(t *testing.T) {
+	ctx := context.Background()
+	target := &lossyTarget{InMemoryRepo: inmr.NewInMemoryRepo()}
+
+	// лишние данные в приёмнике расхождением не считаются
+	_, err := target.SaveURL(ctx, "https://example.com/only-in-target", "")
+	require.NoError(t, err)
+
+	report, err := New(newSource(t, 2, 2), target, Options{ID: "test", Verify: true}).Run(ctx)
+	require.ErrorIs(t, err, ErrVerificationFailed)
+	require.Equal(t, 6, report.Verified.MismatchCount)
+	require.Len(t, report.Verified.Mismatches, 6)
+}
+
+func TestMigration_DeletedFlag(t *testing.T) {
+	ctx := context.Background()
+	source := newSource(t, 1, 2)
+	target := inmr.NewInMemoryRepo()
+
+	_, err := New(source, target, Options{ID: "test"}).Run(ctx)
+	require.NoError(t, err)
+
+	users, err := target.GetUsersPage(ctx, "", 0)
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	userURLs, err := target.GetUserURLs(ctx, users[0].ID)
+	require.NoError(t, err)
+	require.Len(t, userURLs, 3)
+
+	deleted := 0
+	for _, u := range userURLs {
+		_, err = target.GetURLByID(ctx, u.ShortURL)
+		if errors.Is(err, repoCommon.ErrURLDeleted) {
+			deleted++
+		}
+	}
+	require.Equal(t, 1, deleted)
+
+	// удаление в приёмнике работает для перенесённых владельцев
+	ch := make(chan model.UpdateURLDeletedFlag, 1)
+	ch <- model.UpdateURLDeletedFlag{URLID: userURLs[0].ShortURL}
+	close(ch)
+	require.NoError(t, target.UpdateURLsDeletedFlag(ctx, users[0].ID, ch))
+}
+
+// collectingWriter собирает выгруженные данные
+type collectingWriter struct {
+	users      []snapshot.User
+	urls       []snapshot.URL
+	accounts   []snapshot.Account
+	identities []snapshot.Identity
+	keys       []snapshot.APIKey
+	workspaces []snapshot.Workspace
+}
+
+func (w *collectingWriter) WriteUser(user snapshot.User) error {
+	w.users = append(w.users, user)
+	return nil
+}
+
+func (w *collectingWriter) WriteURL(url snapshot.URL) error {
+	w.urls = append(w.urls, url)
+	return nil
+}
+
+func (w *collectingWriter) WriteAccount(account snapshot.Account) error {
+	w.accounts = append(w.accounts, account)
+	return nil
+}
+
+func (w *collectingWriter) WriteIdentity(identity snapshot.Identity) error {
+	w.identities = append(w.identities, identity)
+	return nil
+}
+
+func (w *collectingWriter) WriteAPIKey(key snapshot.APIKey) error {
+	w.keys = append(w.keys, key)
+	return nil
+}
+
+func (w *collectingWriter) WriteWorkspace(ws snapshot.Workspace) error {
+	w.workspaces = append(w.workspaces, ws)
+	return nil
+}
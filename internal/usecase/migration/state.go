@@ -0,0 +1,76 @@
+package migration
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// state состояние переноса, сохраняемое между запусками
+type state struct {
+	ID     string `json:"id"`     // ID переноса
+	Stage  string `json:"stage"`  // этап, на котором остановился перенос
+	Cursor string `json:"cursor"` // ID последней перенесённой записи этапа
+	Counts        // перенесено записей
+}
+
+// loadState прочитает состояние из файла; если файла нет, вернёт начальное состояние.
+// Второе значение сообщает, что состояние прочитано из файла
+func loadState(filename string, id string) (*state, bool, error) {
+	st := &state{ID: id, Stage: StageUsers}
+	if filename == "" {
+		return st, false, nil
+	}
+
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return st, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("can not read migration state: %w", err)
+	}
+
+	if err = json.Unmarshal(data, st); err != nil {
+		return nil, false, fmt.Errorf("can not parse migration state: %w", err)
+	}
+	if st.ID != id {
+		return nil, false, fmt.Errorf("%w: %s", ErrStateMismatch, filename)
+	}
+
+	return st, true, nil
+}
+
+// save атомарно перезапишет файл состояния
+func (st *state) save(filename string) error {
+	if filename == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("can not save migration state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("can not save migration state: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("can not save migration state: %w", err)
+	}
+
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("can not save migration state: %w", err)
+	}
+
+	return nil
+}
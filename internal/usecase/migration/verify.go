@@ -0,0 +1,273 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/KartoonYoko/go-url-shortener/internal/model/snapshot"
+)
+
+// maxReportedMismatches сколько расхождений попадёт в отчёт
+const maxReportedMismatches = 20
+
+// VerifyReport результат проверки переноса
+type VerifyReport struct {
+	Counts                 // проверено записей
+	MismatchCount int      // найдено расхождений
+	Mismatches    []string // описания первых расхождений
+}
+
+func (r *VerifyReport) addMismatch(format string, args ...interface{}) {
+	r.MismatchCount++
+	if len(r.Mismatches) < maxReportedMismatches {
+		r.Mismatches = append(r.Mismatches, fmt.Sprintf(format, args...))
+	}
+}
+
+// verify сверяет исходное хранилище с приёмником.
+//
+// Оба хранилища читаются страницами в порядке возрастания ID и сравниваются слиянием.
+// Записи, которые есть только в приёмнике, расхождением не считаются:
+// приёмник мог содержать данные до переноса.
+func verify(ctx context.Context, source SourceRepo, target SourceRepo, batchSize int) (*VerifyReport, error) {
+	report := &VerifyReport{}
+
+	err := verifyPages(ctx, report, "user", source.GetUsersPage, target.GetUsersPage,
+		func(u snapshot.User) string { return u.ID }, batchSize, &report.Users, compareUser)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyPages(ctx, report, "url", source.GetURLsPage, target.GetURLsPage,
+		func(u snapshot.URL) string { return u.ID }, batchSize, &report.URLs, compareURL)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyPages(ctx, report, "account of user", source.GetAccountsPage, target.GetAccountsPage,
+		func(a snapshot.Account) string { return a.UserID }, batchSize, &report.Accounts, compareAccount)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyPages(ctx, report, "identity", source.GetIdentitiesPage, target.GetIdentitiesPage,
+		snapshot.Identity.Key, batchSize, &report.Identities, compareIdentity)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyPages(ctx, report, "api key", source.GetAPIKeysPage, target.GetAPIKeysPage,
+		func(k snapshot.APIKey) string { return k.ID }, batchSize, &report.APIKeys, compareAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	err = verifyPages(ctx, report, "workspace", source.GetWorkspacesPage, target.GetWorkspacesPage,
+		func(ws snapshot.Workspace) string { return ws.ID }, batchSize, &report.Workspaces, compareWorkspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// verifyPages сверяет записи одного вида; checked считает проверенные записи
+func verifyPages[T any](
+	ctx context.Context,
+	report *VerifyReport,
+	name string,
+	source func(ctx context.Context, afterID string, limit int) ([]T, error),
+	target func(ctx context.Context, afterID string, limit int) ([]T, error),
+	key func(T) string,
+	batchSize int,
+	checked *int,
+	compare func(report *VerifyReport, expected T, got T)) error {
+	sourcePager := newPager(source, key, batchSize)
+	targetPager := newPager(target, key, batchSize)
+	for {
+		item, ok, err := sourcePager.next(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		*checked++
+		got, found, err := targetPager.seek(ctx, key(item))
+		if err != nil {
+			return err
+		}
+		if !found {
+			report.addMismatch("%s %s not found", name, key(item))
+			continue
+		}
+		compare(report, item, got)
+	}
+}
+
+// compareUser сверяет роль и состояние пользователя
+func compareUser(report *VerifyReport, expected snapshot.User, got snapshot.User) {
+	if expected.Role != got.Role {
+		report.addMismatch("user %s: role %q, expected %q", expected.ID, got.Role, expected.Role)
+	}
+	if expected.Disabled != got.Disabled {
+		report.addMismatch("user %s: disabled %t, expected %t", expected.ID, got.Disabled, expected.Disabled)
+	}
+}
+
+// compareAccount сверяет почту и хеш пароля учётной записи
+func compareAccount(report *VerifyReport, expected snapshot.Account, got snapshot.Account) {
+	if expected.Email != got.Email {
+		report.addMismatch("account of user %s: email %q, expected %q", expected.UserID, got.Email, expected.Email)
+	}
+	if expected.PasswordHash != got.PasswordHash {
+		report.addMismatch("account of user %s: password hash differs", expected.UserID)
+	}
+}
+
+// compareIdentity сверяет владельца удостоверения
+func compareIdentity(report *VerifyReport, expected snapshot.Identity, got snapshot.Identity) {
+	if expected.UserID != got.UserID {
+		report.addMismatch("identity %s: user %s, expected %s", expected.Key(), got.UserID, expected.UserID)
+	}
+}
+
+// compareAPIKey сверяет владельца, хеш и права API-ключа
+func compareAPIKey(report *VerifyReport, expected snapshot.APIKey, got snapshot.APIKey) {
+	if expected.UserID != got.UserID {
+		report.addMismatch("api key %s: user %s, expected %s", expected.ID, got.UserID, expected.UserID)
+	}
+	if expected.Hash != got.Hash {
+		report.addMismatch("api key %s: hash differs", expected.ID)
+	}
+	if !slices.Equal(expected.Scopes, got.Scopes) {
+		report.addMismatch("api key %s: scopes %v, expected %v", expected.ID, got.Scopes, expected.Scopes)
+	}
+}
+
+// compareWorkspace сверяет название, роли участников и ссылки рабочего пространства
+func compareWorkspace(report *VerifyReport, expected snapshot.Workspace, got snapshot.Workspace) {
+	if expected.Name != got.Name {
+		report.addMismatch("workspace %s: name %q, expected %q", expected.ID, got.Name, expected.Name)
+	}
+
+	roles := make(map[string]string, len(got.Members))
+	for _, m := range got.Members {
+		roles[m.UserID] = m.Role
+	}
+	for _, m := range expected.Members {
+		role, ok := roles[m.UserID]
+		if !ok {
+			report.addMismatch("workspace %s: member %s not found", expected.ID, m.UserID)
+			continue
+		}
+		if role != m.Role {
+			report.addMismatch("workspace %s: member %s role %q, expected %q", expected.ID, m.UserID, role, m.Role)
+		}
+	}
+
+	links := make(map[string]struct{}, len(got.URLIDs))
+	for _, urlID := range got.URLIDs {
+		links[urlID] = struct{}{}
+	}
+	for _, urlID := range expected.URLIDs {
+		if _, ok := links[urlID]; !ok {
+			report.addMismatch("workspace %s: url %s not found", expected.ID, urlID)
+		}
+	}
+}
+
+// compareURL сверяет URL из исходного хранилища с URL'ом из приёмника
+func compareURL(report *VerifyReport, expected snapshot.URL, got snapshot.URL) {
+	if expected.OriginalURL != got.OriginalURL {
+		report.addMismatch("url %s: original url %q, expected %q", expected.ID, got.OriginalURL, expected.OriginalURL)
+		return
+	}
+	if expected.Deleted && !got.Deleted {
+		report.addMismatch("url %s: is not marked as deleted", expected.ID)
+	}
+
+	owners := make(map[string]struct{}, len(got.UserIDs))
+	for _, userID := range got.UserIDs {
+		owners[userID] = struct{}{}
+	}
+	for _, userID := range expected.UserIDs {
+		if _, ok := owners[userID]; !ok {
+			report.addMismatch("url %s: owner %s not found", expected.ID, userID)
+		}
+	}
+}
+
+// pager последовательно читает записи хранилища страницами
+type pager[T any] struct {
+	fetch  func(ctx context.Context, afterID string, limit int) ([]T, error)
+	key    func(T) string
+	limit  int
+	buf    []T
+	pos    int
+	cursor string
+	done   bool
+}
+
+func newPager[T any](
+	fetch func(ctx context.Context, afterID string, limit int) ([]T, error),
+	key func(T) string,
+	limit int) *pager[T] {
+	return &pager[T]{
+		fetch: fetch,
+		key:   key,
+		limit: limit,
+	}
+}
+
+// peek вернёт текущую запись, не сдвигая позицию
+func (p *pager[T]) peek(ctx context.Context) (T, bool, error) {
+	var zero T
+	if p.pos >= len(p.buf) {
+		if p.done {
+			return zero, false, nil
+		}
+
+		page, err := p.fetch(ctx, p.cursor, p.limit)
+		if err != nil {
+			return zero, false, err
+		}
+		if len(page) == 0 {
+			p.done = true
+			return zero, false, nil
+		}
+
+		p.buf = page
+		p.pos = 0
+		p.cursor = p.key(page[len(page)-1])
+	}
+
+	return p.buf[p.pos], true, nil
+}
+
+// next вернёт текущую запись и сдвинет позицию
+func (p *pager[T]) next(ctx context.Context) (T, bool, error) {
+	item, ok, err := p.peek(ctx)
+	if ok {
+		p.pos++
+	}
+	return item, ok, err
+}
+
+// seek пропустит записи с ключом меньше key и вернёт запись с ключом key, если она есть
+func (p *pager[T]) seek(ctx context.Context, key string) (T, bool, error) {
+	var zero T
+	for {
+		item, ok, err := p.peek(ctx)
+		if err != nil || !ok {
+			return zero, false, err
+		}
+
+		switch k := p.key(item); {
+		case k < key:
+			p.pos++
+		case k == key:
+			p.pos++
+			return item, true, nil
+		default:
+			return zero, false, nil
+		}
+	}
+}
@@ -8,6 +8,9 @@ import (
 // ErrURLDeleted сообщает, что URL удалён
 var ErrURLDeleted = errors.New("service: url was removed")
 
+// ErrURLNotFound сообщает, что URL не найден
+var ErrURLNotFound = errors.New("service: url not found")
+
 // URLAlreadyExistsError сигнализирует, что URL уже существует
 type URLAlreadyExistsError struct {
 	URL      string // URL, который уже существует в базе
@@ -13,12 +13,25 @@ import (
 
 // ShortenerRepo интерфейс хранилища
 type ShortenerRepo interface {
+	UnitOfWork
+
 	SaveURL(ctx context.Context, url string, userID string) (string, error)
 	SaveURLsBatch(ctx context.Context,
 		request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error)
 	GetURLByID(ctx context.Context, id string) (string, error)
 	GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error)
 	UpdateURLsDeletedFlag(ctx context.Context, userID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	AddWorkspaceURLs(ctx context.Context, workspaceID string, ids []string) error
+	GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error)
+	UpdateWorkspaceURLsDeletedFlag(ctx context.Context, workspaceID string, modelsCh <-chan model.UpdateURLDeletedFlag) error
+	TakeDownURL(ctx context.Context, id string) error
+}
+
+// UnitOfWork выполняет несколько вызовов хранилища атомарно
+type UnitOfWork interface {
+	// WithinTx выполнит fn в транзакции: вызовы хранилища с переданным в fn контекстом
+	// будут зафиксированы вместе или, если fn вернула ошибку, отменены
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type shortenerUsecase struct {
@@ -34,9 +47,25 @@ func New(repo ShortenerRepo, baseURLAddress string) *shortenerUsecase {
 	}
 }
 
-// сохранит url и вернёт его id'шник
-func (s *shortenerUsecase) SaveURL(ctx context.Context, hash string, userID string) (string, error) {
-	hash, err := s.repository.SaveURL(ctx, hash, userID)
+// сохранит url владельца owner и вернёт его id'шник
+func (s *shortenerUsecase) SaveURL(ctx context.Context, url string, owner model.Owner) (string, error) {
+	var hash string
+	var errAlreadyExists error
+	err := s.repository.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		hash, err = s.saveURL(ctx, url, owner)
+
+		// для существующего URL'а сохраняется новый владелец, поэтому транзакция фиксируется
+		var repoErrURLAlreadyExists *repository.URLAlreadyExistsError
+		if errors.As(err, &repoErrURLAlreadyExists) {
+			errAlreadyExists = err
+			return nil
+		}
+		return err
+	})
+	if err == nil {
+		err = errAlreadyExists
+	}
 	if err != nil {
 		var repoErrURLAlreadyExists *repository.URLAlreadyExistsError
 		if errors.As(err, &repoErrURLAlreadyExists) {
@@ -49,6 +78,26 @@ func (s *shortenerUsecase) SaveURL(ctx context.Context, hash string, userID stri
 	return s.getShorURL(hash), nil
 }
 
+// saveURL сохранит URL; ссылка пространства сохраняется без пользователя и связывается с пространством
+func (s *shortenerUsecase) saveURL(ctx context.Context, url string, owner model.Owner) (string, error) {
+	if !owner.IsWorkspace() {
+		return s.repository.SaveURL(ctx, url, owner.UserID)
+	}
+
+	hash, err := s.repository.SaveURL(ctx, url, "")
+	var repoErrURLAlreadyExists *repository.URLAlreadyExistsError
+	if errors.As(err, &repoErrURLAlreadyExists) {
+		hash = repoErrURLAlreadyExists.ID
+	} else if err != nil {
+		return "", err
+	}
+	if errLink := s.repository.AddWorkspaceURLs(ctx, owner.WorkspaceID, []string{hash}); errLink != nil {
+		return "", errLink
+	}
+
+	return hash, err
+}
+
 // GetURLByID вернёт URL
 func (s *shortenerUsecase) GetURLByID(ctx context.Context, id string) (string, error) {
 	url, err := s.repository.GetURLByID(ctx, id)
@@ -62,9 +111,15 @@ func (s *shortenerUsecase) GetURLByID(ctx context.Context, id string) (string, e
 	return url, nil
 }
 
-// GetUserURLs вернёт URL'ы пользователя
-func (s *shortenerUsecase) GetUserURLs(ctx context.Context, userID string) ([]model.GetUserURLsItemResponse, error) {
-	res, err := s.repository.GetUserURLs(ctx, userID)
+// GetUserURLs вернёт URL'ы владельца owner
+func (s *shortenerUsecase) GetUserURLs(ctx context.Context, owner model.Owner) ([]model.GetUserURLsItemResponse, error) {
+	var res []model.GetUserURLsItemResponse
+	var err error
+	if owner.IsWorkspace() {
+		res, err = s.repository.GetWorkspaceURLs(ctx, owner.WorkspaceID)
+	} else {
+		res, err = s.repository.GetUserURLs(ctx, owner.UserID)
+	}
 	if err != nil {
 		logger.Log.Error("get user urls error", zap.Error(err))
 		return nil, err
@@ -76,10 +131,27 @@ func (s *shortenerUsecase) GetUserURLs(ctx context.Context, userID string) ([]mo
 	return res, nil
 }
 
-// SaveURLsBatch сохранит URL'ы пачкой
+// SaveURLsBatch сохранит URL'ы владельца owner пачкой
 func (s *shortenerUsecase) SaveURLsBatch(ctx context.Context,
-	request []model.CreateShortenURLBatchItemRequest, userID string) ([]model.CreateShortenURLBatchItemResponse, error) {
-	response, err := s.repository.SaveURLsBatch(ctx, request, userID)
+	request []model.CreateShortenURLBatchItemRequest, owner model.Owner) ([]model.CreateShortenURLBatchItemResponse, error) {
+	var response []model.CreateShortenURLBatchItemResponse
+	err := s.repository.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if !owner.IsWorkspace() {
+			response, err = s.repository.SaveURLsBatch(ctx, request, owner.UserID)
+			return err
+		}
+
+		response, err = s.repository.SaveURLsBatch(ctx, request, "")
+		if err != nil {
+			return err
+		}
+		ids := make([]string, 0, len(response))
+		for _, v := range response {
+			ids = append(ids, v.ShortURL)
+		}
+		return s.repository.AddWorkspaceURLs(ctx, owner.WorkspaceID, ids)
+	})
 	if err != nil {
 		logger.Log.Error("save urls batch error", zap.Error(err))
 		return nil, err
@@ -91,6 +163,20 @@ func (s *shortenerUsecase) SaveURLsBatch(ctx context.Context,
 	return response, nil
 }
 
+// TakeDownURL удалит URL у всех его владельцев
+func (s *shortenerUsecase) TakeDownURL(ctx context.Context, id string) error {
+	err := s.repository.TakeDownURL(ctx, id)
+	if errors.Is(err, repository.ErrNotFoundKey) {
+		return ErrURLNotFound
+	}
+	if err != nil {
+		logger.Log.Error("take down url error", zap.String("URL_ID", id), zap.Error(err))
+		return err
+	}
+
+	return nil
+}
+
 func (s *shortenerUsecase) getShorURL(hash string) string {
 	return fmt.Sprintf("%s/%s", s.baseURLAddress, hash)
 }
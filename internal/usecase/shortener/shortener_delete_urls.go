@@ -7,10 +7,15 @@ import (
 	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
 )
 
-// DeleteURLs удаляет URL'ы
-func (s *shortenerUsecase) DeleteURLs(ctx context.Context, userID string, urlsIDs []string) error {
-	modelToUpdateCh := fanIn(ctx, fanOut(ctx, generator(ctx, urlsIDs), 10)...)
-	return s.repository.UpdateURLsDeletedFlag(ctx, userID, modelToUpdateCh)
+// DeleteURLs удаляет URL'ы владельца owner
+func (s *shortenerUsecase) DeleteURLs(ctx context.Context, owner model.Owner, urlsIDs []string) error {
+	return s.repository.WithinTx(ctx, func(ctx context.Context) error {
+		modelToUpdateCh := fanIn(ctx, fanOut(ctx, generator(ctx, urlsIDs), 10)...)
+		if owner.IsWorkspace() {
+			return s.repository.UpdateWorkspaceURLsDeletedFlag(ctx, owner.WorkspaceID, modelToUpdateCh)
+		}
+		return s.repository.UpdateURLsDeletedFlag(ctx, owner.UserID, modelToUpdateCh)
+	})
 }
 
 func generator(ctx context.Context, input []string) chan string {
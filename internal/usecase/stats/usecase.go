@@ -13,14 +13,21 @@ type StatsRepo interface {
 	GetStats(ctx context.Context) (*model.StatsResponse, error)
 }
 
+// StatsProvider дополняет статистику данными, которые не хранятся в хранилище
+type StatsProvider interface {
+	FillStats(response *model.StatsResponse)
+}
+
 type statsUsecase struct {
 	repository StatsRepo
+	providers  []StatsProvider
 }
 
-// New инициализирует shortenerUsecase
-func New(repo StatsRepo) *statsUsecase {
+// New инициализирует statsUsecase
+func New(repo StatsRepo, providers ...StatsProvider) *statsUsecase {
 	uc := new(statsUsecase)
 	uc.repository = repo
+	uc.providers = providers
 	return uc
 }
 
@@ -35,5 +42,9 @@ func (s *statsUsecase) GetStats(ctx context.Context) (*model.StatsResponse, erro
 		return nil, err
 	}
 
+	for _, p := range s.providers {
+		p.FillStats(res)
+	}
+
 	return res, nil
 }
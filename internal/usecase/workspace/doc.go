@@ -0,0 +1,5 @@
+/*
+Package workspace это usecase для работы с рабочими пространствами:
+общими наборами ссылок, которыми управляют несколько пользователей
+*/
+package workspace
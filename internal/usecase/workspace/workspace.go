@@ -0,0 +1,210 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	modelAuth "github.com/KartoonYoko/go-url-shortener/internal/model/auth"
+	model "github.com/KartoonYoko/go-url-shortener/internal/model/shortener"
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	repoCommon "github.com/KartoonYoko/go-url-shortener/internal/repository"
+	"github.com/google/uuid"
+)
+
+// maxNameLength максимальная длина названия пространства
+const maxNameLength = 100
+
+// Ошибки рабочих пространств
+var (
+	// ErrWorkspaceNotFound пространства нет или пользователь в нём не состоит
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrForbidden         = errors.New("workspace role does not allow this action")
+	ErrInvalidName       = errors.New("workspace name must be 1 to 100 characters long")
+	ErrInvalidRole       = errors.New("workspace role must be one of viewer, editor, owner")
+	ErrLastOwner         = errors.New("workspace must have at least one owner")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrMemberNotFound    = errors.New("workspace member not found")
+)
+
+// WorkspaceRepo интерфейс хранилища
+type WorkspaceRepo interface {
+	CreateWorkspace(ctx context.Context, ws modelWorkspace.Workspace, owner modelWorkspace.Member) error
+	GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error)
+	GetWorkspaceMember(ctx context.Context, workspaceID string, userID string) (*modelWorkspace.Member, error)
+	GetWorkspaceMembers(ctx context.Context, workspaceID string) ([]modelWorkspace.Member, error)
+	GetWorkspaceOwners(ctx context.Context, workspaceID string) ([]string, error)
+	SaveWorkspaceMember(ctx context.Context, member modelWorkspace.Member) error
+	DeleteWorkspaceMember(ctx context.Context, workspaceID string, userID string) error
+	GetUser(ctx context.Context, userID string) (*modelAuth.User, error)
+	GetWorkspaceURLs(ctx context.Context, workspaceID string) ([]model.GetUserURLsItemResponse, error)
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type workspaceUseCase struct {
+	repository WorkspaceRepo
+	now        func() time.Time
+}
+
+// New инициализирует workspaceUseCase
+func New(repo WorkspaceRepo) *workspaceUseCase {
+	return &workspaceUseCase{
+		repository: repo,
+		now:        time.Now,
+	}
+}
+
+// CreateWorkspace создаст пространство, владельцем которого станет пользователь userID
+func (uc *workspaceUseCase) CreateWorkspace(ctx context.Context,
+	userID string, name string) (*modelWorkspace.Membership, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+		return nil, ErrInvalidName
+	}
+
+	ws := modelWorkspace.Workspace{
+		ID:        uuid.New().String(),
+		Name:      name,
+		CreatedAt: uc.now(),
+	}
+	owner := modelWorkspace.Member{WorkspaceID: ws.ID, UserID: userID, Role: modelWorkspace.RoleOwner}
+	if err := uc.repository.CreateWorkspace(ctx, ws, owner); err != nil {
+		return nil, err
+	}
+
+	return &modelWorkspace.Membership{Workspace: ws, Role: owner.Role}, nil
+}
+
+// GetUserWorkspaces вернёт пространства, в которых состоит пользователь
+func (uc *workspaceUseCase) GetUserWorkspaces(ctx context.Context, userID string) ([]modelWorkspace.Membership, error) {
+	return uc.repository.GetUserWorkspaces(ctx, userID)
+}
+
+// Authorize проверит, что у пользователя есть в пространстве права роли role
+func (uc *workspaceUseCase) Authorize(ctx context.Context, userID string, workspaceID string, role string) error {
+	_, err := uc.member(ctx, userID, workspaceID, role)
+	return err
+}
+
+// GetMembers вернёт участников пространства; доступно любому участнику
+func (uc *workspaceUseCase) GetMembers(ctx context.Context,
+	userID string, workspaceID string) ([]modelWorkspace.Member, error) {
+	if _, err := uc.member(ctx, userID, workspaceID, modelWorkspace.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	return uc.repository.GetWorkspaceMembers(ctx, workspaceID)
+}
+
+// SetMember добавит в пространство пользователя memberID или сменит его роль; доступно владельцам
+func (uc *workspaceUseCase) SetMember(ctx context.Context,
+	userID string, workspaceID string, memberID string, role string) (*modelWorkspace.Member, error) {
+	if !slices.Contains(modelWorkspace.Roles, role) {
+		return nil, ErrInvalidRole
+	}
+	if _, err := uc.member(ctx, userID, workspaceID, modelWorkspace.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	_, err := uc.repository.GetUser(ctx, memberID)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	member := &modelWorkspace.Member{WorkspaceID: workspaceID, UserID: memberID, Role: role}
+	err = uc.repository.WithinTx(ctx, func(ctx context.Context) error {
+		if role != modelWorkspace.RoleOwner {
+			if err := uc.checkNotLastOwner(ctx, workspaceID, memberID); err != nil {
+				return err
+			}
+		}
+		return uc.repository.SaveWorkspaceMember(ctx, *member)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return member, nil
+}
+
+// RemoveMember исключит пользователя memberID из пространства;
+// доступно владельцам, а участник может покинуть пространство сам
+func (uc *workspaceUseCase) RemoveMember(ctx context.Context, userID string, workspaceID string, memberID string) error {
+	role := modelWorkspace.RoleOwner
+	if userID == memberID {
+		role = modelWorkspace.RoleViewer
+	}
+	if _, err := uc.member(ctx, userID, workspaceID, role); err != nil {
+		return err
+	}
+
+	err := uc.repository.WithinTx(ctx, func(ctx context.Context) error {
+		if err := uc.checkNotLastOwner(ctx, workspaceID, memberID); err != nil {
+			return err
+		}
+		return uc.repository.DeleteWorkspaceMember(ctx, workspaceID, memberID)
+	})
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return ErrMemberNotFound
+	}
+
+	return err
+}
+
+// GetStats вернёт статистику пространства; доступно любому участнику
+func (uc *workspaceUseCase) GetStats(ctx context.Context,
+	userID string, workspaceID string) (*modelWorkspace.StatsResponse, error) {
+	if _, err := uc.member(ctx, userID, workspaceID, modelWorkspace.RoleViewer); err != nil {
+		return nil, err
+	}
+
+	urls, err := uc.repository.GetWorkspaceURLs(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	members, err := uc.repository.GetWorkspaceMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &modelWorkspace.StatsResponse{URLs: len(urls), Members: len(members)}, nil
+}
+
+// member вернёт участника пространства, если у него есть права роли role;
+// не участнику пространство не видно
+func (uc *workspaceUseCase) member(ctx context.Context,
+	userID string, workspaceID string, role string) (*modelWorkspace.Member, error) {
+	member, err := uc.repository.GetWorkspaceMember(ctx, workspaceID, userID)
+	if errors.Is(err, repoCommon.ErrNotFoundKey) {
+		return nil, ErrWorkspaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !member.HasRole(role) {
+		return nil, ErrForbidden
+	}
+
+	return member, nil
+}
+
+// checkNotLastOwner вернёт ErrLastOwner, если memberID - единственный владелец пространства;
+// вызывается в одной транзакции с изменением участника, чтобы параллельные
+// изменения не оставили пространство без владельцев
+func (uc *workspaceUseCase) checkNotLastOwner(ctx context.Context, workspaceID string, memberID string) error {
+	owners, err := uc.repository.GetWorkspaceOwners(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if len(owners) == 1 && owners[0] == memberID {
+		return ErrLastOwner
+	}
+
+	return nil
+}
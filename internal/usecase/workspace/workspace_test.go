@@ -0,0 +1,178 @@
+package workspace
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	modelWorkspace "github.com/KartoonYoko/go-url-shortener/internal/model/workspace"
+	inmr "github.com/KartoonYoko/go-url-shortener/internal/repository/inmemoryrepo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceUseCase_CreateWorkspace(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := New(repo)
+	userID := newUser(t, repo)
+
+	_, err := uc.CreateWorkspace(ctx, userID, "  ")
+	require.ErrorIs(t, err, ErrInvalidName)
+
+	created, err := uc.CreateWorkspace(ctx, userID, " campaigns ")
+	require.NoError(t, err)
+	require.Equal(t, "campaigns", created.Workspace.Name)
+	require.Equal(t, modelWorkspace.RoleOwner, created.Role)
+
+	memberships, err := uc.GetUserWorkspaces(ctx, userID)
+	require.NoError(t, err)
+	require.Equal(t, []modelWorkspace.Membership{*created}, memberships)
+}
+
+func TestWorkspaceUseCase_Authorize(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := New(repo)
+	ownerID := newUser(t, repo)
+	viewerID := newUser(t, repo)
+	ws, err := uc.CreateWorkspace(ctx, ownerID, "campaigns")
+	require.NoError(t, err)
+	_, err = uc.SetMember(ctx, ownerID, ws.Workspace.ID, viewerID, modelWorkspace.RoleViewer)
+	require.NoError(t, err)
+
+	require.NoError(t, uc.Authorize(ctx, ownerID, ws.Workspace.ID, modelWorkspace.RoleEditor))
+	require.NoError(t, uc.Authorize(ctx, viewerID, ws.Workspace.ID, modelWorkspace.RoleViewer))
+	require.ErrorIs(t, uc.Authorize(ctx, viewerID, ws.Workspace.ID, modelWorkspace.RoleEditor), ErrForbidden)
+	require.ErrorIs(t, uc.Authorize(ctx, newUser(t, repo), ws.Workspace.ID, modelWorkspace.RoleViewer), ErrWorkspaceNotFound)
+	require.ErrorIs(t, uc.Authorize(ctx, ownerID, "unknown", modelWorkspace.RoleViewer), ErrWorkspaceNotFound)
+}
+
+func TestWorkspaceUseCase_SetMember(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := New(repo)
+	ownerID := newUser(t, repo)
+	editorID := newUser(t, repo)
+	ws, err := uc.CreateWorkspace(ctx, ownerID, "campaigns")
+	require.NoError(t, err)
+	wsID := ws.Workspace.ID
+
+	_, err = uc.SetMember(ctx, ownerID, wsID, editorID, "admin")
+	require.ErrorIs(t, err, ErrInvalidRole)
+	_, err = uc.SetMember(ctx, ownerID, wsID, "unknown", modelWorkspace.RoleEditor)
+	require.ErrorIs(t, err, ErrUserNotFound)
+
+	member, err := uc.SetMember(ctx, ownerID, wsID, editorID, modelWorkspace.RoleEditor)
+	require.NoError(t, err)
+	require.Equal(t, modelWorkspace.Member{WorkspaceID: wsID, UserID: editorID, Role: modelWorkspace.RoleEditor}, *member)
+
+	// участниками управляют только владельцы
+	_, err = uc.SetMember(ctx, editorID, wsID, editorID, modelWorkspace.RoleOwner)
+	require.ErrorIs(t, err, ErrForbidden)
+
+	// единственный владелец не может снять с себя роль
+	_, err = uc.SetMember(ctx, ownerID, wsID, ownerID, modelWorkspace.RoleEditor)
+	require.ErrorIs(t, err, ErrLastOwner)
+	_, err = uc.SetMember(ctx, ownerID, wsID, editorID, modelWorkspace.RoleOwner)
+	require.NoError(t, err)
+	_, err = uc.SetMember(ctx, ownerID, wsID, ownerID, modelWorkspace.RoleEditor)
+	require.NoError(t, err)
+
+	members, err := uc.GetMembers(ctx, ownerID, wsID)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []modelWorkspace.Member{
+		{WorkspaceID: wsID, UserID: ownerID, Role: modelWorkspace.RoleEditor},
+		{WorkspaceID: wsID, UserID: editorID, Role: modelWorkspace.RoleOwner},
+	}, members)
+}
+
+func TestWorkspaceUseCase_RemoveMember(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := New(repo)
+	ownerID := newUser(t, repo)
+	editorID := newUser(t, repo)
+	viewerID := newUser(t, repo)
+	ws, err := uc.CreateWorkspace(ctx, ownerID, "campaigns")
+	require.NoError(t, err)
+	wsID := ws.Workspace.ID
+	_, err = uc.SetMember(ctx, ownerID, wsID, editorID, modelWorkspace.RoleEditor)
+	require.NoError(t, err)
+	_, err = uc.SetMember(ctx, ownerID, wsID, viewerID, modelWorkspace.RoleViewer)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, uc.RemoveMember(ctx, editorID, wsID, viewerID), ErrForbidden)
+	require.ErrorIs(t, uc.RemoveMember(ctx, ownerID, wsID, ownerID), ErrLastOwner)
+	require.ErrorIs(t, uc.RemoveMember(ctx, ownerID, wsID, newUser(t, repo)), ErrMemberNotFound)
+
+	// участник может покинуть пространство сам
+	require.NoError(t, uc.RemoveMember(ctx, viewerID, wsID, viewerID))
+	require.NoError(t, uc.RemoveMember(ctx, ownerID, wsID, editorID))
+	require.ErrorIs(t, uc.Authorize(ctx, editorID, wsID, modelWorkspace.RoleViewer), ErrWorkspaceNotFound)
+
+	members, err := uc.GetMembers(ctx, ownerID, wsID)
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+}
+
+// TestWorkspaceUseCase_ConcurrentOwnerRemoval проверяет, что два владельца,
+// одновременно покидающие пространство, не оставят его без владельцев
+func TestWorkspaceUseCase_ConcurrentOwnerRemoval(t *testing.T) {
+	ctx := context.Background()
+	for i := 0; i < 50; i++ {
+		repo := inmr.NewInMemoryRepo()
+		uc := New(repo)
+		firstID := newUser(t, repo)
+		secondID := newUser(t, repo)
+		ws, err := uc.CreateWorkspace(ctx, firstID, "campaigns")
+		require.NoError(t, err)
+		wsID := ws.Workspace.ID
+		_, err = uc.SetMember(ctx, firstID, wsID, secondID, modelWorkspace.RoleOwner)
+		require.NoError(t, err)
+
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		for j, userID := range []string{firstID, secondID} {
+			wg.Add(1)
+			go func(j int, userID string) {
+				defer wg.Done()
+				errs[j] = uc.RemoveMember(ctx, userID, wsID, userID)
+			}(j, userID)
+		}
+		wg.Wait()
+
+		require.ElementsMatch(t, []error{nil, ErrLastOwner}, errs)
+		owners, err := repo.GetWorkspaceOwners(ctx, wsID)
+		require.NoError(t, err)
+		require.Len(t, owners, 1)
+	}
+}
+
+func TestWorkspaceUseCase_GetStats(t *testing.T) {
+	ctx := context.Background()
+	repo := inmr.NewInMemoryRepo()
+	uc := New(repo)
+	ownerID := newUser(t, repo)
+	ws, err := uc.CreateWorkspace(ctx, ownerID, "campaigns")
+	require.NoError(t, err)
+	for _, url := range []string{"https://example.com/1", "https://example.com/2"} {
+		id, err := repo.SaveURL(ctx, url, "")
+		require.NoError(t, err)
+		require.NoError(t, repo.AddWorkspaceURLs(ctx, ws.Workspace.ID, []string{id}))
+	}
+	_, err = repo.SaveURL(ctx, "https://example.com/personal", ownerID)
+	require.NoError(t, err)
+
+	stats, err := uc.GetStats(ctx, ownerID, ws.Workspace.ID)
+	require.NoError(t, err)
+	require.Equal(t, modelWorkspace.StatsResponse{URLs: 2, Members: 1}, *stats)
+
+	_, err = uc.GetStats(ctx, newUser(t, repo), ws.Workspace.ID)
+	require.ErrorIs(t, err, ErrWorkspaceNotFound)
+}
+
+func newUser(t *testing.T, repo *inmr.InMemoryRepo) string {
+	userID, err := repo.GetNewUserID(context.Background())
+	require.NoError(t, err)
+	return userID
+}